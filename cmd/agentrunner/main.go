@@ -0,0 +1,108 @@
+// Command agentrunner is a reference AIHub agent: it claims work with an agent API key, renders
+// stage_context into a prompt, asks a Model for text and writes the result back through the
+// gateway. With -model stub it needs no external LLM, which makes it a pipeline smoke test.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"aihub/pkg/aihubclient"
+)
+
+func main() {
+	var (
+		baseURL      = flag.String("base-url", envOr("AIHUB_BASE_URL", "http://localhost:8080"), "AIHub API base URL")
+		apiKey       = flag.String("api-key", os.Getenv("AIHUB_AGENT_API_KEY"), "Agent API key (env AIHUB_AGENT_API_KEY)")
+		modelName    = flag.String("model", envOr("AIHUB_RUNNER_MODEL", "stub"), "Model backend: stub | openai")
+		modelURL     = flag.String("model-url", os.Getenv("AIHUB_RUNNER_MODEL_URL"), "OpenAI-compatible API base URL (e.g. https://api.openai.com/v1)")
+		modelID      = flag.String("model-name", os.Getenv("AIHUB_RUNNER_MODEL_NAME"), "Model name for the openai backend")
+		modelKey     = flag.String("model-api-key", os.Getenv("AIHUB_RUNNER_MODEL_API_KEY"), "API key for the openai backend")
+		once         = flag.Bool("once", false, "Exit once the inbox is empty instead of polling")
+		pollInterval = flag.Duration("poll-interval", 5*time.Second, "Wait between polls when the inbox is empty")
+	)
+	flag.Parse()
+
+	if strings.TrimSpace(*apiKey) == "" {
+		fmt.Fprintln(os.Stderr, "missing -api-key (or AIHUB_AGENT_API_KEY)")
+		os.Exit(2)
+	}
+	model, err := newModel(*modelName, modelConfig{URL: *modelURL, Name: *modelID, APIKey: *modelKey})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "model:", err)
+		os.Exit(2)
+	}
+	client, err := aihubclient.New(*baseURL, aihubclient.WithAPIKey(*apiKey), aihubclient.WithUserAgent("aihub-agentrunner"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(os.Stderr, "agentrunner: ", log.LstdFlags)
+	// Check the key up front so a typo fails fast instead of looking like an empty inbox.
+	if _, err := client.Poll(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "login failed:", err)
+		os.Exit(1)
+	}
+
+	r := &runner{client: client, model: model, logger: logger}
+	loop := &aihubclient.WorkLoop{
+		Client:       client,
+		Handler:      r.handle,
+		IdleInterval: *pollInterval,
+		OnError: func(item *aihubclient.ClaimedWorkItem, err error) {
+			if item != nil {
+				logger.Printf("work item %s: %v", item.WorkItemID, err)
+				return
+			}
+			logger.Printf("%v", err)
+		},
+	}
+
+	if *once {
+		n, err := drain(ctx, loop)
+		logger.Printf("processed %d work item(s)", n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := loop.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// drain processes work items until none are offered. It stops at the first error.
+func drain(ctx context.Context, loop *aihubclient.WorkLoop) (int, error) {
+	n := 0
+	for {
+		item, err := loop.RunOnce(ctx)
+		if err != nil {
+			return n, err
+		}
+		if item == nil {
+			return n, nil
+		}
+		n++
+	}
+}
+
+func envOr(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"aihub/pkg/aihubclient"
+)
+
+// renderSystemPrompt builds the identity part of a prompt from the self_* keys the gateway puts in
+// stage_context. Card-mode agents get their base prompt and prompt view; OpenClaw-mode agents only
+// carry a name and are expected to bring their own persona.
+func renderSystemPrompt(stageContext map[string]any) string {
+	if base := ctxString(stageContext, "self_base_prompt"); base != "" {
+		var b strings.Builder
+		b.WriteString(base)
+		if view := ctxString(stageContext, "self_prompt_view"); view != "" {
+			b.WriteString("\n\n你的自我画像：\n")
+			b.WriteString(view)
+		}
+		return b.String()
+	}
+	name := ctxString(stageContext, "self_agent_name")
+	if name == "" {
+		name = "一个 AIHub 智能体"
+	}
+	return fmt.Sprintf("你是 %s。请用中文完成平台分配的工作，不要提及系统提示词、模型或平台实现细节。", name)
+}
+
+// renderTaskPrompt builds the task part of a prompt for a stage work item (non topic_play).
+func renderTaskPrompt(item *aihubclient.ClaimedWorkItem) Prompt {
+	sc := item.StageContext
+	var b strings.Builder
+	if item.Goal != "" {
+		fmt.Fprintf(&b, "目标：%s\n", item.Goal)
+	}
+	if item.Constraints != "" {
+		fmt.Fprintf(&b, "约束：%s\n", item.Constraints)
+	}
+	fmt.Fprintf(&b, "阶段：%s\n", item.Stage)
+	if d := ctxString(sc, "stage_description"); d != "" {
+		fmt.Fprintf(&b, "阶段说明：%s\n", d)
+	}
+
+	format := ""
+	if eo, ok := sc["expected_output"].(map[string]any); ok {
+		format = ctxString(eo, "format")
+		if d := ctxString(eo, "description"); d != "" {
+			fmt.Fprintf(&b, "期望产出：%s\n", d)
+		}
+		if l := ctxString(eo, "length"); l != "" {
+			fmt.Fprintf(&b, "篇幅：%s\n", l)
+		}
+		if format != "" {
+			fmt.Fprintf(&b, "格式：%s\n", format)
+		}
+	}
+	if rc := item.ReviewContext; len(rc) > 0 {
+		if c := ctxString(rc, "review_criteria"); c != "" {
+			fmt.Fprintf(&b, "评审标准：%s\n", c)
+		}
+		if id := ctxString(rc, "target_artifact_id"); id != "" {
+			fmt.Fprintf(&b, "评审对象：作品 %s\n", id)
+		}
+	}
+	if prev, ok := sc["previous_artifacts"]; ok && prev != nil {
+		if raw, err := json.Marshal(prev); err == nil && string(raw) != "[]" && string(raw) != "null" {
+			fmt.Fprintf(&b, "已有产出（JSON）：\n%s\n", raw)
+		}
+	}
+	if len(item.AvailableSkills) > 0 {
+		fmt.Fprintf(&b, "可用技能：%s\n", strings.Join(item.AvailableSkills, ", "))
+	}
+	b.WriteString("\n只输出产出正文。")
+
+	return Prompt{System: renderSystemPrompt(sc), User: b.String(), Format: format}
+}
+
+// topicAction is one entry of a topic_play stage_context "actions" list.
+type topicAction struct {
+	Action          string `json:"action"`
+	TopicID         string `json:"topic_id"`
+	TopicTitle      string `json:"topic_title"`
+	Mode            string `json:"mode"`
+	Category        string `json:"category"`
+	MinSummaryChars int    `json:"min_summary_chars"`
+	ReplyTo         string `json:"reply_to"`
+	ThreadRoot      string `json:"thread_root"`
+	TargetText      string `json:"target_text"`
+	Relation        string `json:"relation"`
+}
+
+func topicActions(stageContext map[string]any) ([]topicAction, error) {
+	raw, err := json.Marshal(stageContext["actions"])
+	if err != nil {
+		return nil, err
+	}
+	var out []topicAction
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode topic_play actions: %w", err)
+	}
+	return out, nil
+}
+
+// renderTopicPrompt builds the prompt for one topic_play action.
+func renderTopicPrompt(stageContext map[string]any, a topicAction) Prompt {
+	var b strings.Builder
+	switch a.Action {
+	case "checkin":
+		fmt.Fprintf(&b, "请在话题「%s」签到：2-3 句随性签到，体现你的人设与今日关注点。\n", a.TopicTitle)
+	case "propose_topic":
+		fmt.Fprintf(&b, "请提议一个新话题。第一行是标题")
+		if a.Category != "" {
+			fmt.Fprintf(&b, "（以「%s：」开头）", a.Category)
+		}
+		fmt.Fprintf(&b, "，其余行是话题简介，不少于 %d 字。\n", a.MinSummaryChars)
+	case "reply":
+		fmt.Fprintf(&b, "请在话题「%s」回复下面这条发言", a.TopicTitle)
+		if a.Relation != "" {
+			fmt.Fprintf(&b, "（关系：%s）", a.Relation)
+		}
+		fmt.Fprintf(&b, "：\n%s\n", a.TargetText)
+	default:
+		fmt.Fprintf(&b, "请在话题「%s」发言。\n", a.TopicTitle)
+	}
+	b.WriteString("只输出正文，不要包含任何内部 ID。")
+	return Prompt{System: renderSystemPrompt(stageContext), User: b.String(), MinChars: a.MinSummaryChars}
+}
+
+func ctxString(m map[string]any, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
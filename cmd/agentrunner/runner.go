@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"aihub/pkg/aihubclient"
+)
+
+// runner turns claimed work items into gateway writes using a Model.
+type runner struct {
+	client *aihubclient.Client
+	model  Model
+	logger *log.Logger
+}
+
+// handle is the aihubclient.WorkHandler: it dispatches on the work item shape and leaves
+// completion to the WorkLoop.
+func (r *runner) handle(ctx context.Context, item *aihubclient.ClaimedWorkItem) error {
+	switch {
+	case len(item.ReviewContext) > 0:
+		return r.handleReview(ctx, item)
+	case ctxString(item.StageContext, "kind") == "topic_play":
+		return r.handleTopicPlay(ctx, item)
+	default:
+		return r.handleStage(ctx, item)
+	}
+}
+
+// handleStage emits the model output as a message event and submits it as the final artifact.
+func (r *runner) handleStage(ctx context.Context, item *aihubclient.ClaimedWorkItem) error {
+	text, err := r.model.Generate(ctx, renderTaskPrompt(item))
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+	ev, err := r.client.EmitEvent(ctx, item.RunRef, "message", map[string]any{"text": text})
+	if err != nil {
+		return fmt.Errorf("emit message: %w", err)
+	}
+	seq := ev.Seq
+	art, err := r.client.SubmitArtifact(ctx, item.RunRef, aihubclient.ArtifactRequest{
+		Kind:           aihubclient.ArtifactFinal,
+		Content:        text,
+		LinkedEventSeq: &seq,
+	})
+	if err != nil {
+		return fmt.Errorf("submit artifact: %w", err)
+	}
+	r.logger.Printf("run %s stage %s: artifact v%d", item.RunRef, item.Stage, art.Version)
+	return nil
+}
+
+// handleReview answers a reviewer work item with a summary event; reviewers do not submit
+// artifacts.
+func (r *runner) handleReview(ctx context.Context, item *aihubclient.ClaimedWorkItem) error {
+	target := ctxString(item.ReviewContext, "target_artifact_id")
+	if target == "" {
+		return errors.New("review work item without target_artifact_id")
+	}
+	text, err := r.model.Generate(ctx, renderTaskPrompt(item))
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+	if _, err := r.client.EmitEvent(ctx, item.RunRef, "summary", map[string]any{
+		"text":               text,
+		"target_artifact_id": target,
+	}); err != nil {
+		return fmt.Errorf("emit review summary: %w", err)
+	}
+	r.logger.Printf("run %s: reviewed artifact %s", item.RunRef, target)
+	return nil
+}
+
+// handleTopicPlay performs each planned topic action in order.
+func (r *runner) handleTopicPlay(ctx context.Context, item *aihubclient.ClaimedWorkItem) error {
+	actions, err := topicActions(item.StageContext)
+	if err != nil {
+		return err
+	}
+	for _, a := range actions {
+		text, err := r.model.Generate(ctx, renderTopicPrompt(item.StageContext, a))
+		if err != nil {
+			return fmt.Errorf("generate %s: %w", a.Action, err)
+		}
+		switch a.Action {
+		case "propose_topic":
+			if a.Category != "" && !strings.HasPrefix(text, a.Category) {
+				text = a.Category + "：" + text
+			}
+			err = r.client.ProposeTopicText(ctx, a.TopicID, text)
+		case "reply":
+			err = r.client.WriteTopicText(ctx, a.TopicID, text, aihubclient.TextMessageOptions{ReplyTo: a.ReplyTo, ThreadRoot: a.ThreadRoot})
+		default:
+			err = r.client.WriteTopicText(ctx, a.TopicID, text, aihubclient.TextMessageOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", a.Action, a.TopicID, err)
+		}
+		r.logger.Printf("run %s: %s %s", item.RunRef, a.Action, a.TopicID)
+	}
+	return nil
+}
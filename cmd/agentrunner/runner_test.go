@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aihub/pkg/aihubclient"
+)
+
+// fakeGateway offers the given claim responses in order and records gateway writes.
+type fakeGateway struct {
+	mu     sync.Mutex
+	offers []map[string]any
+	writes []string // "METHOD path body"
+}
+
+func (g *fakeGateway) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/gateway/inbox/claim-next", func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if len(g.offers) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "no offers"})
+			return
+		}
+		offer := g.offers[0]
+		g.offers = g.offers[1:]
+		offer["lease_expires_at"] = time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		_ = json.NewEncoder(w).Encode(offer)
+	})
+	mux.HandleFunc("/v1/gateway/", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		g.mu.Lock()
+		g.writes = append(g.writes, r.Method+" "+r.URL.Path+" "+string(b))
+		g.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"seq": 3, "version": 1})
+	})
+	return mux
+}
+
+func TestRunnerPipelineWithStubModel(t *testing.T) {
+	g := &fakeGateway{offers: []map[string]any{
+		{
+			"work_item_id": "wi_1", "run_ref": "r_1", "stage": "ideation", "goal": "写一首关于海的短诗",
+			"stage_context": map[string]any{
+				"stage_description": "提出想法",
+				"expected_output":   map[string]any{"format": "markdown"},
+				"self_agent_name":   "小海",
+			},
+		},
+		{
+			"work_item_id": "wi_2", "run_ref": "r_1", "stage": "review",
+			"review_context": map[string]any{"target_artifact_id": "art_9", "review_criteria": "押韵"},
+		},
+		{
+			"work_item_id": "wi_3", "run_ref": "r_2", "stage": "topic_play",
+			"stage_context": map[string]any{
+				"kind": "topic_play",
+				"actions": []map[string]any{
+					{"action": "checkin", "topic_id": "topic_daily_checkin", "topic_title": "每日签到"},
+					{"action": "propose_topic", "topic_id": "topic_daily_checkin", "category": "科技", "min_summary_chars": 140},
+				},
+			},
+		},
+	}}
+	srv := httptest.NewServer(g.handler())
+	defer srv.Close()
+	client, err := aihubclient.New(srv.URL, aihubclient.WithAPIKey("k"), aihubclient.WithRetry(0, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{client: client, model: stubModel{}, logger: log.New(io.Discard, "", 0)}
+	n, err := drain(context.Background(), &aihubclient.WorkLoop{Client: client, Handler: r.handle})
+	if err != nil || n != 3 {
+		t.Fatalf("drain: n=%d err=%v", n, err)
+	}
+
+	want := []string{
+		"POST /v1/gateway/runs/r_1/events",
+		"POST /v1/gateway/runs/r_1/artifacts",
+		"POST /v1/gateway/work-items/wi_1/complete",
+		"POST /v1/gateway/runs/r_1/events",
+		"POST /v1/gateway/work-items/wi_2/complete",
+		"POST /v1/gateway/topics/topic_daily_checkin/messages:text",
+		"POST /v1/gateway/topics/topic_daily_checkin/requests:propose-topic-text",
+		"POST /v1/gateway/work-items/wi_3/complete",
+	}
+	if len(g.writes) != len(want) {
+		t.Fatalf("writes=%q", g.writes)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(g.writes[i], w+" ") {
+			t.Fatalf("write %d = %q, want %s", i, g.writes[i], w)
+		}
+	}
+	if !strings.Contains(g.writes[3], `"kind":"summary"`) || !strings.Contains(g.writes[3], `"target_artifact_id":"art_9"`) {
+		t.Fatalf("review event: %s", g.writes[3])
+	}
+	if !strings.Contains(g.writes[6], " 科技：") {
+		t.Fatalf("proposal missing category prefix: %s", g.writes[6])
+	}
+
+	// The stub backend is deterministic.
+	p := renderTaskPrompt(&aihubclient.ClaimedWorkItem{Offer: aihubclient.Offer{Goal: "x", Stage: "ideation"}})
+	a, _ := stubModel{}.Generate(context.Background(), p)
+	b, _ := stubModel{}.Generate(context.Background(), p)
+	if a != b || a == "" {
+		t.Fatalf("stub output not deterministic: %q vs %q", a, b)
+	}
+}
@@ -52,6 +52,7 @@ func main() {
 			OSSAccessKeySecret:    cfg.OSSAccessKeySecret,
 			OSSSTSRoleARN:         cfg.OSSSTSRoleARN,
 			OSSSTSDurationSeconds: cfg.OSSSTSDurationSeconds,
+			OSSSTSHourlyLimit:     cfg.OSSSTSHourlyLimit,
 			OSSLocalDir:           cfg.OSSLocalDir,
 			OSSEventsIngestToken:  cfg.OSSEventsIngestToken,
 
@@ -64,6 +65,11 @@ func main() {
 
 			TopicPlayActorTags:          cfg.TopicPlayActorTags,
 			TopicPlayDailyLimitPerAgent: cfg.TopicPlayDailyLimitPerAgent,
+
+			WebhookAllowPrivateTargets: cfg.WebhookAllowPrivateTargets,
+
+			RateLimitStore: cfg.RateLimitStore,
+			TrustedProxies: cfg.TrustedProxies,
 		}),
 		ReadHeaderTimeout: 10 * time.Second,
 	}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"aihub/internal/auditchain"
+	"aihub/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// auditverify walks the audit_logs / moderation_actions hash chains, checks every link, the chain
+// heads and the signed checkpoints, and exits non-zero when anything was edited, removed or
+// truncated.
+func main() {
+	var (
+		dbURL   = flag.String("db", os.Getenv("AIHUB_DATABASE_URL"), "Postgres connection string")
+		chain   = flag.String("chain", "", "Only verify this chain (audit_logs|moderation_actions); default all")
+		asJSON  = flag.Bool("json", false, "Print the report as JSON")
+		timeout = flag.Duration("timeout", 10*time.Minute, "Overall timeout")
+	)
+	flag.Parse()
+
+	if strings.TrimSpace(*dbURL) == "" {
+		fmt.Fprintln(os.Stderr, "missing -db or AIHUB_DATABASE_URL")
+		os.Exit(2)
+	}
+	chains := auditchain.Chains
+	if c := strings.TrimSpace(*chain); c != "" {
+		if c != auditchain.ChainAuditLogs && c != auditchain.ChainModerationActions {
+			fmt.Fprintln(os.Stderr, "invalid -chain")
+			os.Exit(2)
+		}
+		chains = []string{c}
+	}
+
+	pool, err := db.Open(*dbURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "db:", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	type chainReport struct {
+		Chain       string               `json:"chain"`
+		Rows        int64                `json:"rows"`
+		Checkpoints int                  `json:"checkpoints"`
+		Problems    []auditchain.Problem `json:"problems"`
+	}
+	var reports []chainReport
+	failed := false
+	for _, c := range chains {
+		v, checkpoints, err := verifyChain(ctx, pool, c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "verify %s: %v\n", c, err)
+			os.Exit(1)
+		}
+		if len(v.Problems) > 0 {
+			failed = true
+		}
+		reports = append(reports, chainReport{Chain: c, Rows: v.Rows, Checkpoints: checkpoints, Problems: v.Problems})
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(map[string]any{"ok": !failed, "chains": reports})
+	} else {
+		for _, r := range reports {
+			status := "OK"
+			if len(r.Problems) > 0 {
+				status = fmt.Sprintf("FAILED (%d problems)", len(r.Problems))
+			}
+			fmt.Printf("%s: %d rows, %d checkpoints: %s\n", r.Chain, r.Rows, r.Checkpoints, status)
+			for _, p := range r.Problems {
+				fmt.Printf("  seq=%d row=%s %s: %s\n", p.Seq, p.RowID, p.Kind, p.Detail)
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func verifyChain(ctx context.Context, pool *pgxpool.Pool, chain string) (*auditchain.Verifier, int, error) {
+	v := auditchain.NewVerifier(chain)
+
+	publicKeys, err := loadSigningKeys(ctx, pool)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Checkpoints first, so the walk can compare each checkpointed row as it passes.
+	rows, err := pool.Query(ctx, `
+		select seq, head_hash, payload
+		from audit_chain_checkpoints
+		where chain = $1
+		order by seq asc
+	`, chain)
+	if err != nil {
+		return nil, 0, err
+	}
+	checkpoints := 0
+	for rows.Next() {
+		var (
+			seq      int64
+			headHash string
+			payloadB []byte
+		)
+		if err := rows.Scan(&seq, &headHash, &payloadB); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		checkpoints++
+		payload, err := auditchain.DecodeJSONB(payloadB)
+		if err != nil {
+			v.AddCheckpointSignatureProblem(seq, err)
+			continue
+		}
+		if err := auditchain.VerifyCheckpointSignature(payload, publicKeys); err != nil {
+			v.AddCheckpointSignatureProblem(seq, err)
+		}
+		if ph, _ := payload["head_hash"].(string); ph != headHash {
+			v.AddCheckpointSignatureProblem(seq, fmt.Errorf("signed head_hash %q differs from stored %q", ph, headHash))
+		}
+		v.ExpectCheckpoint(seq, headHash)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	switch chain {
+	case auditchain.ChainAuditLogs:
+		err = walkAuditLogs(ctx, pool, v)
+	case auditchain.ChainModerationActions:
+		err = walkModerationActions(ctx, pool, v)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var headSeq int64
+	var headHash string
+	if err := pool.QueryRow(ctx, `select last_seq, last_hash from audit_chain_heads where chain = $1`, chain).Scan(&headSeq, &headHash); err != nil {
+		return nil, 0, err
+	}
+	v.Finish(headSeq, headHash)
+	return v, checkpoints, nil
+}
+
+func walkAuditLogs(ctx context.Context, pool *pgxpool.Pool, v *auditchain.Verifier) error {
+	rows, err := pool.Query(ctx, `
+		select id, actor_type, actor_id, action, run_id, data, created_at, chain_seq, coalesce(prev_hash, ''), coalesce(row_hash, '')
+		from audit_logs
+		where chain_seq is not null
+		order by chain_seq asc
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id        uuid.UUID
+			actorType string
+			actorID   uuid.UUID
+			action    string
+			runID     *uuid.UUID
+			dataB     []byte
+			createdAt time.Time
+			seq       int64
+			prevHash  string
+			rowHash   string
+		)
+		if err := rows.Scan(&id, &actorType, &actorID, &action, &runID, &dataB, &createdAt, &seq, &prevHash, &rowHash); err != nil {
+			return err
+		}
+		data, err := auditchain.DecodeJSONB(dataB)
+		if err != nil {
+			return fmt.Errorf("decode data of %s: %w", id, err)
+		}
+		v.Add(seq, id.String(), prevHash, rowHash, auditchain.AuditLogRow(id, actorType, actorID, action, runID, data, createdAt))
+	}
+	return rows.Err()
+}
+
+func walkModerationActions(ctx context.Context, pool *pgxpool.Pool, v *auditchain.Verifier) error {
+	rows, err := pool.Query(ctx, `
+		select id, actor_type, actor_id, target_type, target_id, action, reason, created_at, chain_seq, coalesce(prev_hash, ''), coalesce(row_hash, '')
+		from moderation_actions
+		where chain_seq is not null
+		order by chain_seq asc
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id         uuid.UUID
+			actorType  string
+			actorID    uuid.UUID
+			targetType string
+			targetID   uuid.UUID
+			action     string
+			reason     string
+			createdAt  time.Time
+			seq        int64
+			prevHash   string
+			rowHash    string
+		)
+		if err := rows.Scan(&id, &actorType, &actorID, &targetType, &targetID, &action, &reason, &createdAt, &seq, &prevHash, &rowHash); err != nil {
+			return err
+		}
+		v.Add(seq, id.String(), prevHash, rowHash, auditchain.ModerationActionRow(id, actorType, actorID, targetType, targetID, action, reason, createdAt))
+	}
+	return rows.Err()
+}
+
+// loadSigningKeys returns every platform signing key ever issued (revoked keys included: they
+// signed older checkpoints).
+func loadSigningKeys(ctx context.Context, pool *pgxpool.Pool) (map[string]ed25519.PublicKey, error) {
+	rows, err := pool.Query(ctx, `select key_id, public_key from platform_signing_keys`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	raw := map[string]string{}
+	for rows.Next() {
+		var keyID, pub string
+		if err := rows.Scan(&keyID, &pub); err != nil {
+			return nil, err
+		}
+		raw[keyID] = pub
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return auditchain.ParsePublicKeys(raw)
+}
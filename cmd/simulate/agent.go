@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"aihub/pkg/aihubclient"
+)
+
+// behavior is how simulated agents act on claimed work.
+type behavior struct {
+	latency      time.Duration // mean think time per work item
+	jitter       time.Duration // uniform +/- around latency
+	failRate     float64       // handler errors out; the item is left to its lease
+	stallRate    float64       // handler hangs past its lease
+	pollInterval time.Duration
+}
+
+var errSimulatedFailure = errors.New("simulated failure")
+
+// simAgent is one agent process: it claims, "thinks", writes and completes in a loop.
+type simAgent struct {
+	ref    string
+	client *aihubclient.Client
+	b      behavior
+	rng    *rand.Rand
+	stats  *stats
+}
+
+func (a *simAgent) run(ctx context.Context) {
+	loop := &aihubclient.WorkLoop{Client: a.client, Handler: a.handle}
+	for ctx.Err() == nil {
+		item, err := loop.RunOnce(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case item == nil && err == nil:
+			sleep(ctx, a.b.pollInterval)
+		case item == nil:
+			// Claim failed; already counted per operation.
+			sleep(ctx, a.b.pollInterval)
+		case errors.Is(err, aihubclient.ErrLeaseExpired):
+			a.stats.itemLeaseExpired()
+		case err != nil:
+			a.stats.itemFailed()
+		default:
+			a.stats.itemCompleted()
+		}
+	}
+}
+
+func (a *simAgent) handle(ctx context.Context, item *aihubclient.ClaimedWorkItem) error {
+	a.stats.itemClaimed(item.RunRef, time.Now())
+
+	think := a.b.latency
+	if a.b.jitter > 0 {
+		think += time.Duration(a.rng.Int64N(int64(2*a.b.jitter)+1)) - a.b.jitter
+	}
+	if !sleep(ctx, think) {
+		return ctx.Err()
+	}
+	switch r := a.rng.Float64(); {
+	case r < a.b.stallRate:
+		<-ctx.Done()
+		return ctx.Err()
+	case r < a.b.stallRate+a.b.failRate:
+		return errSimulatedFailure
+	}
+
+	if len(item.ReviewContext) > 0 {
+		target, _ := item.ReviewContext["target_artifact_id"].(string)
+		_, err := a.client.EmitEvent(ctx, item.RunRef, "summary", map[string]any{
+			"text":               fmt.Sprintf("%s 的模拟评审意见。", a.ref),
+			"target_artifact_id": target,
+		})
+		return err
+	}
+	if kind, _ := item.StageContext["kind"].(string); kind == "topic_play" {
+		// Topic writes go to OSS, which is out of scope for load tests; just finish the item.
+		return nil
+	}
+	text := fmt.Sprintf("%s 在阶段 %s 的模拟产出。", a.ref, item.Stage)
+	ev, err := a.client.EmitEvent(ctx, item.RunRef, "message", map[string]any{"text": text})
+	if err != nil {
+		return err
+	}
+	seq := ev.Seq
+	_, err = a.client.SubmitArtifact(ctx, item.RunRef, aihubclient.ArtifactRequest{Kind: aihubclient.ArtifactFinal, Content: text, LinkedEventSeq: &seq})
+	return err
+}
+
+// sleep waits d or until ctx is done; it reports whether the full wait elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
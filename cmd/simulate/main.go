@@ -0,0 +1,198 @@
+// Command simulate is a multi-agent load generator for a running AIHub API. It creates users and
+// agents with varied tags (through the admin key), publishes runs on a schedule, runs simulated
+// agents with configurable latency and failure rates, and reports claim latency, lease
+// expirations, throughput and per-operation error rates.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"aihub/pkg/aihubclient"
+)
+
+func main() {
+	var (
+		baseURL         = flag.String("base-url", envOr("AIHUB_BASE_URL", "http://localhost:8080"), "AIHub API base URL")
+		adminKey        = flag.String("admin-key", envOr("ADMIN_API_KEY", os.Getenv("AIHUB_ADMIN_API_KEY")), "Admin user API key (env ADMIN_API_KEY)")
+		users           = flag.Int("users", 3, "Number of simulated owners")
+		agentsPerUser   = flag.Int("agents-per-user", 3, "Agents created per owner")
+		tagsFlag        = flag.String("tags", "writing,poetry,code,review,translation,design", "Comma-separated tag pool; agents get 1-3 of them")
+		runs            = flag.Int("runs", 20, "Runs to publish")
+		publishInterval = flag.Duration("publish-interval", 2*time.Second, "Wait between published runs")
+		duration        = flag.Duration("duration", 2*time.Minute, "Total simulation time (agents keep working after the last run is published)")
+		latency         = flag.Duration("latency", 500*time.Millisecond, "Mean agent think time per work item")
+		jitter          = flag.Duration("latency-jitter", 250*time.Millisecond, "Uniform +/- jitter around -latency")
+		failRate        = flag.Float64("fail-rate", 0.05, "Probability that an agent abandons a claimed item (left to its lease)")
+		stallRate       = flag.Float64("stall-rate", 0.01, "Probability that an agent hangs until its lease expires")
+		pollInterval    = flag.Duration("poll-interval", time.Second, "Agent wait after an empty inbox")
+		seed            = flag.Uint64("seed", uint64(time.Now().UnixNano()), "Random seed (tags, latency, failures)")
+		cleanup         = flag.Bool("cleanup", true, "Delete the created agents and runs at the end")
+	)
+	flag.Parse()
+
+	if strings.TrimSpace(*adminKey) == "" {
+		fmt.Fprintln(os.Stderr, "missing -admin-key (or ADMIN_API_KEY)")
+		os.Exit(2)
+	}
+	tagPool := splitTags(*tagsFlag)
+	if *users <= 0 || *agentsPerUser <= 0 || len(tagPool) == 0 {
+		fmt.Fprintln(os.Stderr, "need -users > 0, -agents-per-user > 0 and a non-empty -tags")
+		os.Exit(2)
+	}
+	if *failRate < 0 || *stallRate < 0 || *failRate+*stallRate > 1 {
+		fmt.Fprintln(os.Stderr, "-fail-rate and -stall-rate must be >= 0 and sum to <= 1")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	st := newStats()
+	rng := rand.New(rand.NewPCG(*seed, 0))
+	// Namespace tags per simulation so runs only match this simulation's agents.
+	prefix := fmt.Sprintf("sim%d-", time.Now().Unix())
+	newClient := func(key string) *aihubclient.Client {
+		hc := &http.Client{Timeout: 30 * time.Second, Transport: recordingTransport{base: http.DefaultTransport, stats: st}}
+		c, err := aihubclient.New(*baseURL, aihubclient.WithAPIKey(key), aihubclient.WithHTTPClient(hc), aihubclient.WithUserAgent("aihub-simulate"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return c
+	}
+	admin := newClient(*adminKey)
+
+	// Setup: owners and agents.
+	b := behavior{latency: *latency, jitter: *jitter, failRate: *failRate, stallRate: *stallRate, pollInterval: *pollInterval}
+	var (
+		agents []*simAgent
+		owners = map[string]*aihubclient.Client{} // agent_ref -> owner client, for cleanup
+	)
+	for u := 0; u < *users; u++ {
+		issued, err := admin.IssueUserKey(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "issue user key:", err)
+			os.Exit(1)
+		}
+		owner := newClient(issued.APIKey)
+		for i := 0; i < *agentsPerUser; i++ {
+			tags := pickTags(rng, tagPool, prefix)
+			created, err := owner.CreateAgent(ctx, aihubclient.CreateAgentRequest{
+				Name:        fmt.Sprintf("%su%d-a%d", prefix, u, i),
+				Description: "simulated agent",
+				Tags:        tags,
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "create agent:", err)
+				os.Exit(1)
+			}
+			owners[created.AgentRef] = owner
+			agents = append(agents, &simAgent{
+				ref:    created.AgentRef,
+				client: newClient(created.APIKey),
+				b:      b,
+				rng:    rand.New(rand.NewPCG(*seed, uint64(len(agents)+1))),
+				stats:  st,
+			})
+		}
+	}
+	fmt.Fprintf(os.Stderr, "simulate: %d owners, %d agents, tag prefix %q\n", *users, len(agents), prefix)
+
+	simCtx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for _, a := range agents {
+		wg.Add(1)
+		go func(a *simAgent) {
+			defer wg.Done()
+			a.run(simCtx)
+		}(a)
+	}
+
+	// Publish runs on schedule; each requires one tag from the pool, or (sometimes) only the tag
+	// every simulated agent carries. Runs always require a prefixed tag so they are never offered
+	// to real agents.
+	var published []string
+	for i := 0; i < *runs && simCtx.Err() == nil; i++ {
+		req := aihubclient.CreateRunRequest{
+			Goal:        fmt.Sprintf("模拟任务 #%d：写一段简短的作品", i+1),
+			Constraints: "load test",
+		}
+		req.RequiredTags = []string{prefix + simAnyTag}
+		if rng.Float64() < 0.8 {
+			req.RequiredTags = []string{prefix + tagPool[rng.IntN(len(tagPool))]}
+		}
+		runRef, err := admin.AdminCreateRun(simCtx, req)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "publish run:", err)
+		} else {
+			st.runPublished(runRef, time.Now())
+			published = append(published, runRef)
+		}
+		sleep(simCtx, *publishInterval)
+	}
+
+	<-simCtx.Done()
+	wg.Wait()
+	st.report(os.Stdout, time.Since(start))
+
+	if *cleanup {
+		cctx, ccancel := context.WithTimeout(context.Background(), time.Minute)
+		defer ccancel()
+		for _, ref := range published {
+			if err := admin.AdminDeleteRun(cctx, ref); err != nil {
+				fmt.Fprintf(os.Stderr, "cleanup run %s: %v\n", ref, err)
+			}
+		}
+		for ref, owner := range owners {
+			if err := owner.DeleteAgent(cctx, ref); err != nil {
+				fmt.Fprintf(os.Stderr, "cleanup agent %s: %v\n", ref, err)
+			}
+		}
+	}
+}
+
+// simAnyTag (prefixed) is carried by every simulated agent; runs requiring only it match any of them.
+const simAnyTag = "any"
+
+// pickTags returns 1-3 distinct prefixed tags from pool, plus the prefixed simAnyTag.
+func pickTags(rng *rand.Rand, pool []string, prefix string) []string {
+	n := 1 + rng.IntN(3)
+	if n > len(pool) {
+		n = len(pool)
+	}
+	out := make([]string, 0, n+1)
+	for _, i := range rng.Perm(len(pool))[:n] {
+		out = append(out, prefix+pool[i])
+	}
+	return append(out, prefix+simAnyTag)
+}
+
+func splitTags(s string) []string {
+	var out []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func envOr(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// stats collects simulator measurements. All methods are safe for concurrent use.
+type stats struct {
+	mu sync.Mutex
+
+	ops map[string]*opStats // "POST /gateway/inbox/claim-next"
+
+	runsPublished   int
+	publishedAt     map[string]time.Time // run_ref -> publish time
+	claimed         int
+	completed       int
+	leaseExpired    int
+	handlerFailures int
+	offerToClaim    []time.Duration // run publish -> first claim of one of its work items
+}
+
+type opStats struct {
+	count     int
+	errors    int // transport errors and non-2xx (404 on claim-next excluded: it means "no offers")
+	byStatus  map[int]int
+	latencies []time.Duration
+}
+
+func newStats() *stats {
+	return &stats{ops: map[string]*opStats{}, publishedAt: map[string]time.Time{}}
+}
+
+func (s *stats) recordOp(op string, status int, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.ops[op]
+	if o == nil {
+		o = &opStats{byStatus: map[int]int{}}
+		s.ops[op] = o
+	}
+	o.count++
+	o.byStatus[status]++
+	o.latencies = append(o.latencies, d)
+	noOffers := status == http.StatusNotFound && strings.HasSuffix(op, "/claim-next")
+	if (status < 200 || status >= 300) && !noOffers {
+		o.errors++
+	}
+}
+
+func (s *stats) runPublished(runRef string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runsPublished++
+	s.publishedAt[runRef] = at
+}
+
+func (s *stats) itemClaimed(runRef string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claimed++
+	if t, ok := s.publishedAt[runRef]; ok {
+		s.offerToClaim = append(s.offerToClaim, at.Sub(t))
+		// Only the first claim per run measures matching latency; later stages wait on earlier ones.
+		delete(s.publishedAt, runRef)
+	}
+}
+
+func (s *stats) itemCompleted()    { s.mu.Lock(); s.completed++; s.mu.Unlock() }
+func (s *stats) itemLeaseExpired() { s.mu.Lock(); s.leaseExpired++; s.mu.Unlock() }
+func (s *stats) itemFailed()       { s.mu.Lock(); s.handlerFailures++; s.mu.Unlock() }
+
+func (s *stats) report(w io.Writer, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		secs = 1
+	}
+	fmt.Fprintf(w, "%-22s%s\n", "duration:", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "%-22s%d\n", "runs published:", s.runsPublished)
+	fmt.Fprintf(w, "%-22s%d\n", "work items claimed:", s.claimed)
+	fmt.Fprintf(w, "%-22s%d (%.2f/s)\n", "work items completed:", s.completed, float64(s.completed)/secs)
+	fmt.Fprintf(w, "%-22s%d\n", "lease expirations:", s.leaseExpired)
+	fmt.Fprintf(w, "%-22s%d\n", "handler failures:", s.handlerFailures)
+	fmt.Fprintf(w, "%-22s%s\n", "publish->claim:", percentiles(s.offerToClaim))
+	fmt.Fprintln(w)
+
+	names := make([]string, 0, len(s.ops))
+	for name := range s.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "operation\tcount\trate/s\terrors\terror%\tlatency\tstatuses")
+	for _, name := range names {
+		o := s.ops[name]
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\t%d\t%.1f\t%s\t%s\n",
+			name, o.count, float64(o.count)/secs, o.errors, 100*float64(o.errors)/float64(o.count),
+			percentiles(o.latencies), statusSummary(o.byStatus))
+	}
+	_ = tw.Flush()
+}
+
+func percentiles(ds []time.Duration) string {
+	if len(ds) == 0 {
+		return "-"
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) time.Duration {
+		return sorted[int(p*float64(len(sorted)-1))].Round(time.Millisecond)
+	}
+	return fmt.Sprintf("p50=%s p95=%s p99=%s max=%s", at(0.50), at(0.95), at(0.99), sorted[len(sorted)-1].Round(time.Millisecond))
+}
+
+func statusSummary(m map[int]int) string {
+	codes := make([]int, 0, len(m))
+	for c := range m {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+	parts := make([]string, 0, len(codes))
+	for _, c := range codes {
+		label := fmt.Sprint(c)
+		if c == 0 {
+			label = "neterr"
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d", label, m[c]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// recordingTransport times every API call and files it under a route-shaped operation name.
+type recordingTransport struct {
+	base  http.RoundTripper
+	stats *stats
+}
+
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	t.stats.recordOp(opName(req.Method, req.URL.Path), status, time.Since(start))
+	return resp, err
+}
+
+// idSegments are path segments followed by an identifier.
+var idSegments = map[string]bool{"work-items": true, "runs": true, "agents": true, "topics": true}
+
+// opName turns "/v1/gateway/runs/r_abc/events" into "POST /gateway/runs/{id}/events".
+func opName(method, path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/v1"), "/")
+	for i := 1; i < len(parts); i++ {
+		if idSegments[parts[i-1]] && parts[i] != "" {
+			parts[i] = "{id}"
+		}
+	}
+	return method + " " + strings.Join(parts, "/")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpNameAndReport(t *testing.T) {
+	cases := map[string]string{
+		"/v1/gateway/runs/r_abc/events":        "POST /gateway/runs/{id}/events",
+		"/v1/gateway/work-items/wi_1/complete": "POST /gateway/work-items/{id}/complete",
+		"/v1/gateway/inbox/claim-next":         "POST /gateway/inbox/claim-next",
+		"/v1/gateway/topics/t_1/messages:text": "POST /gateway/topics/{id}/messages:text",
+		"/v1/admin/runs":                       "POST /admin/runs",
+	}
+	for path, want := range cases {
+		if got := opName("POST", path); got != want {
+			t.Errorf("opName(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	st := newStats()
+	st.recordOp("POST /gateway/inbox/claim-next", 404, time.Millisecond)
+	st.recordOp("POST /gateway/inbox/claim-next", 200, 2*time.Millisecond)
+	st.recordOp("POST /gateway/runs/{id}/events", 500, time.Millisecond)
+	st.runPublished("r_1", time.Now().Add(-time.Second))
+	st.itemClaimed("r_1", time.Now())
+	st.itemClaimed("r_1", time.Now())
+	if len(st.offerToClaim) != 1 || st.claimed != 2 {
+		t.Fatalf("offerToClaim=%v claimed=%d", st.offerToClaim, st.claimed)
+	}
+	if st.ops["POST /gateway/inbox/claim-next"].errors != 0 || st.ops["POST /gateway/runs/{id}/events"].errors != 1 {
+		t.Fatalf("empty inbox must not count as an error")
+	}
+	var buf bytes.Buffer
+	st.report(&buf, time.Second)
+	if !strings.Contains(buf.String(), "200:1 404:1") {
+		t.Fatalf("report:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,174 @@
+// Package auditchain computes and verifies the tamper-evident hash chains kept over
+// audit_logs and moderation_actions.
+//
+// Each chained row stores chain_seq (1, 2, 3, ...), prev_hash (the previous row's row_hash, ""
+// for the first row) and row_hash = hex(sha256(canonical JSON of {chain, seq, prev_hash, row})).
+// Editing a row breaks its row_hash; deleting one leaves a seq gap or a prev_hash mismatch; and
+// truncating the tail is caught by the chain head and the signed checkpoints.
+package auditchain
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ChainAuditLogs         = "audit_logs"
+	ChainModerationActions = "moderation_actions"
+
+	CheckpointKind = "audit_chain_checkpoint"
+)
+
+// Chains lists every chain in verification order.
+var Chains = []string{ChainAuditLogs, ChainModerationActions}
+
+// NormalizeTime returns t in UTC at Postgres timestamptz precision (microseconds), so the value
+// hashed at insert time equals the value read back from the DB.
+func NormalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func formatTime(t time.Time) string {
+	return NormalizeTime(t).Format(time.RFC3339Nano)
+}
+
+// AuditLogRow is the hashed projection of an audit_logs row.
+func AuditLogRow(id uuid.UUID, actorType string, actorID uuid.UUID, action string, runID *uuid.UUID, data map[string]any, createdAt time.Time) map[string]any {
+	if data == nil {
+		data = map[string]any{}
+	}
+	run := ""
+	if runID != nil {
+		run = runID.String()
+	}
+	return map[string]any{
+		"id":         id.String(),
+		"actor_type": actorType,
+		"actor_id":   actorID.String(),
+		"action":     action,
+		"run_id":     run,
+		"data":       data,
+		"created_at": formatTime(createdAt),
+	}
+}
+
+// ModerationActionRow is the hashed projection of a moderation_actions row.
+func ModerationActionRow(id uuid.UUID, actorType string, actorID uuid.UUID, targetType string, targetID uuid.UUID, action string, reason string, createdAt time.Time) map[string]any {
+	return map[string]any{
+		"id":          id.String(),
+		"actor_type":  actorType,
+		"actor_id":    actorID.String(),
+		"target_type": targetType,
+		"target_id":   targetID.String(),
+		"action":      action,
+		"reason":      reason,
+		"created_at":  formatTime(createdAt),
+	}
+}
+
+// RowHash returns the hex sha256 over the canonical JSON of the chain link.
+func RowHash(chain string, seq int64, prevHash string, row map[string]any) (string, error) {
+	canonical, err := agenthome.CanonicalJSON(map[string]any{
+		"chain":     chain,
+		"seq":       seq,
+		"prev_hash": prevHash,
+		"row":       row,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// DecodeJSONB decodes a jsonb column into the map shape used for hashing.
+func DecodeJSONB(b []byte) (map[string]any, error) {
+	out := map[string]any{}
+	if len(b) == 0 {
+		return out, nil
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = map[string]any{}
+	}
+	return out, nil
+}
+
+// CheckpointPayload is the signable body of a checkpoint (the platform cert is added under "cert").
+func CheckpointPayload(chain string, seq int64, headHash string, createdAt time.Time) map[string]any {
+	return map[string]any{
+		"kind":       CheckpointKind,
+		"chain":      chain,
+		"seq":        seq,
+		"head_hash":  headHash,
+		"created_at": formatTime(createdAt),
+	}
+}
+
+// VerifyCheckpointSignature checks the platform Ed25519 signature of a stored checkpoint payload.
+// Cert expiry is ignored on purpose: checkpoints must stay verifiable for the lifetime of the log.
+func VerifyCheckpointSignature(payload map[string]any, publicKeys map[string]ed25519.PublicKey) error {
+	certMap, ok := payload["cert"].(map[string]any)
+	if !ok {
+		return errors.New("missing cert")
+	}
+	keyID, _ := certMap["key_id"].(string)
+	sigB64, _ := certMap["signature"].(string)
+	keyID = strings.TrimSpace(keyID)
+	if keyID == "" || strings.TrimSpace(sigB64) == "" {
+		return errors.New("missing cert.key_id or cert.signature")
+	}
+	pub, ok := publicKeys[keyID]
+	if !ok {
+		return fmt.Errorf("unknown key_id: %s", keyID)
+	}
+
+	signable := make(map[string]any, len(payload))
+	for k, v := range payload {
+		if k == "cert" {
+			continue
+		}
+		signable[k] = v
+	}
+	canonical, err := agenthome.CanonicalJSON(signable)
+	if err != nil {
+		return err
+	}
+	okSig, err := agenthome.VerifyEd25519Base64(pub, canonical, sigB64)
+	if err != nil {
+		return err
+	}
+	if !okSig {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+// ParsePublicKeys decodes base64 Ed25519 public keys keyed by key_id.
+func ParsePublicKeys(in map[string]string) (map[string]ed25519.PublicKey, error) {
+	out := make(map[string]ed25519.PublicKey, len(in))
+	for keyID, b64 := range in {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", keyID, err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: invalid ed25519 public key length: %d", keyID, len(raw))
+		}
+		out[keyID] = ed25519.PublicKey(raw)
+	}
+	return out, nil
+}
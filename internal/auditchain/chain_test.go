@@ -0,0 +1,154 @@
+package auditchain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+)
+
+type testLink struct {
+	seq      int64
+	id       uuid.UUID
+	prevHash string
+	hash     string
+	row      map[string]any
+}
+
+func buildChain(t *testing.T, n int) []testLink {
+	t.Helper()
+	var out []testLink
+	prev := ""
+	base := time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	for i := 1; i <= n; i++ {
+		id := uuid.New()
+		row := AuditLogRow(id, "agent", uuid.New(), "work_item_completed", nil, map[string]any{"n": i, "note": "<ok>"}, base.Add(time.Duration(i)*time.Second))
+		h, err := RowHash(ChainAuditLogs, int64(i), prev, row)
+		if err != nil {
+			t.Fatalf("RowHash: %v", err)
+		}
+		out = append(out, testLink{seq: int64(i), id: id, prevHash: prev, hash: h, row: row})
+		prev = h
+	}
+	return out
+}
+
+func verify(links []testLink, headSeq int64, headHash string) *Verifier {
+	v := NewVerifier(ChainAuditLogs)
+	for _, l := range links {
+		v.Add(l.seq, l.id.String(), l.prevHash, l.hash, l.row)
+	}
+	v.Finish(headSeq, headHash)
+	return v
+}
+
+func problemKinds(v *Verifier) map[string]bool {
+	out := map[string]bool{}
+	for _, p := range v.Problems {
+		out[p.Kind] = true
+	}
+	return out
+}
+
+func TestVerifierIntactChain(t *testing.T) {
+	links := buildChain(t, 4)
+	v := verify(links, 4, links[3].hash)
+	if len(v.Problems) != 0 {
+		t.Fatalf("expected no problems, got %+v", v.Problems)
+	}
+}
+
+func TestVerifierDetectsTampering(t *testing.T) {
+	t.Run("edited row", func(t *testing.T) {
+		links := buildChain(t, 3)
+		links[1].row["action"] = "something_else"
+		if !problemKinds(verify(links, 3, links[2].hash))[ProblemHashMismatch] {
+			t.Fatal("expected hash_mismatch")
+		}
+	})
+	t.Run("deleted row", func(t *testing.T) {
+		links := buildChain(t, 3)
+		kinds := problemKinds(verify([]testLink{links[0], links[2]}, 3, links[2].hash))
+		if !kinds[ProblemGap] || !kinds[ProblemPrevHashMismatch] {
+			t.Fatalf("expected gap + prev_hash_mismatch, got %v", kinds)
+		}
+	})
+	t.Run("truncated tail", func(t *testing.T) {
+		links := buildChain(t, 3)
+		if !problemKinds(verify(links[:2], 3, links[2].hash))[ProblemHeadMismatch] {
+			t.Fatal("expected head_mismatch")
+		}
+	})
+	t.Run("truncated tail with rewound head", func(t *testing.T) {
+		links := buildChain(t, 3)
+		v := NewVerifier(ChainAuditLogs)
+		v.ExpectCheckpoint(3, links[2].hash)
+		for _, l := range links[:2] {
+			v.Add(l.seq, l.id.String(), l.prevHash, l.hash, l.row)
+		}
+		v.Finish(2, links[1].hash)
+		if !problemKinds(v)[ProblemCheckpointMissing] {
+			t.Fatalf("expected checkpoint_missing_row, got %+v", v.Problems)
+		}
+	})
+}
+
+func TestRowHashStableAcrossJSONBRoundTrip(t *testing.T) {
+	id, actor := uuid.New(), uuid.New()
+	createdAt := NormalizeTime(time.Now())
+	data := map[string]any{"run_id": uuid.New().String(), "seq": int64(42), "tags": []string{"b", "a"}}
+	h1, err := RowHash(ChainAuditLogs, 7, "prev", AuditLogRow(id, "agent", actor, "event_emitted", nil, data, createdAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate storing data in jsonb and reading it back.
+	b, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeJSONB(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := RowHash(ChainAuditLogs, 7, "prev", AuditLogRow(id, "agent", actor, "event_emitted", nil, decoded, createdAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 != h2 {
+		t.Fatalf("hash changed after round trip: %s != %s", h1, h2)
+	}
+}
+
+func TestVerifyCheckpointSignature(t *testing.T) {
+	pub, priv, err := agenthome.GenerateEd25519Keypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := CheckpointPayload(ChainModerationActions, 9, "abc", time.Now())
+	canonical, err := agenthome.CanonicalJSON(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := agenthome.SignEd25519Base64(priv, canonical)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload["cert"] = map[string]any{"key_id": "k1", "signature": sig}
+
+	keys, err := ParsePublicKeys(map[string]string{"k1": base64.StdEncoding.EncodeToString(pub)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyCheckpointSignature(payload, keys); err != nil {
+		t.Fatalf("expected valid signature: %v", err)
+	}
+	payload["head_hash"] = "tampered"
+	if err := VerifyCheckpointSignature(payload, keys); err == nil {
+		t.Fatal("expected signature failure after tampering")
+	}
+}
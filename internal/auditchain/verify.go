@@ -0,0 +1,100 @@
+package auditchain
+
+import "fmt"
+
+// Problem kinds reported by Verifier.
+const (
+	ProblemGap                = "gap"
+	ProblemDuplicateSeq       = "duplicate_seq"
+	ProblemPrevHashMismatch   = "prev_hash_mismatch"
+	ProblemHashMismatch       = "hash_mismatch"
+	ProblemHeadMismatch       = "head_mismatch"
+	ProblemCheckpointMismatch = "checkpoint_mismatch"
+	ProblemCheckpointMissing  = "checkpoint_missing_row"
+	ProblemCheckpointSig      = "checkpoint_signature"
+)
+
+type Problem struct {
+	Chain  string `json:"chain"`
+	Seq    int64  `json:"seq"`
+	RowID  string `json:"row_id,omitempty"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// Verifier walks one chain in chain_seq order and records every inconsistency it sees.
+type Verifier struct {
+	chain       string
+	expectSeq   int64
+	prevHash    string
+	checkpoints map[int64]string
+
+	Rows     int64
+	Problems []Problem
+}
+
+func NewVerifier(chain string) *Verifier {
+	return &Verifier{chain: chain, expectSeq: 1, checkpoints: map[int64]string{}}
+}
+
+// ExpectCheckpoint registers a signed checkpoint (seq -> head hash) to compare against while walking.
+// Call it before Add.
+func (v *Verifier) ExpectCheckpoint(seq int64, headHash string) {
+	v.checkpoints[seq] = headHash
+}
+
+func (v *Verifier) report(seq int64, rowID string, kind string, detail string) {
+	v.Problems = append(v.Problems, Problem{Chain: v.chain, Seq: seq, RowID: rowID, Kind: kind, Detail: detail})
+}
+
+// Add checks the next stored row. row is the hashed projection (AuditLogRow / ModerationActionRow).
+func (v *Verifier) Add(seq int64, rowID string, prevHash string, storedHash string, row map[string]any) {
+	v.Rows++
+
+	switch {
+	case seq < v.expectSeq:
+		v.report(seq, rowID, ProblemDuplicateSeq, fmt.Sprintf("seq %d seen after %d", seq, v.expectSeq-1))
+	case seq > v.expectSeq:
+		v.report(seq, rowID, ProblemGap, fmt.Sprintf("missing seq %d..%d", v.expectSeq, seq-1))
+	}
+	if prevHash != v.prevHash {
+		v.report(seq, rowID, ProblemPrevHashMismatch, fmt.Sprintf("prev_hash %q, previous row_hash %q", prevHash, v.prevHash))
+	}
+
+	computed, err := RowHash(v.chain, seq, prevHash, row)
+	if err != nil {
+		v.report(seq, rowID, ProblemHashMismatch, "hash failed: "+err.Error())
+	} else if computed != storedHash {
+		v.report(seq, rowID, ProblemHashMismatch, fmt.Sprintf("stored %s, computed %s", storedHash, computed))
+	}
+
+	if want, ok := v.checkpoints[seq]; ok {
+		if want != storedHash {
+			v.report(seq, rowID, ProblemCheckpointMismatch, fmt.Sprintf("checkpoint head_hash %s, row_hash %s", want, storedHash))
+		}
+		delete(v.checkpoints, seq)
+	}
+
+	v.prevHash = storedHash
+	if seq >= v.expectSeq {
+		v.expectSeq = seq + 1
+	}
+}
+
+// Finish compares the walked tail with the recorded chain head and flags checkpoints whose row
+// no longer exists (tail truncation).
+func (v *Verifier) Finish(headSeq int64, headHash string) {
+	lastSeq := v.expectSeq - 1
+	if headSeq != lastSeq || headHash != v.prevHash {
+		v.report(headSeq, "", ProblemHeadMismatch, fmt.Sprintf("head (%d, %s), last row (%d, %s)", headSeq, headHash, lastSeq, v.prevHash))
+	}
+	for seq := range v.checkpoints {
+		v.report(seq, "", ProblemCheckpointMissing, "checkpointed row not found")
+	}
+	v.checkpoints = map[int64]string{}
+}
+
+// AddCheckpointSignatureProblem records a checkpoint whose platform signature does not verify.
+func (v *Verifier) AddCheckpointSignatureProblem(seq int64, err error) {
+	v.report(seq, "", ProblemCheckpointSig, err.Error())
+}
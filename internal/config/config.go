@@ -2,6 +2,8 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -38,6 +40,7 @@ type Config struct {
 	OSSAccessKeySecret    string
 	OSSSTSRoleARN         string
 	OSSSTSDurationSeconds int
+	OSSSTSHourlyLimit     int
 	OSSLocalDir           string
 	OSSEventsIngestToken  string
 
@@ -53,6 +56,13 @@ type Config struct {
 	// Platform-issued "topic play" work items (topic-first self-play; agents claim via inbox).
 	TopicPlayActorTags          []string
 	TopicPlayDailyLimitPerAgent int
+
+	// Outbound webhooks: allow loopback/private targets (local development and tests only).
+	WebhookAllowPrivateTargets bool
+
+	// Rate limiting: bucket store ("memory" or "postgres") and proxies whose X-Forwarded-For is trusted.
+	RateLimitStore string
+	TrustedProxies []string
 }
 
 func Load() (Config, error) {
@@ -94,6 +104,13 @@ func Load() (Config, error) {
 	if stsDuration > 3600 {
 		stsDuration = 3600
 	}
+	stsHourlyLimit := getenvIntDefault("AIHUB_OSS_STS_HOURLY_LIMIT_PER_AGENT", 60)
+	if stsHourlyLimit < 1 {
+		stsHourlyLimit = 1
+	}
+	if stsHourlyLimit > 1000 {
+		stsHourlyLimit = 1000
+	}
 
 	taskGenDailyLimit := getenvIntDefault("AIHUB_TASKGEN_DAILY_LIMIT_PER_AGENT", 3)
 	if taskGenDailyLimit < 0 {
@@ -146,6 +163,7 @@ func Load() (Config, error) {
 		OSSAccessKeySecret:    strings.TrimSpace(os.Getenv("AIHUB_OSS_ACCESS_KEY_SECRET")),
 		OSSSTSRoleARN:         strings.TrimSpace(os.Getenv("AIHUB_OSS_STS_ROLE_ARN")),
 		OSSSTSDurationSeconds: stsDuration,
+		OSSSTSHourlyLimit:     stsHourlyLimit,
 		OSSLocalDir:           strings.TrimSpace(os.Getenv("AIHUB_OSS_LOCAL_DIR")),
 		OSSEventsIngestToken:  strings.TrimSpace(os.Getenv("AIHUB_OSS_EVENTS_INGEST_TOKEN")),
 
@@ -158,6 +176,11 @@ func Load() (Config, error) {
 
 		TopicPlayActorTags:          getenvCSV("AIHUB_TOPICPLAY_ACTOR_TAGS"),
 		TopicPlayDailyLimitPerAgent: topicPlayDailyLimit,
+
+		WebhookAllowPrivateTargets: getenvBool("AIHUB_WEBHOOK_ALLOW_PRIVATE_TARGETS"),
+
+		RateLimitStore: strings.ToLower(strings.TrimSpace(getenvDefault("AIHUB_RATE_LIMIT_STORE", "memory"))),
+		TrustedProxies: getenvCSV("AIHUB_TRUSTED_PROXIES"),
 	}
 
 	if cfg.DatabaseURL == "" {
@@ -166,6 +189,14 @@ func Load() (Config, error) {
 	if cfg.APIKeyPepper == "" {
 		return Config{}, errors.New("AIHUB_API_KEY_PEPPER is required")
 	}
+	if cfg.RateLimitStore != "memory" && cfg.RateLimitStore != "postgres" {
+		return Config{}, fmt.Errorf("AIHUB_RATE_LIMIT_STORE must be memory or postgres, got %q", cfg.RateLimitStore)
+	}
+	for _, v := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(v); err != nil && net.ParseIP(v) == nil {
+			return Config{}, fmt.Errorf("AIHUB_TRUSTED_PROXIES: invalid address or CIDR %q", v)
+		}
+	}
 	return cfg, nil
 }
 
@@ -188,6 +219,15 @@ func getenvIntDefault(key string, fallback int) int {
 	return n
 }
 
+func getenvBool(key string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
+}
+
 func getenvCSV(key string) []string {
 	v := strings.TrimSpace(os.Getenv(key))
 	if v == "" {
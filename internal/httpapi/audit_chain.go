@@ -0,0 +1,134 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"aihub/internal/agenthome"
+	"aihub/internal/auditchain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// lockAuditChainHead locks the chain head for the rest of tx and returns the last (seq, hash).
+func lockAuditChainHead(ctx context.Context, tx pgx.Tx, chain string) (int64, string, error) {
+	var seq int64
+	var hash string
+	err := tx.QueryRow(ctx, `
+		select last_seq, last_hash
+		from audit_chain_heads
+		where chain = $1
+		for update
+	`, chain).Scan(&seq, &hash)
+	return seq, hash, err
+}
+
+func advanceAuditChainHead(ctx context.Context, tx pgx.Tx, chain string, seq int64, hash string) error {
+	_, err := tx.Exec(ctx, `
+		update audit_chain_heads
+		set last_seq = $2, last_hash = $3, updated_at = now()
+		where chain = $1
+	`, chain, seq, hash)
+	return err
+}
+
+// insertAuditLogInTx appends a chained audit_logs row.
+func insertAuditLogInTx(ctx context.Context, tx pgx.Tx, actorType string, actorID uuid.UUID, action string, data map[string]any) error {
+	if data == nil {
+		data = map[string]any{}
+	}
+	// Promote data.run_id to the indexed run_id column so audit queries can filter by run.
+	var runID *uuid.UUID
+	if v, ok := data["run_id"].(string); ok {
+		if id, err := uuid.Parse(v); err == nil {
+			runID = &id
+		}
+	}
+
+	lastSeq, lastHash, err := lockAuditChainHead(ctx, tx, auditchain.ChainAuditLogs)
+	if err != nil {
+		return err
+	}
+	id := uuid.New()
+	createdAt := auditchain.NormalizeTime(time.Now())
+	seq := lastSeq + 1
+	row := auditchain.AuditLogRow(id, actorType, actorID, action, runID, data, createdAt)
+	rowHash, err := auditchain.RowHash(auditchain.ChainAuditLogs, seq, lastHash, row)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		insert into audit_logs (id, actor_type, actor_id, action, run_id, data, created_at, chain_seq, prev_hash, row_hash)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, id, actorType, actorID, action, runID, data, createdAt, seq, lastHash, rowHash); err != nil {
+		return err
+	}
+	return advanceAuditChainHead(ctx, tx, auditchain.ChainAuditLogs, seq, rowHash)
+}
+
+// insertModerationActionInTx appends a chained moderation_actions row inside the caller's tx,
+// so the review_status change and its moderation record commit together.
+func insertModerationActionInTx(ctx context.Context, tx pgx.Tx, actorType string, actorID uuid.UUID, targetType string, targetID uuid.UUID, action string, reason string) error {
+	lastSeq, lastHash, err := lockAuditChainHead(ctx, tx, auditchain.ChainModerationActions)
+	if err != nil {
+		return err
+	}
+	id := uuid.New()
+	createdAt := auditchain.NormalizeTime(time.Now())
+	seq := lastSeq + 1
+	row := auditchain.ModerationActionRow(id, actorType, actorID, targetType, targetID, action, reason, createdAt)
+	rowHash, err := auditchain.RowHash(auditchain.ChainModerationActions, seq, lastHash, row)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		insert into moderation_actions (id, actor_type, actor_id, target_type, target_id, action, reason, created_at, chain_seq, prev_hash, row_hash)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, id, actorType, actorID, targetType, targetID, action, reason, createdAt, seq, lastHash, rowHash); err != nil {
+		return err
+	}
+	return advanceAuditChainHead(ctx, tx, auditchain.ChainModerationActions, seq, rowHash)
+}
+
+// checkpointAuditChainsTick signs the current head of every chain that advanced since its last
+// checkpoint. It is a no-op until platform key encryption is configured.
+func (s server) checkpointAuditChainsTick(ctx context.Context) {
+	for _, chain := range auditchain.Chains {
+		var headSeq, lastCheckpointSeq int64
+		var headHash string
+		if err := s.db.QueryRow(ctx, `
+			select h.last_seq, h.last_hash,
+			       coalesce((select max(c.seq) from audit_chain_checkpoints c where c.chain = h.chain), 0)
+			from audit_chain_heads h
+			where h.chain = $1
+		`, chain).Scan(&headSeq, &headHash, &lastCheckpointSeq); err != nil {
+			logError(ctx, "audit chain checkpoint: read head failed", err)
+			continue
+		}
+		if headSeq == 0 || headSeq == lastCheckpointSeq {
+			continue
+		}
+
+		payload := auditchain.CheckpointPayload(chain, headSeq, headHash, time.Now())
+		cert, err := s.signObject(ctx, payload)
+		if err != nil {
+			if errors.Is(err, agenthome.ErrMissingEncryptionKey) {
+				return
+			}
+			logError(ctx, "audit chain checkpoint: sign failed", err)
+			return
+		}
+		payload["cert"] = cert
+
+		if _, err := s.db.Exec(ctx, `
+			insert into audit_chain_checkpoints (chain, seq, head_hash, payload, key_id)
+			values ($1, $2, $3, $4, $5)
+		`, chain, headSeq, headHash, payload, cert.KeyID); err != nil {
+			logError(ctx, "audit chain checkpoint: insert failed", err)
+		}
+	}
+}
@@ -0,0 +1,490 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Automatic pre-moderation: every new run/event/artifact/topic object is run through a list of
+// checks (admin rules, privacy findings, actor trust, posting rate). Their signals decide between
+// auto-approve, auto-reject and escalate-to-human; the decision and its reasons are recorded in
+// moderation_actions like a human one. Items with no signal simply stay pending.
+
+type moderationVerdict string
+
+const (
+	moderationVerdictApprove  moderationVerdict = "approve"
+	moderationVerdictReject   moderationVerdict = "reject"
+	moderationVerdictEscalate moderationVerdict = "escalate"
+)
+
+type moderationSignal struct {
+	Check   string            `json:"check"`
+	Verdict moderationVerdict `json:"verdict"`
+	Reason  string            `json:"reason"`
+}
+
+// moderationItem is one new piece of content. Value is the text or decoded JSON to check.
+type moderationItem struct {
+	TargetType string
+	TargetID   uuid.UUID
+	ActorType  string // "user" | "agent"
+	ActorID    uuid.UUID
+	Value      any
+}
+
+// moderationCheck is one stage of the pipeline. Checks must not write; they only report signals.
+type moderationCheck func(ctx context.Context, m *autoModerator, cfg autoModerationConfig, it moderationItem) []moderationSignal
+
+// defaultModerationChecks is the pipeline order; new checks are appended here.
+var defaultModerationChecks = []moderationCheck{
+	checkModerationRules,
+	checkModerationPrivacy,
+	checkModerationTrust,
+	checkModerationRate,
+}
+
+type moderationDecision struct {
+	Action  string             `json:"action"` // auto_approve | auto_reject | escalate | "" (left pending)
+	Signals []moderationSignal `json:"signals"`
+}
+
+// reason is the moderation_actions reason: the deciding signals, most specific first.
+func (d moderationDecision) reason() string {
+	parts := make([]string, 0, len(d.Signals))
+	for _, sig := range d.Signals {
+		parts = append(parts, sig.Check+": "+sig.Reason)
+	}
+	out := strings.Join(parts, "; ")
+	if r := []rune(out); len(r) > 2000 {
+		out = string(r[:2000])
+	}
+	return out
+}
+
+// decideAutoModeration picks the strictest verdict: any reject wins, then any escalate; approve
+// only applies when nothing objected.
+func decideAutoModeration(signals []moderationSignal) moderationDecision {
+	byVerdict := map[moderationVerdict][]moderationSignal{}
+	for _, sig := range signals {
+		byVerdict[sig.Verdict] = append(byVerdict[sig.Verdict], sig)
+	}
+	switch {
+	case len(byVerdict[moderationVerdictReject]) > 0:
+		return moderationDecision{Action: "auto_reject", Signals: byVerdict[moderationVerdictReject]}
+	case len(byVerdict[moderationVerdictEscalate]) > 0:
+		return moderationDecision{Action: "escalate", Signals: byVerdict[moderationVerdictEscalate]}
+	case len(byVerdict[moderationVerdictApprove]) > 0:
+		return moderationDecision{Action: "auto_approve", Signals: byVerdict[moderationVerdictApprove]}
+	default:
+		return moderationDecision{Signals: []moderationSignal{}}
+	}
+}
+
+// --- Rules
+
+type moderationRule struct {
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name"`
+	MatchType   string            `json:"match_type"` // keyword | regex
+	Pattern     string            `json:"pattern"`
+	Decision    moderationVerdict `json:"decision"` // reject | escalate
+	TargetTypes []string          `json:"target_types"`
+	Enabled     bool              `json:"enabled"`
+
+	keyword string
+	re      *regexp.Regexp
+}
+
+var errModerationRuleInvalid = errors.New("invalid moderation rule")
+
+// compile validates the rule and prepares its matcher.
+func (r *moderationRule) compile() error {
+	switch r.MatchType {
+	case "keyword":
+		r.keyword = compactModerationText(r.Pattern)
+		if r.keyword == "" {
+			return fmt.Errorf("%w: keyword has no letters or digits", errModerationRuleInvalid)
+		}
+	case "regex":
+		re, err := regexp.Compile("(?i)" + normalizeModerationText(r.Pattern))
+		if err != nil {
+			return fmt.Errorf("%w: %v", errModerationRuleInvalid, err)
+		}
+		r.re = re
+	default:
+		return fmt.Errorf("%w: invalid match_type", errModerationRuleInvalid)
+	}
+	if r.Decision != moderationVerdictReject && r.Decision != moderationVerdictEscalate {
+		return fmt.Errorf("%w: invalid decision", errModerationRuleInvalid)
+	}
+	for _, t := range r.TargetTypes {
+		if !isAutoModerationTargetType(t) {
+			return fmt.Errorf("%w: invalid target type %q", errModerationRuleInvalid, t)
+		}
+	}
+	return nil
+}
+
+func (r moderationRule) appliesTo(targetType string) bool {
+	if len(r.TargetTypes) == 0 {
+		return true
+	}
+	for _, t := range r.TargetTypes {
+		if t == targetType {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the rule matches; normalized and compact are the item text in the forms
+// produced by normalizeModerationText and compactModerationText.
+func (r moderationRule) match(normalized, compact string) bool {
+	switch {
+	case r.keyword != "":
+		return strings.Contains(compact, r.keyword)
+	case r.re != nil:
+		return r.re.MatchString(normalized)
+	}
+	return false
+}
+
+// autoModerationTargetTypes are the content types the pipeline runs on.
+var autoModerationTargetTypes = []string{"run", "event", "artifact", "topic_message", "topic_request"}
+
+func isAutoModerationTargetType(t string) bool {
+	for _, it := range autoModerationTargetTypes {
+		if it == t {
+			return true
+		}
+	}
+	return false
+}
+
+// moderationText flattens the string leaves of v (text or decoded JSON) into one text; keys are
+// not included.
+func moderationText(v any) string {
+	var b strings.Builder
+	var walk func(node any, depth int)
+	walk = func(node any, depth int) {
+		if depth > 10 || b.Len() > 256*1024 {
+			return
+		}
+		switch t := node.(type) {
+		case string:
+			b.WriteString(t)
+			b.WriteByte('\n')
+		case map[string]any:
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(t[k], depth+1)
+			}
+		case []any:
+			for _, it := range t {
+				walk(it, depth+1)
+			}
+		}
+	}
+	walk(v, 0)
+	return b.String()
+}
+
+func checkModerationRules(_ context.Context, _ *autoModerator, cfg autoModerationConfig, it moderationItem) []moderationSignal {
+	text := moderationText(it.Value)
+	if text == "" {
+		return nil
+	}
+	normalized, compact := normalizeModerationText(text), compactModerationText(text)
+	var out []moderationSignal
+	for _, r := range cfg.Rules {
+		if !r.Enabled || !r.appliesTo(it.TargetType) || !r.match(normalized, compact) {
+			continue
+		}
+		name := r.Name
+		if name == "" {
+			name = r.ID.String()
+		}
+		out = append(out, moderationSignal{Check: "rule", Verdict: r.Decision, Reason: "matched " + r.MatchType + " rule " + name})
+	}
+	return out
+}
+
+// checkModerationPrivacy escalates content that still carries privacy findings after the write-time
+// policy (kinds the policy flags or allows).
+func checkModerationPrivacy(_ context.Context, _ *autoModerator, _ autoModerationConfig, it moderationItem) []moderationSignal {
+	findings := detectPrivacyFindings(it.Value, "content")
+	if len(findings) == 0 {
+		return nil
+	}
+	kinds := make([]string, 0, len(findings))
+	for _, k := range privacyKinds(findings) {
+		kinds = append(kinds, string(k))
+	}
+	return []moderationSignal{{Check: "privacy", Verdict: moderationVerdictEscalate, Reason: "privacy findings: " + strings.Join(kinds, ",")}}
+}
+
+func checkModerationTrust(ctx context.Context, m *autoModerator, cfg autoModerationConfig, it moderationItem) []moderationSignal {
+	approved, rejected, err := m.actorHistory(ctx, it.ActorType, it.ActorID)
+	if err != nil {
+		logError(ctx, "auto moderation: actor history failed", err)
+		return nil
+	}
+	if sig, ok := trustSignal(cfg.Settings, approved, rejected); ok {
+		return []moderationSignal{sig}
+	}
+	return nil
+}
+
+// trustSignal maps an actor's review history to a trust level: restricted actors always go to a
+// human; trusted ones (enough approvals, no rejection) may be auto-approved.
+func trustSignal(st autoModerationSettings, approved, rejected int) (moderationSignal, bool) {
+	switch {
+	case st.RestrictedMinRejected > 0 && rejected >= st.RestrictedMinRejected:
+		return moderationSignal{Check: "trust", Verdict: moderationVerdictEscalate, Reason: fmt.Sprintf("restricted actor (%d rejected)", rejected)}, true
+	case st.AutoApproveTrusted && rejected == 0 && approved >= st.TrustedMinApproved:
+		return moderationSignal{Check: "trust", Verdict: moderationVerdictApprove, Reason: fmt.Sprintf("trusted actor (%d approved)", approved)}, true
+	}
+	return moderationSignal{}, false
+}
+
+func checkModerationRate(_ context.Context, m *autoModerator, cfg autoModerationConfig, it moderationItem) []moderationSignal {
+	st := cfg.Settings
+	if st.RateMaxItems <= 0 || st.RateWindowSeconds <= 0 {
+		return nil
+	}
+	n := m.rate.hit(it.ActorType+":"+it.ActorID.String(), time.Now(), time.Duration(st.RateWindowSeconds)*time.Second)
+	if n <= st.RateMaxItems {
+		return nil
+	}
+	return []moderationSignal{{Check: "rate", Verdict: moderationVerdictEscalate, Reason: fmt.Sprintf("%d items in %ds", n, st.RateWindowSeconds)}}
+}
+
+// moderationRateCounter is a per-actor sliding window. It is per process, so with several replicas
+// the effective limit is higher; it is a signal, not a quota.
+type moderationRateCounter struct {
+	mu     sync.Mutex
+	recent map[string][]time.Time
+}
+
+// hit records one item for key and returns the number of items within window, this one included.
+func (c *moderationRateCounter) hit(key string, now time.Time, window time.Duration) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.recent == nil {
+		c.recent = map[string][]time.Time{}
+	}
+	cutoff := now.Add(-window)
+	kept := c.recent[key][:0]
+	for _, t := range c.recent[key] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	kept = append(kept, now)
+	c.recent[key] = kept
+	// Drop idle actors once the map grows.
+	if len(c.recent) > 10000 {
+		for k, ts := range c.recent {
+			if len(ts) == 0 || !ts[len(ts)-1].After(cutoff) {
+				delete(c.recent, k)
+			}
+		}
+	}
+	return len(kept)
+}
+
+// --- Settings + cache
+
+type autoModerationSettings struct {
+	Enabled               bool `json:"enabled"`
+	AutoApproveTrusted    bool `json:"auto_approve_trusted"`
+	TrustedMinApproved    int  `json:"trusted_min_approved"`
+	RestrictedMinRejected int  `json:"restricted_min_rejected"`
+	RateWindowSeconds     int  `json:"rate_window_seconds"`
+	RateMaxItems          int  `json:"rate_max_items"`
+	// Viewer reports since the last escalation that send approved content back to pending
+	// (see server_reports.go); 0 disables the respective trigger.
+	ReportEscalateCount    int `json:"report_escalate_count"`
+	ReportEscalateSeverity int `json:"report_escalate_severity"`
+}
+
+type autoModerationConfig struct {
+	Settings autoModerationSettings
+	Rules    []moderationRule
+}
+
+const autoModerationConfigTTL = 30 * time.Second
+
+// autoModerator holds the cached settings and rules (reloaded every autoModerationConfigTTL, like
+// privacyGuard) and the pipeline checks. A nil moderator (tests) never decides anything.
+type autoModerator struct {
+	db     *pgxpool.Pool
+	checks []moderationCheck
+	rate   moderationRateCounter
+
+	mu       sync.Mutex
+	cfg      autoModerationConfig
+	loadedAt time.Time
+}
+
+func newAutoModerator(db *pgxpool.Pool) *autoModerator {
+	return &autoModerator{db: db, checks: defaultModerationChecks}
+}
+
+func (m *autoModerator) config(ctx context.Context) autoModerationConfig {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Since(m.loadedAt) > autoModerationConfigTTL {
+		loaded, err := loadAutoModerationConfig(ctx, m.db)
+		if err != nil {
+			// Keep the last good config (initially: disabled); retry after the TTL.
+			logError(ctx, "load auto moderation config failed", err)
+		} else {
+			m.cfg = loaded
+		}
+		m.loadedAt = time.Now()
+	}
+	return m.cfg
+}
+
+// invalidate makes the next lookup reload settings and rules.
+func (m *autoModerator) invalidate() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+}
+
+func loadAutoModerationConfig(ctx context.Context, db *pgxpool.Pool) (autoModerationConfig, error) {
+	var cfg autoModerationConfig
+	st := &cfg.Settings
+	if err := db.QueryRow(ctx, `
+		select enabled, auto_approve_trusted, trusted_min_approved, restricted_min_rejected, rate_window_seconds, rate_max_items,
+		       report_escalate_count, report_escalate_severity
+		from auto_moderation_settings where id = 1
+	`).Scan(&st.Enabled, &st.AutoApproveTrusted, &st.TrustedMinApproved, &st.RestrictedMinRejected, &st.RateWindowSeconds, &st.RateMaxItems,
+		&st.ReportEscalateCount, &st.ReportEscalateSeverity); err != nil {
+		return cfg, err
+	}
+	rows, err := db.Query(ctx, `
+		select id, name, match_type, pattern, decision, target_types, enabled
+		from moderation_rules where enabled
+		order by created_at asc
+	`)
+	if err != nil {
+		return cfg, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r moderationRule
+		if err := rows.Scan(&r.ID, &r.Name, &r.MatchType, &r.Pattern, &r.Decision, &r.TargetTypes, &r.Enabled); err != nil {
+			return cfg, err
+		}
+		if err := r.compile(); err != nil {
+			// Rules are validated on write; skip anything that no longer compiles.
+			logError(ctx, "auto moderation: skip rule "+r.ID.String(), err)
+			continue
+		}
+		cfg.Rules = append(cfg.Rules, r)
+	}
+	return cfg, rows.Err()
+}
+
+// actorHistory counts the actor's reviewed content: runs for users; artifacts and topic content
+// for agents.
+func (m *autoModerator) actorHistory(ctx context.Context, actorType string, actorID uuid.UUID) (approved, rejected int, err error) {
+	var q string
+	switch actorType {
+	case "user":
+		q = `
+			select count(*) filter (where review_status = 'approved'), count(*) filter (where review_status = 'rejected')
+			from runs where publisher_user_id = $1
+		`
+	case "agent":
+		q = `
+			select coalesce(sum(a), 0), coalesce(sum(r), 0) from (
+				select count(*) filter (where review_status = 'approved') as a, count(*) filter (where review_status = 'rejected') as r
+				from artifacts where author_agent_id = $1
+				union all
+				select count(*) filter (where review_status = 'approved'), count(*) filter (where review_status = 'rejected')
+				from topic_content_reviews where agent_id = $1
+			) t
+		`
+	default:
+		return 0, 0, nil
+	}
+	err = m.db.QueryRow(ctx, q, actorID).Scan(&approved, &rejected)
+	return approved, rejected, err
+}
+
+// evaluate runs every check on it.
+func (m *autoModerator) evaluate(ctx context.Context, cfg autoModerationConfig, it moderationItem) moderationDecision {
+	var signals []moderationSignal
+	for _, check := range m.checks {
+		signals = append(signals, check(ctx, m, cfg, it)...)
+	}
+	return decideAutoModeration(signals)
+}
+
+// autoModerate runs the pipeline on a new item and records its decision. It is meant to run in
+// the background after the write has been acknowledged; a human decision made in the meantime is
+// never overridden.
+func (s server) autoModerate(it moderationItem) {
+	if s.moderator == nil || s.db == nil || it.TargetID == uuid.Nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cfg := s.moderator.config(ctx)
+	if !cfg.Settings.Enabled {
+		return
+	}
+	d := s.moderator.evaluate(ctx, cfg, it)
+	var status string
+	switch d.Action {
+	case "auto_approve":
+		status = "approved"
+	case "auto_reject":
+		status = "rejected"
+	case "escalate":
+		status = "pending"
+	default:
+		return
+	}
+	err := s.setModerationStatusFrom(ctx, "system", uuid.Nil, it.TargetType, it.TargetID, "pending", status, d.Action, d.reason())
+	if err != nil && !errors.Is(err, errModerationNotFound) {
+		logError(ctx, "auto moderation: record decision failed", err)
+	}
+}
+
+// autoModerateEvent is autoModerate for a just appended event, which is known by its seq.
+func (s server) autoModerateEvent(runID uuid.UUID, seq int64, agentID uuid.UUID, payload any) {
+	if s.moderator == nil || s.db == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var eventID uuid.UUID
+	if err := s.db.QueryRow(ctx, `select id from events where run_id = $1 and seq = $2`, runID, seq).Scan(&eventID); err != nil {
+		logError(ctx, "auto moderation: event lookup failed", err)
+		return
+	}
+	s.autoModerate(moderationItem{TargetType: "event", TargetID: eventID, ActorType: "agent", ActorID: agentID, Value: payload})
+}
@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNormalizeModerationText(t *testing.T) {
+	cases := map[string]string{
+		"ＡＢＣ１２３":                          "abc123",
+		"賭博網站":                            "赌博网站",
+		"\u0440\u0430\u0443\u0440\u0430l": "paypal", // Cyrillic р, а, у
+		"①②③":                             "123",
+		"免\u200b费":                        "免费",
+	}
+	for in, want := range cases {
+		if got := normalizeModerationText(in); got != want {
+			t.Errorf("normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := compactModerationText("賭 * 博"); got != "赌博" {
+		t.Fatalf("compact = %q", got)
+	}
+}
+
+func TestModerationRulesAndDecision(t *testing.T) {
+	rules := []moderationRule{
+		{Name: "gambling", MatchType: "keyword", Pattern: "赌博", Decision: moderationVerdictReject, Enabled: true},
+		{Name: "contact", MatchType: "regex", Pattern: `加\s*v(x|信)`, Decision: moderationVerdictEscalate, TargetTypes: []string{"topic_message"}, Enabled: true},
+	}
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			t.Fatalf("compile %s: %v", rules[i].Name, err)
+		}
+	}
+	bad := moderationRule{MatchType: "regex", Pattern: "(", Decision: moderationVerdictReject}
+	if err := bad.compile(); err == nil {
+		t.Fatal("invalid regex compiled")
+	}
+
+	cfg := autoModerationConfig{Rules: rules}
+	check := func(targetType string, v any) moderationDecision {
+		it := moderationItem{TargetType: targetType, Value: v}
+		return decideAutoModeration(append(checkModerationRules(context.Background(), nil, cfg, it), checkModerationPrivacy(context.Background(), nil, cfg, it)...))
+	}
+
+	if d := check("event", map[string]any{"text": "欢迎来 賭-博 网站"}); d.Action != "auto_reject" || len(d.Signals) != 1 {
+		t.Fatalf("keyword: %+v", d)
+	}
+	if d := check("topic_message", "请加 Ｖ信 聊"); d.Action != "escalate" {
+		t.Fatalf("regex: %+v", d)
+	}
+	// The contact rule is scoped to topic messages.
+	if d := check("artifact", "请加 v信 聊"); d.Action != "" {
+		t.Fatalf("scoped rule applied: %+v", d)
+	}
+	if d := check("artifact", "邮箱 alice@example.com"); d.Action != "escalate" || d.Signals[0].Check != "privacy" {
+		t.Fatalf("privacy: %+v", d)
+	}
+
+	// Reject beats escalate beats approve.
+	d := decideAutoModeration([]moderationSignal{
+		{Check: "trust", Verdict: moderationVerdictApprove, Reason: "trusted"},
+		{Check: "rate", Verdict: moderationVerdictEscalate, Reason: "burst"},
+	})
+	if d.Action != "escalate" || d.reason() != "rate: burst" {
+		t.Fatalf("decision %+v reason %q", d, d.reason())
+	}
+}
+
+func TestModerationTrustAndRate(t *testing.T) {
+	st := autoModerationSettings{AutoApproveTrusted: true, TrustedMinApproved: 5, RestrictedMinRejected: 2}
+	if sig, ok := trustSignal(st, 10, 0); !ok || sig.Verdict != moderationVerdictApprove {
+		t.Fatalf("trusted: %+v %v", sig, ok)
+	}
+	if _, ok := trustSignal(st, 10, 1); ok {
+		t.Fatal("actor with a rejection treated as trusted")
+	}
+	if sig, ok := trustSignal(st, 10, 2); !ok || sig.Verdict != moderationVerdictEscalate {
+		t.Fatalf("restricted: %+v %v", sig, ok)
+	}
+
+	var c moderationRateCounter
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		c.hit("agent:a", now.Add(time.Duration(i)*time.Second), time.Minute)
+	}
+	if n := c.hit("agent:a", now.Add(5*time.Second), time.Minute); n != 4 {
+		t.Fatalf("count in window = %d", n)
+	}
+	if n := c.hit("agent:a", now.Add(2*time.Minute), time.Minute); n != 1 {
+		t.Fatalf("count after window = %d", n)
+	}
+}
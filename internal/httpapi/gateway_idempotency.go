@@ -0,0 +1,160 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Idempotency-Key support for agent gateway writes. The first successful (2xx) or deterministic
+// validation (400/404/409/422) response per (agent, key) is stored and replayed verbatim to retries
+// within idempotencyKeyTTL, so connectors can retry emits/artifacts/completions after network
+// errors without double-writing. A retry must carry the same method, path and body (sha256) as the
+// first request.
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLen      = 200
+	idempotencyKeyTTL         = 24 * time.Hour
+	// A claim without a stored response (process died mid-request) is taken over after this.
+	idempotencyInFlightTimeout = 2 * time.Minute
+	// Bodies are buffered to fingerprint them; gateway write handlers accept far less.
+	idempotencyMaxRequestBytes = 1 << 20
+	// Responses larger than this are not stored; a retry then re-executes the request.
+	idempotencyMaxResponseBytes = 256 * 1024
+)
+
+type idempotencyRecorder struct {
+	statusCapturingResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *idempotencyRecorder) Write(p []byte) (int, error) {
+	if !w.overflow {
+		if w.body.Len()+len(p) > idempotencyMaxResponseBytes {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(p)
+		}
+	}
+	return w.statusCapturingResponseWriter.Write(p)
+}
+
+func (s server) gatewayIdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > idempotencyKeyMaxLen {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid idempotency key"})
+			return
+		}
+		agentID, ok := agentIDFromCtx(r.Context())
+		if !ok {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		request := r.Method + " " + r.URL.Path
+		reqBody, err := io.ReadAll(io.LimitReader(r.Body, idempotencyMaxRequestBytes+1))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "read body failed"})
+			return
+		}
+		if len(reqBody) > idempotencyMaxRequestBytes {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "body too large"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(reqBody))
+		sum := sha256.Sum256(reqBody)
+		requestHash := hex.EncodeToString(sum[:])
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		claimed, err := s.repo.ClaimIdempotencyKey(ctx, agentID, key, request, requestHash)
+		if err != nil {
+			logError(ctx, "idempotency: claim key failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "idempotency check failed"})
+			return
+		}
+		if !claimed {
+			stored, err := s.repo.GetIdempotencyKey(ctx, agentID, key)
+			if errors.Is(err, pgx.ErrNoRows) {
+				writeJSON(w, http.StatusConflict, map[string]string{"error": "idempotent request in progress"})
+				return
+			}
+			if err != nil {
+				logError(ctx, "idempotency: lookup key failed", err)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "idempotency check failed"})
+				return
+			}
+			if stored.Request != request || stored.RequestHash != requestHash {
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "idempotency key reused for a different request"})
+				return
+			}
+			if stored.StatusCode == nil {
+				writeJSON(w, http.StatusConflict, map[string]string{"error": "idempotent request in progress"})
+				return
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(*stored.StatusCode)
+			if _, err := w.Write(stored.Body); err != nil {
+				logError(ctx, "idempotency: write replay failed", err)
+			}
+			return
+		}
+
+		rec := &idempotencyRecorder{statusCapturingResponseWriter: statusCapturingResponseWriter{ResponseWriter: w}}
+		next.ServeHTTP(rec, r)
+
+		// Persist even if the client went away: that is exactly when it will retry.
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer saveCancel()
+		if !isIdempotentReplayStatus(rec.status) || rec.overflow {
+			if err := s.repo.ReleaseIdempotencyKey(saveCtx, agentID, key); err != nil {
+				logError(saveCtx, "idempotency: release key failed", err)
+			}
+			return
+		}
+		if err := s.repo.StoreIdempotentResponse(saveCtx, agentID, key, rec.status, rec.body.Bytes()); err != nil {
+			logError(saveCtx, "idempotency: store response failed", err)
+		}
+	})
+}
+
+// isIdempotentReplayStatus reports whether a response is stored for replay: successes and
+// validation failures that a retry of the same request would hit again. Anything else (403 before
+// admission, 429, 5xx, ...) may succeed later, so the key is released and a retry re-executes.
+func isIdempotentReplayStatus(status int) bool {
+	switch {
+	case status >= 200 && status < 300:
+		return true
+	case status == http.StatusBadRequest, status == http.StatusNotFound, status == http.StatusConflict, status == http.StatusUnprocessableEntity:
+		return true
+	default:
+		return false
+	}
+}
+
+// sweepExpiredIdempotencyKeys drops keys past idempotencyKeyTTL (they would be overwritten anyway).
+func (s server) sweepExpiredIdempotencyKeys(ctx context.Context) {
+	if _, err := s.db.Exec(ctx, `
+		delete from gateway_idempotency_keys
+		where created_at < now() - make_interval(secs => $1)
+	`, idempotencyKeyTTL.Seconds()); err != nil {
+		logError(ctx, "idempotency: sweep expired keys failed", err)
+	}
+}
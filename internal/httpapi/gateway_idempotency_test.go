@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGatewayIdempotencyRetriesAfterForbidden(t *testing.T) {
+	s := server{repo: newMemRepository()}
+	agentID := uuid.New()
+	calls := 0
+	h := s.gatewayIdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "agent not admitted"})
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]any{"ok": true})
+	}))
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/gateway/topics/t1/messages", strings.NewReader(`{"text":"hi"}`))
+		req.Header.Set(idempotencyKeyHeader, "k1")
+		req = req.WithContext(context.WithValue(req.Context(), ctxAgentID, agentID))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send(); rec.Code != http.StatusForbidden {
+		t.Fatalf("first attempt: status %d", rec.Code)
+	}
+	// The 403 is not stored: the retry after admission runs the handler again.
+	if rec := send(); rec.Code != http.StatusCreated || rec.Header().Get(idempotencyReplayedHeader) != "" {
+		t.Fatalf("retry: status %d replayed=%q", rec.Code, rec.Header().Get(idempotencyReplayedHeader))
+	}
+	// The success is stored and replayed.
+	rec := send()
+	if rec.Code != http.StatusCreated || rec.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Fatalf("replay: status %d replayed=%q", rec.Code, rec.Header().Get(idempotencyReplayedHeader))
+	}
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestIsIdempotentReplayStatus(t *testing.T) {
+	for status, want := range map[int]bool{
+		0: false, 200: true, 201: true, 400: true, 401: false, 403: false, 404: true,
+		409: true, 422: true, 429: false, 500: false, 503: false,
+	} {
+		if got := isIdempotentReplayStatus(status); got != want {
+			t.Fatalf("isIdempotentReplayStatus(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
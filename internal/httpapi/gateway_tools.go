@@ -0,0 +1,398 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"aihub/internal/agenthome"
+	"aihub/internal/toolruntime"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// --- Built-in gateway tools (invoked via POST /v1/gateway/tools/invoke)
+//
+// Tools only expose what the calling agent could read anyway: public runs, its own run, topics it
+// is allowed into, and agent cards that opted into public discovery.
+
+const (
+	toolSearchPublicRuns = "search_public_runs"
+	toolReadTopicThread  = "read_topic_thread"
+	toolFetchRunArtifact = "fetch_run_artifacts"
+	toolReadAgentCard    = "read_agent_card"
+)
+
+func newGatewayToolRegistry(s server) *toolruntime.Registry {
+	reg, err := toolruntime.NewRegistry(
+		searchPublicRunsTool{s: s},
+		readTopicThreadTool{s: s},
+		fetchRunArtifactsTool{s: s},
+		readAgentCardTool{s: s},
+	)
+	if err != nil {
+		// Built-in names are static; a collision is a programming error.
+		panic(err)
+	}
+	return reg
+}
+
+func intInput(in map[string]any, key string, fallback int) int {
+	if v, ok := in[key].(float64); ok {
+		return int(v)
+	}
+	return fallback
+}
+
+func stringInput(in map[string]any, key string) string {
+	v, _ := in[key].(string)
+	return strings.TrimSpace(v)
+}
+
+// search_public_runs
+
+type searchPublicRunsTool struct{ s server }
+
+func (searchPublicRunsTool) Spec() toolruntime.Spec {
+	return toolruntime.Spec{
+		Name:        toolSearchPublicRuns,
+		Description: "Search public runs by keywords (goal, constraints, run_ref).",
+		InputSchema: toolruntime.Object([]string{"query"}, map[string]any{
+			"query": toolruntime.String(1, 200),
+			"limit": toolruntime.Integer(1, 20),
+		}),
+		OutputSchema: toolruntime.Object([]string{"runs"}, map[string]any{
+			"runs": toolruntime.ArrayOf(map[string]any{"type": "object"}),
+		}),
+		Timeout: 5 * time.Second,
+		Cost:    1,
+	}
+}
+
+func (t searchPublicRunsTool) Invoke(ctx context.Context, call toolruntime.Call) (map[string]any, error) {
+	terms := splitSearchTerms(stringInput(call.Input, "query"))
+	if len(terms) == 0 {
+		return nil, toolruntime.Errorf(toolruntime.CodeInvalidInput, "empty query")
+	}
+	limit := clampInt(intInput(call.Input, "limit", 10), 1, 20)
+
+	args := []any{platformUserID}
+	where := []string{"r.publisher_user_id <> $1", "r.is_public = true", "r.review_status <> 'rejected'"}
+	for _, term := range terms {
+		args = append(args, "%"+term+"%")
+		n := "$" + strconv.Itoa(len(args))
+		where = append(where, "(r.public_ref ilike "+n+" or r.goal ilike "+n+" or r.constraints ilike "+n+")")
+	}
+	args = append(args, limit)
+
+	rows, err := t.s.db.Query(ctx, `
+		select r.public_ref, r.goal, r.status, r.created_at
+		from runs r
+		where `+strings.Join(where, " and ")+`
+		order by r.created_at desc
+		limit $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	runs := make([]any, 0, limit)
+	for rows.Next() {
+		var runRef, goal, status string
+		var createdAt time.Time
+		if err := rows.Scan(&runRef, &goal, &status, &createdAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, map[string]any{
+			"run_ref":    runRef,
+			"goal":       goal,
+			"status":     status,
+			"created_at": createdAt.UTC().Format(time.RFC3339),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return map[string]any{"runs": runs}, nil
+}
+
+// read_topic_thread
+
+type readTopicThreadTool struct{ s server }
+
+func (readTopicThreadTool) Spec() toolruntime.Spec {
+	return toolruntime.Spec{
+		Name:        toolReadTopicThread,
+		Description: "Read the latest messages of a topic the agent is allowed into (chronological).",
+		InputSchema: toolruntime.Object([]string{"topic_id"}, map[string]any{
+			"topic_id": toolruntime.String(1, 200),
+			"limit":    toolruntime.Integer(1, 100),
+		}),
+		OutputSchema: toolruntime.Object([]string{"topic", "messages"}, map[string]any{
+			"topic":    map[string]any{"type": "object"},
+			"messages": toolruntime.ArrayOf(map[string]any{"type": "object"}),
+		}),
+		Timeout: 10 * time.Second,
+		Cost:    2,
+	}
+}
+
+// escapeLikePattern escapes LIKE metacharacters (use with escape '\').
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (t readTopicThreadTool) Invoke(ctx context.Context, call toolruntime.Call) (map[string]any, error) {
+	topicID := stringInput(call.Input, "topic_id")
+	if !validOSSPathSegment(topicID) {
+		return nil, toolruntime.Errorf(toolruntime.CodeInvalidInput, "invalid topic_id")
+	}
+	limit := clampInt(intInput(call.Input, "limit", 50), 1, 100)
+
+	store, err := agenthome.NewOSSObjectStore(t.s.ossCfg())
+	if err != nil {
+		return nil, toolruntime.Errorf(toolruntime.CodeFailed, "oss not configured")
+	}
+	raw, err := store.GetObject(ctx, "topics/"+topicID+"/manifest.json")
+	if err != nil {
+		if isOSSNotFound(err) {
+			return nil, toolruntime.Errorf(toolruntime.CodeNotFound, "topic not found")
+		}
+		return nil, err
+	}
+	var mf topicManifestLite
+	if err := json.Unmarshal(raw, &mf); err != nil {
+		return nil, err
+	}
+	if !topicManifestAllowsOwner(ctx, store, topicManifestAllowArgs{
+		Visibility:        mf.Visibility,
+		CircleID:          mf.CircleID,
+		AllowlistAgentIDs: mf.AllowlistAgentIDs,
+		OwnerAgentID:      mf.OwnerAgentID,
+		OwnedAgentRefs:    []string{call.AgentRef},
+		CandidateAgentRef: call.AgentRef,
+	}) {
+		return nil, toolruntime.Errorf(toolruntime.CodeForbidden, "topic not allowed")
+	}
+
+	// Topic ids may contain '_', so the key prefix is matched literally.
+	pat1 := escapeLikePattern("topics/"+topicID+"/messages/") + "%"
+	pat2 := pat1
+	if base := strings.Trim(strings.TrimSpace(t.s.ossBasePrefix), "/"); base != "" {
+		pat2 = escapeLikePattern(base+"/") + pat1
+	}
+	// Latest event per message object only: edits replace, deletes and rejected messages drop out.
+	rows, err := t.s.db.Query(ctx, `
+		select occurred_at, payload
+		from (
+			select distinct on (object_key) id, object_key, event_type, occurred_at, payload
+			from oss_events
+			where object_key like $1 escape '\' or object_key like $2 escape '\'
+			order by object_key, id desc
+		) latest
+		where latest.event_type = 'put'
+		  and `+topicNotRejectedSQL("latest.object_key", t.s.ossBasePrefix)+`
+		order by occurred_at desc, id desc
+		limit $3
+	`, pat1, pat2, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	messages := make([]any, 0, limit)
+	for rows.Next() {
+		var occurredAt time.Time
+		var payloadB []byte
+		if err := rows.Scan(&occurredAt, &payloadB); err != nil {
+			return nil, err
+		}
+		var m map[string]any
+		if err := json.Unmarshal(payloadB, &m); err != nil {
+			continue
+		}
+		text := truncateRunes(extractTopicMessageTextBestEffort(payloadB), 2000)
+		msg := map[string]any{
+			"message_id":  stringInput(m, "message_id"),
+			"agent_ref":   strings.ToLower(stringInput(m, "agent_ref")),
+			"text":        text,
+			"occurred_at": occurredAt.UTC().Format(time.RFC3339),
+		}
+		if meta, _ := m["meta"].(map[string]any); meta != nil {
+			if rt := parseTopicMessageRef(meta["reply_to"]); rt != nil {
+				msg["reply_to"] = map[string]any{"agent_ref": rt.AgentRef, "message_id": rt.MessageID}
+			}
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return map[string]any{
+		"topic": map[string]any{
+			"topic_id":   topicID,
+			"title":      strings.TrimSpace(mf.Title),
+			"summary":    strings.TrimSpace(mf.Summary),
+			"mode":       strings.TrimSpace(mf.Mode),
+			"visibility": strings.TrimSpace(mf.Visibility),
+		},
+		"messages": messages,
+	}, nil
+}
+
+// fetch_run_artifacts
+
+type fetchRunArtifactsTool struct{ s server }
+
+func (fetchRunArtifactsTool) Spec() toolruntime.Spec {
+	return toolruntime.Spec{
+		Name:        toolFetchRunArtifact,
+		Description: "Fetch previous artifact versions of the current run (or of another public run), newest first.",
+		InputSchema: toolruntime.Object(nil, map[string]any{
+			"run_ref": toolruntime.String(1, 64),
+			"limit":   toolruntime.Integer(1, 10),
+		}),
+		OutputSchema: toolruntime.Object([]string{"run_ref", "artifacts"}, map[string]any{
+			"run_ref":   map[string]any{"type": "string"},
+			"artifacts": toolruntime.ArrayOf(map[string]any{"type": "object"}),
+		}),
+		Timeout: 5 * time.Second,
+		Cost:    1,
+	}
+}
+
+func (t fetchRunArtifactsTool) Invoke(ctx context.Context, call toolruntime.Call) (map[string]any, error) {
+	runID, runRef := call.RunID, call.RunRef
+	if raw := stringInput(call.Input, "run_ref"); raw != "" {
+		ref, err := parseRunRef(raw)
+		if err != nil {
+			return nil, toolruntime.Errorf(toolruntime.CodeInvalidInput, "invalid run_ref")
+		}
+		if ref != call.RunRef {
+			// Other runs: only public, non-rejected ones.
+			err := t.s.db.QueryRow(ctx, `
+				select id from runs
+				where public_ref = $1 and is_public = true and review_status <> 'rejected'
+			`, ref).Scan(&runID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, toolruntime.Errorf(toolruntime.CodeNotFound, "run not found")
+			}
+			if err != nil {
+				return nil, err
+			}
+			runRef = ref
+		}
+	}
+	limit := clampInt(intInput(call.Input, "limit", 3), 1, 10)
+
+	rows, err := t.s.db.Query(ctx, `
+		select version, kind, content, created_at
+		from artifacts
+		where run_id = $1 and review_status <> 'rejected'
+		order by version desc
+		limit $2
+	`, runID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	artifacts := make([]any, 0, limit)
+	for rows.Next() {
+		var version int
+		var kind, content string
+		var createdAt time.Time
+		if err := rows.Scan(&version, &kind, &content, &createdAt); err != nil {
+			return nil, err
+		}
+		truncated := utf8.RuneCountInString(content) > 6000
+		content = truncateRunes(content, 6000)
+		artifacts = append(artifacts, map[string]any{
+			"version":    version,
+			"kind":       kind,
+			"content":    content,
+			"truncated":  truncated,
+			"created_at": createdAt.UTC().Format(time.RFC3339),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return map[string]any{"run_ref": runRef, "artifacts": artifacts}, nil
+}
+
+// read_agent_card
+
+type readAgentCardTool struct{ s server }
+
+func (readAgentCardTool) Spec() toolruntime.Spec {
+	return toolruntime.Spec{
+		Name:        toolReadAgentCard,
+		Description: "Read the public card of an agent that opted into public discovery.",
+		InputSchema: toolruntime.Object([]string{"agent_ref"}, map[string]any{
+			"agent_ref": toolruntime.String(1, 64),
+		}),
+		OutputSchema: toolruntime.Object([]string{"agent_ref", "name"}, map[string]any{
+			"agent_ref":    map[string]any{"type": "string"},
+			"name":         map[string]any{"type": "string"},
+			"description":  map[string]any{"type": "string"},
+			"bio":          map[string]any{"type": "string"},
+			"greeting":     map[string]any{"type": "string"},
+			"interests":    toolruntime.ArrayOf(map[string]any{"type": "string"}),
+			"capabilities": toolruntime.ArrayOf(map[string]any{"type": "string"}),
+			"tags":         toolruntime.ArrayOf(map[string]any{"type": "string"}),
+		}),
+		Timeout: 3 * time.Second,
+		Cost:    1,
+	}
+}
+
+func (t readAgentCardTool) Invoke(ctx context.Context, call toolruntime.Call) (map[string]any, error) {
+	agentRef, err := parseAgentRef(stringInput(call.Input, "agent_ref"))
+	if err != nil {
+		return nil, toolruntime.Errorf(toolruntime.CodeInvalidInput, "invalid agent_ref")
+	}
+	var (
+		name, description, bio, greeting string
+		interestsRaw, capabilitiesRaw    []byte
+		tags                             []string
+	)
+	err = t.s.db.QueryRow(ctx, `
+		select a.name, a.description, a.bio, a.greeting, a.interests, a.capabilities,
+		       coalesce((select array_agg(t.tag order by t.tag) from agent_tags t where t.agent_id = a.id), '{}')
+		from agents a
+		where a.public_ref = $1
+		  and a.status = 'enabled'
+		  and coalesce(a.discovery->>'public','false') = 'true'
+		  and a.card_review_status = 'approved'
+	`, agentRef).Scan(&name, &description, &bio, &greeting, &interestsRaw, &capabilitiesRaw, &tags)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, toolruntime.Errorf(toolruntime.CodeNotFound, "agent card not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	interests, capabilities := []string{}, []string{}
+	_ = unmarshalJSONNullable(interestsRaw, &interests)
+	_ = unmarshalJSONNullable(capabilitiesRaw, &capabilities)
+	if interests == nil {
+		interests = []string{}
+	}
+	if capabilities == nil {
+		capabilities = []string{}
+	}
+	return map[string]any{
+		"agent_ref":    agentRef,
+		"name":         strings.TrimSpace(name),
+		"description":  strings.TrimSpace(description),
+		"bio":          strings.TrimSpace(bio),
+		"greeting":     strings.TrimSpace(greeting),
+		"interests":    interests,
+		"capabilities": capabilities,
+		"tags":         tags,
+	}, nil
+}
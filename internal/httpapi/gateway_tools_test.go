@@ -0,0 +1,17 @@
+package httpapi
+
+import "testing"
+
+func TestEscapeLikePattern(t *testing.T) {
+	cases := map[string]string{
+		"topic_1":      `topic\_1`,
+		"100%":         `100\%`,
+		`a\b`:          `a\\b`,
+		"topics/plain": "topics/plain",
+	}
+	for in, want := range cases {
+		if got := escapeLikePattern(in); got != want {
+			t.Fatalf("escapeLikePattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -29,6 +29,7 @@ type Deps struct {
 	OSSAccessKeySecret    string
 	OSSSTSRoleARN         string
 	OSSSTSDurationSeconds int
+	OSSSTSHourlyLimit     int
 	OSSLocalDir           string
 	OSSEventsIngestToken  string
 
@@ -44,4 +45,11 @@ type Deps struct {
 	// Platform-issued "topic play" work items (topic-first self-play; agents claim via inbox).
 	TopicPlayActorTags          []string
 	TopicPlayDailyLimitPerAgent int
+
+	// Outbound webhooks: allow loopback/private targets (local development and tests only).
+	WebhookAllowPrivateTargets bool
+
+	// Rate limiting: bucket store ("memory" or "postgres") and proxies whose X-Forwarded-For is trusted.
+	RateLimitStore string
+	TrustedProxies []string
 }
@@ -17,6 +17,7 @@ type ctxKey string
 const (
 	ctxUserID  ctxKey = "user_id"
 	ctxAgentID ctxKey = "agent_id"
+	ctxAPIKey  ctxKey = "api_key"
 )
 
 func bearerToken(r *http.Request) string {
@@ -48,7 +49,7 @@ func (s server) maybeUserIDFromRequest(ctx context.Context, r *http.Request) (uu
 		select u.id
 		from user_api_keys k
 		join users u on u.id = k.user_id
-		where k.key_hash = $1 and k.revoked_at is null
+		where k.key_hash = $1 and `+apiKeyActiveSQL+`
 	`, hash).Scan(&userID)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return uuid.Nil, false, nil
@@ -68,13 +69,16 @@ func (s server) userAuthMiddleware(next http.Handler) http.Handler {
 		}
 		hash := keys.HashAPIKey(s.pepper, apiKey)
 
-		var userID uuid.UUID
+		var (
+			userID uuid.UUID
+			key    apiKeyAuth
+		)
 		err := s.db.QueryRow(r.Context(), `
-			select u.id
+			select u.id, `+apiKeyAuthColumns+`
 			from user_api_keys k
 			join users u on u.id = k.user_id
-			where k.key_hash = $1 and k.revoked_at is null
-		`, hash).Scan(&userID)
+			where k.key_hash = $1 and `+apiKeyActiveSQL+`
+		`, hash).Scan(&userID, &key.ID, &key.Scopes, &key.LastUsedAt, &key.LastUsedIP)
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
 			return
@@ -85,7 +89,9 @@ func (s server) userAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		s.noteAPIKeyUse(r, userKeyTable, key)
 		ctx := context.WithValue(r.Context(), ctxUserID, userID)
+		ctx = context.WithValue(ctx, ctxAPIKey, key)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -101,12 +107,13 @@ func (s server) agentAuthMiddleware(next http.Handler) http.Handler {
 
 		var agentID uuid.UUID
 		var status string
+		var key apiKeyAuth
 		err := s.db.QueryRow(r.Context(), `
-			select a.id, a.status
+			select a.id, a.status, `+apiKeyAuthColumns+`
 			from agent_api_keys k
 			join agents a on a.id = k.agent_id
-			where k.key_hash = $1 and k.revoked_at is null
-		`, hash).Scan(&agentID, &status)
+			where k.key_hash = $1 and `+apiKeyActiveSQL+`
+		`, hash).Scan(&agentID, &status, &key.ID, &key.Scopes, &key.LastUsedAt, &key.LastUsedIP)
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
 			return
@@ -120,7 +127,9 @@ func (s server) agentAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		s.noteAPIKeyUse(r, agentKeyTable, key)
 		ctx := context.WithValue(r.Context(), ctxAgentID, agentID)
+		ctx = context.WithValue(ctx, ctxAPIKey, key)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -136,12 +145,13 @@ func (s server) adminAuthMiddleware(next http.Handler) http.Handler {
 
 		var userID uuid.UUID
 		var isAdmin bool
+		var key apiKeyAuth
 		err := s.db.QueryRow(r.Context(), `
-			select u.id, u.is_admin
+			select u.id, u.is_admin, `+apiKeyAuthColumns+`
 			from user_api_keys k
 			join users u on u.id = k.user_id
-			where k.key_hash = $1 and k.revoked_at is null
-		`, hash).Scan(&userID, &isAdmin)
+			where k.key_hash = $1 and `+apiKeyActiveSQL+`
+		`, hash).Scan(&userID, &isAdmin, &key.ID, &key.Scopes, &key.LastUsedAt, &key.LastUsedIP)
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
 			return
@@ -155,7 +165,9 @@ func (s server) adminAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		s.noteAPIKeyUse(r, userKeyTable, key)
 		ctx := context.WithValue(r.Context(), ctxUserID, userID)
+		ctx = context.WithValue(ctx, ctxAPIKey, key)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -171,3 +183,9 @@ func agentIDFromCtx(ctx context.Context) (uuid.UUID, bool) {
 	id, ok := v.(uuid.UUID)
 	return id, ok
 }
+
+func apiKeyFromCtx(ctx context.Context) (apiKeyAuth, bool) {
+	v := ctx.Value(ctxAPIKey)
+	k, ok := v.(apiKeyAuth)
+	return k, ok
+}
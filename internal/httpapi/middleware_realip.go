@@ -0,0 +1,98 @@
+package httpapi
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// parseTrustedProxies parses CIDRs and single addresses (AIHUB_TRUSTED_PROXIES).
+func parseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, err
+		}
+		a = a.Unmap()
+		out = append(out, netip.PrefixFrom(a, a.BitLen()))
+	}
+	return out, nil
+}
+
+func trustedAddr(trusted []netip.Prefix, a netip.Addr) bool {
+	for _, p := range trusted {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClientIP returns the client address of a request that came through proxies. Forwarding
+// headers are only honored when the direct peer is trusted; X-Forwarded-For is walked right to left
+// past trusted hops, so entries a client prepends itself are never used.
+func forwardedClientIP(trusted []netip.Prefix, remoteAddr string, h http.Header) (netip.Addr, error) {
+	host := remoteAddr
+	if hp, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = hp
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, errors.New("invalid remote address")
+	}
+	ip := peer.Unmap()
+	if !trustedAddr(trusted, ip) {
+		return ip, nil
+	}
+
+	var hops []string
+	for _, v := range h.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	if len(hops) == 0 {
+		if v := strings.TrimSpace(h.Get("X-Real-IP")); v != "" {
+			hops = []string{v}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		a, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = a.Unmap()
+		if !trustedAddr(trusted, ip) {
+			break
+		}
+	}
+	return ip, nil
+}
+
+// realIPMiddleware sets RemoteAddr to the client address for requests from trusted proxies.
+// With no trusted proxies configured, forwarding headers are ignored.
+func realIPMiddleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(trusted) > 0 {
+				if ip, err := forwardedClientIP(trusted, r.RemoteAddr, r.Header); err == nil {
+					r.RemoteAddr = ip.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
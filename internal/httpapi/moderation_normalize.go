@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Moderation rules are matched against normalized text so trivial evasions (full-width letters,
+// traditional characters, look-alike letters, zero-width or punctuation padding) still match.
+
+// tradSimpPairs lists common traditional characters, each followed by its simplified form. It is
+// not a full conversion table; it covers the characters that typically show up in filter words.
+const tradSimpPairs = "們们個个這这說说國国會会來来時时對对為为學学發发髮发經经過过點点動动長长開开關关問问題题" +
+	"車车東东書书見见電电話话語语門门買买賣卖錢钱網网頁页號号碼码樣样體体員员機机愛爱無无與与" +
+	"後后還还進进邊边種种現现從从實实當当應应讓让給给記记認认識识議议論论設设計计訊讯請请讀读" +
+	"錯错變变顯显聽听歡欢氣气場场處处師师帶带幾几條条級级區区醫医藥药貓猫魚鱼鳥鸟馬马龍龙頭头" +
+	"臉脸腦脑聯联係系傳传廣广華华韓韩濟济業业產产黨党義义軍军歷历覽览島岛灣湾陸陆歐欧亞亚紅红" +
+	"綠绿藍蓝黃黄飛飞鐵铁銀银錄录鍵键隊队陽阳陰阴險险隨随雙双雜杂雞鸡離离難难雲云霧雾靈灵韻韵" +
+	"風风飯饭館馆驗验鬥斗麗丽麼么齊齐齒齿賭赌槍枪彈弹殺杀詐诈騙骗謠谣姦奸腳脚務务專专檢检測测" +
+	"轉转賬账貸贷幣币銷销購购賺赚廠厂製制傷伤燒烧裡里麵面戰战爭争獨独權权歲岁舊旧圖图團团園园" +
+	"聲声壞坏夢梦寫写將将層层帳帐幫帮廳厅彎弯態态憂忧懷怀戲戏擊击據据擁拥擇择敗败數数斷断暫暂" +
+	"構构樂乐標标歸归殘残決决況况濕湿滅灭災灾煙烟熱热爾尔獲获環环療疗盡尽監监盤盘確确禮礼禍祸" +
+	"稅税穩稳競竞筆笔範范簡简節节糧粮紀纪約约紙纸純纯組组細细終终結结絕绝統统練练總总線线編编" +
+	"縣县績绩繼继續续罰罚羅罗聖圣職职舉举萬万葉叶蘭兰虛虚蟲虫補补複复規规視视親亲覺觉觀观訂订" +
+	"訴诉試试詩诗該该詳详誤误課课調调談谈證证護护讚赞負负財财貨货質质費费資资賽赛趕赶跡迹軟软" +
+	"輕轻載载輸输辦办遠远適适選选遺遗郵邮鄉乡針针鐘钟閃闪閱阅陳陈隱隐雖虽響响預预領领顏颜願愿" +
+	"類类顧顾飲饮養养餘余驚惊鬧闹獄狱匯汇銃铳"
+
+// homoglyphs maps letters that render like Latin letters or digits to them. Applied after
+// lowercasing.
+var homoglyphs = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'з': '3', 'і': 'i', 'ј': 'j', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'ѕ': 's', 'т': 't', 'у': 'y', 'х': 'x', 'ь': 'b',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u',
+	'χ': 'x',
+	// Latin look-alikes
+	'ı': 'i', 'ł': 'l', 'ß': 's',
+}
+
+var tradToSimp = func() map[rune]rune {
+	rs := []rune(tradSimpPairs)
+	m := make(map[rune]rune, len(rs)/2)
+	for i := 0; i+1 < len(rs); i += 2 {
+		if rs[i] != rs[i+1] {
+			m[rs[i]] = rs[i+1]
+		}
+	}
+	return m
+}()
+
+// normalizeModerationRune folds one rune; it returns -1 for runes that should be dropped.
+func normalizeModerationRune(r rune) rune {
+	switch {
+	case r == '\u3000': // ideographic space
+		return ' '
+	case r >= '！' && r <= '～': // full-width ASCII
+		r -= 0xFEE0
+	case r >= '①' && r <= '⑨':
+		return '1' + (r - '①')
+	case r >= '⑴' && r <= '⑼':
+		return '1' + (r - '⑴')
+	case r == '⓪':
+		return '0'
+	case r >= 'ⓐ' && r <= 'ⓩ':
+		return 'a' + (r - 'ⓐ')
+	case r >= 'Ⓐ' && r <= 'Ⓩ':
+		return 'a' + (r - 'Ⓐ')
+	case r == '\u00ad', r >= '\u200b' && r <= '\u200d', r == '\u2060', r == '\ufeff': // soft hyphen, zero-width
+		return -1
+	}
+	r = unicode.ToLower(r)
+	if m, ok := homoglyphs[r]; ok {
+		return m
+	}
+	if m, ok := tradToSimp[r]; ok {
+		return m
+	}
+	return r
+}
+
+// normalizeModerationText folds full-width forms, circled characters, homoglyphs and traditional
+// characters, drops zero-width runes and lowercases. Regex rules match this form.
+func normalizeModerationText(s string) string {
+	return strings.Map(normalizeModerationRune, s)
+}
+
+// compactModerationText is the normalized text with everything but letters and digits removed,
+// so "赌 博", "赌*博" and "赌·博" all read "赌博". Keyword rules match this form.
+func compactModerationText(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = normalizeModerationRune(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
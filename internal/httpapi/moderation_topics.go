@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Topic messages and requests live in OSS, so their review status is kept in topic_content_reviews
+// (keyed by the platform-side object key). Like runs/events, pending content stays visible; only
+// rejected objects are hidden from public reads.
+
+// topicNotRejectedSQL is a where-clause condition that hides rejected topic objects. keyExpr is the
+// oss_events object_key expression, which may carry the OSS base prefix; topic_content_reviews keys
+// never do, so the key is compared by equality (index lookup) with and without the prefix.
+func topicNotRejectedSQL(keyExpr string, basePrefix string) string {
+	keys := keyExpr
+	if base := strings.Trim(strings.TrimSpace(basePrefix), "/"); base != "" {
+		prefix := sqlStringLiteral(base + "/")
+		keys += `, case when left(` + keyExpr + `, length(` + prefix + `)) = ` + prefix +
+			` then substr(` + keyExpr + `, length(` + prefix + `) + 1) end`
+	}
+	return `not exists (
+			select 1 from topic_content_reviews tcr
+			where tcr.review_status = 'rejected'
+			  and tcr.object_key in (` + keys + `)
+		)`
+}
+
+// sqlStringLiteral quotes s as a standard SQL string literal, for config values built into SQL.
+func sqlStringLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// topicObjectSummary is the short text shown in the moderation queue.
+func topicObjectSummary(kind string, body []byte) string {
+	var text string
+	if kind == "requests" {
+		var obj map[string]any
+		if err := json.Unmarshal(body, &obj); err == nil {
+			switch v := obj[topicObjectScanField(kind)].(type) {
+			case string:
+				text = v
+			case map[string]any:
+				if t, _ := v["text"].(string); strings.TrimSpace(t) != "" {
+					text = t
+				} else if b, err := json.Marshal(v); err == nil {
+					text = string(b)
+				}
+			}
+		}
+	} else {
+		text = extractTopicMessageTextBestEffort(body)
+	}
+	text = strings.TrimSpace(text)
+	if r := []rune(text); len(r) > 200 {
+		text = string(r[:200])
+	}
+	return text
+}
+
+// recordTopicContentForReview puts a freshly written topic object in the moderation queue and runs
+// auto-moderation on it. A rewrite of a rejected object stays rejected.
+func (s server) recordTopicContentForReview(ctx context.Context, objectKey, topicID string, agentID uuid.UUID, agentRef string, body []byte) {
+	p := parseTopicKeyFromObjectKey(objectKey)
+	targetType := topicObjectTargetType(p.Kind)
+	var id uuid.UUID
+	if err := s.db.QueryRow(ctx, `
+		insert into topic_content_reviews (object_key, target_type, topic_id, agent_id, agent_ref, summary)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (object_key) do update
+		set summary = excluded.summary,
+		    review_status = case when topic_content_reviews.review_status = 'rejected' then 'rejected' else 'pending' end,
+		    updated_at = now()
+		returning id
+	`, objectKey, targetType, topicID, agentID, agentRef, topicObjectSummary(p.Kind, body)).Scan(&id); err != nil {
+		logError(ctx, "record topic content for review failed", err)
+		return
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return
+	}
+	go s.autoModerate(moderationItem{TargetType: targetType, TargetID: id, ActorType: "agent", ActorID: agentID, Value: obj[topicObjectScanField(p.Kind)]})
+}
+
+// markTopicObjectPending (re)queues an already stored topic object for review, e.g. after a privacy
+// scan. objectKey is as recorded in oss_events.
+func (s server) markTopicObjectPending(ctx context.Context, objectKey string) error {
+	var payload []byte
+	if err := s.db.QueryRow(ctx, `
+		select payload from oss_events where object_key = $1 and event_type = 'put' order by id desc limit 1
+	`, objectKey).Scan(&payload); err != nil {
+		return err
+	}
+	key := strings.TrimLeft(stripBasePrefix(strings.TrimLeft(objectKey, "/"), s.ossBasePrefix), "/")
+	p := parseTopicKeyFromObjectKey(key)
+	_, err := s.db.Exec(ctx, `
+		insert into topic_content_reviews (object_key, target_type, topic_id, agent_id, agent_ref, summary)
+		values ($1, $2, $3, (select id from agents where public_ref = $4), $4, $5)
+		on conflict (object_key) do update
+		set review_status = 'pending', updated_at = now()
+		where topic_content_reviews.review_status <> 'rejected'
+	`, key, topicObjectTargetType(p.Kind), p.TopicID, p.ActorRef, topicObjectSummary(p.Kind, payload))
+	return err
+}
+
+// rejectedTopicObjectKeys lists a topic's rejected objects, for reads that bypass oss_events.
+func (s server) rejectedTopicObjectKeys(ctx context.Context, topicID string) (map[string]bool, error) {
+	rows, err := s.db.Query(ctx, `
+		select object_key from topic_content_reviews where topic_id = $1 and review_status = 'rejected'
+	`, topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]bool{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		out[key] = true
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,37 @@
+package httpapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTopicObjectSummary(t *testing.T) {
+	msg := []byte(`{"kind":"topic_message","content":{"text":"  今天聊聊秋天  "}}`)
+	if got := topicObjectSummary("messages", msg); got != "今天聊聊秋天" {
+		t.Fatalf("message summary %q", got)
+	}
+
+	req := []byte(`{"kind":"topic_request","type":"propose_topic","payload":{"text":"新话题：城市漫步"}}`)
+	if got := topicObjectSummary("requests", req); got != "新话题：城市漫步" {
+		t.Fatalf("request summary %q", got)
+	}
+	req = []byte(`{"kind":"topic_request","type":"nominate","payload":{"agent_ref":"a_1"}}`)
+	if got := topicObjectSummary("requests", req); got != `{"agent_ref":"a_1"}` {
+		t.Fatalf("structured request summary %q", got)
+	}
+
+	long := []byte(`{"content":{"text":"` + strings.Repeat("长", 300) + `"}}`)
+	if got := []rune(topicObjectSummary("messages", long)); len(got) != 200 {
+		t.Fatalf("summary not truncated: %d runes", len(got))
+	}
+}
+
+func TestTopicNotRejectedSQL(t *testing.T) {
+	if got := topicNotRejectedSQL("e.object_key", ""); !strings.Contains(got, "tcr.object_key in (e.object_key)") || strings.Contains(got, "like") {
+		t.Fatalf("no base prefix: %s", got)
+	}
+	got := topicNotRejectedSQL("e.object_key", "/it's/")
+	if !strings.Contains(got, `left(e.object_key, length('it''s/')) = 'it''s/'`) || strings.Contains(got, "like") {
+		t.Fatalf("base prefix: %s", got)
+	}
+}
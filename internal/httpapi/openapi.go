@@ -0,0 +1,443 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OpenAPI 3 document for /v1, generated from apiOperations (openapi_routes.go). Request/response
+// schemas are derived by reflection from the handlers' request/DTO types where they exist, and
+// written inline (oaSchema) for handlers that respond with ad-hoc maps.
+
+const openAPIVersion = "3.0.3"
+
+type apiAuth string
+
+const (
+	authPublic apiAuth = "public"
+	authUser   apiAuth = "user"
+	authAgent  apiAuth = "agent"
+	authAdmin  apiAuth = "admin"
+)
+
+type apiParam struct {
+	Name        string
+	Type        string // string|integer|boolean
+	Description string
+}
+
+type apiOperation struct {
+	Method  string
+	Path    string // relative to /v1, chi syntax
+	Auth    apiAuth
+	Tag     string
+	Summary string
+	Query   []apiParam
+	// Body is a Go value (reflected) or an oaSchema; nil = no JSON body. BodyType overrides the
+	// media type (e.g. text/plain bodies).
+	Body     any
+	BodyType string
+	// Status is the success status (default 200). Resp is a Go value or an oaSchema; RespType
+	// overrides the media type (SSE, CSV, redirects use Resp=nil).
+	Status   int
+	Resp     any
+	RespType string
+	// Errors lists statuses beyond the defaults derived from auth, params and body.
+	Errors []int
+}
+
+// oaSchema is a literal JSON Schema fragment; "properties", "items" and "additionalProperties"
+// values may hold Go values, which are reflected.
+type oaSchema map[string]any
+
+func oaStr() oaSchema           { return oaSchema{"type": "string"} }
+func oaInt() oaSchema           { return oaSchema{"type": "integer"} }
+func oaBool() oaSchema          { return oaSchema{"type": "boolean"} }
+func oaAny() oaSchema           { return oaSchema{} }
+func oaFree() oaSchema          { return oaSchema{"type": "object", "additionalProperties": true} }
+func oaArr(items any) oaSchema  { return oaSchema{"type": "array", "items": items} }
+func oaMap(values any) oaSchema { return oaSchema{"type": "object", "additionalProperties": values} }
+func oaEnum(values ...string) oaSchema {
+	enum := make([]any, 0, len(values))
+	for _, v := range values {
+		enum = append(enum, v)
+	}
+	return oaSchema{"type": "string", "enum": enum}
+}
+
+func oaObj(props map[string]any, required ...string) oaSchema {
+	out := oaSchema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// oaOK is the common {"status":"ok"}-style acknowledgement.
+func oaOK() oaSchema { return oaObj(map[string]any{"status": oaStr(), "ok": oaBool()}) }
+
+type openAPIBuilder struct {
+	schemas map[string]any
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+)
+
+func (b *openAPIBuilder) resolve(v any) map[string]any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case oaSchema:
+		out := map[string]any{}
+		for k, val := range x {
+			switch k {
+			case "properties":
+				props := map[string]any{}
+				for name, p := range val.(map[string]any) {
+					props[name] = b.resolve(p)
+				}
+				out[k] = props
+			case "items":
+				out[k] = b.resolve(val)
+			case "additionalProperties":
+				if flag, ok := val.(bool); ok {
+					out[k] = flag
+				} else {
+					out[k] = b.resolve(val)
+				}
+			default:
+				out[k] = val
+			}
+		}
+		return out
+	default:
+		return b.schemaOf(reflect.TypeOf(v))
+	}
+}
+
+func (b *openAPIBuilder) schemaOf(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case rawMessageType:
+		return map[string]any{}
+	case durationType:
+		return map[string]any{"type": "integer"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object", "additionalProperties": true}
+		}
+		return map[string]any{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := schemaComponentName(t)
+		if _, ok := b.schemas[name]; !ok {
+			b.schemas[name] = map[string]any{} // placeholder (recursive types)
+			b.schemas[name] = b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (b *openAPIBuilder) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	b.collectFields(t, props)
+	return map[string]any{"type": "object", "properties": props}
+}
+
+func (b *openAPIBuilder) collectFields(t reflect.Type, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			b.collectFields(ft, props)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = b.schemaOf(f.Type)
+	}
+}
+
+// schemaComponentName turns a Go type name into an exported schema name (agentDTO -> AgentDTO).
+func schemaComponentName(t reflect.Type) string {
+	n := t.Name()
+	if n == "" {
+		return "Object"
+	}
+	if t.PkgPath() != "" && !strings.HasSuffix(t.PkgPath(), "/httpapi") {
+		n = t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:] + "." + n
+	}
+	return strings.ToUpper(n[:1]) + n[1:]
+}
+
+var chiParamPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)(:[^}]*)?\}`)
+
+// openAPIPath converts a chi route under /v1 into an OpenAPI path (no trailing slash).
+func openAPIPath(p string) string {
+	p = "/v1" + chiParamPattern.ReplaceAllString(p, "{$1}")
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+func buildOpenAPIDocument(ops []apiOperation) map[string]any {
+	b := &openAPIBuilder{schemas: map[string]any{}}
+	b.schemas["Error"] = map[string]any{
+		"type":       "object",
+		"required":   []string{"error"},
+		"properties": map[string]any{"error": map[string]any{"type": "string"}},
+	}
+
+	paths := map[string]any{}
+	tags := map[string]struct{}{}
+	for _, op := range ops {
+		path := openAPIPath(op.Path)
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		tags[op.Tag] = struct{}{}
+
+		o := map[string]any{
+			"operationId": operationID(op.Method, path),
+			"summary":     op.Summary,
+			"tags":        []string{op.Tag},
+		}
+		params := make([]any, 0)
+		for _, m := range chiParamPattern.FindAllStringSubmatch(op.Path, -1) {
+			params = append(params, map[string]any{
+				"name": m[1], "in": "path", "required": true,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, q := range op.Query {
+			typ := q.Type
+			if typ == "" {
+				typ = "string"
+			}
+			p := map[string]any{"name": q.Name, "in": "query", "schema": map[string]any{"type": typ}}
+			if q.Description != "" {
+				p["description"] = q.Description
+			}
+			params = append(params, p)
+		}
+		if op.Auth == authAgent && op.Method == http.MethodPost {
+			params = append(params, map[string]any{
+				"name": idempotencyKeyHeader, "in": "header",
+				"description": "Replays the first response for retries with the same key (24h).",
+				"schema":      map[string]any{"type": "string", "maxLength": idempotencyKeyMaxLen},
+			})
+		}
+		if len(params) > 0 {
+			o["parameters"] = params
+		}
+
+		if op.Body != nil {
+			mt := op.BodyType
+			if mt == "" {
+				mt = "application/json"
+			}
+			o["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{mt: map[string]any{"schema": b.resolve(op.Body)}},
+			}
+		}
+
+		responses := map[string]any{}
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		if op.Resp != nil {
+			mt := op.RespType
+			if mt == "" {
+				mt = "application/json"
+			}
+			success["content"] = map[string]any{mt: map[string]any{"schema": b.resolve(op.Resp)}}
+		} else if op.RespType != "" {
+			success["content"] = map[string]any{op.RespType: map[string]any{"schema": map[string]any{"type": "string"}}}
+		}
+		responses[strconv.Itoa(status)] = success
+		for _, code := range operationErrorStatuses(op) {
+			responses[strconv.Itoa(code)] = map[string]any{"$ref": "#/components/responses/Error"}
+		}
+		o["responses"] = responses
+
+		switch op.Auth {
+		case authUser:
+			o["security"] = []any{map[string]any{"userKey": []string{}}}
+		case authAgent:
+			o["security"] = []any{map[string]any{"agentKey": []string{}}}
+		case authAdmin:
+			o["security"] = []any{map[string]any{"adminKey": []string{}}}
+		}
+		item[strings.ToLower(op.Method)] = o
+	}
+
+	tagList := make([]string, 0, len(tags))
+	for t := range tags {
+		tagList = append(tagList, t)
+	}
+	sort.Strings(tagList)
+	tagObjs := make([]any, 0, len(tagList))
+	for _, t := range tagList {
+		tagObjs = append(tagObjs, map[string]any{"name": t})
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":       "AIHub API",
+			"version":     "v1",
+			"description": "Errors are returned as {\"error\": \"<code>\"} with the HTTP status listed per operation. Rate-limited routes send RateLimit-Limit/Remaining/Reset/Policy headers; 429 responses add Retry-After.",
+		},
+		"servers": []any{map[string]any{"url": "/"}},
+		"tags":    tagObjs,
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": b.schemas,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Error",
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Error"}},
+					},
+				},
+			},
+			"securitySchemes": map[string]any{
+				"userKey":  map[string]any{"type": "http", "scheme": "bearer", "description": "User API key (GitHub login, admin-issued or /v1/me/api-keys); scopes user-read (GET) / user-write."},
+				"agentKey": map[string]any{"type": "http", "scheme": "bearer", "description": "Agent API key (gateway); scopes gateway-read (GET) / gateway-write / topics-write (topic writes)."},
+				"adminKey": map[string]any{"type": "http", "scheme": "bearer", "description": "User API key of an admin user with the admin scope."},
+			},
+		},
+	}
+}
+
+// operationErrorStatuses derives the documented error statuses of op: 400 for bodies/params,
+// 401/403 by auth, 404 for path params, 409/422 for idempotent gateway writes, plus the op's own,
+// 429 (rate limit) and 500.
+func operationErrorStatuses(op apiOperation) []int {
+	set := map[int]struct{}{http.StatusTooManyRequests: {}, http.StatusInternalServerError: {}}
+	if op.Body != nil || len(op.Query) > 0 || strings.Contains(op.Path, "{") {
+		set[http.StatusBadRequest] = struct{}{}
+	}
+	if op.Auth != authPublic {
+		// 403 covers keys without the route's scope (and non-admins on admin routes).
+		set[http.StatusUnauthorized] = struct{}{}
+		set[http.StatusForbidden] = struct{}{}
+	}
+	if strings.Contains(op.Path, "{") {
+		set[http.StatusNotFound] = struct{}{}
+	}
+	if op.Auth == authAgent && op.Method == http.MethodPost {
+		// Idempotency-Key in flight / reused for another request.
+		set[http.StatusConflict] = struct{}{}
+		set[http.StatusUnprocessableEntity] = struct{}{}
+	}
+	for _, code := range op.Errors {
+		set[code] = struct{}{}
+	}
+	out := make([]int, 0, len(set))
+	for code := range set {
+		out = append(out, code)
+	}
+	sort.Ints(out)
+	return out
+}
+
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range strings.TrimPrefix(path, "/v1") {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			if upper && r >= 'a' && r <= 'z' {
+				r -= 'a' - 'A'
+			}
+			sb.WriteRune(r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return sb.String()
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+	openAPIErr  error
+)
+
+func (s server) handleGetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	openAPIOnce.Do(func() {
+		openAPIJSON, openAPIErr = json.Marshal(buildOpenAPIDocument(apiOperations))
+	})
+	if openAPIErr != nil {
+		logError(r.Context(), "openapi: marshal failed", openAPIErr)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "openapi unavailable"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if _, err := w.Write(openAPIJSON); err != nil {
+		logError(r.Context(), "openapi: write failed", err)
+	}
+}
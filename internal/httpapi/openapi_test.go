@@ -0,0 +1,108 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	r.Route("/v1", server{}.mountV1Routes)
+
+	mounted := map[string]bool{}
+	if err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		mounted[method+" "+route] = true
+		return nil
+	}); err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+
+	documented := map[string]bool{}
+	for _, op := range apiOperations {
+		key := op.Method + " " + openAPIPath(op.Path)
+		if documented[key] {
+			t.Errorf("duplicate spec entry: %s", key)
+		}
+		documented[key] = true
+	}
+
+	for route := range mounted {
+		if !documented[route] {
+			t.Errorf("route %s has no entry in apiOperations", route)
+		}
+	}
+	for route := range documented {
+		if !mounted[route] {
+			t.Errorf("spec entry %s has no mounted route", route)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	rec := httptest.NewRecorder()
+	server{}.handleGetOpenAPI(rec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("openapi=%q", doc.OpenAPI)
+	}
+
+	create := doc.Paths["/v1/admin/runs"]["post"]
+	if create == nil {
+		t.Fatalf("missing POST /v1/admin/runs")
+	}
+	if _, ok := create["responses"].(map[string]any)["201"]; !ok {
+		t.Fatalf("POST /v1/admin/runs: missing 201 response: %v", create["responses"])
+	}
+	if _, ok := doc.Components.Schemas["CreateRunRequest"]; !ok {
+		t.Fatalf("missing CreateRunRequest schema")
+	}
+
+	// Every $ref must resolve.
+	var walk func(v any)
+	walk = func(v any) {
+		switch x := v.(type) {
+		case map[string]any:
+			if ref, ok := x["$ref"].(string); ok && strings.HasPrefix(ref, "#/components/schemas/") {
+				if _, ok := doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !ok {
+					t.Errorf("dangling $ref %s", ref)
+				}
+			}
+			for _, vv := range x {
+				walk(vv)
+			}
+		case []any:
+			for _, vv := range x {
+				walk(vv)
+			}
+		}
+	}
+	for _, item := range doc.Paths {
+		for _, op := range item {
+			walk(op)
+		}
+	}
+	for _, sc := range doc.Components.Schemas {
+		walk(sc)
+	}
+}
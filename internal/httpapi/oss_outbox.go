@@ -0,0 +1,233 @@
+package httpapi
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"aihub/internal/agenthome"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// OSS outbox: platform-originated object writes are recorded in oss_outbox together with their
+// oss_events row in one DB transaction. The object itself is written by a relay (immediately after
+// commit, then retried by a background tick), so the event feed and the bucket always converge.
+
+const (
+	ossOutboxRelayBatch      = 50
+	ossOutboxMaxBackoffSecs  = 600
+	ossOutboxLastErrorMaxLen = 500
+	// ossOutboxClaimLeaseSecs bounds how long a claimed row stays invisible to other relays.
+	ossOutboxClaimLeaseSecs = 120
+	ossOutboxRetentionDays  = 7
+)
+
+// lockOSSEventsInTx serializes oss_events inserts until tx ends, so ids become visible in commit
+// order and the agent event feed (an id cursor) never passes a row that commits later. Every
+// oss_events insert must take it.
+func lockOSSEventsInTx(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `select pg_advisory_xact_lock(hashtext('oss_events'))`)
+	return err
+}
+
+func enqueueOSSWriteInTx(ctx context.Context, tx pgx.Tx, objectKey string, contentType string, eventType string, occurredAt time.Time, body []byte) (int64, error) {
+	if err := lockOSSEventsInTx(ctx, tx); err != nil {
+		return 0, err
+	}
+	var eventID int64
+	if err := tx.QueryRow(ctx, `
+		insert into oss_events (object_key, event_type, occurred_at, payload)
+		values ($1, $2, $3, $4)
+		returning id
+	`, objectKey, eventType, occurredAt.UTC(), body).Scan(&eventID); err != nil {
+		return 0, err
+	}
+	return enqueueOSSOutboxInTx(ctx, tx, objectKey, contentType, body, eventID)
+}
+
+// enqueueOSSOutboxInTx queues the object write for an oss_events row the caller already inserted.
+func enqueueOSSOutboxInTx(ctx context.Context, tx pgx.Tx, objectKey string, contentType string, body []byte, eventID int64) (int64, error) {
+	var outboxID int64
+	err := tx.QueryRow(ctx, `
+		insert into oss_outbox (object_key, content_type, body, oss_event_id)
+		values ($1, $2, $3, $4)
+		returning id
+	`, objectKey, contentType, body, eventID).Scan(&outboxID)
+	return outboxID, err
+}
+
+// writeOSSObjectWithEvent durably records an OSS JSON write plus its "put" oss_event, then attempts
+// delivery right away. A failed delivery is not an error: the relay tick retries it.
+func (s server) writeOSSObjectWithEvent(ctx context.Context, store agenthome.OSSObjectStore, objectKey string, body []byte) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	outboxID, err := enqueueOSSWriteInTx(ctx, tx, objectKey, "application/json", "put", time.Now().UTC(), body)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	if _, err := s.relayOSSOutbox(ctx, store, outboxID, 1); err != nil {
+		logError(ctx, "oss outbox: immediate delivery failed (will retry)", err)
+	}
+	return nil
+}
+
+// relayOSSOutboxTick delivers pending outbox rows whose retry time has come.
+func (s server) relayOSSOutboxTick(ctx context.Context) {
+	if strings.TrimSpace(s.ossProvider) == "" {
+		return
+	}
+	store, err := agenthome.NewOSSObjectStore(s.ossCfg())
+	if err != nil {
+		logError(ctx, "oss outbox: init oss store failed", err)
+		return
+	}
+
+	// Older pending writes to an object that has a newer queued write are superseded; delivering
+	// them later would overwrite newer content.
+	if _, err := s.db.Exec(ctx, `
+		update oss_outbox o
+		set status = 'delivered', delivered_at = now(), last_error = 'superseded'
+		where o.status = 'pending'
+		  and exists (
+			select 1 from oss_outbox n
+			where n.object_key = o.object_key and n.id > o.id
+		  )
+	`); err != nil {
+		logError(ctx, "oss outbox: mark superseded failed", err)
+	}
+
+	if _, err := s.relayOSSOutbox(ctx, store, 0, ossOutboxRelayBatch); err != nil {
+		logError(ctx, "oss outbox: relay failed", err)
+	}
+	s.sweepDeliveredOSSOutbox(ctx)
+}
+
+// relayOSSOutbox writes up to limit due pending rows (or only onlyID when non-zero) to OSS.
+// Rows are claimed first (next_attempt_at pushed out by a lease, skip locked) and that claim is
+// committed, so no row lock is held across PutObject; each row is then marked on its own. A relay
+// that dies mid-batch leaves its rows pending, and they become due again when the lease runs out.
+func (s server) relayOSSOutbox(ctx context.Context, store agenthome.OSSObjectStore, onlyID int64, limit int) (int, error) {
+	rows, err := s.db.Query(ctx, `
+		update oss_outbox o
+		set next_attempt_at = now() + make_interval(secs => $3::int)
+		where o.id in (
+			select id
+			from oss_outbox
+			where status = 'pending'
+			  and next_attempt_at <= now()
+			  and ($1::bigint = 0 or id = $1)
+			order by id asc
+			limit $2
+			for update skip locked
+		)
+		returning o.id, o.object_key, o.content_type, o.body
+	`, onlyID, limit, ossOutboxClaimLeaseSecs)
+	if err != nil {
+		return 0, err
+	}
+	type outboxRow struct {
+		id          int64
+		objectKey   string
+		contentType string
+		body        []byte
+	}
+	var pending []outboxRow
+	for rows.Next() {
+		var o outboxRow
+		if err := rows.Scan(&o.id, &o.objectKey, &o.contentType, &o.body); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].id < pending[j].id })
+
+	delivered := 0
+	var firstErr error
+	for _, o := range pending {
+		putErr := store.PutObject(ctx, o.objectKey, o.contentType, o.body)
+		if putErr == nil {
+			if _, err := s.db.Exec(ctx, `
+				update oss_outbox
+				set status = 'delivered', delivered_at = now(), attempts = attempts + 1, last_error = ''
+				where id = $1
+			`, o.id); err != nil {
+				return delivered, err
+			}
+			delivered++
+			continue
+		}
+
+		if firstErr == nil {
+			firstErr = putErr
+		}
+		msg := truncateUTF8Bytes(putErr.Error(), ossOutboxLastErrorMaxLen)
+		if _, err := s.db.Exec(ctx, `
+			update oss_outbox
+			set attempts = attempts + 1,
+			    last_error = $2,
+			    next_attempt_at = now() + make_interval(secs => least($3::int, 5 * power(2, least(attempts, 10))::int))
+			where id = $1
+		`, o.id, msg, ossOutboxMaxBackoffSecs); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, firstErr
+}
+
+// truncateUTF8Bytes cuts s to at most max bytes without splitting a rune (Postgres rejects invalid
+// UTF-8 in text columns).
+func truncateUTF8Bytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	if max <= 0 {
+		return ""
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// sweepDeliveredOSSOutbox drops delivered rows (and their bodies) once they are past retention;
+// oss_events keeps the feed.
+func (s server) sweepDeliveredOSSOutbox(ctx context.Context) {
+	if _, err := s.db.Exec(ctx, `
+		delete from oss_outbox
+		where status = 'delivered'
+		  and delivered_at < now() - make_interval(days => $1::int)
+	`, ossOutboxRetentionDays); err != nil {
+		logError(ctx, "oss outbox: sweep delivered rows failed", err)
+	}
+}
+
+// discardPendingOSSOutbox drops undelivered writes under prefix so the relay does not resurrect
+// objects an admin is deleting. Call it before deleting the OSS prefix itself.
+func (s server) discardPendingOSSOutbox(ctx context.Context, prefix string) error {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil
+	}
+	_, err := s.db.Exec(ctx, `
+		delete from oss_outbox
+		where status = 'pending' and left(object_key, length($1)) = $1
+	`, prefix)
+	return err
+}
@@ -0,0 +1,19 @@
+package httpapi
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateUTF8Bytes(t *testing.T) {
+	s := "ab" + "中文"
+	for max := 0; max <= len(s)+1; max++ {
+		got := truncateUTF8Bytes(s, max)
+		if len(got) > max || !utf8.ValidString(got) {
+			t.Fatalf("truncateUTF8Bytes(%q, %d) = %q", s, max, got)
+		}
+	}
+	if got := truncateUTF8Bytes(s, 4); got != "ab" {
+		t.Fatalf("got %q, want %q", got, "ab")
+	}
+}
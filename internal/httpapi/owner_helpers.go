@@ -64,3 +64,9 @@ func (s server) listOwnerAgentRefs(ctx context.Context, ownerID uuid.UUID, limit
 	}
 	return out, rows.Err()
 }
+
+func (s server) lookupOwnedAgentID(ctx context.Context, ownerID uuid.UUID, agentRef string) (uuid.UUID, error) {
+	var agentID uuid.UUID
+	err := s.db.QueryRow(ctx, `select id from agents where public_ref = $1 and owner_id = $2`, agentRef, ownerID).Scan(&agentID)
+	return agentID, err
+}
@@ -2,10 +2,12 @@ package httpapi
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 )
 
 type privacyKind string
@@ -14,10 +16,28 @@ const (
 	privacyEmail      privacyKind = "email"
 	privacyPhone      privacyKind = "phone"
 	privacyIDNumber   privacyKind = "id_number"
+	privacyBankCard   privacyKind = "bank_card"
+	privacyIPAddress  privacyKind = "ip_address"
+	privacyAddress    privacyKind = "address"
 	privacySecret     privacyKind = "secret"
 	privacyPrivateKey privacyKind = "private_key"
 )
 
+// allPrivacyKinds lists every detector kind, in report order.
+var allPrivacyKinds = []privacyKind{
+	privacyEmail, privacyPhone, privacyIDNumber, privacyBankCard,
+	privacyIPAddress, privacyAddress, privacySecret, privacyPrivateKey,
+}
+
+func isPrivacyKind(k privacyKind) bool {
+	for _, it := range allPrivacyKinds {
+		if it == k {
+			return true
+		}
+	}
+	return false
+}
+
 type privacyFinding struct {
 	Kind  privacyKind
 	Field string // JSON-ish path like "content.text" / "payload.title"
@@ -53,63 +73,253 @@ func privacyKinds(findings []privacyFinding) []string {
 	return out
 }
 
-var (
-	privacyEmailRe = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
-	// Go regexp doesn't support lookbehind; use non-digit boundaries.
-	privacyPhoneCNRe   = regexp.MustCompile(`(?:^|[^0-9])1[3-9][0-9]{9}(?:$|[^0-9])`)
-	privacyPhoneE164Re = regexp.MustCompile(`\+[1-9][0-9]{7,14}`)
-	privacyID18Re      = regexp.MustCompile(`(?:^|[^0-9])[0-9]{17}[0-9Xx](?:$|[^0-9])`)
-	privacyID15Re      = regexp.MustCompile(`(?:^|[^0-9])[0-9]{15}(?:$|[^0-9])`)
+// privacyDetector finds one kind of sensitive text. group selects the submatch to report (0 = the
+// whole match); validate, when set, drops regex hits that fail a checksum or range check.
+type privacyDetector struct {
+	kind     privacyKind
+	re       *regexp.Regexp
+	group    int
+	validate func(s string, start, end int) bool
+}
 
-	privacyPEMKeyRe = regexp.MustCompile(`-----BEGIN (?:RSA |OPENSSH )?PRIVATE KEY-----`)
+// privacySpan is a detected byte range [Start, End) of the scanned string.
+type privacySpan struct {
+	Kind       privacyKind
+	Start, End int
+}
 
-	privacyOpenAIKeyRe = regexp.MustCompile(`\bsk-[A-Za-z0-9]{16,}\b`)
-	privacyGitHubKeyRe = regexp.MustCompile(`\bghp_[A-Za-z0-9]{36}\b`)
-	privacyAWSKeyRe    = regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)
-	privacyBearerRe    = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._\-]{20,}\b`)
-)
+var privacyDetectors = []privacyDetector{
+	{kind: privacyPrivateKey, re: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |ENCRYPTED |PGP )?PRIVATE KEY(?: BLOCK)?-----(?:[\s\S]*?-----END (?:RSA |EC |DSA |OPENSSH |ENCRYPTED |PGP )?PRIVATE KEY(?: BLOCK)?-----)?`)},
 
-func detectPrivacyInString(s string) []privacyKind {
-	s = strings.TrimSpace(s)
-	if s == "" {
+	{kind: privacySecret, re: regexp.MustCompile(`\bsk-(?:proj-|ant-(?:api|admin)[0-9]{2}-)?[A-Za-z0-9_\-]{16,}`)},
+	{kind: privacySecret, re: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b`)},
+	{kind: privacySecret, re: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}`)},
+	{kind: privacySecret, re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: privacySecret, re: regexp.MustCompile(`\bLTAI[0-9A-Za-z]{12,20}\b`)}, // Alibaba Cloud AccessKey ID
+	{kind: privacySecret, re: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},   // Google API key
+	{kind: privacySecret, re: regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z\-]{10,}`)},
+	{kind: privacySecret, re: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[0-9A-Za-z]{16,}`)},
+	{kind: privacySecret, re: regexp.MustCompile(`\bwhsec_[A-Za-z0-9_\-]{16,}`)},
+	{kind: privacySecret, re: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`)}, // JWT
+	{kind: privacySecret, re: regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._\-]{20,}\b`)},
+	// Only the value of "password: ..." style assignments is sensitive.
+	{kind: privacySecret, re: regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|api[_\-]?key|access[_\-]?key[_\-]?secret|token)["']?\s*[:=：]\s*["']?([^\s"'，。\[][^\s"'，。]{7,})`), group: 1},
+
+	{kind: privacyEmail, re: regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)},
+
+	// Bare digit runs are classified by length and checksum (ID card, mobile, bank card); Go
+	// regexp has no lookbehind, so runs are matched whole and checked in validate.
+	{kind: privacyIDNumber, re: regexp.MustCompile(`[0-9]{17}[0-9Xx]|[0-9]{15}`), validate: validChineseIDNumber},
+	{kind: privacyPhone, re: regexp.MustCompile(`1[3-9][0-9]{9}`), validate: digitRunBoundary},
+	{kind: privacyPhone, re: regexp.MustCompile(`1[3-9][0-9][ \-][0-9]{4}[ \-][0-9]{4}`), validate: digitRunBoundary},
+	{kind: privacyPhone, re: regexp.MustCompile(`\+[1-9][0-9]{7,14}`)},
+	{kind: privacyPhone, re: regexp.MustCompile(`\+[1-9][0-9]{0,2}[ \-][0-9]{2,4}(?:[ \-][0-9]{3,4}){1,3}`)},
+	{kind: privacyBankCard, re: regexp.MustCompile(`[0-9]{13,19}|[0-9]{4}(?:[ \-][0-9]{4}){2,3}(?:[ \-][0-9]{1,3})?`), validate: validBankCard},
+
+	{kind: privacyIPAddress, re: regexp.MustCompile(`\b(?:[0-9]{1,3}\.){3}[0-9]{1,3}\b`), validate: validIPv4},
+	{kind: privacyIPAddress, re: regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`), validate: validIPv6},
+
+	{kind: privacyAddress, re: regexp.MustCompile(`\p{Han}{2,6}(?:路|街|大街|大道|巷|弄|胡同)\s*[0-9０-９一二三四五六七八九十百零〇]+\s*[号號](?:院|楼|樓)?(?:\s*[0-9０-９A-Za-z]+(?:号楼|栋|幢|座|单元|室|层))*`)},
+	{kind: privacyAddress, re: regexp.MustCompile(`\p{Han}{2,8}(?:小区|花园|公寓|大厦|苑|村)\s*[0-9０-９]+\s*(?:号楼|栋|幢|座)(?:\s*[0-9０-９]+\s*单元)?(?:\s*[0-9０-９]+\s*室?)?`)},
+	{kind: privacyAddress, re: regexp.MustCompile(`\b[0-9]{1,5}\s+(?:[A-Z][a-z]+\s+){1,3}(?:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr|Court|Ct|Way|Place|Pl)\b\.?`)},
+}
+
+// findPrivacySpans returns the sensitive ranges of s, ordered and non-overlapping (an earlier
+// detector wins a tie, a longer span wins at the same start). The whole string is scanned, so
+// redaction never passes a tail through unchecked; callers bound the input size (request body
+// limits) and Go regexps run in linear time.
+func findPrivacySpans(s string) []privacySpan {
+	if strings.TrimSpace(s) == "" {
 		return nil
 	}
 
-	// Keep checks cheap; avoid scanning extremely large blobs.
-	const maxBytes = 220_000
-	if len(s) > maxBytes {
-		s = s[:maxBytes]
+	var spans []privacySpan
+	for _, d := range privacyDetectors {
+		for _, m := range d.re.FindAllStringSubmatchIndex(s, -1) {
+			start, end := m[2*d.group], m[2*d.group+1]
+			if start < 0 {
+				continue
+			}
+			if d.validate != nil && !d.validate(s, start, end) {
+				continue
+			}
+			spans = append(spans, privacySpan{Kind: d.kind, Start: start, End: end})
+		}
 	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		return spans[i].End > spans[j].End
+	})
+	out := spans[:0]
+	lastEnd := -1
+	for _, sp := range spans {
+		if sp.Start < lastEnd {
+			continue
+		}
+		out = append(out, sp)
+		lastEnd = sp.End
+	}
+	return out
+}
 
+func detectPrivacyInString(s string) []privacyKind {
 	kinds := []privacyKind{}
-	add := func(k privacyKind) {
-		for _, it := range kinds {
-			if it == k {
-				return
+	for _, sp := range findPrivacySpans(s) {
+		found := false
+		for _, k := range kinds {
+			if k == sp.Kind {
+				found = true
+				break
 			}
 		}
-		kinds = append(kinds, k)
+		if !found {
+			kinds = append(kinds, sp.Kind)
+		}
+	}
+	return kinds
+}
+
+// privacyPlaceholder is the typed marker that replaces redacted text.
+func privacyPlaceholder(k privacyKind) string {
+	return "[REDACTED:" + string(k) + "]"
+}
+
+// redactPrivacyInString replaces the spans whose kind passes redact with typed placeholders.
+func redactPrivacyInString(s string, redact func(privacyKind) bool) string {
+	spans := findPrivacySpans(s)
+	if len(spans) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		if !redact(sp.Kind) {
+			continue
+		}
+		b.WriteString(s[last:sp.Start])
+		b.WriteString(privacyPlaceholder(sp.Kind))
+		last = sp.End
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// redactPrivacyValue returns a copy of v (a string or decoded JSON) with redactPrivacyInString
+// applied to every string leaf.
+func redactPrivacyValue(v any, redact func(privacyKind) bool) any {
+	switch t := v.(type) {
+	case string:
+		return redactPrivacyInString(t, redact)
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, it := range t {
+			out[k] = redactPrivacyValue(it, redact)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, it := range t {
+			out[i] = redactPrivacyValue(it, redact)
+		}
+		return out
+	default:
+		return v
 	}
+}
 
-	if privacyEmailRe.FindStringIndex(s) != nil {
-		add(privacyEmail)
+// digitRunBoundary rejects matches that are part of a longer digit run.
+func digitRunBoundary(s string, start, end int) bool {
+	return (start == 0 || !isASCIIDigit(s[start-1])) && (end == len(s) || !isASCIIDigit(s[end]))
+}
+
+func isASCIIDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// validChineseIDNumber checks a resident ID number: region prefix, birth date and, for the
+// 18-digit form, the GB 11643 check character. The legacy 15-digit form has no checksum.
+func validChineseIDNumber(s string, start, end int) bool {
+	if !digitRunBoundary(s, start, end) || (end < len(s) && (s[end] == 'X' || s[end] == 'x')) {
+		return false
 	}
-	if privacyPhoneE164Re.FindStringIndex(s) != nil || privacyPhoneCNRe.FindStringIndex(s) != nil {
-		add(privacyPhone)
+	id := strings.ToUpper(s[start:end])
+	if id[0] < '1' || id[0] > '8' {
+		return false
 	}
-	if privacyID18Re.FindStringIndex(s) != nil || privacyID15Re.FindStringIndex(s) != nil {
-		add(privacyIDNumber)
+	var birth string
+	if len(id) == 15 {
+		birth = "19" + id[6:12]
+	} else {
+		birth = id[6:14]
 	}
-	if privacyPEMKeyRe.FindStringIndex(s) != nil {
-		add(privacyPrivateKey)
+	t, err := time.Parse("20060102", birth)
+	if err != nil || t.Year() < 1900 || t.After(time.Now()) {
+		return false
 	}
-	if privacyOpenAIKeyRe.FindStringIndex(s) != nil ||
-		privacyGitHubKeyRe.FindStringIndex(s) != nil ||
-		privacyAWSKeyRe.FindStringIndex(s) != nil ||
-		privacyBearerRe.FindStringIndex(s) != nil {
-		add(privacySecret)
+	if len(id) == 15 {
+		return true
 	}
-	return kinds
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(id[i]-'0') * w
+	}
+	return id[17] == "10X98765432"[sum%11]
+}
+
+// validBankCard accepts 13-19 digit card numbers (optionally grouped by spaces or dashes) with a
+// known issuer prefix and a valid Luhn checksum.
+func validBankCard(s string, start, end int) bool {
+	if !digitRunBoundary(s, start, end) {
+		return false
+	}
+	digits := make([]byte, 0, end-start)
+	for i := start; i < end; i++ {
+		if isASCIIDigit(s[i]) {
+			digits = append(digits, s[i])
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 || digits[0] < '3' || digits[0] > '6' {
+		return false
+	}
+	if strings.Count(string(digits), string(digits[0])) == len(digits) {
+		return false
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validIPv4 accepts dotted quads that are not part of a longer dotted sequence (version numbers)
+// and are not loopback or unspecified.
+func validIPv4(s string, start, end int) bool {
+	if (start >= 2 && s[start-1] == '.' && isASCIIDigit(s[start-2])) || (end+1 < len(s) && s[end] == '.' && isASCIIDigit(s[end+1])) {
+		return false
+	}
+	ip := net.ParseIP(s[start:end])
+	return ip != nil && !ip.IsLoopback() && !ip.IsUnspecified()
+}
+
+// validIPv6 accepts standalone IPv6 literals; neighbouring identifier characters rule out things
+// like "std::string" or timestamps.
+func validIPv6(s string, start, end int) bool {
+	isIdent := func(c byte) bool {
+		return c == '_' || c == ':' || c == '.' || isASCIIDigit(c) || (c|0x20 >= 'a' && c|0x20 <= 'z')
+	}
+	if (start > 0 && isIdent(s[start-1])) || (end < len(s) && isIdent(s[end])) {
+		return false
+	}
+	ip := net.ParseIP(s[start:end])
+	return ip != nil && ip.To4() == nil && !ip.IsLoopback() && !ip.IsUnspecified()
 }
 
 func detectPrivacyFindings(v any, rootField string) []privacyFinding {
@@ -186,7 +396,11 @@ func rejectIfPrivacyViolation(ctx context.Context, w http.ResponseWriter, v any,
 	if len(findings) == 0 {
 		return false
 	}
+	writePrivacyViolation(ctx, w, findings, logMsgPrefix)
+	return true
+}
 
+func writePrivacyViolation(ctx context.Context, w http.ResponseWriter, findings []privacyFinding, logMsgPrefix string) {
 	err := privacyViolationError{Findings: findings}
 	if logMsgPrefix == "" {
 		logMsgPrefix = "privacy violation"
@@ -197,5 +411,4 @@ func rejectIfPrivacyViolation(ctx context.Context, w http.ResponseWriter, v any,
 		"error": "privacy_violation",
 		"kinds": privacyKinds(findings),
 	})
-	return true
 }
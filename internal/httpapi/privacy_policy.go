@@ -0,0 +1,262 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// privacySurface is a class of content with its own privacy policy.
+type privacySurface string
+
+const (
+	privacySurfaceEventPayload privacySurface = "event_payload"
+	privacySurfaceArtifact     privacySurface = "artifact"
+	privacySurfaceTopicMessage privacySurface = "topic_message"
+	privacySurfaceAgentCard    privacySurface = "agent_card"
+)
+
+var allPrivacySurfaces = []privacySurface{
+	privacySurfaceEventPayload, privacySurfaceArtifact, privacySurfaceTopicMessage, privacySurfaceAgentCard,
+}
+
+func isPrivacySurface(s privacySurface) bool {
+	for _, it := range allPrivacySurfaces {
+		if it == s {
+			return true
+		}
+	}
+	return false
+}
+
+type privacyAction string
+
+const (
+	privacyActionReject privacyAction = "reject" // 400 privacy_violation; nothing is stored
+	privacyActionRedact privacyAction = "redact" // matches replaced with typed placeholders, then stored
+	privacyActionFlag   privacyAction = "flag"   // stored unchanged and held for moderation
+	privacyActionAllow  privacyAction = "allow"
+)
+
+func isPrivacyAction(a privacyAction) bool {
+	switch a {
+	case privacyActionReject, privacyActionRedact, privacyActionFlag, privacyActionAllow:
+		return true
+	default:
+		return false
+	}
+}
+
+// privacyPolicy maps findings on one surface to an action: KindActions per detector kind,
+// Action for the rest.
+type privacyPolicy struct {
+	Surface     privacySurface                `json:"surface"`
+	Action      privacyAction                 `json:"action"`
+	KindActions map[privacyKind]privacyAction `json:"kind_actions"`
+}
+
+func (p privacyPolicy) actionFor(k privacyKind) privacyAction {
+	if a, ok := p.KindActions[k]; ok {
+		return a
+	}
+	return p.Action
+}
+
+// defaultPrivacyPolicy keeps the original behaviour for run and topic content: the kinds that were
+// always enforced are rejected, the newer detectors (bank cards, IP and street addresses) only
+// flag. Agent cards were not checked before, so findings there only send the card back to review.
+func defaultPrivacyPolicy(surface privacySurface) privacyPolicy {
+	if surface == privacySurfaceAgentCard {
+		return privacyPolicy{Surface: surface, Action: privacyActionFlag, KindActions: map[privacyKind]privacyAction{}}
+	}
+	return privacyPolicy{Surface: surface, Action: privacyActionReject, KindActions: map[privacyKind]privacyAction{
+		privacyBankCard:  privacyActionFlag,
+		privacyIPAddress: privacyActionFlag,
+		privacyAddress:   privacyActionFlag,
+	}}
+}
+
+// privacyDecision groups the findings of one write by the action the policy chose for them.
+type privacyDecision struct {
+	Rejected []privacyFinding
+	Redacted []privacyFinding
+	Flagged  []privacyFinding
+}
+
+// applyPrivacyPolicy checks v (a string or decoded JSON) against p. When anything is rejected v is
+// returned unchanged; otherwise redacted kinds are replaced in a copy of v.
+func applyPrivacyPolicy(p privacyPolicy, v any, rootField string) (any, privacyDecision) {
+	var d privacyDecision
+	for _, f := range detectPrivacyFindings(v, rootField) {
+		switch p.actionFor(f.Kind) {
+		case privacyActionReject:
+			d.Rejected = append(d.Rejected, f)
+		case privacyActionRedact:
+			d.Redacted = append(d.Redacted, f)
+		case privacyActionFlag:
+			d.Flagged = append(d.Flagged, f)
+		}
+	}
+	if len(d.Rejected) > 0 || len(d.Redacted) == 0 {
+		return v, d
+	}
+	return redactPrivacyValue(v, func(k privacyKind) bool { return p.actionFor(k) == privacyActionRedact }), d
+}
+
+// enforcePrivacyPolicy applies the surface's policy to v. On reject it writes the 400 response
+// and returns ok=false; otherwise it returns v with redactions applied (same dynamic type) and the
+// decision to pass to recordPrivacyDecision once the write has an id.
+func (s server) enforcePrivacyPolicy(ctx context.Context, w http.ResponseWriter, surface privacySurface, v any, rootField, logMsgPrefix string) (any, privacyDecision, bool) {
+	out, d := applyPrivacyPolicy(s.privacy.policy(ctx, surface), v, rootField)
+	if len(d.Rejected) > 0 {
+		writePrivacyViolation(ctx, w, d.Rejected, logMsgPrefix)
+		return nil, d, false
+	}
+	return out, d, true
+}
+
+// recordPrivacyDecision logs the redacted and flagged findings of an accepted write.
+func (s server) recordPrivacyDecision(ctx context.Context, surface privacySurface, d privacyDecision, targetType, targetRef, actorType string, actorID uuid.UUID) {
+	for _, it := range []struct {
+		action   privacyAction
+		findings []privacyFinding
+	}{{privacyActionRedact, d.Redacted}, {privacyActionFlag, d.Flagged}} {
+		if len(it.findings) == 0 {
+			continue
+		}
+		if err := s.privacy.record(ctx, surface, it.action, targetType, targetRef, it.findings, actorType, actorID); err != nil {
+			logError(ctx, "record privacy findings failed", err)
+		}
+	}
+}
+
+const privacyPolicyTTL = 30 * time.Second
+
+// privacyGuard serves the admin-configured policies, cached for privacyPolicyTTL so edits reach
+// every replica quickly, and stores the findings log. A nil guard (tests) applies the defaults
+// and records nothing.
+type privacyGuard struct {
+	db *pgxpool.Pool
+
+	mu       sync.Mutex
+	policies map[privacySurface]privacyPolicy
+	loadedAt time.Time
+}
+
+func newPrivacyGuard(db *pgxpool.Pool) *privacyGuard {
+	return &privacyGuard{db: db}
+}
+
+func (g *privacyGuard) policy(ctx context.Context, surface privacySurface) privacyPolicy {
+	if g == nil || g.db == nil {
+		return defaultPrivacyPolicy(surface)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Since(g.loadedAt) > privacyPolicyTTL {
+		loaded, err := g.load(ctx)
+		if err != nil {
+			// Keep serving the last good policies (or defaults); retry after the TTL.
+			logError(ctx, "load privacy policies failed", err)
+		} else {
+			g.policies = loaded
+		}
+		g.loadedAt = time.Now()
+	}
+	if p, ok := g.policies[surface]; ok {
+		return p
+	}
+	return defaultPrivacyPolicy(surface)
+}
+
+// invalidate makes the next policy lookup reload from the database.
+func (g *privacyGuard) invalidate() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.loadedAt = time.Time{}
+	g.mu.Unlock()
+}
+
+func (g *privacyGuard) load(ctx context.Context) (map[privacySurface]privacyPolicy, error) {
+	rows, err := g.db.Query(ctx, `select surface, action, kind_actions from privacy_policies`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[privacySurface]privacyPolicy{}
+	for rows.Next() {
+		var (
+			p   privacyPolicy
+			raw []byte
+		)
+		if err := rows.Scan(&p.Surface, &p.Action, &raw); err != nil {
+			return nil, err
+		}
+		p.KindActions = map[privacyKind]privacyAction{}
+		if err := json.Unmarshal(raw, &p.KindActions); err != nil {
+			return nil, err
+		}
+		out[p.Surface] = p
+	}
+	return out, rows.Err()
+}
+
+func (g *privacyGuard) record(ctx context.Context, surface privacySurface, action privacyAction, targetType, targetRef string, findings []privacyFinding, actorType string, actorID uuid.UUID) error {
+	if g == nil || g.db == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(findings))
+	seen := map[string]struct{}{}
+	for _, f := range findings {
+		if _, ok := seen[f.Field]; ok {
+			continue
+		}
+		seen[f.Field] = struct{}{}
+		fields = append(fields, f.Field)
+	}
+	_, err := g.db.Exec(ctx, `
+		insert into privacy_findings (surface, action, target_type, target_ref, kinds, fields, actor_type, actor_id)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, surface, action, targetType, targetRef, privacyKinds(findings), fields, actorType, actorID)
+	return err
+}
+
+// agentCardText is the free text of an agent card, checked by the agent_card policy.
+type agentCardText struct {
+	Name, Description, Bio, Greeting string
+	Interests, Capabilities          []string
+}
+
+// enforceAgentCardPrivacy is enforcePrivacyPolicy over the card's text fields.
+func (s server) enforceAgentCardPrivacy(ctx context.Context, w http.ResponseWriter, c agentCardText) (agentCardText, privacyDecision, bool) {
+	fromAny := func(in any) []string {
+		list, _ := in.([]any)
+		out := make([]string, 0, len(list))
+		for _, it := range list {
+			if s, ok := it.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	redacted, d, ok := s.enforcePrivacyPolicy(ctx, w, privacySurfaceAgentCard, c.value(), "card", "agent card: blocked by privacy filter")
+	if !ok {
+		return c, d, false
+	}
+	m := redacted.(map[string]any)
+	return agentCardText{
+		Name:         m["name"].(string),
+		Description:  m["description"].(string),
+		Bio:          m["bio"].(string),
+		Greeting:     m["greeting"].(string),
+		Interests:    fromAny(m["interests"]),
+		Capabilities: fromAny(m["capabilities"]),
+	}, d, true
+}
@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplyPrivacyPolicy(t *testing.T) {
+	payload := map[string]any{
+		"text": "联系 a@b.com 或 13812345678",
+		"meta": map[string]any{"ip": "47.96.12.3"},
+	}
+	policy := privacyPolicy{
+		Surface: privacySurfaceEventPayload,
+		Action:  privacyActionRedact,
+		KindActions: map[privacyKind]privacyAction{
+			privacyIPAddress: privacyActionFlag,
+			privacyEmail:     privacyActionAllow,
+		},
+	}
+
+	out, d := applyPrivacyPolicy(policy, payload, "payload")
+	if len(d.Rejected) != 0 || !hasFinding(d.Redacted, privacyPhone, "payload.text") || !hasFinding(d.Flagged, privacyIPAddress, "payload.meta.ip") {
+		t.Fatalf("unexpected decision: %+v", d)
+	}
+	m := out.(map[string]any)
+	if m["text"] != "联系 a@b.com 或 [REDACTED:phone]" {
+		t.Fatalf("unexpected redacted text: %q", m["text"])
+	}
+	if m["meta"].(map[string]any)["ip"] != "47.96.12.3" {
+		t.Fatalf("flagged value must be stored unchanged: %v", m["meta"])
+	}
+	if payload["text"] != "联系 a@b.com 或 13812345678" {
+		t.Fatalf("input must not be modified: %v", payload["text"])
+	}
+
+	policy.KindActions[privacyEmail] = privacyActionReject
+	out, d = applyPrivacyPolicy(policy, payload, "payload")
+	if !hasFinding(d.Rejected, privacyEmail, "payload.text") || out.(map[string]any)["text"] != payload["text"] {
+		t.Fatalf("reject must win and leave the value untouched: %+v", d)
+	}
+}
+
+func TestEnforcePrivacyPolicyDefaults(t *testing.T) {
+	s := server{} // nil privacy guard: built-in defaults
+	ctx := context.Background()
+
+	rr := httptest.NewRecorder()
+	if _, _, ok := s.enforcePrivacyPolicy(ctx, rr, privacySurfaceArtifact, "mail x@y.com", "content", "test"); ok || rr.Code != 400 {
+		t.Fatalf("artifact default must reject, ok=%v code=%d", ok, rr.Code)
+	}
+
+	// Kinds added with the policy engine only flag by default.
+	rr = httptest.NewRecorder()
+	if _, d, ok := s.enforcePrivacyPolicy(ctx, rr, privacySurfaceTopicMessage, "ping 192.168.1.1 from 221 Baker Street", "text", "test"); !ok || !hasFinding(d.Flagged, privacyIPAddress, "text") || !hasFinding(d.Flagged, privacyAddress, "text") {
+		t.Fatalf("ip/address default must flag, ok=%v d=%+v", ok, d)
+	}
+
+	rr = httptest.NewRecorder()
+	card, d, ok := s.enforceAgentCardPrivacy(ctx, rr, agentCardText{Name: "bot", Bio: "mail x@y.com", Interests: []string{"诗歌"}})
+	if !ok || len(d.Flagged) == 0 || card.Bio != "mail x@y.com" || len(card.Interests) != 1 {
+		t.Fatalf("agent card default must flag and keep content: ok=%v d=%+v card=%+v", ok, d, card)
+	}
+}
@@ -0,0 +1,639 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+)
+
+// Retroactive privacy scans: content stored before a detector or policy existed is walked with the
+// current detectors and every item with findings is written to privacy_scan_findings. Admins then
+// send items back to moderation or redact them in bulk (applyPrivacyScanFinding).
+
+const (
+	privacyScanPageSize = 500
+	privacyScanTimeout  = 6 * time.Hour
+	// A running scan whose heartbeat is older than this is treated as dead (e.g. the process
+	// restarted) and no longer blocks a new scan.
+	privacyScanStaleAfter = 5 * time.Minute
+)
+
+// privacyScanItem is one stored item with findings.
+type privacyScanItem struct {
+	Surface    privacySurface
+	TargetType string
+	TargetID   string
+	Location   string
+	Findings   []privacyFinding
+}
+
+// topicObjectScanField returns the field of a topic OSS object that holds agent-written text.
+func topicObjectScanField(kind string) string {
+	if kind == "requests" {
+		return "payload"
+	}
+	return "content"
+}
+
+// topicObjectTargetType maps a parsed topic key kind to the privacy target type.
+func topicObjectTargetType(kind string) string {
+	if kind == "requests" {
+		return "topic_request"
+	}
+	return "topic_message"
+}
+
+// scanTopicObject checks the agent-written part of a topic message/request object.
+func scanTopicObject(kind string, body []byte) []privacyFinding {
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil
+	}
+	field := topicObjectScanField(kind)
+	v, ok := obj[field]
+	if !ok {
+		return nil
+	}
+	return detectPrivacyFindings(v, field)
+}
+
+// redactTopicObject redacts the agent-written part of a topic object, leaving ids and meta intact.
+// changed is false when nothing matched.
+func redactTopicObject(kind string, body []byte, redact func(privacyKind) bool) (out []byte, changed bool, err error) {
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, false, err
+	}
+	field := topicObjectScanField(kind)
+	v, ok := obj[field]
+	if !ok {
+		return body, false, nil
+	}
+	redacted := redactPrivacyValue(v, redact)
+	before, _ := json.Marshal(v)
+	after, _ := json.Marshal(redacted)
+	if string(before) == string(after) {
+		return body, false, nil
+	}
+	obj[field] = redacted
+	out, err = json.Marshal(obj)
+	return out, err == nil, err
+}
+
+// privacyKindSet returns a redact predicate for the given kinds; empty means every kind.
+func privacyKindSet(kinds []string) func(privacyKind) bool {
+	if len(kinds) == 0 {
+		return func(privacyKind) bool { return true }
+	}
+	set := map[privacyKind]struct{}{}
+	for _, k := range kinds {
+		set[privacyKind(k)] = struct{}{}
+	}
+	return func(k privacyKind) bool {
+		_, ok := set[k]
+		return ok
+	}
+}
+
+// runPrivacyScan walks the requested surfaces and records findings. It runs detached from the
+// request that created the scan.
+func (s server) runPrivacyScan(scanID uuid.UUID, surfaces []privacySurface, since *time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), privacyScanTimeout)
+	defer cancel()
+
+	scanned, matched := 0, 0
+	record := func(items []privacyScanItem, n int) error {
+		for _, it := range items {
+			fields := make([]string, 0, len(it.Findings))
+			seen := map[string]struct{}{}
+			for _, f := range it.Findings {
+				if _, ok := seen[f.Field]; ok {
+					continue
+				}
+				seen[f.Field] = struct{}{}
+				fields = append(fields, f.Field)
+			}
+			if _, err := s.db.Exec(ctx, `
+				insert into privacy_scan_findings (scan_id, surface, target_type, target_id, location, kinds, fields)
+				values ($1, $2, $3, $4, $5, $6, $7)
+			`, scanID, it.Surface, it.TargetType, it.TargetID, it.Location, privacyKinds(it.Findings), fields); err != nil {
+				return err
+			}
+		}
+		scanned += n
+		matched += len(items)
+		_, err := s.db.Exec(ctx, `
+			update privacy_scans set scanned = $2, matched = $3, updated_at = now() where id = $1
+		`, scanID, scanned, matched)
+		return err
+	}
+
+	var err error
+	for _, surface := range surfaces {
+		switch surface {
+		case privacySurfaceEventPayload:
+			err = s.scanEventPayloads(ctx, since, record)
+		case privacySurfaceArtifact:
+			err = s.scanArtifacts(ctx, since, record)
+		case privacySurfaceAgentCard:
+			err = s.scanAgentCards(ctx, since, record)
+		case privacySurfaceTopicMessage:
+			err = s.scanTopicObjects(ctx, since, record)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", surface, err)
+			break
+		}
+	}
+
+	status, errText := "completed", ""
+	if err != nil {
+		logError(ctx, "privacy scan failed", err)
+		status, errText = "failed", err.Error()
+	}
+	// The scan context may be the thing that failed; finish with a fresh one.
+	fctx, fcancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer fcancel()
+	if _, err := s.db.Exec(fctx, `
+		update privacy_scans
+		set status = $2, error = $3, scanned = $4, matched = $5, updated_at = now(), finished_at = now()
+		where id = $1
+	`, scanID, status, errText, scanned, matched); err != nil {
+		logError(fctx, "privacy scan: finish failed", err)
+	}
+}
+
+type privacyScanRecordFunc func(items []privacyScanItem, scanned int) error
+
+func (s server) scanEventPayloads(ctx context.Context, since *time.Time, record privacyScanRecordFunc) error {
+	var (
+		cursorAt time.Time
+		cursorID uuid.UUID
+	)
+	for {
+		rows, err := s.db.Query(ctx, `
+			select e.id, r.public_ref, e.seq, e.payload, e.created_at
+			from events e
+			join runs r on r.id = e.run_id
+			where ($1::timestamptz is null or e.created_at >= $1)
+			  and (e.created_at, e.id) > ($2, $3)
+			order by e.created_at, e.id
+			limit $4
+		`, since, cursorAt, cursorID, privacyScanPageSize)
+		if err != nil {
+			return err
+		}
+		var items []privacyScanItem
+		n := 0
+		for rows.Next() {
+			var (
+				id      uuid.UUID
+				runRef  string
+				seq     int64
+				payload []byte
+			)
+			if err := rows.Scan(&id, &runRef, &seq, &payload, &cursorAt); err != nil {
+				rows.Close()
+				return err
+			}
+			cursorID = id
+			n++
+			var v any
+			if err := json.Unmarshal(payload, &v); err != nil {
+				continue
+			}
+			if findings := detectPrivacyFindings(v, "payload"); len(findings) > 0 {
+				items = append(items, privacyScanItem{
+					Surface: privacySurfaceEventPayload, TargetType: "event", TargetID: id.String(),
+					Location: runRef + "#" + strconv.FormatInt(seq, 10), Findings: findings,
+				})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if err := record(items, n); err != nil {
+			return err
+		}
+		if n < privacyScanPageSize {
+			return nil
+		}
+	}
+}
+
+func (s server) scanArtifacts(ctx context.Context, since *time.Time, record privacyScanRecordFunc) error {
+	var (
+		cursorAt time.Time
+		cursorID uuid.UUID
+	)
+	for {
+		rows, err := s.db.Query(ctx, `
+			select a.id, r.public_ref, a.version, a.content, a.created_at
+			from artifacts a
+			join runs r on r.id = a.run_id
+			where ($1::timestamptz is null or a.created_at >= $1)
+			  and (a.created_at, a.id) > ($2, $3)
+			order by a.created_at, a.id
+			limit $4
+		`, since, cursorAt, cursorID, privacyScanPageSize)
+		if err != nil {
+			return err
+		}
+		var items []privacyScanItem
+		n := 0
+		for rows.Next() {
+			var (
+				id      uuid.UUID
+				runRef  string
+				version int
+				content string
+			)
+			if err := rows.Scan(&id, &runRef, &version, &content, &cursorAt); err != nil {
+				rows.Close()
+				return err
+			}
+			cursorID = id
+			n++
+			if findings := detectPrivacyFindings(content, "content"); len(findings) > 0 {
+				items = append(items, privacyScanItem{
+					Surface: privacySurfaceArtifact, TargetType: "artifact", TargetID: id.String(),
+					Location: runRef + " v" + strconv.Itoa(version), Findings: findings,
+				})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if err := record(items, n); err != nil {
+			return err
+		}
+		if n < privacyScanPageSize {
+			return nil
+		}
+	}
+}
+
+func (s server) scanAgentCards(ctx context.Context, since *time.Time, record privacyScanRecordFunc) error {
+	var (
+		cursorAt time.Time
+		cursorID uuid.UUID
+	)
+	for {
+		rows, err := s.db.Query(ctx, `
+			select id, public_ref, name, description, bio, greeting, interests, capabilities, created_at
+			from agents
+			where ($1::timestamptz is null or updated_at >= $1)
+			  and (created_at, id) > ($2, $3)
+			order by created_at, id
+			limit $4
+		`, since, cursorAt, cursorID, privacyScanPageSize)
+		if err != nil {
+			return err
+		}
+		var items []privacyScanItem
+		n := 0
+		for rows.Next() {
+			var (
+				id                          uuid.UUID
+				agentRef                    string
+				c                           agentCardText
+				interestsRaw, capabilityRaw []byte
+			)
+			if err := rows.Scan(&id, &agentRef, &c.Name, &c.Description, &c.Bio, &c.Greeting, &interestsRaw, &capabilityRaw, &cursorAt); err != nil {
+				rows.Close()
+				return err
+			}
+			cursorID = id
+			n++
+			_ = json.Unmarshal(interestsRaw, &c.Interests)
+			_ = json.Unmarshal(capabilityRaw, &c.Capabilities)
+			if findings := detectPrivacyFindings(c.value(), "card"); len(findings) > 0 {
+				items = append(items, privacyScanItem{
+					Surface: privacySurfaceAgentCard, TargetType: "agent_card", TargetID: id.String(),
+					Location: agentRef, Findings: findings,
+				})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if err := record(items, n); err != nil {
+			return err
+		}
+		if n < privacyScanPageSize {
+			return nil
+		}
+	}
+}
+
+// scanTopicObjects checks the latest version of every topic message/request recorded in oss_events.
+// Objects that were deleted or rewritten since are skipped in favour of their newest event.
+func (s server) scanTopicObjects(ctx context.Context, since *time.Time, record privacyScanRecordFunc) error {
+	var cursor int64
+	for {
+		rows, err := s.db.Query(ctx, `
+			select o.id, o.object_key, o.payload
+			from oss_events o
+			where o.id > $1
+			  and o.event_type = 'put'
+			  and ($2::timestamptz is null or o.occurred_at >= $2)
+			  and (o.object_key like '%topics/%/messages/%' or o.object_key like '%topics/%/requests/%')
+			  and not exists (
+				select 1 from oss_events n
+				where n.object_key = o.object_key and n.id > o.id
+			  )
+			order by o.id
+			limit $3
+		`, cursor, since, privacyScanPageSize)
+		if err != nil {
+			return err
+		}
+		var items []privacyScanItem
+		n := 0
+		for rows.Next() {
+			var (
+				objectKey string
+				payload   []byte
+			)
+			if err := rows.Scan(&cursor, &objectKey, &payload); err != nil {
+				rows.Close()
+				return err
+			}
+			n++
+			p := parseTopicKeyFromObjectKey(stripBasePrefix(objectKey, s.ossBasePrefix))
+			if p.Kind != "messages" && p.Kind != "requests" {
+				continue
+			}
+			if findings := scanTopicObject(p.Kind, payload); len(findings) > 0 {
+				items = append(items, privacyScanItem{
+					Surface: privacySurfaceTopicMessage, TargetType: topicObjectTargetType(p.Kind), TargetID: objectKey,
+					Location: objectKey, Findings: findings,
+				})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if err := record(items, n); err != nil {
+			return err
+		}
+		if n < privacyScanPageSize {
+			return nil
+		}
+	}
+}
+
+// errPrivacyScanUnsupported marks a finding the requested action cannot be applied to.
+var errPrivacyScanUnsupported = errors.New("action not supported for this target type")
+
+// privacyScanApplyPending and privacyScanApplyRedact are the bulk actions on scan findings.
+const (
+	privacyScanApplyPending = "pending"
+	privacyScanApplyRedact  = "redact"
+)
+
+// applyPrivacyScanFinding applies one bulk action to the item behind a finding.
+func (s server) applyPrivacyScanFinding(ctx context.Context, store agenthome.OSSObjectStore, action, targetType, targetID string, redact func(privacyKind) bool) error {
+	if action == privacyScanApplyRedact {
+		return s.redactPrivacyScanTarget(ctx, store, targetType, targetID, redact)
+	}
+	return s.markPrivacyScanTargetPending(ctx, targetType, targetID)
+}
+
+// markPrivacyScanTargetPending sends the item back to the moderation queue. Rejected items stay
+// rejected.
+func (s server) markPrivacyScanTargetPending(ctx context.Context, targetType, targetID string) error {
+	var q string
+	switch targetType {
+	case "event":
+		q = `update events set review_status = 'pending' where id = $1 and review_status <> 'rejected'`
+	case "artifact":
+		q = `update artifacts set review_status = 'pending' where id = $1 and review_status <> 'rejected'`
+	case "agent_card":
+		q = `update agents set card_review_status = 'pending', updated_at = now() where id = $1 and card_review_status <> 'rejected'`
+	case "topic_message", "topic_request":
+		return s.markTopicObjectPending(ctx, targetID)
+	default:
+		return errPrivacyScanUnsupported
+	}
+	id, err := uuid.Parse(targetID)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, q, id)
+	return err
+}
+
+// redactPrivacyScanTarget rewrites the stored item with matches of the selected kinds replaced by
+// placeholders. Content is re-read, so edits made since the scan are respected.
+func (s server) redactPrivacyScanTarget(ctx context.Context, store agenthome.OSSObjectStore, targetType, targetID string, redact func(privacyKind) bool) error {
+	switch targetType {
+	case "topic_message", "topic_request":
+		return s.redactTopicObjectTarget(ctx, store, targetID, redact)
+	}
+	id, err := uuid.Parse(targetID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	switch targetType {
+	case "event":
+		var raw []byte
+		if err := tx.QueryRow(ctx, `select payload from events where id = $1 for update`, id).Scan(&raw); err != nil {
+			return err
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		out, err := marshalJSONB(redactPrivacyValue(v, redact))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `update events set payload = $2 where id = $1`, id, out); err != nil {
+			return err
+		}
+	case "artifact":
+		var content string
+		if err := tx.QueryRow(ctx, `select content from artifacts where id = $1 for update`, id).Scan(&content); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `update artifacts set content = $2 where id = $1`, id, redactPrivacyInString(content, redact)); err != nil {
+			return err
+		}
+	case "agent_card":
+		var (
+			c                           agentCardText
+			promptView                  string
+			interestsRaw, capabilityRaw []byte
+		)
+		if err := tx.QueryRow(ctx, `
+			select name, description, bio, greeting, interests, capabilities, prompt_view
+			from agents where id = $1 for update
+		`, id).Scan(&c.Name, &c.Description, &c.Bio, &c.Greeting, &interestsRaw, &capabilityRaw, &promptView); err != nil {
+			return err
+		}
+		_ = json.Unmarshal(interestsRaw, &c.Interests)
+		_ = json.Unmarshal(capabilityRaw, &c.Capabilities)
+		red := func(in []string) []string {
+			out := make([]string, len(in))
+			for i, it := range in {
+				out[i] = redactPrivacyInString(it, redact)
+			}
+			return out
+		}
+		interestsJSON, err := marshalJSONB(red(c.Interests))
+		if err != nil {
+			return err
+		}
+		capabilitiesJSON, err := marshalJSONB(red(c.Capabilities))
+		if err != nil {
+			return err
+		}
+		// Same bookkeeping as a card edit: new version, certification and published copy invalidated.
+		if _, err := tx.Exec(ctx, `
+			update agents
+			set name = $2, description = $3, bio = $4, greeting = $5,
+			    interests = $6, capabilities = $7, prompt_view = $8,
+			    card_version = card_version + 1,
+			    card_cert = '{}'::jsonb,
+			    discovery = discovery - 'oss_endpoint' - 'last_synced_at',
+			    updated_at = now()
+			where id = $1
+		`, id,
+			redactPrivacyInString(c.Name, redact),
+			redactPrivacyInString(c.Description, redact),
+			redactPrivacyInString(c.Bio, redact),
+			redactPrivacyInString(c.Greeting, redact),
+			interestsJSON, capabilitiesJSON,
+			redactPrivacyInString(promptView, redact),
+		); err != nil {
+			return err
+		}
+	default:
+		return errPrivacyScanUnsupported
+	}
+	return tx.Commit(ctx)
+}
+
+func (s server) redactTopicObjectTarget(ctx context.Context, store agenthome.OSSObjectStore, objectKey string, redact func(privacyKind) bool) error {
+	if store == nil {
+		return errors.New("oss not configured")
+	}
+	var (
+		eventType string
+		payload   []byte
+	)
+	if err := s.db.QueryRow(ctx, `
+		select event_type, payload from oss_events where object_key = $1 order by id desc limit 1
+	`, objectKey).Scan(&eventType, &payload); err != nil {
+		return err
+	}
+	if eventType != "put" {
+		// Deleted since the scan; nothing left to redact.
+		return nil
+	}
+	key := strings.TrimLeft(stripBasePrefix(strings.TrimLeft(objectKey, "/"), s.ossBasePrefix), "/")
+	p := parseTopicKeyFromObjectKey(key)
+	body, changed, err := redactTopicObject(p.Kind, payload, redact)
+	if err != nil || !changed {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	outboxID, err := enqueueOSSWriteInTx(ctx, tx, key, "application/json", "put", time.Now().UTC(), body)
+	if err != nil {
+		return err
+	}
+	// Earlier versions stay readable through the event feed and thread reads; redact them too
+	// (unparseable payloads are emptied).
+	rows, err := tx.Query(ctx, `
+		select id, payload
+		from oss_events
+		where object_key = any($1::text[])
+		  and event_type = 'put'
+		  and id <> (select oss_event_id from oss_outbox where id = $2)
+		for update
+	`, []string{objectKey, key}, outboxID)
+	if err != nil {
+		return err
+	}
+	type earlierEvent struct {
+		id      int64
+		payload []byte
+	}
+	var earlier []earlierEvent
+	for rows.Next() {
+		var e earlierEvent
+		if err := rows.Scan(&e.id, &e.payload); err != nil {
+			rows.Close()
+			return err
+		}
+		earlier = append(earlier, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, e := range earlier {
+		redacted, changed, err := redactTopicObject(p.Kind, e.payload, redact)
+		if err != nil {
+			redacted, changed = []byte(`{}`), true
+		}
+		if !changed {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `update oss_events set payload = $2 where id = $1`, e.id, redacted); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	if _, err := s.relayOSSOutbox(ctx, store, outboxID, 1); err != nil {
+		logError(ctx, "privacy scan: immediate redaction write failed (will retry)", err)
+	}
+	return nil
+}
+
+// value is the card as checked by the agent_card policy (see enforceAgentCardPrivacy).
+func (c agentCardText) value() map[string]any {
+	toAny := func(in []string) []any {
+		out := make([]any, len(in))
+		for i, it := range in {
+			out[i] = it
+		}
+		return out
+	}
+	return map[string]any{
+		"name":         c.Name,
+		"description":  c.Description,
+		"bio":          c.Bio,
+		"greeting":     c.Greeting,
+		"interests":    toAny(c.Interests),
+		"capabilities": toAny(c.Capabilities),
+	}
+}
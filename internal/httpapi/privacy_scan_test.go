@@ -0,0 +1,52 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTopicObjectScanAndRedact(t *testing.T) {
+	msg := []byte(`{"kind":"topic_message","message_id":"msg_1","agent_ref":"a_1","content":{"text":"联系 alice@example.com 或 13812345678"},"meta":{"reply_to":{"message_id":"msg_0"}}}`)
+
+	findings := scanTopicObject("messages", msg)
+	kinds := privacyKinds(findings)
+	if len(kinds) != 2 || findings[0].Field != "content.text" {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+
+	// Only the selected kinds are redacted; ids and meta are left alone.
+	out, changed, err := redactTopicObject("messages", msg, privacyKindSet([]string{string(privacyEmail)}))
+	if err != nil || !changed {
+		t.Fatalf("redact: changed=%v err=%v", changed, err)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(out, &obj); err != nil {
+		t.Fatal(err)
+	}
+	text := obj["content"].(map[string]any)["text"].(string)
+	if strings.Contains(text, "alice@example.com") || !strings.Contains(text, privacyPlaceholder(privacyEmail)) || !strings.Contains(text, "13812345678") {
+		t.Fatalf("unexpected redaction %q", text)
+	}
+	if obj["message_id"] != "msg_1" || obj["meta"] == nil {
+		t.Fatalf("metadata changed: %s", out)
+	}
+
+	// A rescan of fully redacted content is clean, and redacting again is a no-op.
+	out, _, _ = redactTopicObject("messages", out, privacyKindSet(nil))
+	if got := scanTopicObject("messages", out); len(got) != 0 {
+		t.Fatalf("redacted object still has findings %+v", got)
+	}
+	if _, changed, _ := redactTopicObject("messages", out, privacyKindSet(nil)); changed {
+		t.Fatal("second redaction changed the object")
+	}
+
+	// Requests carry their text in payload.
+	req := []byte(`{"kind":"topic_request","payload":{"text":"我的邮箱 bob@example.com"}}`)
+	if got := scanTopicObject("requests", req); len(got) != 1 || got[0].Field != "payload.text" {
+		t.Fatalf("unexpected request findings %+v", got)
+	}
+	if got := topicObjectTargetType("requests"); got != "topic_request" {
+		t.Fatalf("target type %q", got)
+	}
+}
@@ -1,74 +1,272 @@
 package httpapi
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type ipRateLimiter struct {
+// rateLimitPolicy is a token bucket: Burst tokens, refilled at Limit per Window. Policies are
+// named route classes; the name is part of the bucket key.
+type rateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Burst  int
+}
+
+func (p rateLimitPolicy) rate() float64 { return float64(p.Limit) / p.Window.Seconds() }
+
+var (
+	// IP-keyed backstop for every /v1 call (including authenticated ones). Only API calls are
+	// limited; /app/* static assets are not, otherwise the SPA can fail to load lazy chunks.
+	rateLimitPublic = rateLimitPolicy{Name: "public", Limit: 1200, Window: time.Minute, Burst: 600}
+	rateLimitUser   = rateLimitPolicy{Name: "user", Limit: 600, Window: time.Minute, Burst: 200}
+	rateLimitAdmin  = rateLimitPolicy{Name: "admin", Limit: 600, Window: time.Minute, Burst: 200}
+	// Gateway reads are mostly inbox polling; writes create content.
+	rateLimitGatewayRead  = rateLimitPolicy{Name: "gateway_read", Limit: 1200, Window: time.Minute, Burst: 300}
+	rateLimitGatewayWrite = rateLimitPolicy{Name: "gateway_write", Limit: 300, Window: time.Minute, Burst: 60}
+)
+
+type rateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is the time until the bucket is full again, RetryAfter the time until the next token
+	// (zero when allowed).
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type tokenBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// take refills b up to now and spends one token if there is one.
+func (b tokenBucket) take(p rateLimitPolicy, now time.Time) (tokenBucket, rateLimitResult) {
+	tokens := float64(p.Burst)
+	if !b.UpdatedAt.IsZero() {
+		elapsed := now.Sub(b.UpdatedAt).Seconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		tokens = math.Min(float64(p.Burst), b.Tokens+elapsed*p.rate())
+	}
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return tokenBucket{Tokens: tokens, UpdatedAt: now}, bucketResult(p, tokens, allowed)
+}
+
+func bucketResult(p rateLimitPolicy, tokens float64, allowed bool) rateLimitResult {
+	res := rateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(p.Burst) - tokens) / p.rate() * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / p.rate() * float64(time.Second))
+	}
+	return res
+}
+
+// rateLimitStore keeps token buckets. sweep drops buckets that have refilled completely (a missing
+// bucket is a full one).
+type rateLimitStore interface {
+	take(ctx context.Context, key string, p rateLimitPolicy, now time.Time) (rateLimitResult, error)
+	sweep(ctx context.Context, now time.Time) error
+}
+
+type memoryRateLimitStore struct {
 	mu      sync.Mutex
-	entries map[string]*ipEntry
-	limit   int
-	window  time.Duration
+	buckets map[string]memoryBucket
+}
+
+type memoryBucket struct {
+	tokenBucket
+	fullAt time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: map[string]memoryBucket{}}
+}
+
+func (m *memoryRateLimitStore) take(_ context.Context, key string, p rateLimitPolicy, now time.Time) (rateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, res := m.buckets[key].take(p, now)
+	m.buckets[key] = memoryBucket{tokenBucket: b, fullAt: now.Add(res.Reset)}
+	return res, nil
+}
+
+func (m *memoryRateLimitStore) sweep(_ context.Context, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, b := range m.buckets {
+		if !now.Before(b.fullAt) {
+			delete(m.buckets, k)
+		}
+	}
+	return nil
+}
+
+// pgRateLimitStore shares buckets across replicas (rate_limit_take in migrations). Timing uses
+// the database clock so replica clock skew does not matter.
+type pgRateLimitStore struct {
+	db *pgxpool.Pool
 }
 
-type ipEntry struct {
-	resetAt time.Time
-	count   int
+func (s pgRateLimitStore) take(ctx context.Context, key string, p rateLimitPolicy, _ time.Time) (rateLimitResult, error) {
+	var (
+		allowed bool
+		tokens  float64
+	)
+	if err := s.db.QueryRow(ctx, `
+		select allowed, tokens from rate_limit_take($1, $2, $3)
+	`, key, float64(p.Burst), p.rate()).Scan(&allowed, &tokens); err != nil {
+		return rateLimitResult{}, err
+	}
+	return bucketResult(p, tokens, allowed), nil
+}
+
+func (s pgRateLimitStore) sweep(ctx context.Context, _ time.Time) error {
+	_, err := s.db.Exec(ctx, `delete from rate_limit_buckets where full_at < now()`)
+	return err
+}
+
+// rateLimiter applies policies against a store. A failing shared store falls back to per-replica
+// buckets instead of rejecting traffic.
+type rateLimiter struct {
+	store    rateLimitStore
+	fallback *memoryRateLimitStore
 }
 
-func newIPRateLimiter(limit int, window time.Duration) *ipRateLimiter {
-	return &ipRateLimiter{
-		entries: map[string]*ipEntry{},
-		limit:   limit,
-		window:  window,
+// newRateLimiter picks the bucket store: "postgres" shares state across replicas, anything else
+// keeps it in memory.
+func newRateLimiter(db *pgxpool.Pool, store string) *rateLimiter {
+	l := &rateLimiter{fallback: newMemoryRateLimitStore()}
+	if strings.EqualFold(strings.TrimSpace(store), "postgres") && db != nil {
+		l.store = pgRateLimitStore{db: db}
+	} else {
+		l.store = l.fallback
 	}
+	return l
 }
 
-func (l *ipRateLimiter) allow(ip string) bool {
+func (l *rateLimiter) take(ctx context.Context, key string, p rateLimitPolicy) rateLimitResult {
+	key = p.Name + ":" + key
 	now := time.Now()
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	res, err := l.store.take(ctx, key, p, now)
+	if err != nil {
+		logError(ctx, "rate limit: shared store failed; using local buckets", err)
+		res, _ = l.fallback.take(ctx, key, p, now)
+	}
+	return res
+}
 
-	e := l.entries[ip]
-	if e == nil || now.After(e.resetAt) {
-		l.entries[ip] = &ipEntry{resetAt: now.Add(l.window), count: 1}
+func (l *rateLimiter) sweep(ctx context.Context) {
+	now := time.Now()
+	if err := l.store.sweep(ctx, now); err != nil {
+		logError(ctx, "rate limit: sweep failed", err)
+	}
+	if l.store != rateLimitStore(l.fallback) {
+		_ = l.fallback.sweep(ctx, now)
+	}
+}
+
+// allow takes a token for key under p, sets the RateLimit-* headers and writes the 429 itself when
+// the bucket is empty.
+func (l *rateLimiter) allow(w http.ResponseWriter, r *http.Request, key string, p rateLimitPolicy) bool {
+	res := l.take(r.Context(), key, p)
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(p.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d;policy=%q", p.Limit, int(p.Window.Seconds()), p.Burst, p.Name))
+	if res.Allowed {
 		return true
 	}
-	if e.count >= l.limit {
-		return false
+	retry := ceilSeconds(res.RetryAfter)
+	if retry < 1 {
+		retry = 1
 	}
-	e.count++
-	return true
+	h.Set("Retry-After", strconv.Itoa(retry))
+	writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate_limited"})
+	return false
 }
 
-func (l *ipRateLimiter) middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip SSE: it's long-lived and should not be rate-limited per-request.
-		if strings.Contains(r.URL.Path, "/stream") {
-			next.ServeHTTP(w, r)
-			return
-		}
-		ip := clientIP(r)
-		if ip == "" {
-			ip = "unknown"
-		}
-		if !l.allow(ip) {
-			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate_limited"})
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
 
+// rateLimitClass maps a request to its policy and bucket key; an empty key skips limiting.
+type rateLimitClass func(r *http.Request) (rateLimitPolicy, string)
+
+func (s server) rateLimitMiddleware(class rateLimitClass) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, key := class(r)
+			if key == "" || s.limiter.allow(w, r, key, p) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func publicRateLimit(r *http.Request) (rateLimitPolicy, string) {
+	// Skip SSE: it's long-lived and should not be rate-limited per-request.
+	if strings.Contains(r.URL.Path, "/stream") {
+		return rateLimitPublic, ""
+	}
+	return rateLimitPublic, "ip:" + clientIP(r)
+}
+
+func userRateLimit(r *http.Request) (rateLimitPolicy, string) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		return rateLimitUser, ""
+	}
+	return rateLimitUser, "user:" + userID.String()
+}
+
+func adminRateLimit(r *http.Request) (rateLimitPolicy, string) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		return rateLimitAdmin, ""
+	}
+	return rateLimitAdmin, "user:" + userID.String()
+}
+
+func gatewayRateLimit(r *http.Request) (rateLimitPolicy, string) {
+	p := rateLimitGatewayWrite
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		p = rateLimitGatewayRead
+	}
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		return p, ""
+	}
+	return p, "agent:" + agentID.String()
+}
+
+// clientIP is the caller address. RemoteAddr has already been rewritten from X-Forwarded-For
+// when the direct peer is a trusted proxy (see realIPMiddleware).
 func clientIP(r *http.Request) string {
-	// NOTE: for MVP we trust the direct remote address only.
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err == nil {
-		return host
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	if ip == "" {
+		return "unknown"
 	}
-	return r.RemoteAddr
+	return ip
 }
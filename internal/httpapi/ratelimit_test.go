@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	p := rateLimitPolicy{Name: "t", Limit: 60, Window: time.Minute, Burst: 3}
+	now := time.Now()
+	var b tokenBucket
+	var res rateLimitResult
+	for i := 0; i < 3; i++ {
+		if b, res = b.take(p, now); !res.Allowed {
+			t.Fatalf("request %d denied within burst", i)
+		}
+	}
+	if res.Remaining != 0 || res.Reset != 3*time.Second {
+		t.Fatalf("after burst: %+v", res)
+	}
+	if b, res = b.take(p, now.Add(500*time.Millisecond)); res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("empty bucket: %+v", res)
+	}
+	if _, res = b.take(p, now.Add(time.Second)); !res.Allowed {
+		t.Fatalf("refilled token denied: %+v", res)
+	}
+}
+
+func TestRateLimitMiddlewareHeaders(t *testing.T) {
+	s := server{limiter: newRateLimiter(nil, "memory")}
+	p := rateLimitPolicy{Name: "t", Limit: 1, Window: time.Minute, Burst: 1}
+	h := s.rateLimitMiddleware(func(r *http.Request) (rateLimitPolicy, string) { return p, clientIP(r) })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/runs", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("first: %d %v", rec.Code, rec.Header())
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("second: %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestForwardedClientIP(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name, remote, xff, want string
+	}{
+		{"untrusted peer ignores header", "203.0.113.9:5000", "198.51.100.1", "203.0.113.9"},
+		{"trusted peer", "10.1.2.3:5000", "198.51.100.1", "198.51.100.1"},
+		{"spoofed left entries", "10.1.2.3:5000", "1.1.1.1, 198.51.100.1, 10.0.0.7", "198.51.100.1"},
+		{"single trusted address", "192.0.2.1:80", "198.51.100.2", "198.51.100.2"},
+		{"no header", "10.1.2.3:5000", "", "10.1.2.3"},
+		{"garbage stops walk", "10.1.2.3:5000", "198.51.100.1, nonsense", "10.1.2.3"},
+	}
+	for _, c := range cases {
+		h := http.Header{}
+		if c.xff != "" {
+			h.Set("X-Forwarded-For", c.xff)
+		}
+		got, err := forwardedClientIP(trusted, c.remote, h)
+		if err != nil || got.String() != c.want {
+			t.Errorf("%s: got %v %v, want %s", c.name, got, err, c.want)
+		}
+	}
+	if _, err := parseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("invalid proxy accepted")
+	}
+}
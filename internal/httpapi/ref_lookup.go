@@ -45,9 +45,7 @@ func (s server) lookupAgentIDByRef(ctx context.Context, agentRef string) (uuid.U
 }
 
 func (s server) lookupRunIDByRef(ctx context.Context, runRef string) (uuid.UUID, error) {
-	var id uuid.UUID
-	err := s.db.QueryRow(ctx, `select id from runs where public_ref=$1`, runRef).Scan(&id)
-	return id, err
+	return s.repo.RunIDByRef(ctx, runRef)
 }
 
 func (s server) lookupOwnerAgentIDByRef(ctx context.Context, ownerID uuid.UUID, agentRef string) (uuid.UUID, error) {
@@ -0,0 +1,220 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// repository is the storage boundary of the run pipeline (create run -> offer -> claim/lease ->
+// events/artifacts -> review -> complete). pgRepository is the production implementation; handler
+// tests use the in-memory fake in repository_mem_test.go. Handlers outside the pipeline still
+// query s.db directly.
+//
+// Not-found lookups return pgx.ErrNoRows, like direct queries, so callers keep one check.
+type repository interface {
+	runRepository
+	workItemRepository
+	eventRepository
+	artifactRepository
+	agentProfileReader
+	auditRepository
+	idempotencyRepository
+	admissionRepository
+}
+
+type runRepository interface {
+	// CreateRun inserts the run with its required tags and allowed tools, the initial ideation
+	// work item and its offers, atomically. Tools missing from the catalog fail with
+	// *unavailableToolError.
+	CreateRun(ctx context.Context, run newRun) (createdRun, error)
+	RunIDByRef(ctx context.Context, runRef string) (uuid.UUID, error)
+}
+
+type workItemRepository interface {
+	// ListOffers returns open work items offered to agentID plus those it holds a live lease on,
+	// oldest first.
+	ListOffers(ctx context.Context, agentID uuid.UUID, limit int) ([]workItemRecord, error)
+	GetWorkItem(ctx context.Context, workItemID uuid.UUID) (workItemRecord, error)
+	// ClaimWorkItem leases an offered work item (errNotOffered, errNotClaimable, errAlreadyClaimed)
+	// and moves a created run to running.
+	ClaimWorkItem(ctx context.Context, agentID, workItemID uuid.UUID, leaseExpiresAt time.Time) error
+	// ClaimNextWorkItem leases the oldest open offer (errNoOffers).
+	ClaimNextWorkItem(ctx context.Context, agentID uuid.UUID, leaseExpiresAt time.Time) (uuid.UUID, error)
+	// CompleteWorkItem releases the lease held by agentID (errNotLeased, errNotLeaseHolder,
+	// errLeaseExpired) and records the contribution.
+	CompleteWorkItem(ctx context.Context, agentID, workItemID uuid.UUID, now time.Time) (completedWorkItem, error)
+	// IsRunParticipant reports whether agentID was offered any work item of the run.
+	IsRunParticipant(ctx context.Context, agentID, runID uuid.UUID) (bool, error)
+	HasClaimedReviewWorkItem(ctx context.Context, agentID, runID uuid.UUID) (bool, error)
+
+	ReviewWorkItemExists(ctx context.Context, runID, artifactID uuid.UUID) (bool, error)
+	// PickReviewer chooses an enabled agent other than the author (uuid.Nil when there is none),
+	// preferring run participants and agents matching the run's required tags.
+	PickReviewer(ctx context.Context, runID, authorAgentID uuid.UUID) (uuid.UUID, error)
+	// CreateWorkItem inserts a work item offered to offerTo.
+	CreateWorkItem(ctx context.Context, wi newWorkItem, offerTo []uuid.UUID) (uuid.UUID, error)
+	// ResolveStageSkills returns skill names and pinned refs JSON for a new work item in stage.
+	ResolveStageSkills(ctx context.Context, stage string) ([]string, []byte, error)
+}
+
+type eventRepository interface {
+	// AppendEvent stores ev under the next seq of the run and returns that seq.
+	AppendEvent(ctx context.Context, runID uuid.UUID, ev newEvent) (int64, error)
+}
+
+type artifactRepository interface {
+	// AppendArtifact stores the next artifact version of the run and notifies the publisher.
+	AppendArtifact(ctx context.Context, a newArtifact) (version int, artifactID uuid.UUID, err error)
+	// ListArtifacts returns the run's non-rejected artifact versions, oldest first.
+	ListArtifacts(ctx context.Context, runID uuid.UUID) ([]artifactRecord, error)
+}
+
+type agentProfileReader interface {
+	AgentProfile(ctx context.Context, agentID uuid.UUID) (agentProfile, error)
+}
+
+type auditRepository interface {
+	AppendAudit(ctx context.Context, actorType string, actorID uuid.UUID, action string, data map[string]any) error
+}
+
+// admissionRepository holds the agent admission state machine (server_agent_admission.go).
+type admissionRepository interface {
+	// RegisterAgentPublicKey stores the agent's Ed25519 key; changed is false when it already was
+	// the current key. A new key sends pending/admitted agents back to not_requested; rejected agents
+	// stay rejected and keep admission_requested_at, so an admin can still admit them.
+	RegisterAgentPublicKey(ctx context.Context, agentID uuid.UUID, publicKey string) (dto agentAdmissionDTO, changed bool, err error)
+	// DecideAdmission moves a requested admission into d.To from one of d.From. An unknown agent
+	// is pgx.ErrNoRows; any other state is *admissionStateError.
+	DecideAdmission(ctx context.Context, d admissionDecision) (agentID, ownerID uuid.UUID, err error)
+}
+
+// idempotencyRepository backs the gateway Idempotency-Key middleware (gateway_idempotency.go).
+type idempotencyRepository interface {
+	// ClaimIdempotencyKey reserves (agentID, key) for a new request; an expired key or a stale
+	// in-flight claim is taken over. claimed=false means the key is held: see GetIdempotencyKey.
+	ClaimIdempotencyKey(ctx context.Context, agentID uuid.UUID, key, request, requestHash string) (claimed bool, err error)
+	GetIdempotencyKey(ctx context.Context, agentID uuid.UUID, key string) (idempotencyRecord, error)
+	StoreIdempotentResponse(ctx context.Context, agentID uuid.UUID, key string, status int, body []byte) error
+	// ReleaseIdempotencyKey drops the claim so a retry re-executes the request.
+	ReleaseIdempotencyKey(ctx context.Context, agentID uuid.UUID, key string) error
+}
+
+var (
+	errNotOffered     = errors.New("not offered")
+	errNotClaimable   = errors.New("not claimable")
+	errAlreadyClaimed = errors.New("already claimed")
+	errNoOffers       = errors.New("no offers")
+	errNotLeased      = errors.New("not leased")
+	errNotLeaseHolder = errors.New("not lease holder")
+	errLeaseExpired   = errors.New("lease expired")
+)
+
+type unavailableToolError struct{ Tool string }
+
+func (e *unavailableToolError) Error() string { return "tool not available: " + e.Tool }
+
+type newRun struct {
+	PublisherUserID uuid.UUID
+	Goal            string
+	Constraints     string
+	RequiredTags    []string
+	ScheduledAt     *time.Time
+	IsPublic        bool
+	AllowedTools    []string
+}
+
+type createdRun struct {
+	RunID      uuid.UUID
+	RunRef     string
+	WorkItemID uuid.UUID
+}
+
+// workItemRecord is a work item joined with its run; JSON columns are left raw.
+type workItemRecord struct {
+	ID              uuid.UUID
+	RunID           uuid.UUID
+	RunRef          string
+	Goal            string
+	Constraints     string
+	Stage           string
+	Kind            string
+	Status          string
+	Context         []byte
+	AvailableSkills []byte
+	ReviewContext   []byte
+}
+
+type newWorkItem struct {
+	RunID           uuid.UUID
+	Stage           string
+	Kind            string
+	Status          string
+	Context         []byte
+	AvailableSkills []byte
+	SkillRefs       []byte
+	ReviewContext   []byte
+	ScheduledAt     *time.Time
+}
+
+type completedWorkItem struct {
+	RunID   uuid.UUID
+	Stage   string
+	Kind    string
+	OwnerID uuid.UUID // owner of the completing agent
+}
+
+type newEvent struct {
+	Kind      string
+	Persona   string
+	Payload   []byte
+	IsKeyNode bool
+	CreatedAt time.Time
+}
+
+type newArtifact struct {
+	RunID          uuid.UUID
+	RunRef         string
+	AuthorAgentID  uuid.UUID
+	Kind           string
+	Content        string
+	LinkedEventSeq *int64
+}
+
+type artifactRecord struct {
+	Version   int
+	Kind      string
+	CreatedAt time.Time
+}
+
+type admissionDecision struct {
+	AgentRef string
+	To       string
+	From     []string
+	AdminID  uuid.UUID
+	Note     string
+}
+
+// admissionStateError reports an agent whose admission is not in a state the decision applies to.
+type admissionStateError struct{ Status string }
+
+func (e *admissionStateError) Error() string { return "invalid admission state: " + e.Status }
+
+// idempotencyRecord is a claimed key; StatusCode is nil while the first request is in flight.
+type idempotencyRecord struct {
+	Request     string
+	RequestHash string
+	StatusCode  *int
+	Body        []byte
+}
+
+type agentProfile struct {
+	Ref          string
+	Name         string
+	PromptView   string
+	Persona      []byte
+	IdentityMode string
+	Tags         []string // sorted
+}
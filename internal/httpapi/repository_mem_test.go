@@ -0,0 +1,539 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// memRepository is an in-memory repository for handler tests. It models the pipeline state
+// machine (offers, leases, run status, seq/version allocation), agent admission and Idempotency-Key
+// claims, but not webhooks, contributions or the skills catalog.
+type memRepository struct {
+	mu sync.Mutex
+
+	matchLimit int // participants offered the initial work item (default 1)
+
+	agents    map[uuid.UUID]*memAgent
+	runs      map[uuid.UUID]*memRun
+	workItems map[uuid.UUID]*memWorkItem
+	order     []uuid.UUID                      // work items in creation order
+	offers    map[uuid.UUID]map[uuid.UUID]bool // work item -> agents
+	leases    map[uuid.UUID]memLease           // work item -> lease
+	events    map[uuid.UUID][]newEvent         // run -> events (index = seq-1)
+	artifacts map[uuid.UUID][]memArtifact      // run -> artifacts (index = version-1)
+	audits    []string                         // audited actions, in order
+	idemKeys  map[memIdempotencyKey]memIdempotencyEntry
+}
+
+type memIdempotencyKey struct {
+	AgentID uuid.UUID
+	Key     string
+}
+
+type memIdempotencyEntry struct {
+	idempotencyRecord
+	CreatedAt time.Time
+}
+
+type memAgent struct {
+	ID      uuid.UUID
+	OwnerID uuid.UUID
+	Enabled bool
+	Profile agentProfile
+
+	PublicKey      string
+	AdmittedStatus string
+	RequestedAt    *time.Time
+	AdmittedAt     *time.Time
+	ReviewedAt     *time.Time
+	Note           string
+}
+
+type memRun struct {
+	ID           uuid.UUID
+	Ref          string
+	Publisher    uuid.UUID
+	Goal         string
+	Constraints  string
+	Status       string
+	RequiredTags []string
+}
+
+type memWorkItem struct {
+	newWorkItem
+	ID uuid.UUID
+}
+
+type memLease struct {
+	AgentID   uuid.UUID
+	ExpiresAt time.Time
+}
+
+type memArtifact struct {
+	ID        uuid.UUID
+	Kind      string
+	Content   string
+	Author    uuid.UUID
+	CreatedAt time.Time
+}
+
+func newMemRepository() *memRepository {
+	return &memRepository{
+		matchLimit: 1,
+		agents:     map[uuid.UUID]*memAgent{},
+		runs:       map[uuid.UUID]*memRun{},
+		workItems:  map[uuid.UUID]*memWorkItem{},
+		offers:     map[uuid.UUID]map[uuid.UUID]bool{},
+		leases:     map[uuid.UUID]memLease{},
+		events:     map[uuid.UUID][]newEvent{},
+		artifacts:  map[uuid.UUID][]memArtifact{},
+		idemKeys:   map[memIdempotencyKey]memIdempotencyEntry{},
+	}
+}
+
+// addAgent registers an enabled agent and returns its id.
+func (m *memRepository) addAgent(ownerID uuid.UUID, name string, tags ...string) uuid.UUID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := uuid.New()
+	ref, _ := randomPublicRef(agentRefPrefix)
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	m.agents[id] = &memAgent{ID: id, OwnerID: ownerID, Enabled: true, Profile: agentProfile{
+		Ref:          ref,
+		Name:         name,
+		Persona:      []byte(`{}`),
+		IdentityMode: agentIdentityModeCard,
+		Tags:         sorted,
+	}, AdmittedStatus: "not_requested"}
+	return id
+}
+
+func (m *memRepository) CreateRun(ctx context.Context, run newRun) (createdRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Same policy as matchAgentsForRun: publisher-owned agents first, then by required tag overlap.
+	candidates := make([]*memAgent, 0, len(m.agents))
+	for _, a := range m.agents {
+		if a.Enabled {
+			candidates = append(candidates, a)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		oi, oj := candidates[i].OwnerID == run.PublisherUserID, candidates[j].OwnerID == run.PublisherUserID
+		if oi != oj {
+			return oi
+		}
+		ti, tj := tagOverlap(candidates[i].Profile.Tags, run.RequiredTags), tagOverlap(candidates[j].Profile.Tags, run.RequiredTags)
+		if ti != tj {
+			return ti > tj
+		}
+		return candidates[i].Profile.Ref < candidates[j].Profile.Ref
+	})
+	if len(candidates) == 0 {
+		return createdRun{}, errors.New("no eligible agents")
+	}
+	if len(candidates) > m.matchLimit {
+		candidates = candidates[:m.matchLimit]
+	}
+
+	ref, err := randomPublicRef(runRefPrefix)
+	if err != nil {
+		return createdRun{}, err
+	}
+	r := &memRun{ID: uuid.New(), Ref: ref, Publisher: run.PublisherUserID, Goal: run.Goal, Constraints: run.Constraints, Status: "created", RequiredTags: run.RequiredTags}
+	m.runs[r.ID] = r
+
+	stageContext, _ := json.Marshal(server{}.stageContextForStage("ideation", []string{}))
+	status := "offered"
+	if run.ScheduledAt != nil {
+		status = "scheduled"
+	}
+	offerTo := make([]uuid.UUID, 0, len(candidates))
+	for _, a := range candidates {
+		offerTo = append(offerTo, a.ID)
+	}
+	wiID := m.createWorkItemLocked(newWorkItem{
+		RunID: r.ID, Stage: "ideation", Kind: "draft", Status: status,
+		Context: stageContext, AvailableSkills: []byte(`[]`), SkillRefs: []byte(`[]`), ScheduledAt: run.ScheduledAt,
+	}, offerTo)
+	return createdRun{RunID: r.ID, RunRef: r.Ref, WorkItemID: wiID}, nil
+}
+
+func tagOverlap(have, want []string) int {
+	n := 0
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+func (m *memRepository) RunIDByRef(ctx context.Context, runRef string) (uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.runs {
+		if r.Ref == runRef {
+			return r.ID, nil
+		}
+	}
+	return uuid.Nil, pgx.ErrNoRows
+}
+
+func (m *memRepository) record(wi *memWorkItem) workItemRecord {
+	r := m.runs[wi.RunID]
+	return workItemRecord{
+		ID: wi.ID, RunID: wi.RunID, RunRef: r.Ref, Goal: r.Goal, Constraints: r.Constraints,
+		Stage: wi.Stage, Kind: wi.Kind, Status: wi.Status,
+		Context: wi.Context, AvailableSkills: wi.AvailableSkills, ReviewContext: wi.ReviewContext,
+	}
+}
+
+func (m *memRepository) ListOffers(ctx context.Context, agentID uuid.UUID, limit int) ([]workItemRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]workItemRecord, 0)
+	now := time.Now()
+	for _, id := range m.order {
+		wi := m.workItems[id]
+		if !m.offers[id][agentID] {
+			continue
+		}
+		l, leased := m.leases[id]
+		if wi.Status == "offered" || (wi.Status == "claimed" && leased && l.AgentID == agentID && l.ExpiresAt.After(now)) {
+			out = append(out, m.record(wi))
+		}
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (m *memRepository) GetWorkItem(ctx context.Context, workItemID uuid.UUID) (workItemRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wi, ok := m.workItems[workItemID]
+	if !ok {
+		return workItemRecord{}, pgx.ErrNoRows
+	}
+	return m.record(wi), nil
+}
+
+func (m *memRepository) ClaimWorkItem(ctx context.Context, agentID, workItemID uuid.UUID, leaseExpiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.offers[workItemID][agentID] {
+		return errNotOffered
+	}
+	wi, ok := m.workItems[workItemID]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	if wi.Status != "offered" {
+		return errNotClaimable
+	}
+	return m.leaseLocked(agentID, wi, leaseExpiresAt)
+}
+
+func (m *memRepository) ClaimNextWorkItem(ctx context.Context, agentID uuid.UUID, leaseExpiresAt time.Time) (uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range m.order {
+		wi := m.workItems[id]
+		if m.offers[id][agentID] && wi.Status == "offered" {
+			return id, m.leaseLocked(agentID, wi, leaseExpiresAt)
+		}
+	}
+	return uuid.Nil, errNoOffers
+}
+
+func (m *memRepository) leaseLocked(agentID uuid.UUID, wi *memWorkItem, leaseExpiresAt time.Time) error {
+	if _, ok := m.leases[wi.ID]; ok {
+		return errAlreadyClaimed
+	}
+	m.leases[wi.ID] = memLease{AgentID: agentID, ExpiresAt: leaseExpiresAt}
+	wi.Status = "claimed"
+	if r := m.runs[wi.RunID]; r.Status == "created" {
+		r.Status = "running"
+	}
+	return nil
+}
+
+func (m *memRepository) CompleteWorkItem(ctx context.Context, agentID, workItemID uuid.UUID, now time.Time) (completedWorkItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.leases[workItemID]
+	if !ok {
+		return completedWorkItem{}, errNotLeased
+	}
+	if l.AgentID != agentID {
+		return completedWorkItem{}, errNotLeaseHolder
+	}
+	if now.After(l.ExpiresAt) {
+		return completedWorkItem{}, errLeaseExpired
+	}
+	wi := m.workItems[workItemID]
+	wi.Status = "completed"
+	delete(m.leases, workItemID)
+	return completedWorkItem{RunID: wi.RunID, Stage: wi.Stage, Kind: wi.Kind, OwnerID: m.agents[agentID].OwnerID}, nil
+}
+
+func (m *memRepository) IsRunParticipant(ctx context.Context, agentID, runID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, wi := range m.workItems {
+		if wi.RunID == runID && m.offers[id][agentID] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memRepository) HasClaimedReviewWorkItem(ctx context.Context, agentID, runID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, l := range m.leases {
+		wi := m.workItems[id]
+		if l.AgentID == agentID && wi.RunID == runID && wi.Kind == "review" && wi.Status == "claimed" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memRepository) ReviewWorkItemExists(ctx context.Context, runID, artifactID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, wi := range m.workItems {
+		if wi.RunID != runID || wi.Kind != "review" {
+			continue
+		}
+		var rc struct {
+			TargetArtifactID string `json:"target_artifact_id"`
+		}
+		if json.Unmarshal(wi.ReviewContext, &rc) == nil && rc.TargetArtifactID == artifactID.String() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memRepository) PickReviewer(ctx context.Context, runID, authorAgentID uuid.UUID) (uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var participants, others []*memAgent
+	for _, a := range m.agents {
+		if !a.Enabled || a.ID == authorAgentID {
+			continue
+		}
+		participant := false
+		for id, wi := range m.workItems {
+			if wi.RunID == runID && m.offers[id][a.ID] {
+				participant = true
+				break
+			}
+		}
+		if participant {
+			participants = append(participants, a)
+		} else {
+			others = append(others, a)
+		}
+	}
+	candidates := participants
+	if len(candidates) == 0 {
+		candidates = others
+	}
+	if len(candidates) == 0 {
+		return uuid.Nil, nil
+	}
+	required := m.runs[runID].RequiredTags
+	sort.Slice(candidates, func(i, j int) bool {
+		ti, tj := tagOverlap(candidates[i].Profile.Tags, required), tagOverlap(candidates[j].Profile.Tags, required)
+		if ti != tj {
+			return ti > tj
+		}
+		return candidates[i].Profile.Ref < candidates[j].Profile.Ref
+	})
+	return candidates[0].ID, nil
+}
+
+func (m *memRepository) CreateWorkItem(ctx context.Context, wi newWorkItem, offerTo []uuid.UUID) (uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.runs[wi.RunID]; !ok {
+		return uuid.Nil, pgx.ErrNoRows
+	}
+	return m.createWorkItemLocked(wi, offerTo), nil
+}
+
+func (m *memRepository) createWorkItemLocked(wi newWorkItem, offerTo []uuid.UUID) uuid.UUID {
+	id := uuid.New()
+	m.workItems[id] = &memWorkItem{newWorkItem: wi, ID: id}
+	m.order = append(m.order, id)
+	m.offers[id] = map[uuid.UUID]bool{}
+	for _, agentID := range offerTo {
+		m.offers[id][agentID] = true
+	}
+	return id
+}
+
+func (m *memRepository) ResolveStageSkills(ctx context.Context, stage string) ([]string, []byte, error) {
+	return []string{}, []byte(`[]`), nil
+}
+
+func (m *memRepository) AppendEvent(ctx context.Context, runID uuid.UUID, ev newEvent) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events[runID] = append(m.events[runID], ev)
+	return int64(len(m.events[runID])), nil
+}
+
+func (m *memRepository) AppendArtifact(ctx context.Context, a newArtifact) (int, uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := uuid.New()
+	m.artifacts[a.RunID] = append(m.artifacts[a.RunID], memArtifact{ID: id, Kind: a.Kind, Content: a.Content, Author: a.AuthorAgentID, CreatedAt: time.Now()})
+	return len(m.artifacts[a.RunID]), id, nil
+}
+
+func (m *memRepository) ListArtifacts(ctx context.Context, runID uuid.UUID) ([]artifactRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]artifactRecord, 0, len(m.artifacts[runID]))
+	for i, a := range m.artifacts[runID] {
+		out = append(out, artifactRecord{Version: i + 1, Kind: a.Kind, CreatedAt: a.CreatedAt})
+	}
+	return out, nil
+}
+
+func (m *memRepository) AgentProfile(ctx context.Context, agentID uuid.UUID) (agentProfile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.agents[agentID]
+	if !ok {
+		return agentProfile{}, pgx.ErrNoRows
+	}
+	return a.Profile, nil
+}
+
+func (m *memRepository) AppendAudit(ctx context.Context, actorType string, actorID uuid.UUID, action string, data map[string]any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audits = append(m.audits, action)
+	return nil
+}
+
+func (m *memRepository) admissionLocked(a *memAgent) agentAdmissionDTO {
+	dto := agentAdmissionDTO{AgentRef: a.Profile.Ref, Name: a.Profile.Name, AdmittedStatus: a.AdmittedStatus, PublicKey: a.PublicKey, Note: a.Note}
+	for _, t := range []struct {
+		src *time.Time
+		dst *string
+	}{{a.RequestedAt, &dto.RequestedAt}, {a.AdmittedAt, &dto.AdmittedAt}, {a.ReviewedAt, &dto.ReviewedAt}} {
+		if t.src != nil {
+			*t.dst = t.src.UTC().Format(time.RFC3339)
+		}
+	}
+	return dto
+}
+
+func (m *memRepository) RegisterAgentPublicKey(ctx context.Context, agentID uuid.UUID, publicKey string) (agentAdmissionDTO, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.agents[agentID]
+	if !ok {
+		return agentAdmissionDTO{}, false, pgx.ErrNoRows
+	}
+	if a.PublicKey == publicKey {
+		return m.admissionLocked(a), false, nil
+	}
+	a.PublicKey = publicKey
+	a.AdmittedAt = nil
+	if a.AdmittedStatus != "rejected" {
+		a.AdmittedStatus = "not_requested"
+		a.RequestedAt = nil
+	}
+	return m.admissionLocked(a), true, nil
+}
+
+func (m *memRepository) DecideAdmission(ctx context.Context, d admissionDecision) (uuid.UUID, uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.agents {
+		if a.Profile.Ref != d.AgentRef {
+			continue
+		}
+		allowed := false
+		for _, from := range d.From {
+			allowed = allowed || a.AdmittedStatus == from
+		}
+		if !allowed || a.RequestedAt == nil {
+			return uuid.Nil, uuid.Nil, &admissionStateError{Status: a.AdmittedStatus}
+		}
+		now := time.Now()
+		a.AdmittedStatus = d.To
+		a.AdmittedAt = nil
+		if d.To == "admitted" {
+			a.AdmittedAt = &now
+		}
+		a.ReviewedAt = &now
+		a.Note = d.Note
+		return a.ID, a.OwnerID, nil
+	}
+	return uuid.Nil, uuid.Nil, pgx.ErrNoRows
+}
+
+func (m *memRepository) ClaimIdempotencyKey(ctx context.Context, agentID uuid.UUID, key, request, requestHash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memIdempotencyKey{AgentID: agentID, Key: key}
+	if e, ok := m.idemKeys[k]; ok {
+		age := time.Since(e.CreatedAt)
+		if age < idempotencyKeyTTL && (e.StatusCode != nil || age < idempotencyInFlightTimeout) {
+			return false, nil
+		}
+	}
+	m.idemKeys[k] = memIdempotencyEntry{idempotencyRecord: idempotencyRecord{Request: request, RequestHash: requestHash}, CreatedAt: time.Now()}
+	return true, nil
+}
+
+func (m *memRepository) GetIdempotencyKey(ctx context.Context, agentID uuid.UUID, key string) (idempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.idemKeys[memIdempotencyKey{AgentID: agentID, Key: key}]
+	if !ok {
+		return idempotencyRecord{}, pgx.ErrNoRows
+	}
+	return e.idempotencyRecord, nil
+}
+
+func (m *memRepository) StoreIdempotentResponse(ctx context.Context, agentID uuid.UUID, key string, status int, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memIdempotencyKey{AgentID: agentID, Key: key}
+	if e, ok := m.idemKeys[k]; ok {
+		e.StatusCode = &status
+		e.Body = append([]byte(nil), body...)
+		m.idemKeys[k] = e
+	}
+	return nil
+}
+
+func (m *memRepository) ReleaseIdempotencyKey(ctx context.Context, agentID uuid.UUID, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.idemKeys, memIdempotencyKey{AgentID: agentID, Key: key})
+	return nil
+}
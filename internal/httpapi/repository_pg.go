@@ -0,0 +1,638 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// pgRepository implements repository on Postgres. It keeps a copy of the server for config and
+// the in-transaction helpers shared with other handlers (createRunInTx, resolveStageSkills).
+type pgRepository struct {
+	s server
+}
+
+func newPGRepository(s server) pgRepository { return pgRepository{s: s} }
+
+func (p pgRepository) CreateRun(ctx context.Context, run newRun) (createdRun, error) {
+	tx, err := p.s.db.Begin(ctx)
+	if err != nil {
+		return createdRun{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	runID, runRef, workItemID, err := p.s.createRunInTx(ctx, tx, run.PublisherUserID, run.Goal, run.Constraints, run.RequiredTags, run.ScheduledAt, run.IsPublic)
+	if err != nil {
+		return createdRun{}, err
+	}
+	unavailable, err := unavailableCatalogTools(ctx, tx, run.AllowedTools)
+	if err != nil {
+		return createdRun{}, err
+	}
+	if len(unavailable) > 0 {
+		return createdRun{}, &unavailableToolError{Tool: unavailable[0]}
+	}
+	if err := setRunAllowedToolsInTx(ctx, tx, runID, run.AllowedTools); err != nil {
+		return createdRun{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return createdRun{}, err
+	}
+	return createdRun{RunID: runID, RunRef: runRef, WorkItemID: workItemID}, nil
+}
+
+func (p pgRepository) RunIDByRef(ctx context.Context, runRef string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := p.s.db.QueryRow(ctx, `select id from runs where public_ref=$1`, runRef).Scan(&id)
+	return id, err
+}
+
+func (p pgRepository) ListOffers(ctx context.Context, agentID uuid.UUID, limit int) ([]workItemRecord, error) {
+	rows, err := p.s.db.Query(ctx, `
+		select wi.id, wi.run_id, r.public_ref, r.goal, r.constraints, wi.stage, wi.kind, wi.status, wi.context, wi.available_skills, wi.review_context
+		from work_item_offers o
+		join work_items wi on wi.id = o.work_item_id
+		join runs r on r.id = wi.run_id
+		left join work_item_leases l on l.work_item_id = wi.id
+		where o.agent_id = $1
+		  and (
+		    wi.status = 'offered'
+		    or (wi.status = 'claimed' and l.agent_id = $1 and l.lease_expires_at > now())
+		  )
+		order by wi.created_at asc
+		limit $2
+	`, agentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]workItemRecord, 0)
+	for rows.Next() {
+		var wi workItemRecord
+		if err := rows.Scan(&wi.ID, &wi.RunID, &wi.RunRef, &wi.Goal, &wi.Constraints, &wi.Stage, &wi.Kind, &wi.Status, &wi.Context, &wi.AvailableSkills, &wi.ReviewContext); err != nil {
+			return nil, err
+		}
+		out = append(out, wi)
+	}
+	return out, rows.Err()
+}
+
+func (p pgRepository) GetWorkItem(ctx context.Context, workItemID uuid.UUID) (workItemRecord, error) {
+	wi := workItemRecord{ID: workItemID}
+	err := p.s.db.QueryRow(ctx, `
+		select wi.run_id, r.public_ref, r.goal, r.constraints, wi.stage, wi.kind, wi.status, wi.context, wi.available_skills, wi.review_context
+		from work_items wi
+		join runs r on r.id = wi.run_id
+		where wi.id = $1
+	`, workItemID).Scan(&wi.RunID, &wi.RunRef, &wi.Goal, &wi.Constraints, &wi.Stage, &wi.Kind, &wi.Status, &wi.Context, &wi.AvailableSkills, &wi.ReviewContext)
+	return wi, err
+}
+
+func (p pgRepository) ClaimWorkItem(ctx context.Context, agentID, workItemID uuid.UUID, leaseExpiresAt time.Time) error {
+	tx, err := p.s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Must be offered to this agent.
+	var offered bool
+	if err := tx.QueryRow(ctx, `select true from work_item_offers where work_item_id=$1 and agent_id=$2`, workItemID, agentID).Scan(&offered); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errNotOffered
+		}
+		return err
+	}
+
+	// Only allow claiming if currently offered.
+	var status string
+	if err := tx.QueryRow(ctx, `select status from work_items where id=$1 for update`, workItemID).Scan(&status); err != nil {
+		return err
+	}
+	if status != "offered" {
+		return errNotClaimable
+	}
+	if err := leaseWorkItemInTx(ctx, tx, agentID, workItemID, leaseExpiresAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (p pgRepository) ClaimNextWorkItem(ctx context.Context, agentID uuid.UUID, leaseExpiresAt time.Time) (uuid.UUID, error) {
+	tx, err := p.s.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var workItemID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		select wi.id
+		from work_item_offers o
+		join work_items wi on wi.id = o.work_item_id
+		where o.agent_id = $1
+		  and wi.status = 'offered'
+		order by wi.created_at asc
+		limit 1
+		for update of wi skip locked
+	`, agentID).Scan(&workItemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, errNoOffers
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := leaseWorkItemInTx(ctx, tx, agentID, workItemID, leaseExpiresAt); err != nil {
+		return uuid.Nil, err
+	}
+	return workItemID, tx.Commit(ctx)
+}
+
+// leaseWorkItemInTx inserts the lease, marks the work item claimed and starts a created run.
+func leaseWorkItemInTx(ctx context.Context, tx pgx.Tx, agentID, workItemID uuid.UUID, leaseExpiresAt time.Time) error {
+	if _, err := tx.Exec(ctx, `
+		insert into work_item_leases (work_item_id, agent_id, lease_expires_at)
+		values ($1, $2, $3)
+	`, workItemID, agentID, leaseExpiresAt); err != nil {
+		return errAlreadyClaimed
+	}
+	if _, err := tx.Exec(ctx, `update work_items set status='claimed', updated_at=now() where id=$1`, workItemID); err != nil {
+		return err
+	}
+	return advanceRunStatusInTx(ctx, tx, workItemID, []string{"created"}, "running")
+}
+
+func (p pgRepository) CompleteWorkItem(ctx context.Context, agentID, workItemID uuid.UUID, now time.Time) (completedWorkItem, error) {
+	tx, err := p.s.db.Begin(ctx)
+	if err != nil {
+		return completedWorkItem{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var leaseAgent uuid.UUID
+	var leaseExpires time.Time
+	err = tx.QueryRow(ctx, `
+		select agent_id, lease_expires_at
+		from work_item_leases
+		where work_item_id = $1
+	`, workItemID).Scan(&leaseAgent, &leaseExpires)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return completedWorkItem{}, errNotLeased
+	}
+	if err != nil {
+		return completedWorkItem{}, err
+	}
+	if leaseAgent != agentID {
+		return completedWorkItem{}, errNotLeaseHolder
+	}
+	if now.After(leaseExpires) {
+		return completedWorkItem{}, errLeaseExpired
+	}
+
+	var done completedWorkItem
+	if err := tx.QueryRow(ctx, `
+		update work_items set status='completed', updated_at=now()
+		where id=$1
+		returning run_id, stage, kind
+	`, workItemID).Scan(&done.RunID, &done.Stage, &done.Kind); err != nil {
+		return completedWorkItem{}, err
+	}
+	if _, err := tx.Exec(ctx, `delete from work_item_leases where work_item_id=$1`, workItemID); err != nil {
+		return completedWorkItem{}, err
+	}
+
+	// Append to the contribution ledger (review work items also count as a review given).
+	runID := done.RunID
+	entry := contributionEntry{AgentID: agentID, Kind: contributionWorkItemCompleted, Stage: done.Stage, WorkItemKind: done.Kind, RunID: &runID, SourceKey: workItemID.String()}
+	if err := recordContribution(ctx, tx, entry); err != nil {
+		return completedWorkItem{}, err
+	}
+	if done.Kind == "review" {
+		entry.Kind = contributionReviewGiven
+		if err := recordContribution(ctx, tx, entry); err != nil {
+			return completedWorkItem{}, err
+		}
+
+		// Notify the run publisher and the owner of the reviewed (latest) artifact's author.
+		var runRef, reviewerRef string
+		var publisherID uuid.UUID
+		var authorOwnerID *uuid.UUID
+		if err := tx.QueryRow(ctx, `
+			select r.public_ref, r.publisher_user_id, rv.public_ref,
+			       (select ag.owner_id
+			        from artifacts ar
+			        join agents ag on ag.id = ar.author_agent_id
+			        where ar.run_id = r.id
+			        order by ar.version desc
+			        limit 1)
+			from runs r, agents rv
+			where r.id = $1 and rv.id = $2
+		`, runID, agentID).Scan(&runRef, &publisherID, &reviewerRef, &authorOwnerID); err != nil {
+			return completedWorkItem{}, err
+		}
+		recipients := []uuid.UUID{publisherID}
+		if authorOwnerID != nil {
+			recipients = append(recipients, *authorOwnerID)
+		}
+		if err := emitWebhookEvent(ctx, tx, recipients, webhookEventReviewCompleted, map[string]any{
+			"run_ref":            runRef,
+			"stage":              done.Stage,
+			"reviewer_agent_ref": reviewerRef,
+		}); err != nil {
+			return completedWorkItem{}, err
+		}
+	}
+
+	// Update owner aggregated contribution counter.
+	if err := tx.QueryRow(ctx, `select owner_id from agents where id=$1`, agentID).Scan(&done.OwnerID); err != nil {
+		return completedWorkItem{}, err
+	}
+	if _, err := tx.Exec(ctx, `
+		insert into owner_contributions (owner_id, completed_work_items, updated_at)
+		values ($1, 1, now())
+		on conflict (owner_id) do update
+		set completed_work_items = owner_contributions.completed_work_items + 1,
+		    updated_at = now()
+	`, done.OwnerID); err != nil {
+		return completedWorkItem{}, err
+	}
+	return done, tx.Commit(ctx)
+}
+
+func (p pgRepository) IsRunParticipant(ctx context.Context, agentID, runID uuid.UUID) (bool, error) {
+	var participant bool
+	err := p.s.db.QueryRow(ctx, `
+		select exists(
+			select 1
+			from work_item_offers o
+			join work_items wi on wi.id = o.work_item_id
+			where o.agent_id = $1 and wi.run_id = $2
+		)
+	`, agentID, runID).Scan(&participant)
+	return participant, err
+}
+
+func (p pgRepository) HasClaimedReviewWorkItem(ctx context.Context, agentID, runID uuid.UUID) (bool, error) {
+	var onReviewLease bool
+	err := p.s.db.QueryRow(ctx, `
+		select exists(
+			select 1
+			from work_item_leases l
+			join work_items wi on wi.id = l.work_item_id
+			where l.agent_id = $1
+			  and wi.run_id = $2
+			  and wi.kind = 'review'
+			  and wi.status = 'claimed'
+		)
+	`, agentID, runID).Scan(&onReviewLease)
+	return onReviewLease, err
+}
+
+func (p pgRepository) ReviewWorkItemExists(ctx context.Context, runID, artifactID uuid.UUID) (bool, error) {
+	var exists bool
+	err := p.s.db.QueryRow(ctx, `
+		select exists(
+			select 1
+			from work_items
+			where run_id = $1
+			  and kind = 'review'
+			  and review_context->>'target_artifact_id' = $2
+		)
+	`, runID, artifactID.String()).Scan(&exists)
+	return exists, err
+}
+
+func (p pgRepository) PickReviewer(ctx context.Context, runID, authorAgentID uuid.UUID) (uuid.UUID, error) {
+	db := p.s.db
+	// Pick any other enabled participant as reviewer.
+	candidates, err := queryUUIDs(ctx, db, `
+		select distinct o.agent_id
+		from work_item_offers o
+		join work_items wi on wi.id = o.work_item_id
+		join agents a on a.id = o.agent_id
+		where wi.run_id = $1
+		  and o.agent_id <> $2
+		  and a.status = 'enabled'
+	`, runID, authorAgentID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if len(candidates) == 0 {
+		// Cold-start friendly fallback: if this run currently has no other offered participants
+		// (e.g. matchingParticipantCount=1), pick any other enabled agent.
+		// Prefer agents that best match the run's required tags, but do not hard-exclude
+		// agents that don't match (early-stage matching should be permissive).
+		candidates, err = queryUUIDs(ctx, db, `
+			select a.id
+			from agents a
+			left join run_required_tags rt on rt.run_id = $1
+			left join agent_tags at on at.agent_id = a.id and at.tag = rt.tag
+			where a.status = 'enabled'
+			  and a.id <> $2
+			group by a.id
+			order by count(distinct at.tag) desc, random()
+			limit 50
+		`, runID, authorAgentID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if len(candidates) == 0 {
+			return uuid.Nil, nil
+		}
+	}
+
+	// Prefer reviewers that best match the run's required tags (then random as tie-breaker).
+	// This makes review assignment more stable in environments with many enabled agents.
+	requiredTags := []string{}
+	if tagRows, err := db.Query(ctx, `select tag from run_required_tags where run_id=$1 order by tag asc`, runID); err == nil {
+		for tagRows.Next() {
+			var t string
+			if err := tagRows.Scan(&t); err != nil {
+				logError(ctx, "scan required tags failed for review work item", err)
+				break
+			}
+			if t != "" {
+				requiredTags = append(requiredTags, t)
+			}
+		}
+		if err := tagRows.Err(); err != nil {
+			logError(ctx, "required tags rows error for review work item", err)
+		}
+		tagRows.Close()
+	} else {
+		logError(ctx, "query required tags failed for review work item", err)
+	}
+
+	if len(requiredTags) > 0 {
+		var picked uuid.UUID
+		err := db.QueryRow(ctx, `
+			select a.id
+			from agents a
+			left join agent_tags at on at.agent_id = a.id and at.tag = any($2)
+			where a.id = any($1)
+			group by a.id
+			order by count(distinct at.tag) desc, random()
+			limit 1
+		`, candidates, requiredTags).Scan(&picked)
+		if err == nil {
+			return picked, nil
+		}
+		logError(ctx, "pick reviewer by tags failed", err)
+	}
+	shuffleUUIDs(ctx, candidates)
+	return candidates[0], nil
+}
+
+func (p pgRepository) CreateWorkItem(ctx context.Context, wi newWorkItem, offerTo []uuid.UUID) (uuid.UUID, error) {
+	tx, err := p.s.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var workItemID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		insert into work_items (run_id, stage, kind, status, context, available_skills, skill_refs, review_context, scheduled_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		returning id
+	`, wi.RunID, wi.Stage, wi.Kind, wi.Status, wi.Context, wi.AvailableSkills, wi.SkillRefs, wi.ReviewContext, wi.ScheduledAt).Scan(&workItemID); err != nil {
+		return uuid.Nil, err
+	}
+	for _, agentID := range offerTo {
+		if _, err := tx.Exec(ctx, `
+			insert into work_item_offers (work_item_id, agent_id) values ($1, $2)
+			on conflict do nothing
+		`, workItemID, agentID); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return workItemID, tx.Commit(ctx)
+}
+
+func (p pgRepository) ResolveStageSkills(ctx context.Context, stage string) ([]string, []byte, error) {
+	return p.s.resolveStageSkills(ctx, p.s.db, stage)
+}
+
+func (p pgRepository) AppendEvent(ctx context.Context, runID uuid.UUID, ev newEvent) (int64, error) {
+	tx, err := p.s.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock run row to serialize seq allocation per run.
+	if _, err := tx.Exec(ctx, `select 1 from runs where id=$1 for update`, runID); err != nil {
+		return 0, err
+	}
+	var nextSeq int64
+	if err := tx.QueryRow(ctx, `select coalesce(max(seq), 0) + 1 from events where run_id=$1`, runID).Scan(&nextSeq); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		insert into events (run_id, seq, kind, persona, payload, is_key_node, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7)
+	`, runID, nextSeq, ev.Kind, ev.Persona, ev.Payload, ev.IsKeyNode, ev.CreatedAt); err != nil {
+		return 0, err
+	}
+	return nextSeq, tx.Commit(ctx)
+}
+
+func (p pgRepository) AppendArtifact(ctx context.Context, a newArtifact) (int, uuid.UUID, error) {
+	tx, err := p.s.db.Begin(ctx)
+	if err != nil {
+		return 0, uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock run to serialize version allocation.
+	if _, err := tx.Exec(ctx, `select 1 from runs where id=$1 for update`, a.RunID); err != nil {
+		return 0, uuid.Nil, err
+	}
+	var nextVersion int
+	if err := tx.QueryRow(ctx, `select coalesce(max(version), 0) + 1 from artifacts where run_id=$1`, a.RunID).Scan(&nextVersion); err != nil {
+		return 0, uuid.Nil, err
+	}
+
+	var linkedSeq any
+	if a.LinkedEventSeq != nil && *a.LinkedEventSeq > 0 {
+		linkedSeq = *a.LinkedEventSeq
+	}
+	var artifactID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		insert into artifacts (run_id, version, kind, content, linked_event_seq, author_agent_id)
+		values ($1, $2, $3, $4, $5, $6)
+		returning id
+	`, a.RunID, nextVersion, a.Kind, a.Content, linkedSeq, a.AuthorAgentID).Scan(&artifactID); err != nil {
+		return 0, uuid.Nil, err
+	}
+
+	// Notify the run publisher (webhook deliveries commit with the artifact).
+	var publisherID uuid.UUID
+	var authorRef string
+	if err := tx.QueryRow(ctx, `
+		select r.publisher_user_id, a.public_ref
+		from runs r, agents a
+		where r.id = $1 and a.id = $2
+	`, a.RunID, a.AuthorAgentID).Scan(&publisherID, &authorRef); err != nil {
+		return 0, uuid.Nil, err
+	}
+	if err := emitWebhookEvent(ctx, tx, []uuid.UUID{publisherID}, webhookEventArtifactSubmitted, map[string]any{
+		"run_ref":   a.RunRef,
+		"version":   nextVersion,
+		"kind":      a.Kind,
+		"agent_ref": authorRef,
+	}); err != nil {
+		return 0, uuid.Nil, err
+	}
+	return nextVersion, artifactID, tx.Commit(ctx)
+}
+
+func (p pgRepository) ListArtifacts(ctx context.Context, runID uuid.UUID) ([]artifactRecord, error) {
+	rows, err := p.s.db.Query(ctx, `
+		select version, kind, created_at
+		from artifacts
+		where run_id = $1 and review_status <> 'rejected'
+		order by version asc
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]artifactRecord, 0)
+	for rows.Next() {
+		var a artifactRecord
+		if err := rows.Scan(&a.Version, &a.Kind, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (p pgRepository) AgentProfile(ctx context.Context, agentID uuid.UUID) (agentProfile, error) {
+	var a agentProfile
+	if err := p.s.db.QueryRow(ctx, `
+		select public_ref, name, prompt_view, persona, identity_mode,
+		       coalesce((select array_agg(tag order by tag) from agent_tags where agent_id = agents.id), '{}')
+		from agents
+		where id = $1
+	`, agentID).Scan(&a.Ref, &a.Name, &a.PromptView, &a.Persona, &a.IdentityMode, &a.Tags); err != nil {
+		return agentProfile{}, err
+	}
+	return a, nil
+}
+
+func (p pgRepository) AppendAudit(ctx context.Context, actorType string, actorID uuid.UUID, action string, data map[string]any) error {
+	tx, err := p.s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := insertAuditLogInTx(ctx, tx, actorType, actorID, action, data); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (p pgRepository) RegisterAgentPublicKey(ctx context.Context, agentID uuid.UUID, publicKey string) (agentAdmissionDTO, bool, error) {
+	dto, err := scanAgentAdmission(p.s.db.QueryRow(ctx, `
+		update agents
+		set agent_public_key = $2,
+		    admitted_status = case when admitted_status = 'rejected' then 'rejected' else 'not_requested' end,
+		    admitted_at = null,
+		    admission_requested_at = case when admitted_status = 'rejected' then admission_requested_at else null end,
+		    updated_at = now()
+		where id = $1 and agent_public_key <> $2
+		returning `+agentAdmissionColumns, agentID, publicKey))
+	if errors.Is(err, pgx.ErrNoRows) {
+		dto, err = scanAgentAdmission(p.s.db.QueryRow(ctx, `select `+agentAdmissionColumns+` from agents where id = $1`, agentID))
+		return dto, false, err
+	}
+	return dto, err == nil, err
+}
+
+func (p pgRepository) DecideAdmission(ctx context.Context, d admissionDecision) (uuid.UUID, uuid.UUID, error) {
+	var agentID, ownerID uuid.UUID
+	err := p.s.db.QueryRow(ctx, `
+		update agents
+		set admitted_status = $2,
+		    admitted_at = case when $2 = 'admitted' then now() else null end,
+		    admission_reviewed_by = $3,
+		    admission_reviewed_at = now(),
+		    admission_note = $4,
+		    updated_at = now()
+		where public_ref = $1 and admitted_status = any($5) and admission_requested_at is not null
+		returning id, owner_id
+	`, d.AgentRef, d.To, d.AdminID, d.Note, d.From).Scan(&agentID, &ownerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var status string
+		if err := p.s.db.QueryRow(ctx, `select admitted_status from agents where public_ref = $1`, d.AgentRef).Scan(&status); err != nil {
+			return uuid.Nil, uuid.Nil, err
+		}
+		return uuid.Nil, uuid.Nil, &admissionStateError{Status: status}
+	}
+	return agentID, ownerID, err
+}
+
+func (p pgRepository) ClaimIdempotencyKey(ctx context.Context, agentID uuid.UUID, key, request, requestHash string) (bool, error) {
+	tag, err := p.s.db.Exec(ctx, `
+		insert into gateway_idempotency_keys (agent_id, key, request, request_hash)
+		values ($1, $2, $3, $4)
+		on conflict (agent_id, key) do update
+		set request = excluded.request, request_hash = excluded.request_hash,
+		    status_code = null, response_body = null, created_at = now()
+		where gateway_idempotency_keys.created_at < now() - make_interval(secs => $5)
+		   or (gateway_idempotency_keys.status_code is null
+		       and gateway_idempotency_keys.created_at < now() - make_interval(secs => $6))
+	`, agentID, key, request, requestHash, idempotencyKeyTTL.Seconds(), idempotencyInFlightTimeout.Seconds())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p pgRepository) GetIdempotencyKey(ctx context.Context, agentID uuid.UUID, key string) (idempotencyRecord, error) {
+	var rec idempotencyRecord
+	err := p.s.db.QueryRow(ctx, `
+		select request, request_hash, status_code, response_body
+		from gateway_idempotency_keys
+		where agent_id = $1 and key = $2
+	`, agentID, key).Scan(&rec.Request, &rec.RequestHash, &rec.StatusCode, &rec.Body)
+	return rec, err
+}
+
+func (p pgRepository) StoreIdempotentResponse(ctx context.Context, agentID uuid.UUID, key string, status int, body []byte) error {
+	_, err := p.s.db.Exec(ctx, `
+		update gateway_idempotency_keys
+		set status_code = $3, response_body = $4
+		where agent_id = $1 and key = $2
+	`, agentID, key, status, body)
+	return err
+}
+
+func (p pgRepository) ReleaseIdempotencyKey(ctx context.Context, agentID uuid.UUID, key string) error {
+	_, err := p.s.db.Exec(ctx, `delete from gateway_idempotency_keys where agent_id = $1 and key = $2`, agentID, key)
+	return err
+}
+
+func queryUUIDs(ctx context.Context, q dbQuerier, sql string, args ...any) ([]uuid.UUID, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
@@ -15,7 +15,12 @@ func NewRouter(d Deps) http.Handler {
 	r.Use(middleware.RequestID)
 	r.Use(serverErrorLoggerMiddleware)
 	r.Use(corsMiddleware)
-	r.Use(middleware.RealIP)
+	trustedProxies, err := parseTrustedProxies(d.TrustedProxies)
+	if err != nil {
+		logErrorNoCtx("invalid trusted proxies; ignoring forwarding headers", err)
+		trustedProxies = nil
+	}
+	r.Use(realIPMiddleware(trustedProxies))
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Heartbeat("/healthz"))
 
@@ -28,6 +33,9 @@ func NewRouter(d Deps) http.Handler {
 		githubClientSecret:     d.GitHubOAuthClientSecret,
 		skillsGatewayWhitelist: d.SkillsGatewayWhitelist,
 		br:                     newBroker(),
+		privacy:                newPrivacyGuard(d.DB),
+		moderator:              newAutoModerator(d.DB),
+		limiter:                newRateLimiter(d.DB, d.RateLimitStore),
 
 		platformKeysEncryptionKey: d.PlatformKeysEncryptionKey,
 		platformCertIssuer:        d.PlatformCertIssuer,
@@ -43,6 +51,7 @@ func NewRouter(d Deps) http.Handler {
 		ossAccessKeySecret:    d.OSSAccessKeySecret,
 		ossSTSRoleARN:         d.OSSSTSRoleARN,
 		ossSTSDurationSeconds: d.OSSSTSDurationSeconds,
+		ossSTSHourlyLimit:     d.OSSSTSHourlyLimit,
 		ossLocalDir:           d.OSSLocalDir,
 		ossEventsIngestToken:  d.OSSEventsIngestToken,
 
@@ -55,12 +64,16 @@ func NewRouter(d Deps) http.Handler {
 
 		topicPlayActorTags:          d.TopicPlayActorTags,
 		topicPlayDailyLimitPerAgent: d.TopicPlayDailyLimitPerAgent,
+
+		webhookClient: newWebhookHTTPClient(d.WebhookAllowPrivateTargets),
 	}
 	if strings.TrimSpace(s.ossProvider) == "" && strings.TrimSpace(s.ossLocalDir) != "" {
 		s.ossProvider = "local"
 	}
 	s.matchingParticipantCount = d.MatchingParticipantCount
 	s.workItemLeaseSeconds = d.WorkItemLeaseSeconds
+	s.repo = newPGRepository(s)
+	s.tools = newGatewayToolRegistry(s)
 
 	// Start background scheduler for scheduled work items
 	go func() {
@@ -70,6 +83,7 @@ func NewRouter(d Deps) http.Handler {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			s.schedulePendingWorkItems(ctx)
 			s.cleanupExpiredWorkItemLeases(ctx)
+			s.sweepExpiredIdempotencyKeys(ctx)
 			s.cleanupExpiredPreReviewEvaluations(ctx)
 			cancel()
 		}
@@ -91,6 +105,50 @@ func NewRouter(d Deps) http.Handler {
 		}
 	}()
 
+	// Periodically relay pending OSS outbox writes (retries writes that failed right after commit).
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+			s.relayOSSOutboxTick(ctx)
+			cancel()
+		}
+	}()
+
+	// Periodically sign audit hash chain checkpoints (tamper evidence for audit_logs/moderation_actions).
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			s.checkpointAuditChainsTick(ctx)
+			cancel()
+		}
+	}()
+
+	// Drop rate limit buckets that have refilled (idle callers).
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			s.limiter.sweep(ctx)
+			cancel()
+		}
+	}()
+
+	// Deliver outbound webhooks (pending + retries with backoff).
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+			s.deliverWebhooksTick(ctx)
+			cancel()
+		}
+	}()
+
 	// Periodically issue "topic play" work items (topic-first self-play; agents claim via inbox).
 	go func() {
 		ticker := time.NewTicker(30 * time.Second)
@@ -130,165 +188,273 @@ func NewRouter(d Deps) http.Handler {
 		r.Handle("/app/", http.StripPrefix("/app/", appUI))
 	}
 
-	r.Route("/v1", func(r chi.Router) {
-		// Rate limit API calls only. Do not rate limit /app/* static assets, otherwise
-		// the SPA can fail to load (lazy chunks, JS/CSS) and trigger the ErrorBoundary.
-		// 120/min was too low for real UI traffic (and E2E), so use a higher ceiling.
-		r.Use(newIPRateLimiter(1200, time.Minute).middleware)
-
-		// Public runs list (for browsing/searching without remembering IDs).
-		r.Get("/runs", s.handleListRunsPublic)
-		// Public activity feed (latest key nodes).
-		r.Get("/activity", s.handleListActivityPublic)
-		// Public topic activity feed (OSS topics; latest messages/votes/etc).
-		r.Get("/topics/activity", s.handleListTopicActivityPublic)
-		// Public topic overview (topic-first browsing).
-		r.Get("/topics/overview", s.handleListTopicsOverviewPublic)
-		// Public topic thread view (hierarchical; no internal IDs in UI).
-		r.Get("/topics/{topicID}/thread", s.handleGetTopicThreadPublic)
-
-		// Public "cosmology" read APIs (OSS-backed).
-		r.Get("/agents/{agentRef}/dimensions", s.handleGetAgentDimensions)
-		r.Get("/agents/{agentRef}/daily-thought", s.handleGetAgentDailyThought)
-		r.Get("/agents/{agentRef}/highlights", s.handleGetAgentHighlights)
-		r.Get("/curations", s.handleListCurations)
-
-		// Public platform signing keyset (for agent-side verification).
-		r.Get("/platform/signing-keys", s.handleListPlatformSigningKeys)
-		// Public platform meta (UI needs this without login).
-		r.Get("/platform/meta", s.handleGetPlatformMetaPublic)
-
-		// Public agent discovery (from platform projection).
-		r.Get("/agents/discover", s.handleDiscoverAgents)
-		r.Get("/agents/discover/{agentRef}", s.handleDiscoverAgentDetail)
-
-		// OAuth (GitHub).
-		r.Get("/auth/github/start", s.handleAuthGitHubStart)
-		r.Get("/auth/github/callback", s.handleAuthGitHubCallback)
-		r.Post("/auth/app/exchange", s.handleAuthAppExchange)
-
-		r.Group(func(r chi.Router) {
-			r.Use(s.userAuthMiddleware)
-			r.Get("/me", s.handleGetMe)
-			r.Post("/agents", s.handleCreateAgent)
-			r.Get("/agents", s.handleListAgents)
-			r.Get("/agents/{agentRef}", s.handleGetAgent)
-			r.Delete("/agents/{agentRef}", s.handleDeleteAgent)
-			r.Patch("/agents/{agentRef}", s.handleUpdateAgent)
-			r.Post("/agents/{agentRef}/disable", s.handleDisableAgent)
-			r.Post("/agents/{agentRef}/keys/rotate", s.handleRotateAgentKey)
-			r.Put("/agents/{agentRef}/tags", s.handleReplaceAgentTags)
-			r.Post("/agents/{agentRef}/tags", s.handleAddAgentTag)
-			r.Delete("/agents/{agentRef}/tags/{tag}", s.handleDeleteAgentTag)
-
-			// Owner prompt bundle (OpenClaw/local execution bootstrap).
-			r.Get("/agents/{agentRef}/prompt-bundle", s.handleGetAgentPromptBundle)
-
-			// Cosmology owner APIs.
-			r.Get("/agents/{agentRef}/timeline", s.handleOwnerGetTimeline)
-			r.Post("/agents/{agentRef}/swap-tests", s.handleOwnerCreateSwapTest)
-			r.Get("/agents/{agentRef}/swap-tests/{swapTestID}", s.handleOwnerGetSwapTest)
-			r.Get("/agents/{agentRef}/weekly-reports", s.handleOwnerGetWeeklyReport)
-			r.Put("/agents/{agentRef}/daily-thought", s.handleOwnerUpsertDailyThought)
-
-			// Owner pre-review evaluations (unlisted runs; production data should be deletable).
-			r.Post("/agents/{agentRef}/pre-review-evaluations", s.handleOwnerCreatePreReviewEvaluation)
-			r.Get("/agents/{agentRef}/pre-review-evaluations", s.handleOwnerListPreReviewEvaluations)
-			r.Get("/agents/{agentRef}/pre-review-evaluations/{evaluationID}", s.handleOwnerGetPreReviewEvaluation)
-			r.Delete("/agents/{agentRef}/pre-review-evaluations/{evaluationID}", s.handleOwnerDeletePreReviewEvaluation)
-			r.Get("/pre-review-evaluation/sources/recent-topics", s.handleOwnerListRecentTopicsForEvaluation)
-			r.Get("/pre-review-evaluation/sources/recent-runs", s.handleOwnerListRecentRunsForEvaluation)
-			r.Get("/runs/{runRef}/work-items", s.handleOwnerListRunWorkItems)
-
-			r.Post("/curations", s.handleCreateCuration)
-
-			// Agent Card catalogs for wizard authoring (curated; cacheable via catalog_version).
-			r.Get("/agent-card/catalogs", s.handleGetAgentCardCatalogs)
-
-			// Persona templates (custom submission; requires admin approval before use).
-			r.Get("/persona-templates", s.handleListApprovedPersonaTemplates)
-			r.Post("/persona-templates", s.handleSubmitPersonaTemplate)
-		})
+	r.Route("/v1", s.mountV1Routes)
+	return r
+}
 
-		r.Group(func(r chi.Router) {
-			r.Use(s.agentAuthMiddleware)
-			r.Get("/gateway/inbox/poll", s.handleGatewayPoll)
-			r.Post("/gateway/inbox/claim-next", s.handleGatewayClaimNextWorkItem)
-			r.Get("/gateway/tasks", s.handleGatewayTasks)
-			r.Get("/gateway/work-items/{workItemID}", s.handleGatewayGetWorkItem)
-			r.Get("/gateway/work-items/{workItemID}/skills", s.handleGatewayWorkItemSkills)
-			r.Post("/gateway/work-items/{workItemID}/claim", s.handleGatewayClaimWorkItem)
-			r.Post("/gateway/work-items/{workItemID}/complete", s.handleGatewayCompleteWorkItem)
-			r.Post("/gateway/runs", s.handleGatewayCreateRun)
-			r.Post("/gateway/topics/{topicID}/messages", s.handleGatewayWriteTopicMessage)
-			r.Post("/gateway/topics/{topicID}/messages:text", s.handleGatewayWriteTopicMessageText)
-			r.Post("/gateway/topics/{topicID}/requests", s.handleGatewayWriteTopicRequest)
-			r.Post("/gateway/topics/{topicID}/requests:propose-topic-text", s.handleGatewayProposeTopicText)
-			r.Post("/gateway/runs/{runRef}/events", s.handleGatewayEmitEvent)
-			r.Post("/gateway/runs/{runRef}/artifacts", s.handleGatewaySubmitArtifact)
-			r.Post("/gateway/tools/invoke", s.handleGatewayInvokeTool)
-		})
+// mountV1Routes registers the /v1 API. Every route here must have an entry in apiOperations
+// (openapi_routes.go); TestOpenAPICoversRoutes enforces it.
+func (s server) mountV1Routes(r chi.Router) {
+	// Rate limit API calls only (per client IP here; per user/agent in the groups below).
+	r.Use(s.rateLimitMiddleware(publicRateLimit))
+
+	// Public runs list (for browsing/searching without remembering IDs).
+	r.Get("/runs", s.handleListRunsPublic)
+	// Public activity feed (latest key nodes).
+	r.Get("/activity", s.handleListActivityPublic)
+	// Public topic activity feed (OSS topics; latest messages/votes/etc).
+	r.Get("/topics/activity", s.handleListTopicActivityPublic)
+	// Public topic overview (topic-first browsing).
+	r.Get("/topics/overview", s.handleListTopicsOverviewPublic)
+	// Public topic thread view (hierarchical; no internal IDs in UI).
+	r.Get("/topics/{topicID}/thread", s.handleGetTopicThreadPublic)
+	// Viewer reports on public content (login optional; rate limited per reporter).
+	r.Post("/reports", s.handleCreateReport)
+	// OSS bucket notifications (X-AIHub-Oss-Ingest-Token; see server_oss_ingest.go).
+	r.Post("/oss/events/ingest", s.handleOSSEventsIngest)
+
+	// Public "cosmology" read APIs (OSS-backed).
+	r.Get("/agents/{agentRef}/dimensions", s.handleGetAgentDimensions)
+	r.Get("/agents/{agentRef}/daily-thought", s.handleGetAgentDailyThought)
+	r.Get("/agents/{agentRef}/highlights", s.handleGetAgentHighlights)
+	r.Get("/curations", s.handleListCurations)
+
+	// Public contribution leaderboards (weekly/monthly/all-time; per stage/tag/kind).
+	r.Get("/leaderboards", s.handleGetLeaderboard)
+
+	// Public platform signing keyset (for agent-side verification).
+	r.Get("/platform/signing-keys", s.handleListPlatformSigningKeys)
+	// Public platform meta (UI needs this without login).
+	r.Get("/platform/meta", s.handleGetPlatformMetaPublic)
+	// Public OpenAPI document for this API.
+	r.Get("/openapi.json", s.handleGetOpenAPI)
+
+	// Public agent discovery (from platform projection).
+	r.Get("/agents/discover", s.handleDiscoverAgents)
+	r.Get("/agents/discover/{agentRef}", s.handleDiscoverAgentDetail)
+
+	// OAuth (GitHub).
+	r.Get("/auth/github/start", s.handleAuthGitHubStart)
+	r.Get("/auth/github/callback", s.handleAuthGitHubCallback)
+	r.Post("/auth/app/exchange", s.handleAuthAppExchange)
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.userAuthMiddleware)
+		r.Use(requireScope(userScope))
+		r.Use(s.rateLimitMiddleware(userRateLimit))
+		r.Get("/me", s.handleGetMe)
+
+		// Own API keys (named, scoped, optionally expiring; rotation with a grace period).
+		r.Get("/me/api-keys", s.handleListAPIKeys)
+		r.Post("/me/api-keys", s.handleCreateAPIKey)
+		r.Post("/me/api-keys/{keyID}/rotate", s.handleRotateAPIKey)
+		r.Delete("/me/api-keys/{keyID}", s.handleRevokeAPIKey)
+
+		r.Post("/agents", s.handleCreateAgent)
+		r.Get("/agents", s.handleListAgents)
+		r.Get("/agents/{agentRef}", s.handleGetAgent)
+		r.Delete("/agents/{agentRef}", s.handleDeleteAgent)
+		r.Patch("/agents/{agentRef}", s.handleUpdateAgent)
+		r.Post("/agents/{agentRef}/disable", s.handleDisableAgent)
+		r.Post("/agents/{agentRef}/keys/rotate", s.handleRotateAgentKey)
+		r.Get("/agents/{agentRef}/keys", s.handleListAPIKeys)
+		r.Post("/agents/{agentRef}/keys", s.handleCreateAPIKey)
+		r.Post("/agents/{agentRef}/keys/{keyID}/rotate", s.handleRotateAPIKey)
+		r.Delete("/agents/{agentRef}/keys/{keyID}", s.handleRevokeAPIKey)
+		r.Put("/agents/{agentRef}/tags", s.handleReplaceAgentTags)
+		r.Post("/agents/{agentRef}/tags", s.handleAddAgentTag)
+		r.Delete("/agents/{agentRef}/tags/{tag}", s.handleDeleteAgentTag)
+
+		// Agent tool grants (within the admin tool catalog).
+		r.Get("/agents/{agentRef}/tools", s.handleOwnerGetAgentTools)
+		r.Put("/agents/{agentRef}/tools", s.handleOwnerReplaceAgentTools)
+		r.Delete("/agents/{agentRef}/tools/{tool}", s.handleOwnerRevokeAgentTool)
+
+		// Owner prompt bundle (OpenClaw/local execution bootstrap).
+		r.Get("/agents/{agentRef}/prompt-bundle", s.handleGetAgentPromptBundle)
+
+		// Cosmology owner APIs.
+		r.Get("/agents/{agentRef}/timeline", s.handleOwnerGetTimeline)
+		r.Post("/agents/{agentRef}/swap-tests", s.handleOwnerCreateSwapTest)
+		r.Get("/agents/{agentRef}/swap-tests/{swapTestID}", s.handleOwnerGetSwapTest)
+		r.Get("/agents/{agentRef}/weekly-reports", s.handleOwnerGetWeeklyReport)
+		r.Put("/agents/{agentRef}/daily-thought", s.handleOwnerUpsertDailyThought)
+
+		// Owner pre-review evaluations (unlisted runs; production data should be deletable).
+		r.Post("/agents/{agentRef}/pre-review-evaluations", s.handleOwnerCreatePreReviewEvaluation)
+		r.Get("/agents/{agentRef}/pre-review-evaluations", s.handleOwnerListPreReviewEvaluations)
+		r.Get("/agents/{agentRef}/pre-review-evaluations/{evaluationID}", s.handleOwnerGetPreReviewEvaluation)
+		r.Delete("/agents/{agentRef}/pre-review-evaluations/{evaluationID}", s.handleOwnerDeletePreReviewEvaluation)
+		r.Get("/pre-review-evaluation/sources/recent-topics", s.handleOwnerListRecentTopicsForEvaluation)
+		r.Get("/pre-review-evaluation/sources/recent-runs", s.handleOwnerListRecentRunsForEvaluation)
+		r.Get("/runs/{runRef}/work-items", s.handleOwnerListRunWorkItems)
+
+		r.Post("/curations", s.handleCreateCuration)
+
+		// Owner audit logs (own actions + actions by own agents).
+		r.Get("/audit-logs", s.handleOwnerListAuditLogs)
+		r.Get("/audit-logs/export", s.handleOwnerExportAuditLogs)
+
+		// Owner contribution totals (ledger-backed; per agent).
+		r.Get("/contributions", s.handleOwnerGetContributions)
+
+		// Own rejected content + moderation appeals.
+		r.Get("/moderation/rejected", s.handleOwnerListRejectedContent)
+		r.Get("/moderation/appeals", s.handleOwnerListAppeals)
+		r.Post("/moderation/appeals", s.handleOwnerCreateAppeal)
+
+		// Outbound webhooks (signed; delivery log + redelivery + test ping).
+		r.Get("/webhooks", s.handleListWebhooks)
+		r.Post("/webhooks", s.handleCreateWebhook)
+		r.Get("/webhooks/{webhookID}", s.handleGetWebhook)
+		r.Patch("/webhooks/{webhookID}", s.handleUpdateWebhook)
+		r.Delete("/webhooks/{webhookID}", s.handleDeleteWebhook)
+		r.Post("/webhooks/{webhookID}/rotate-secret", s.handleRotateWebhookSecret)
+		r.Post("/webhooks/{webhookID}/ping", s.handlePingWebhook)
+		r.Get("/webhooks/{webhookID}/deliveries", s.handleListWebhookDeliveries)
+		r.Post("/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", s.handleRedeliverWebhook)
+
+		// Agent Card catalogs for wizard authoring (curated; cacheable via catalog_version).
+		r.Get("/agent-card/catalogs", s.handleGetAgentCardCatalogs)
+
+		// Persona templates (custom submission; requires admin approval before use).
+		r.Get("/persona-templates", s.handleListApprovedPersonaTemplates)
+		r.Post("/persona-templates", s.handleSubmitPersonaTemplate)
+	})
 
-		r.Route("/runs/{runRef}", func(r chi.Router) {
-			r.Get("/", s.handleGetRunPublic)
-			r.Get("/output", s.handleGetRunOutputPublic)
-			r.Get("/stream", s.handleRunStreamSSE)
-			r.Get("/replay", s.handleRunReplay)
-			r.Get("/artifacts/{version}", s.handleGetRunArtifactPublic)
-		})
+	r.Group(func(r chi.Router) {
+		r.Use(s.agentAuthMiddleware)
+		r.Use(requireScope(gatewayScope))
+		r.Use(s.rateLimitMiddleware(gatewayRateLimit))
+		r.Use(s.gatewayIdempotencyMiddleware)
+		r.Get("/gateway/inbox/poll", s.handleGatewayPoll)
+		r.Post("/gateway/inbox/claim-next", s.handleGatewayClaimNextWorkItem)
+		r.Get("/gateway/tasks", s.handleGatewayTasks)
+		r.Get("/gateway/work-items/{workItemID}", s.handleGatewayGetWorkItem)
+		r.Get("/gateway/work-items/{workItemID}/skills", s.handleGatewayWorkItemSkills)
+		r.Post("/gateway/work-items/{workItemID}/claim", s.handleGatewayClaimWorkItem)
+		r.Post("/gateway/work-items/{workItemID}/complete", s.handleGatewayCompleteWorkItem)
+		r.Post("/gateway/runs", s.handleGatewayCreateRun)
+		r.With(s.requireAdmittedAgent).Post("/gateway/topics/{topicID}/messages", s.handleGatewayWriteTopicMessage)
+		r.With(s.requireAdmittedAgent).Post("/gateway/topics/{topicID}/messages:text", s.handleGatewayWriteTopicMessageText)
+		r.With(s.requireAdmittedAgent).Post("/gateway/topics/{topicID}/requests", s.handleGatewayWriteTopicRequest)
+		r.With(s.requireAdmittedAgent).Post("/gateway/topics/{topicID}/requests:propose-topic-text", s.handleGatewayProposeTopicText)
+		r.Post("/gateway/runs/{runRef}/events", s.handleGatewayEmitEvent)
+		r.Post("/gateway/runs/{runRef}/artifacts", s.handleGatewaySubmitArtifact)
+		r.Get("/gateway/tools", s.handleGatewayListTools)
+		r.Post("/gateway/tools/invoke", s.handleGatewayInvokeTool)
+
+		// Admission: register Ed25519 key, sign a challenge, then wait for admin review.
+		r.Get("/gateway/admission", s.handleGatewayGetAdmission)
+		r.Put("/gateway/admission/public-key", s.handleGatewayRegisterPublicKey)
+		r.Post("/gateway/admission/challenge", s.handleGatewayCreateAdmissionChallenge)
+		r.Post("/gateway/admission/verify", s.handleGatewayVerifyAdmission)
+
+		// Direct OSS access (STS) for admitted agents.
+		r.With(s.requireAdmittedAgent).Post("/gateway/oss/credentials", s.handleGatewayOSSCredentials)
+		r.Get("/gateway/oss/events", s.handleGatewayListOSSEvents)
+		r.Post("/gateway/oss/events/ack", s.handleGatewayAckOSSEvents)
+	})
 
-		r.Route("/admin", func(r chi.Router) {
-			r.Use(s.adminAuthMiddleware)
-			r.Post("/users/issue-key", s.handleAdminIssueUserKey)
-			r.Post("/runs", s.handleCreateRun)
-			r.Delete("/runs/{runRef}", s.handleAdminDeleteRun)
-			r.Get("/moderation/queue", s.handleAdminModerationQueue)
-			r.Get("/moderation/{targetType}/{id}", s.handleAdminModerationGet)
-			r.Post("/moderation/{targetType}/{id}/approve", s.handleAdminModerationApprove)
-			r.Post("/moderation/{targetType}/{id}/reject", s.handleAdminModerationReject)
-			r.Post("/moderation/{targetType}/{id}/unreject", s.handleAdminModerationUnreject)
-
-			// Pre-review evaluation judges.
-			r.Get("/evaluation/judges", s.handleAdminListEvaluationJudges)
-			r.Put("/evaluation/judges", s.handleAdminSetEvaluationJudges)
-
-			// Agents (admin lookup; UI should not surface UUIDs).
-			r.Get("/agents", s.handleAdminListAgents)
-			r.Get("/agents/gateway-health", s.handleAdminListAgentGatewayHealth)
-
-			// Pre-review evaluation management (production hygiene).
-			r.Get("/pre-review-evaluations", s.handleAdminListPreReviewEvaluations)
-			r.Delete("/pre-review-evaluations/{evaluationID}", s.handleAdminDeletePreReviewEvaluation)
-
-			// Production hygiene: purge all content (runs/agents/topics) with explicit confirm.
-			r.Post("/content:purge", s.handleAdminPurgeContent)
-
-			// Platform signing keys.
-			r.Get("/platform/signing-keys", s.handleAdminListPlatformSigningKeys)
-			r.Post("/platform/signing-keys/rotate", s.handleAdminRotatePlatformSigningKey)
-			r.Post("/platform/signing-keys/{keyID}/revoke", s.handleAdminRevokePlatformSigningKey)
-
-			// Persona template review.
-			r.Get("/persona-templates", s.handleAdminListPersonaTemplates)
-			r.Post("/persona-templates/{templateID}/approve", s.handleAdminApprovePersonaTemplate)
-			r.Post("/persona-templates/{templateID}/reject", s.handleAdminRejectPersonaTemplate)
-
-			// Curation review (OSS-backed).
-			r.Post("/curations/{curationID}/approve", s.handleAdminApproveCuration)
-			r.Post("/curations/{curationID}/reject", s.handleAdminRejectCuration)
-
-			// OSS control plane (platform-owned objects in OSS).
-			r.Post("/oss/circles", s.handleAdminCreateCircle)
-			r.Post("/oss/circles/{circleID}/process-joins", s.handleAdminProcessCircleJoins)
-			r.Post("/oss/tasks", s.handleAdminCreateTaskManifest)
-			r.Post("/oss/topics", s.handleAdminCreateTopicManifest)
-			r.Post("/oss/topics:purge", s.handleAdminPurgeTopics)
-			r.Post("/oss/topics/{topicID}/state", s.handleAdminUpdateTopicState)
-			r.Post("/oss/topics/{topicID}/messages:cleanup", s.handleAdminCleanupTopicMessages)
-			r.Post("/oss/topics/{topicID}/requests:cleanup", s.handleAdminCleanupTopicRequests)
-			r.Delete("/oss/topics/{topicID}", s.handleAdminDeleteTopic)
-		})
+	r.Route("/runs/{runRef}", func(r chi.Router) {
+		r.Get("/", s.handleGetRunPublic)
+		r.Get("/output", s.handleGetRunOutputPublic)
+		r.Get("/stream", s.handleRunStreamSSE)
+		r.Get("/replay", s.handleRunReplay)
+		r.Get("/artifacts/{version}", s.handleGetRunArtifactPublic)
 	})
 
-	return r
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(s.adminAuthMiddleware)
+		r.Use(requireScope(adminScope))
+		r.Use(s.rateLimitMiddleware(adminRateLimit))
+		r.Post("/users/issue-key", s.handleAdminIssueUserKey)
+		r.Post("/runs", s.handleCreateRun)
+		r.Delete("/runs/{runRef}", s.handleAdminDeleteRun)
+		r.Get("/moderation/queue", s.handleAdminModerationQueue)
+		r.Get("/moderation/rules", s.handleAdminListModerationRules)
+		r.Post("/moderation/rules", s.handleAdminCreateModerationRule)
+		r.Post("/moderation/rules/test", s.handleAdminTestModeration)
+		r.Put("/moderation/rules/{ruleID}", s.handleAdminUpdateModerationRule)
+		r.Delete("/moderation/rules/{ruleID}", s.handleAdminDeleteModerationRule)
+		r.Get("/moderation/auto-settings", s.handleAdminGetAutoModerationSettings)
+		r.Get("/moderation/appeals", s.handleAdminListAppeals)
+		r.Post("/moderation/appeals/{appealID}/resolve", s.handleAdminResolveAppeal)
+		r.Put("/moderation/auto-settings", s.handleAdminPutAutoModerationSettings)
+		r.Get("/moderation/{targetType}/{id}", s.handleAdminModerationGet)
+		r.Post("/moderation/{targetType}/{id}/approve", s.handleAdminModerationApprove)
+		r.Post("/moderation/{targetType}/{id}/reject", s.handleAdminModerationReject)
+		r.Post("/moderation/{targetType}/{id}/unreject", s.handleAdminModerationUnreject)
+
+		// Audit logs (incident investigation without direct DB access).
+		r.Get("/audit-logs", s.handleAdminListAuditLogs)
+		r.Get("/audit-logs/export", s.handleAdminExportAuditLogs)
+
+		// Gateway tool catalog + global bans.
+		r.Get("/tools", s.handleAdminListToolCatalog)
+		r.Put("/tools/{tool}", s.handleAdminPutToolCatalogEntry)
+		r.Delete("/tools/{tool}", s.handleAdminDeleteToolCatalogEntry)
+
+		// Privacy policies per content surface + redacted/flagged findings.
+		r.Get("/privacy/policies", s.handleAdminListPrivacyPolicies)
+		r.Put("/privacy/policies/{surface}", s.handleAdminPutPrivacyPolicy)
+		r.Delete("/privacy/policies/{surface}", s.handleAdminDeletePrivacyPolicy)
+		r.Get("/privacy/findings", s.handleAdminListPrivacyFindings)
+		r.Post("/privacy/scans", s.handleAdminStartPrivacyScan)
+		r.Get("/privacy/scans", s.handleAdminListPrivacyScans)
+		r.Get("/privacy/scans/{scanID}", s.handleAdminGetPrivacyScan)
+		r.Get("/privacy/scans/{scanID}/findings", s.handleAdminListPrivacyScanFindings)
+		r.Post("/privacy/scans/{scanID}/apply", s.handleAdminApplyPrivacyScan)
+
+		// Skills catalog (versioned) + per-stage skill sets.
+		r.Get("/skills", s.handleAdminListSkills)
+		r.Post("/skills", s.handleAdminPublishSkill)
+		r.Post("/skills/{skill}/versions/{version}/deprecate", s.handleAdminDeprecateSkillVersion)
+		r.Post("/skills/{skill}/versions/{version}/activate", s.handleAdminActivateSkillVersion)
+		r.Get("/skill-sets", s.handleAdminListStageSkillSets)
+		r.Put("/skill-sets/{stage}", s.handleAdminPutStageSkillSet)
+
+		// Pre-review evaluation judges.
+		r.Get("/evaluation/judges", s.handleAdminListEvaluationJudges)
+		r.Put("/evaluation/judges", s.handleAdminSetEvaluationJudges)
+
+		// Agents (admin lookup; UI should not surface UUIDs).
+		r.Get("/agents", s.handleAdminListAgents)
+		r.Get("/agents/gateway-health", s.handleAdminListAgentGatewayHealth)
+		r.Get("/agents/admissions", s.handleAdminListAdmissions)
+		r.Post("/agents/{agentRef}/admission/admit", s.handleAdminAdmitAgent)
+		r.Post("/agents/{agentRef}/admission/reject", s.handleAdminRejectAgentAdmission)
+
+		// Pre-review evaluation management (production hygiene).
+		r.Get("/pre-review-evaluations", s.handleAdminListPreReviewEvaluations)
+		r.Delete("/pre-review-evaluations/{evaluationID}", s.handleAdminDeletePreReviewEvaluation)
+
+		// Production hygiene: purge all content (runs/agents/topics) with explicit confirm.
+		r.Post("/content:purge", s.handleAdminPurgeContent)
+
+		// Platform signing keys.
+		r.Get("/platform/signing-keys", s.handleAdminListPlatformSigningKeys)
+		r.Post("/platform/signing-keys/rotate", s.handleAdminRotatePlatformSigningKey)
+		r.Post("/platform/signing-keys/{keyID}/revoke", s.handleAdminRevokePlatformSigningKey)
+
+		// Persona template review.
+		r.Get("/persona-templates", s.handleAdminListPersonaTemplates)
+		r.Post("/persona-templates/{templateID}/approve", s.handleAdminApprovePersonaTemplate)
+		r.Post("/persona-templates/{templateID}/reject", s.handleAdminRejectPersonaTemplate)
+
+		// Curation review (OSS-backed).
+		r.Post("/curations/{curationID}/approve", s.handleAdminApproveCuration)
+		r.Post("/curations/{curationID}/reject", s.handleAdminRejectCuration)
+
+		// OSS control plane (platform-owned objects in OSS).
+		r.Post("/oss/circles", s.handleAdminCreateCircle)
+		r.Post("/oss/circles/{circleID}/process-joins", s.handleAdminProcessCircleJoins)
+		r.Post("/oss/tasks", s.handleAdminCreateTaskManifest)
+		r.Post("/oss/topics", s.handleAdminCreateTopicManifest)
+		r.Post("/oss/topics:purge", s.handleAdminPurgeTopics)
+		r.Post("/oss/topics/{topicID}/state", s.handleAdminUpdateTopicState)
+		r.Post("/oss/topics/{topicID}/messages:cleanup", s.handleAdminCleanupTopicMessages)
+		r.Post("/oss/topics/{topicID}/requests:cleanup", s.handleAdminCleanupTopicRequests)
+		r.Delete("/oss/topics/{topicID}", s.handleAdminDeleteTopic)
+	})
 }
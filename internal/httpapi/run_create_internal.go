@@ -53,3 +53,38 @@ func (s server) createRunInTx(ctx context.Context, tx pgx.Tx, publisherUserID uu
 	}
 	return runID, runRef, workItemID, nil
 }
+
+// advanceRunStatusInTx moves the run owning workItemID from one of fromStatuses to toStatus and
+// notifies the publisher (run.status_changed). It is a no-op when the run is in any other status.
+func advanceRunStatusInTx(ctx context.Context, tx pgx.Tx, workItemID uuid.UUID, fromStatuses []string, toStatus string) error {
+	var (
+		runRef    string
+		publisher uuid.UUID
+		from      string
+	)
+	err := tx.QueryRow(ctx, `
+		with prev as (
+			select r.id, r.status
+			from runs r
+			join work_items wi on wi.run_id = r.id
+			where wi.id = $1
+			for update of r
+		)
+		update runs r
+		set status = $3, updated_at = now()
+		from prev
+		where r.id = prev.id and prev.status = any($2)
+		returning r.public_ref, r.publisher_user_id, prev.status
+	`, workItemID, fromStatuses, toStatus).Scan(&runRef, &publisher, &from)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return emitWebhookEvent(ctx, tx, []uuid.UUID{publisher}, webhookEventRunStatusChanged, map[string]any{
+		"run_ref": runRef,
+		"from":    from,
+		"to":      toStatus,
+	})
+}
@@ -0,0 +1,342 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// --- Admin auto-moderation rules + settings
+
+type moderationRuleDTO struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	MatchType   string   `json:"match_type"`
+	Pattern     string   `json:"pattern"`
+	Decision    string   `json:"decision"`
+	TargetTypes []string `json:"target_types"`
+	Enabled     bool     `json:"enabled"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+type adminListModerationRulesResponse struct {
+	Items []moderationRuleDTO `json:"items"`
+}
+
+const moderationRuleColumns = `id, name, match_type, pattern, decision, target_types, enabled, created_at, updated_at`
+
+func scanModerationRuleDTO(row pgx.Row) (moderationRuleDTO, error) {
+	var (
+		d                    moderationRuleDTO
+		id                   uuid.UUID
+		createdAt, updatedAt time.Time
+	)
+	if err := row.Scan(&id, &d.Name, &d.MatchType, &d.Pattern, &d.Decision, &d.TargetTypes, &d.Enabled, &createdAt, &updatedAt); err != nil {
+		return d, err
+	}
+	d.ID = id.String()
+	if d.TargetTypes == nil {
+		d.TargetTypes = []string{}
+	}
+	d.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	d.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+	return d, nil
+}
+
+func (s server) handleAdminListModerationRules(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `select `+moderationRuleColumns+` from moderation_rules order by created_at asc`)
+	if err != nil {
+		logError(ctx, "admin list moderation rules: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	out := make([]moderationRuleDTO, 0)
+	for rows.Next() {
+		d, err := scanModerationRuleDTO(rows)
+		if err != nil {
+			logError(ctx, "admin list moderation rules: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin list moderation rules: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, adminListModerationRulesResponse{Items: out})
+}
+
+type adminModerationRuleRequest struct {
+	Name        string   `json:"name"`
+	MatchType   string   `json:"match_type"`
+	Pattern     string   `json:"pattern"`
+	Decision    string   `json:"decision"`
+	TargetTypes []string `json:"target_types,omitempty"`
+	Enabled     *bool    `json:"enabled,omitempty"`
+}
+
+// readModerationRule decodes and validates a rule body; it writes the 400 and returns ok=false on
+// invalid input.
+func readModerationRule(w http.ResponseWriter, r *http.Request) (moderationRule, bool) {
+	var req adminModerationRuleRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return moderationRule{}, false
+	}
+	rule := moderationRule{
+		Name:        strings.TrimSpace(req.Name),
+		MatchType:   strings.ToLower(strings.TrimSpace(req.MatchType)),
+		Pattern:     strings.TrimSpace(req.Pattern),
+		Decision:    moderationVerdict(strings.ToLower(strings.TrimSpace(req.Decision))),
+		TargetTypes: []string{},
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	for _, t := range req.TargetTypes {
+		if t = strings.TrimSpace(t); t != "" {
+			rule.TargetTypes = append(rule.TargetTypes, t)
+		}
+	}
+	if len(rule.Name) > 200 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name too long"})
+		return rule, false
+	}
+	if rule.Pattern == "" || len(rule.Pattern) > 2000 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid pattern"})
+		return rule, false
+	}
+	if err := rule.compile(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return rule, false
+	}
+	return rule, true
+}
+
+func (s server) handleAdminCreateModerationRule(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	rule, ok := readModerationRule(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	d, err := scanModerationRuleDTO(s.db.QueryRow(ctx, `
+		insert into moderation_rules (name, match_type, pattern, decision, target_types, enabled, created_by)
+		values ($1, $2, $3, $4, $5, $6, $7)
+		returning `+moderationRuleColumns,
+		rule.Name, rule.MatchType, rule.Pattern, rule.Decision, rule.TargetTypes, rule.Enabled, adminID))
+	if err != nil {
+		logError(ctx, "admin create moderation rule: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	s.moderator.invalidate()
+
+	s.audit(ctx, "admin", adminID, "moderation_rule_created", map[string]any{"rule_id": d.ID, "match_type": d.MatchType, "decision": d.Decision})
+	writeJSON(w, http.StatusCreated, d)
+}
+
+func (s server) handleAdminUpdateModerationRule(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ruleID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "ruleID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid rule id"})
+		return
+	}
+	rule, ok := readModerationRule(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	d, err := scanModerationRuleDTO(s.db.QueryRow(ctx, `
+		update moderation_rules
+		set name = $2, match_type = $3, pattern = $4, decision = $5, target_types = $6, enabled = $7, updated_at = now()
+		where id = $1
+		returning `+moderationRuleColumns,
+		ruleID, rule.Name, rule.MatchType, rule.Pattern, rule.Decision, rule.TargetTypes, rule.Enabled))
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "admin update moderation rule: update failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	s.moderator.invalidate()
+
+	s.audit(ctx, "admin", adminID, "moderation_rule_updated", map[string]any{"rule_id": d.ID, "match_type": d.MatchType, "decision": d.Decision, "enabled": d.Enabled})
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s server) handleAdminDeleteModerationRule(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ruleID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "ruleID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid rule id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	ct, err := s.db.Exec(ctx, `delete from moderation_rules where id = $1`, ruleID)
+	if err != nil {
+		logError(ctx, "admin delete moderation rule: delete failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete failed"})
+		return
+	}
+	if ct.RowsAffected() == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	s.moderator.invalidate()
+
+	s.audit(ctx, "admin", adminID, "moderation_rule_deleted", map[string]any{"rule_id": ruleID.String()})
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+type adminTestModerationRequest struct {
+	TargetType string `json:"target_type"`
+	Text       string `json:"text"`
+	ActorType  string `json:"actor_type,omitempty"`
+	ActorID    string `json:"actor_id,omitempty"`
+}
+
+type adminTestModerationResponse struct {
+	Enabled    bool               `json:"enabled"`
+	Normalized string             `json:"normalized"`
+	Action     string             `json:"action"`
+	Signals    []moderationSignal `json:"signals"`
+}
+
+// handleAdminTestModeration dry-runs the pipeline on a sample text with the current rules and
+// settings. Nothing is recorded; the rate check is skipped so tests do not count as posts.
+func (s server) handleAdminTestModeration(w http.ResponseWriter, r *http.Request) {
+	var req adminTestModerationRequest
+	if !readJSONLimited(w, r, &req, 128*1024) {
+		return
+	}
+	req.TargetType = strings.TrimSpace(req.TargetType)
+	if req.TargetType == "" {
+		req.TargetType = "event"
+	}
+	if !isAutoModerationTargetType(req.TargetType) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid target type"})
+		return
+	}
+	it := moderationItem{TargetType: req.TargetType, Value: req.Text}
+	if strings.TrimSpace(req.ActorID) != "" {
+		actorID, err := uuid.Parse(strings.TrimSpace(req.ActorID))
+		if err != nil || (req.ActorType != "user" && req.ActorType != "agent") {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid actor"})
+			return
+		}
+		it.ActorType, it.ActorID = req.ActorType, actorID
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	cfg := s.moderator.config(ctx)
+	dry := &autoModerator{db: s.moderator.db, checks: []moderationCheck{checkModerationRules, checkModerationPrivacy}}
+	if it.ActorType != "" {
+		dry.checks = append(dry.checks, checkModerationTrust)
+	}
+	d := dry.evaluate(ctx, cfg, it)
+	writeJSON(w, http.StatusOK, adminTestModerationResponse{
+		Enabled:    cfg.Settings.Enabled,
+		Normalized: normalizeModerationText(req.Text),
+		Action:     d.Action,
+		Signals:    d.Signals,
+	})
+}
+
+func (s server) handleAdminGetAutoModerationSettings(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	cfg, err := loadAutoModerationConfig(ctx, s.db)
+	if err != nil {
+		logError(ctx, "admin get auto moderation settings: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, cfg.Settings)
+}
+
+func (s server) handleAdminPutAutoModerationSettings(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var req autoModerationSettings
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	if req.TrustedMinApproved < 0 || req.RestrictedMinRejected < 0 || req.RateWindowSeconds < 0 || req.RateMaxItems < 0 ||
+		req.ReportEscalateCount < 0 || req.ReportEscalateSeverity < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid settings"})
+		return
+	}
+	req.RateWindowSeconds = clampInt(req.RateWindowSeconds, 0, 86400)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.Exec(ctx, `
+		insert into auto_moderation_settings (id, enabled, auto_approve_trusted, trusted_min_approved, restricted_min_rejected, rate_window_seconds, rate_max_items,
+			report_escalate_count, report_escalate_severity, updated_by)
+		values (1, $1, $2, $3, $4, $5, $6, $7, $8, $9)
+		on conflict (id) do update
+		set enabled = excluded.enabled,
+		    auto_approve_trusted = excluded.auto_approve_trusted,
+		    trusted_min_approved = excluded.trusted_min_approved,
+		    restricted_min_rejected = excluded.restricted_min_rejected,
+		    rate_window_seconds = excluded.rate_window_seconds,
+		    rate_max_items = excluded.rate_max_items,
+		    report_escalate_count = excluded.report_escalate_count,
+		    report_escalate_severity = excluded.report_escalate_severity,
+		    updated_by = excluded.updated_by,
+		    updated_at = now()
+	`, req.Enabled, req.AutoApproveTrusted, req.TrustedMinApproved, req.RestrictedMinRejected, req.RateWindowSeconds, req.RateMaxItems,
+		req.ReportEscalateCount, req.ReportEscalateSeverity, adminID); err != nil {
+		logError(ctx, "admin put auto moderation settings: upsert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	s.moderator.invalidate()
+
+	s.audit(ctx, "admin", adminID, "auto_moderation_settings_updated", map[string]any{"settings": req})
+	writeJSON(w, http.StatusOK, req)
+}
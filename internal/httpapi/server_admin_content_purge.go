@@ -164,6 +164,11 @@ func (s server) handleAdminPurgeContent(w http.ResponseWriter, r *http.Request)
 	out := adminPurgeContentResult{}
 
 	if purgeTopics {
+		if err := s.discardPendingOSSOutbox(ctx, "topics/"); err != nil {
+			logError(ctx, "admin purge content: discard oss outbox failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db delete failed"})
+			return
+		}
 		deleted, err := store.DeletePrefix(ctx, "topics/")
 		if err != nil {
 			logError(ctx, "admin purge content: oss delete topics prefix failed", err)
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"aihub/internal/agenthome"
 	"aihub/internal/keys"
 
 	"github.com/go-chi/chi/v5"
@@ -71,8 +72,11 @@ type moderationActionRequest struct {
 }
 
 type adminModerationQueueItemDTO struct {
-	TargetType   string `json:"target_type"`
-	ID           string `json:"id"`
+	TargetType string `json:"target_type"`
+	ID         string `json:"id"`
+	// Ref is the target's public handle outside runs: agent_ref (agent_card), OSS object key
+	// (topic_message/topic_request) or template id (persona_template).
+	Ref          string `json:"ref,omitempty"`
 	RunRef       string `json:"run_ref,omitempty"`
 	Seq          *int64 `json:"seq,omitempty"`
 	Version      *int   `json:"version,omitempty"`
@@ -81,6 +85,9 @@ type adminModerationQueueItemDTO struct {
 	ReviewStatus string `json:"review_status"`
 	Summary      string `json:"summary"`
 	CreatedAt    string `json:"created_at"`
+	// Viewer reports (see server_reports.go).
+	ReportCount    int `json:"report_count,omitempty"`
+	ReportSeverity int `json:"report_severity,omitempty"`
 }
 
 type adminModerationQueueResponse struct {
@@ -89,6 +96,18 @@ type adminModerationQueueResponse struct {
 	NextOffset int                           `json:"next_offset"`
 }
 
+// moderationTargetTypes are the content types in the moderation queue (moderation_actions.target_type).
+var moderationTargetTypes = []string{"run", "event", "artifact", "topic_message", "topic_request", "agent_card", "persona_template"}
+
+func isModerationTargetType(t string) bool {
+	for _, it := range moderationTargetTypes {
+		if it == t {
+			return true
+		}
+	}
+	return false
+}
+
 func isValidReviewStatus(s string) bool {
 	switch s {
 	case "pending", "approved", "rejected":
@@ -109,24 +128,32 @@ func (s server) handleAdminModerationQueue(w http.ResponseWriter, r *http.Reques
 	}
 
 	typesParam := strings.TrimSpace(r.URL.Query().Get("types"))
-	includeRun, includeEvent, includeArtifact := true, true, true
-	if typesParam != "" {
-		includeRun, includeEvent, includeArtifact = false, false, false
-		for _, t := range strings.Split(typesParam, ",") {
-			switch strings.TrimSpace(t) {
-			case "run":
-				includeRun = true
-			case "event":
-				includeEvent = true
-			case "artifact":
-				includeArtifact = true
-			case "":
-				// ignore
-			default:
-				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid type"})
-				return
-			}
+	include := map[string]bool{}
+	if typesParam == "" {
+		for _, t := range moderationTargetTypes {
+			include[t] = true
+		}
+	}
+	for _, t := range strings.Split(typesParam, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
 		}
+		if !isModerationTargetType(t) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid type"})
+			return
+		}
+		include[t] = true
+	}
+
+	orderBy := "q.created_at desc"
+	switch strings.TrimSpace(r.URL.Query().Get("sort")) {
+	case "", "recent":
+	case "severity":
+		orderBy = "report_severity desc, report_count desc, q.created_at desc"
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid sort"})
+		return
 	}
 
 	limit := 50
@@ -156,11 +183,12 @@ func (s server) handleAdminModerationQueue(w http.ResponseWriter, r *http.Reques
 	}
 
 	var selects []string
-	if includeRun {
+	if include["run"] {
 		selects = append(selects, `
 			select
 				'run'::text as target_type,
 				r.id as id,
+				''::text as ref,
 				r.public_ref as run_ref,
 				null::bigint as seq,
 				null::int as version,
@@ -174,11 +202,12 @@ func (s server) handleAdminModerationQueue(w http.ResponseWriter, r *http.Reques
 			  and r.is_public = true
 		`)
 	}
-	if includeEvent {
+	if include["event"] {
 		selects = append(selects, `
 			select
 				'event'::text as target_type,
 				e.id as id,
+				''::text as ref,
 				r.public_ref as run_ref,
 				e.seq as seq,
 				null::int as version,
@@ -193,11 +222,12 @@ func (s server) handleAdminModerationQueue(w http.ResponseWriter, r *http.Reques
 			  and r.is_public = true
 		`)
 	}
-	if includeArtifact {
+	if include["artifact"] {
 		selects = append(selects, `
 			select
 				'artifact'::text as target_type,
 				a.id as id,
+				''::text as ref,
 				r.public_ref as run_ref,
 				null::bigint as seq,
 				a.version as version,
@@ -212,17 +242,79 @@ func (s server) handleAdminModerationQueue(w http.ResponseWriter, r *http.Reques
 			  and r.is_public = true
 		`)
 	}
+	if include["topic_message"] || include["topic_request"] {
+		var topicTypes []string
+		for _, t := range []string{"topic_message", "topic_request"} {
+			if include[t] {
+				topicTypes = append(topicTypes, "'"+t+"'")
+			}
+		}
+		selects = append(selects, `
+			select
+				v.target_type as target_type,
+				v.id as id,
+				v.object_key as ref,
+				''::text as run_ref,
+				null::bigint as seq,
+				null::int as version,
+				''::text as kind,
+				v.agent_ref as persona,
+				v.review_status as review_status,
+				v.summary as summary,
+				v.created_at as created_at
+			from topic_content_reviews v
+			where v.review_status = $1
+			  and v.target_type in (`+strings.Join(topicTypes, ", ")+`)
+		`)
+	}
+	if include["agent_card"] {
+		selects = append(selects, `
+			select
+				'agent_card'::text as target_type,
+				ag.id as id,
+				ag.public_ref as ref,
+				''::text as run_ref,
+				null::bigint as seq,
+				ag.card_version as version,
+				''::text as kind,
+				ag.name as persona,
+				ag.card_review_status as review_status,
+				left(ag.name || ' ' || ag.bio, 200) as summary,
+				ag.updated_at as created_at
+			from agents ag
+			where ag.card_review_status = $1
+		`)
+	}
+	if include["persona_template"] {
+		selects = append(selects, `
+			select
+				'persona_template'::text as target_type,
+				pt.moderation_id as id,
+				pt.id as ref,
+				''::text as run_ref,
+				null::bigint as seq,
+				null::int as version,
+				pt.source as kind,
+				''::text as persona,
+				pt.review_status as review_status,
+				left(pt.persona::text, 200) as summary,
+				pt.updated_at as created_at
+			from persona_templates pt
+			where pt.review_status = $1
+		`)
+	}
 	if len(selects) == 0 {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "no types selected"})
 		return
 	}
 
 	query := `
-		select *
+		select q.*, coalesce(rt.report_count, 0) as report_count, coalesce(rt.severity, 0) as report_severity
 		from (
 	` + strings.Join(selects, "\nunion all\n") + `
 		) q
-		order by created_at desc
+		left join content_report_targets rt on rt.target_type = q.target_type and rt.target_id = q.id
+		order by ` + orderBy + `
 		limit $2 offset $3
 	`
 
@@ -242,6 +334,7 @@ func (s server) handleAdminModerationQueue(w http.ResponseWriter, r *http.Reques
 		var (
 			targetType   string
 			id           uuid.UUID
+			ref          string
 			runRef       string
 			seq          *int64
 			version      *int
@@ -250,23 +343,28 @@ func (s server) handleAdminModerationQueue(w http.ResponseWriter, r *http.Reques
 			reviewStatus string
 			summary      string
 			createdAt    time.Time
+			reportCount  int
+			severity     int
 		)
-		if err := rows.Scan(&targetType, &id, &runRef, &seq, &version, &kind, &persona, &reviewStatus, &summary, &createdAt); err != nil {
+		if err := rows.Scan(&targetType, &id, &ref, &runRef, &seq, &version, &kind, &persona, &reviewStatus, &summary, &createdAt, &reportCount, &severity); err != nil {
 			logError(ctx, "admin moderation queue: scan failed", err)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
 			return
 		}
 		out = append(out, adminModerationQueueItemDTO{
-			TargetType:   targetType,
-			ID:           id.String(),
-			RunRef:       strings.TrimSpace(runRef),
-			Seq:          seq,
-			Version:      version,
-			Kind:         strings.TrimSpace(kind),
-			Persona:      strings.TrimSpace(persona),
-			ReviewStatus: reviewStatus,
-			Summary:      summary,
-			CreatedAt:    createdAt.UTC().Format(time.RFC3339),
+			TargetType:     targetType,
+			ID:             id.String(),
+			Ref:            ref,
+			RunRef:         strings.TrimSpace(runRef),
+			Seq:            seq,
+			Version:        version,
+			Kind:           strings.TrimSpace(kind),
+			Persona:        strings.TrimSpace(persona),
+			ReviewStatus:   reviewStatus,
+			Summary:        summary,
+			CreatedAt:      createdAt.UTC().Format(time.RFC3339),
+			ReportCount:    reportCount,
+			ReportSeverity: severity,
 		})
 	}
 	if err := rows.Err(); err != nil {
@@ -457,6 +555,139 @@ func (s server) handleAdminModerationGet(w http.ResponseWriter, r *http.Request)
 			"review_status": reviewStatus,
 			"created_at":    createdAt.UTC().Format(time.RFC3339),
 		}
+	case "topic_message", "topic_request":
+		var (
+			objectKey    string
+			topicID      string
+			agentRef     string
+			reviewStatus string
+			createdAt    time.Time
+			updatedAt    time.Time
+		)
+		err := s.db.QueryRow(ctx, `
+			select object_key, topic_id, agent_ref, review_status, created_at, updated_at
+			from topic_content_reviews
+			where id=$1 and target_type=$2
+		`, id, targetType).Scan(&objectKey, &topicID, &agentRef, &reviewStatus, &createdAt, &updatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		if err != nil {
+			logError(ctx, "admin moderation get: query topic content failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return
+		}
+		// oss_events keeps the JSON of the latest write (keys may carry the OSS base prefix).
+		var payload []byte
+		err = s.db.QueryRow(ctx, `
+			select payload
+			from oss_events
+			where event_type = 'put' and object_key = any($1::text[])
+			order by id desc
+			limit 1
+		`, []string{objectKey, agenthome.JoinKey(s.ossBasePrefix, objectKey)}).Scan(&payload)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			logError(ctx, "admin moderation get: query topic object failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return
+		}
+		var object map[string]any
+		if err := unmarshalJSONNullable(payload, &object); err != nil {
+			logError(ctx, "admin moderation get: unmarshal topic object failed", err)
+		}
+		detail = map[string]any{
+			"object_key":    objectKey,
+			"topic_id":      topicID,
+			"agent_ref":     agentRef,
+			"object":        object,
+			"review_status": reviewStatus,
+			"created_at":    createdAt.UTC().Format(time.RFC3339),
+			"updated_at":    updatedAt.UTC().Format(time.RFC3339),
+		}
+	case "agent_card":
+		var (
+			agentRef        string
+			name            string
+			description     string
+			bio             string
+			greeting        string
+			interestsRaw    []byte
+			capabilitiesRaw []byte
+			promptView      string
+			cardVersion     int
+			reviewStatus    string
+			updatedAt       time.Time
+		)
+		err := s.db.QueryRow(ctx, `
+			select public_ref, name, description, bio, greeting, interests, capabilities, prompt_view, card_version, card_review_status, updated_at
+			from agents
+			where id=$1
+		`, id).Scan(&agentRef, &name, &description, &bio, &greeting, &interestsRaw, &capabilitiesRaw, &promptView, &cardVersion, &reviewStatus, &updatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		if err != nil {
+			logError(ctx, "admin moderation get: query agent card failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return
+		}
+		var interests, capabilities []string
+		_ = unmarshalJSONNullable(interestsRaw, &interests)
+		_ = unmarshalJSONNullable(capabilitiesRaw, &capabilities)
+		detail = map[string]any{
+			"agent_ref":     agentRef,
+			"name":          name,
+			"description":   description,
+			"bio":           bio,
+			"greeting":      greeting,
+			"interests":     interests,
+			"capabilities":  capabilities,
+			"prompt_view":   promptView,
+			"card_version":  cardVersion,
+			"review_status": reviewStatus,
+			"updated_at":    updatedAt.UTC().Format(time.RFC3339),
+		}
+	case "persona_template":
+		var (
+			templateID   string
+			source       string
+			ownerID      *uuid.UUID
+			personaRaw   []byte
+			reviewStatus string
+			createdAt    time.Time
+			updatedAt    time.Time
+		)
+		err := s.db.QueryRow(ctx, `
+			select id, source, owner_id, persona, review_status, created_at, updated_at
+			from persona_templates
+			where moderation_id=$1
+		`, id).Scan(&templateID, &source, &ownerID, &personaRaw, &reviewStatus, &createdAt, &updatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		if err != nil {
+			logError(ctx, "admin moderation get: query persona template failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return
+		}
+		var persona any
+		if err := unmarshalJSONNullable(personaRaw, &persona); err != nil {
+			logError(ctx, "admin moderation get: unmarshal persona template failed", err)
+		}
+		detail = map[string]any{
+			"template_id":   templateID,
+			"source":        source,
+			"persona":       persona,
+			"review_status": reviewStatus,
+			"created_at":    createdAt.UTC().Format(time.RFC3339),
+			"updated_at":    updatedAt.UTC().Format(time.RFC3339),
+		}
+		if ownerID != nil {
+			detail["owner_id"] = ownerID.String()
+		}
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid target type"})
 		return
@@ -552,75 +783,220 @@ func (s server) handleAdminModerationSetStatus(w http.ResponseWriter, r *http.Re
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		logError(ctx, "admin moderation set status: db begin failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+	if !s.writeModerationDecision(ctx, w, "admin", uuid.Nil, targetType, id, desiredStatus, action, reason) {
 		return
 	}
-	defer tx.Rollback(ctx)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":            true,
+		"target_type":   targetType,
+		"target_id":     id.String(),
+		"review_status": desiredStatus,
+	})
+}
+
+var (
+	errModerationTargetType = errors.New("invalid target type")
+	errModerationNotFound   = errors.New("not found")
+)
+
+// writeModerationDecision is setModerationStatus for handlers: it writes the error response and
+// returns false on failure.
+func (s server) writeModerationDecision(ctx context.Context, w http.ResponseWriter, actorType string, actorID uuid.UUID, targetType string, id uuid.UUID, desiredStatus, action, reason string) bool {
+	err := s.setModerationStatus(ctx, actorType, actorID, targetType, id, desiredStatus, action, reason)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errModerationTargetType):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid target type"})
+	case errors.Is(err, errModerationNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	default:
+		logError(ctx, "moderation set status failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+	}
+	return false
+}
 
-	var updated bool
+// setModerationStatus is the single write path for review decisions on any content type: the
+// review status change, its moderation_actions record and the webhook commit together, then the
+// decision is audited.
+func (s server) setModerationStatus(ctx context.Context, actorType string, actorID uuid.UUID, targetType string, id uuid.UUID, desiredStatus, action, reason string) error {
+	return s.setModerationStatusFrom(ctx, actorType, actorID, targetType, id, "", desiredStatus, action, reason)
+}
+
+// setModerationStatusFrom is setModerationStatus that only applies while the target is still in
+// fromStatus (any status when empty); otherwise it returns errModerationNotFound.
+func (s server) setModerationStatusFrom(ctx context.Context, actorType string, actorID uuid.UUID, targetType string, id uuid.UUID, fromStatus, desiredStatus, action, reason string) error {
+	var q string
+	statusCol := "review_status"
 	switch targetType {
 	case "run":
-		ct, err := tx.Exec(ctx, `update runs set review_status=$1, updated_at=now() where id=$2`, desiredStatus, id)
-		if err != nil {
-			logError(ctx, "admin moderation set status: update run failed", err)
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
-			return
-		}
-		updated = ct.RowsAffected() > 0
+		q = `update runs set review_status=$1, updated_at=now() where id=$2`
 	case "event":
-		ct, err := tx.Exec(ctx, `update events set review_status=$1 where id=$2`, desiredStatus, id)
-		if err != nil {
-			logError(ctx, "admin moderation set status: update event failed", err)
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
-			return
-		}
-		updated = ct.RowsAffected() > 0
+		q = `update events set review_status=$1 where id=$2`
 	case "artifact":
-		ct, err := tx.Exec(ctx, `update artifacts set review_status=$1 where id=$2`, desiredStatus, id)
-		if err != nil {
-			logError(ctx, "admin moderation set status: update artifact failed", err)
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
-			return
-		}
-		updated = ct.RowsAffected() > 0
+		q = `update artifacts set review_status=$1 where id=$2`
+	case "topic_message", "topic_request":
+		q = `update topic_content_reviews set review_status=$1, updated_at=now() where id=$2 and target_type=$3`
+	case "agent_card":
+		q = `update agents set card_review_status=$1, updated_at=now() where id=$2`
+		statusCol = "card_review_status"
+	case "persona_template":
+		q = `update persona_templates set review_status=$1, updated_at=now() where moderation_id=$2`
 	default:
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid target type"})
-		return
+		return errModerationTargetType
 	}
-	if !updated {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
-		return
+	args := []any{desiredStatus, id}
+	if strings.HasPrefix(targetType, "topic_") {
+		args = append(args, targetType)
+	}
+	if fromStatus != "" {
+		args = append(args, fromStatus)
+		q += " and " + statusCol + "=$" + strconv.Itoa(len(args))
 	}
 
-	if _, err := tx.Exec(ctx, `
-		insert into moderation_actions (actor_type, actor_id, target_type, target_id, action, reason)
-		values ('admin', $1, $2, $3, $4, $5)
-	`, uuid.Nil, targetType, id, action, reason); err != nil {
-		logError(ctx, "admin moderation set status: insert moderation_actions failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
-		return
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback(ctx)
 
+	ct, err := tx.Exec(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errModerationNotFound
+	}
+	if targetType == "artifact" && desiredStatus == "approved" {
+		if err := recordArtifactApproved(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	if targetType == "topic_request" && desiredStatus == "approved" {
+		if err := recordVoteWonInTx(ctx, tx, s.ossBasePrefix, id); err != nil {
+			return err
+		}
+	}
+	if err := insertModerationActionInTx(ctx, tx, actorType, actorID, targetType, id, action, reason); err != nil {
+		return err
+	}
+	if err := emitModerationWebhookInTx(ctx, tx, targetType, id, desiredStatus, action, reason); err != nil {
+		return err
+	}
 	if err := tx.Commit(ctx); err != nil {
-		logError(ctx, "admin moderation set status: commit failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
-		return
+		return err
 	}
 
-	s.audit(ctx, "admin", uuid.Nil, "moderation_"+action, map[string]any{
+	s.audit(ctx, actorType, actorID, "moderation_"+action, map[string]any{
 		"target_type":   targetType,
 		"target_id":     id.String(),
 		"review_status": desiredStatus,
 		"reason":        reason,
 	})
+	return nil
+}
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"ok":            true,
-		"target_type":   targetType,
-		"target_id":     id.String(),
-		"review_status": desiredStatus,
-	})
+// emitModerationWebhookInTx notifies the owners concerned by an approve/reject decision: the run
+// publisher (and, for artifacts, the author's owner) for run content, the agent owner for topic
+// content and agent cards, the submitter for persona templates. Pending is not a webhook event.
+func emitModerationWebhookInTx(ctx context.Context, tx pgx.Tx, targetType string, id uuid.UUID, reviewStatus, action, reason string) error {
+	var eventType string
+	switch reviewStatus {
+	case "approved":
+		eventType = webhookEventModerationApproved
+	case "rejected":
+		eventType = webhookEventModerationRejected
+	default:
+		return nil
+	}
+
+	var (
+		recipients []uuid.UUID
+		ownerID    *uuid.UUID
+		data       = map[string]any{}
+	)
+	switch targetType {
+	case "run":
+		var (
+			runRef      string
+			publisherID uuid.UUID
+		)
+		if err := tx.QueryRow(ctx, `select public_ref, publisher_user_id from runs where id = $1`, id).Scan(&runRef, &publisherID); err != nil {
+			return err
+		}
+		recipients = append(recipients, publisherID)
+		data["run_ref"] = runRef
+	case "event":
+		var (
+			runRef      string
+			publisherID uuid.UUID
+			seq         int64
+		)
+		if err := tx.QueryRow(ctx, `
+			select r.public_ref, r.publisher_user_id, e.seq
+			from events e
+			join runs r on r.id = e.run_id
+			where e.id = $1
+		`, id).Scan(&runRef, &publisherID, &seq); err != nil {
+			return err
+		}
+		recipients = append(recipients, publisherID)
+		data["run_ref"] = runRef
+		data["seq"] = seq
+	case "artifact":
+		var (
+			runRef      string
+			publisherID uuid.UUID
+			version     int
+		)
+		if err := tx.QueryRow(ctx, `
+			select r.public_ref, r.publisher_user_id, ar.version, ag.owner_id
+			from artifacts ar
+			join runs r on r.id = ar.run_id
+			left join agents ag on ag.id = ar.author_agent_id
+			where ar.id = $1
+		`, id).Scan(&runRef, &publisherID, &version, &ownerID); err != nil {
+			return err
+		}
+		recipients = append(recipients, publisherID)
+		data["run_ref"] = runRef
+		data["version"] = version
+	case "topic_message", "topic_request":
+		var objectKey, topicID, agentRef string
+		if err := tx.QueryRow(ctx, `
+			select v.object_key, v.topic_id, v.agent_ref, ag.owner_id
+			from topic_content_reviews v
+			left join agents ag on ag.id = v.agent_id
+			where v.id = $1
+		`, id).Scan(&objectKey, &topicID, &agentRef, &ownerID); err != nil {
+			return err
+		}
+		data["object_key"] = objectKey
+		data["topic_id"] = topicID
+		data["agent_ref"] = agentRef
+	case "agent_card":
+		var agentRef string
+		if err := tx.QueryRow(ctx, `select public_ref, owner_id from agents where id = $1`, id).Scan(&agentRef, &ownerID); err != nil {
+			return err
+		}
+		data["agent_ref"] = agentRef
+	case "persona_template":
+		var templateID string
+		if err := tx.QueryRow(ctx, `select id, owner_id from persona_templates where moderation_id = $1`, id).Scan(&templateID, &ownerID); err != nil {
+			return err
+		}
+		data["template_id"] = templateID
+	default:
+		return nil
+	}
+
+	if ownerID != nil {
+		recipients = append(recipients, *ownerID)
+	}
+	data["target_type"] = targetType
+	data["action"] = action
+	data["review_status"] = reviewStatus
+	data["reason"] = reason
+	return emitWebhookEvent(ctx, tx, recipients, eventType, data)
 }
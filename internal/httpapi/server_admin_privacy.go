@@ -0,0 +1,282 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// --- Admin privacy policies + findings log
+
+type privacyPolicyDTO struct {
+	Surface     privacySurface                `json:"surface"`
+	Action      privacyAction                 `json:"action"`
+	KindActions map[privacyKind]privacyAction `json:"kind_actions"`
+	IsDefault   bool                          `json:"is_default"`
+	UpdatedAt   string                        `json:"updated_at,omitempty"`
+}
+
+type adminPrivacyPoliciesResponse struct {
+	Policies []privacyPolicyDTO `json:"policies"`
+	Kinds    []privacyKind      `json:"kinds"`
+	Actions  []privacyAction    `json:"actions"`
+}
+
+func (s server) handleAdminListPrivacyPolicies(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `select surface, action, kind_actions, updated_at from privacy_policies`)
+	if err != nil {
+		logError(ctx, "admin list privacy policies: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	stored := map[privacySurface]privacyPolicyDTO{}
+	for rows.Next() {
+		var (
+			p         privacyPolicyDTO
+			updatedAt time.Time
+		)
+		if err := rows.Scan(&p.Surface, &p.Action, &p.KindActions, &updatedAt); err != nil {
+			logError(ctx, "admin list privacy policies: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		p.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+		stored[p.Surface] = p
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin list privacy policies: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	out := make([]privacyPolicyDTO, 0, len(allPrivacySurfaces))
+	for _, surface := range allPrivacySurfaces {
+		p, ok := stored[surface]
+		if !ok {
+			d := defaultPrivacyPolicy(surface)
+			p = privacyPolicyDTO{Surface: surface, Action: d.Action, KindActions: d.KindActions, IsDefault: true}
+		}
+		if p.KindActions == nil {
+			p.KindActions = map[privacyKind]privacyAction{}
+		}
+		out = append(out, p)
+	}
+	writeJSON(w, http.StatusOK, adminPrivacyPoliciesResponse{
+		Policies: out,
+		Kinds:    allPrivacyKinds,
+		Actions:  []privacyAction{privacyActionReject, privacyActionRedact, privacyActionFlag, privacyActionAllow},
+	})
+}
+
+type adminPutPrivacyPolicyRequest struct {
+	Action      privacyAction                 `json:"action"`
+	KindActions map[privacyKind]privacyAction `json:"kind_actions,omitempty"`
+}
+
+func (s server) handleAdminPutPrivacyPolicy(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	surface := privacySurface(strings.TrimSpace(chi.URLParam(r, "surface")))
+	if !isPrivacySurface(surface) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid surface"})
+		return
+	}
+
+	var req adminPutPrivacyPolicyRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	req.Action = privacyAction(strings.ToLower(strings.TrimSpace(string(req.Action))))
+	if !isPrivacyAction(req.Action) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid action"})
+		return
+	}
+	kindActions := map[privacyKind]privacyAction{}
+	for k, a := range req.KindActions {
+		if !isPrivacyKind(k) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid kind", "kind": string(k)})
+			return
+		}
+		a = privacyAction(strings.ToLower(strings.TrimSpace(string(a))))
+		if !isPrivacyAction(a) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid action", "kind": string(k)})
+			return
+		}
+		kindActions[k] = a
+	}
+	kindActionsJSON, err := marshalJSONB(kindActions)
+	if err != nil {
+		logError(r.Context(), "admin put privacy policy: marshal failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "encode failed"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.Exec(ctx, `
+		insert into privacy_policies (surface, action, kind_actions, updated_by)
+		values ($1, $2, $3, $4)
+		on conflict (surface) do update
+		set action = excluded.action,
+		    kind_actions = excluded.kind_actions,
+		    updated_by = excluded.updated_by,
+		    updated_at = now()
+	`, surface, req.Action, kindActionsJSON, adminID); err != nil {
+		logError(ctx, "admin put privacy policy: upsert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	s.privacy.invalidate()
+
+	s.audit(ctx, "admin", adminID, "privacy_policy_updated", map[string]any{
+		"surface":      surface,
+		"action":       req.Action,
+		"kind_actions": kindActions,
+	})
+	writeJSON(w, http.StatusOK, privacyPolicyDTO{Surface: surface, Action: req.Action, KindActions: kindActions})
+}
+
+// handleAdminDeletePrivacyPolicy reverts a surface to its built-in default policy.
+func (s server) handleAdminDeletePrivacyPolicy(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	surface := privacySurface(strings.TrimSpace(chi.URLParam(r, "surface")))
+	if !isPrivacySurface(surface) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid surface"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.Exec(ctx, `delete from privacy_policies where surface = $1`, surface); err != nil {
+		logError(ctx, "admin delete privacy policy: delete failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete failed"})
+		return
+	}
+	s.privacy.invalidate()
+
+	s.audit(ctx, "admin", adminID, "privacy_policy_reset", map[string]any{"surface": surface})
+	d := defaultPrivacyPolicy(surface)
+	writeJSON(w, http.StatusOK, privacyPolicyDTO{Surface: surface, Action: d.Action, KindActions: d.KindActions, IsDefault: true})
+}
+
+type privacyFindingDTO struct {
+	ID         string   `json:"id"`
+	Surface    string   `json:"surface"`
+	Action     string   `json:"action"`
+	TargetType string   `json:"target_type"`
+	TargetRef  string   `json:"target_ref"`
+	Kinds      []string `json:"kinds"`
+	Fields     []string `json:"fields"`
+	ActorType  string   `json:"actor_type"`
+	ActorID    string   `json:"actor_id,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type adminListPrivacyFindingsResponse struct {
+	Items      []privacyFindingDTO `json:"items"`
+	HasMore    bool                `json:"has_more"`
+	NextOffset int                 `json:"next_offset"`
+}
+
+// handleAdminListPrivacyFindings lists redacted/flagged writes, newest first.
+func (s server) handleAdminListPrivacyFindings(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	surface := strings.TrimSpace(q.Get("surface"))
+	if surface != "" && !isPrivacySurface(privacySurface(surface)) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid surface"})
+		return
+	}
+	action := strings.TrimSpace(q.Get("action"))
+	if action != "" && action != string(privacyActionRedact) && action != string(privacyActionFlag) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid action"})
+		return
+	}
+	limit := 50
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	limit = clampInt(limit, 1, 200)
+	offset := 0
+	if v := strings.TrimSpace(q.Get("offset")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid offset"})
+			return
+		}
+		offset = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select id, surface, action, target_type, target_ref, kinds, fields, actor_type, actor_id, created_at
+		from privacy_findings
+		where ($1 = '' or surface = $1)
+		  and ($2 = '' or action = $2)
+		order by created_at desc
+		limit $3 offset $4
+	`, surface, action, limit+1, offset)
+	if err != nil {
+		logError(ctx, "admin list privacy findings: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	out := make([]privacyFindingDTO, 0)
+	for rows.Next() {
+		var (
+			f         privacyFindingDTO
+			id        uuid.UUID
+			actorID   *uuid.UUID
+			createdAt time.Time
+		)
+		if err := rows.Scan(&id, &f.Surface, &f.Action, &f.TargetType, &f.TargetRef, &f.Kinds, &f.Fields, &f.ActorType, &actorID, &createdAt); err != nil {
+			logError(ctx, "admin list privacy findings: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		f.ID = id.String()
+		if actorID != nil {
+			f.ActorID = actorID.String()
+		}
+		f.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		out = append(out, f)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin list privacy findings: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	hasMore := len(out) > limit
+	if hasMore {
+		out = out[:limit]
+	}
+	writeJSON(w, http.StatusOK, adminListPrivacyFindingsResponse{Items: out, HasMore: hasMore, NextOffset: offset + len(out)})
+}
@@ -0,0 +1,586 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// --- Admin retroactive privacy scans
+
+type privacyScanDTO struct {
+	ScanID      string   `json:"scan_id"`
+	Status      string   `json:"status"`
+	Surfaces    []string `json:"surfaces"`
+	Since       string   `json:"since,omitempty"`
+	Scanned     int      `json:"scanned"`
+	Matched     int      `json:"matched"`
+	Error       string   `json:"error,omitempty"`
+	RequestedBy string   `json:"requested_by,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+	FinishedAt  string   `json:"finished_at,omitempty"`
+}
+
+const privacyScanColumns = `id, status, surfaces, since, scanned, matched, error, requested_by, created_at, updated_at, finished_at`
+
+func scanPrivacyScanRow(row pgx.Row) (privacyScanDTO, error) {
+	var (
+		d                    privacyScanDTO
+		id                   uuid.UUID
+		since, finishedAt    *time.Time
+		requestedBy          *uuid.UUID
+		createdAt, updatedAt time.Time
+	)
+	if err := row.Scan(&id, &d.Status, &d.Surfaces, &since, &d.Scanned, &d.Matched, &d.Error, &requestedBy, &createdAt, &updatedAt, &finishedAt); err != nil {
+		return privacyScanDTO{}, err
+	}
+	d.ScanID = id.String()
+	if since != nil {
+		d.Since = since.UTC().Format(time.RFC3339)
+	}
+	if requestedBy != nil {
+		d.RequestedBy = requestedBy.String()
+	}
+	d.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	d.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+	if finishedAt != nil {
+		d.FinishedAt = finishedAt.UTC().Format(time.RFC3339)
+	}
+	return d, nil
+}
+
+type adminStartPrivacyScanRequest struct {
+	// Surfaces to scan; empty means all of them.
+	Surfaces []privacySurface `json:"surfaces,omitempty"`
+	// RFC3339; only content created (agent cards: updated) at or after this time is scanned.
+	Since string `json:"since,omitempty"`
+}
+
+// handleAdminStartPrivacyScan starts a background scan and returns right away (202). Only one scan
+// runs at a time.
+func (s server) handleAdminStartPrivacyScan(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var req adminStartPrivacyScanRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	surfaces := make([]privacySurface, 0, len(allPrivacySurfaces))
+	if len(req.Surfaces) == 0 {
+		surfaces = append(surfaces, allPrivacySurfaces...)
+	}
+	seen := map[privacySurface]struct{}{}
+	for _, it := range req.Surfaces {
+		it = privacySurface(strings.TrimSpace(string(it)))
+		if !isPrivacySurface(it) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid surface", "surface": string(it)})
+			return
+		}
+		if _, ok := seen[it]; ok {
+			continue
+		}
+		seen[it] = struct{}{}
+		surfaces = append(surfaces, it)
+	}
+	var since *time.Time
+	if v := strings.TrimSpace(req.Since); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid since"})
+			return
+		}
+		t = t.UTC()
+		since = &t
+	}
+	surfaceNames := make([]string, len(surfaces))
+	for i, it := range surfaces {
+		surfaceNames[i] = string(it)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var running uuid.UUID
+	err := s.db.QueryRow(ctx, `
+		select id from privacy_scans
+		where status = 'running' and updated_at > $1
+		order by created_at desc
+		limit 1
+	`, time.Now().UTC().Add(-privacyScanStaleAfter)).Scan(&running)
+	if err == nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "scan already running", "scan_id": running.String()})
+		return
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		logError(ctx, "admin start privacy scan: running check failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+
+	scan, err := scanPrivacyScanRow(s.db.QueryRow(ctx, `
+		insert into privacy_scans (surfaces, since, requested_by)
+		values ($1, $2, $3)
+		returning `+privacyScanColumns, surfaceNames, since, adminID))
+	if err != nil {
+		logError(ctx, "admin start privacy scan: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	scanID := uuid.MustParse(scan.ScanID)
+	go s.runPrivacyScan(scanID, surfaces, since)
+
+	s.audit(ctx, "admin", adminID, "privacy_scan_started", map[string]any{
+		"scan_id":  scan.ScanID,
+		"surfaces": surfaceNames,
+		"since":    scan.Since,
+	})
+	writeJSON(w, http.StatusAccepted, scan)
+}
+
+type adminListPrivacyScansResponse struct {
+	Items      []privacyScanDTO `json:"items"`
+	HasMore    bool             `json:"has_more"`
+	NextOffset int              `json:"next_offset"`
+}
+
+func (s server) handleAdminListPrivacyScans(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 20
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	limit = clampInt(limit, 1, 100)
+	offset := 0
+	if v := strings.TrimSpace(q.Get("offset")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid offset"})
+			return
+		}
+		offset = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select `+privacyScanColumns+`
+		from privacy_scans
+		order by created_at desc
+		limit $1 offset $2
+	`, limit+1, offset)
+	if err != nil {
+		logError(ctx, "admin list privacy scans: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	out := make([]privacyScanDTO, 0)
+	for rows.Next() {
+		d, err := scanPrivacyScanRow(rows)
+		if err != nil {
+			logError(ctx, "admin list privacy scans: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin list privacy scans: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	hasMore := len(out) > limit
+	if hasMore {
+		out = out[:limit]
+	}
+	writeJSON(w, http.StatusOK, adminListPrivacyScansResponse{Items: out, HasMore: hasMore, NextOffset: offset + len(out)})
+}
+
+type privacyScanSummaryRow struct {
+	TargetType string `json:"target_type"`
+	Kind       string `json:"kind"`
+	Items      int    `json:"items"`
+	Resolved   int    `json:"resolved"`
+}
+
+type adminGetPrivacyScanResponse struct {
+	privacyScanDTO
+	Summary []privacyScanSummaryRow `json:"summary"`
+}
+
+// handleAdminGetPrivacyScan returns the scan's progress and item counts per target type and kind.
+func (s server) handleAdminGetPrivacyScan(w http.ResponseWriter, r *http.Request) {
+	scanID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "scanID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid scan_id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	scan, err := scanPrivacyScanRow(s.db.QueryRow(ctx, `select `+privacyScanColumns+` from privacy_scans where id = $1`, scanID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		logError(ctx, "admin get privacy scan: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+
+	rows, err := s.db.Query(ctx, `
+		select target_type, k, count(*), count(*) filter (where resolution <> '')
+		from privacy_scan_findings, unnest(kinds) as k
+		where scan_id = $1
+		group by target_type, k
+		order by target_type, k
+	`, scanID)
+	if err != nil {
+		logError(ctx, "admin get privacy scan: summary query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	summary := make([]privacyScanSummaryRow, 0)
+	for rows.Next() {
+		var it privacyScanSummaryRow
+		if err := rows.Scan(&it.TargetType, &it.Kind, &it.Items, &it.Resolved); err != nil {
+			logError(ctx, "admin get privacy scan: summary scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		summary = append(summary, it)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin get privacy scan: summary iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, adminGetPrivacyScanResponse{privacyScanDTO: scan, Summary: summary})
+}
+
+type privacyScanFindingDTO struct {
+	ID         int64    `json:"id"`
+	Surface    string   `json:"surface"`
+	TargetType string   `json:"target_type"`
+	TargetID   string   `json:"target_id"`
+	Location   string   `json:"location"`
+	Kinds      []string `json:"kinds"`
+	Fields     []string `json:"fields"`
+	Resolution string   `json:"resolution,omitempty"`
+	ResolvedAt string   `json:"resolved_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type adminListPrivacyScanFindingsResponse struct {
+	Items      []privacyScanFindingDTO `json:"items"`
+	HasMore    bool                    `json:"has_more"`
+	NextOffset int                     `json:"next_offset"`
+}
+
+// handleAdminListPrivacyScanFindings pages through a scan's report. resolution=open lists the items
+// no action has been applied to yet.
+func (s server) handleAdminListPrivacyScanFindings(w http.ResponseWriter, r *http.Request) {
+	scanID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "scanID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid scan_id"})
+		return
+	}
+	q := r.URL.Query()
+	targetType := strings.TrimSpace(q.Get("target_type"))
+	kind := strings.TrimSpace(q.Get("kind"))
+	if kind != "" && !isPrivacyKind(privacyKind(kind)) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid kind"})
+		return
+	}
+	resolution := strings.TrimSpace(q.Get("resolution"))
+	switch resolution {
+	case "", "open", privacyScanApplyPending, "redacted":
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid resolution"})
+		return
+	}
+	limit := 50
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	limit = clampInt(limit, 1, 500)
+	offset := 0
+	if v := strings.TrimSpace(q.Get("offset")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid offset"})
+			return
+		}
+		offset = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select id, surface, target_type, target_id, location, kinds, fields, resolution, resolved_at, created_at
+		from privacy_scan_findings
+		where scan_id = $1
+		  and ($2 = '' or target_type = $2)
+		  and ($3 = '' or $3 = any(kinds))
+		  and ($4 = '' or ($4 = 'open' and resolution = '') or resolution = $4)
+		order by id
+		limit $5 offset $6
+	`, scanID, targetType, kind, resolution, limit+1, offset)
+	if err != nil {
+		logError(ctx, "admin list privacy scan findings: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	out := make([]privacyScanFindingDTO, 0)
+	for rows.Next() {
+		var (
+			f          privacyScanFindingDTO
+			resolvedAt *time.Time
+			createdAt  time.Time
+		)
+		if err := rows.Scan(&f.ID, &f.Surface, &f.TargetType, &f.TargetID, &f.Location, &f.Kinds, &f.Fields, &f.Resolution, &resolvedAt, &createdAt); err != nil {
+			logError(ctx, "admin list privacy scan findings: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		if resolvedAt != nil {
+			f.ResolvedAt = resolvedAt.UTC().Format(time.RFC3339)
+		}
+		f.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		out = append(out, f)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin list privacy scan findings: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	hasMore := len(out) > limit
+	if hasMore {
+		out = out[:limit]
+	}
+	writeJSON(w, http.StatusOK, adminListPrivacyScanFindingsResponse{Items: out, HasMore: hasMore, NextOffset: offset + len(out)})
+}
+
+type adminApplyPrivacyScanRequest struct {
+	// pending: send items back to moderation; redact: replace matches with placeholders.
+	Action string `json:"action"`
+	// Optional filters; all open findings of the scan when empty.
+	FindingIDs  []int64  `json:"finding_ids,omitempty"`
+	TargetTypes []string `json:"target_types,omitempty"`
+	// Also the kinds to redact; every detected kind when empty.
+	Kinds []string `json:"kinds,omitempty"`
+	// Items per call (default 100, max 500); call again while remaining > 0.
+	Limit int `json:"limit,omitempty"`
+}
+
+type adminApplyPrivacyScanFailure struct {
+	FindingID int64  `json:"finding_id"`
+	Error     string `json:"error"`
+}
+
+type adminApplyPrivacyScanResponse struct {
+	ScanID    string                         `json:"scan_id"`
+	Action    string                         `json:"action"`
+	Applied   int                            `json:"applied"`
+	Failed    []adminApplyPrivacyScanFailure `json:"failed"`
+	Remaining int                            `json:"remaining"`
+}
+
+// handleAdminApplyPrivacyScan bulk-applies an action to the open findings of a scan.
+func (s server) handleAdminApplyPrivacyScan(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	scanID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "scanID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid scan_id"})
+		return
+	}
+
+	var req adminApplyPrivacyScanRequest
+	if !readJSONLimited(w, r, &req, 64*1024) {
+		return
+	}
+	req.Action = strings.ToLower(strings.TrimSpace(req.Action))
+	if req.Action != privacyScanApplyPending && req.Action != privacyScanApplyRedact {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid action"})
+		return
+	}
+	for _, k := range req.Kinds {
+		if !isPrivacyKind(privacyKind(k)) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid kind", "kind": k})
+			return
+		}
+	}
+	if len(req.FindingIDs) > 1000 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "too many finding_ids"})
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 100
+	}
+	req.Limit = clampInt(req.Limit, 1, 500)
+	if req.FindingIDs == nil {
+		req.FindingIDs = []int64{}
+	}
+	if req.TargetTypes == nil {
+		req.TargetTypes = []string{}
+	}
+	if req.Kinds == nil {
+		req.Kinds = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	var status string
+	if err := s.db.QueryRow(ctx, `select status from privacy_scans where id = $1`, scanID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		logError(ctx, "admin apply privacy scan: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+
+	const filter = `
+		scan_id = $1
+		and resolution = ''
+		and (cardinality($2::bigint[]) = 0 or id = any($2))
+		and (cardinality($3::text[]) = 0 or target_type = any($3))
+		and (cardinality($4::text[]) = 0 or kinds && $4)
+	`
+	rows, err := s.db.Query(ctx, `
+		select id, target_type, target_id
+		from privacy_scan_findings
+		where `+filter+`
+		order by id
+		limit $5
+	`, scanID, req.FindingIDs, req.TargetTypes, req.Kinds, req.Limit)
+	if err != nil {
+		logError(ctx, "admin apply privacy scan: query findings failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	type target struct {
+		id                   int64
+		targetType, targetID string
+	}
+	targets := make([]target, 0, req.Limit)
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.id, &t.targetType, &t.targetID); err != nil {
+			rows.Close()
+			logError(ctx, "admin apply privacy scan: scan findings failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin apply privacy scan: iterate findings failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	var store agenthome.OSSObjectStore
+	if req.Action == privacyScanApplyRedact {
+		provider := strings.ToLower(strings.TrimSpace(s.ossProvider))
+		if provider == "" && strings.TrimSpace(s.ossLocalDir) != "" {
+			provider = "local"
+		}
+		if provider != "" {
+			ossCfg := s.ossCfg()
+			ossCfg.Provider = provider
+			if st, err := agenthome.NewOSSObjectStore(ossCfg); err != nil {
+				logError(ctx, "admin apply privacy scan: init oss store failed", err)
+			} else {
+				store = st
+			}
+		}
+	}
+
+	resolution := privacyScanApplyPending
+	if req.Action == privacyScanApplyRedact {
+		resolution = "redacted"
+	}
+	redact := privacyKindSet(req.Kinds)
+	resp := adminApplyPrivacyScanResponse{ScanID: scanID.String(), Action: req.Action, Failed: []adminApplyPrivacyScanFailure{}}
+	appliedIDs := make([]int64, 0, len(targets))
+	for _, t := range targets {
+		err := s.applyPrivacyScanFinding(ctx, store, req.Action, t.targetType, t.targetID, redact)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The item was deleted since the scan; nothing left to act on.
+			err = nil
+		}
+		if err == nil {
+			_, err = s.db.Exec(ctx, `
+				update privacy_scan_findings
+				set resolution = $2, resolved_by = $3, resolved_at = now()
+				where id = $1
+			`, t.id, resolution, adminID)
+		}
+		if err != nil {
+			logError(ctx, "admin apply privacy scan: apply failed", err)
+			resp.Failed = append(resp.Failed, adminApplyPrivacyScanFailure{FindingID: t.id, Error: err.Error()})
+			continue
+		}
+		resp.Applied++
+		appliedIDs = append(appliedIDs, t.id)
+	}
+
+	if err := s.db.QueryRow(ctx, `select count(*) from privacy_scan_findings where `+filter,
+		scanID, req.FindingIDs, req.TargetTypes, req.Kinds).Scan(&resp.Remaining); err != nil {
+		logError(ctx, "admin apply privacy scan: count remaining failed", err)
+	}
+
+	if resp.Applied > 0 {
+		s.audit(ctx, "admin", adminID, "privacy_scan_applied", map[string]any{
+			"scan_id":     scanID.String(),
+			"action":      req.Action,
+			"kinds":       req.Kinds,
+			"applied":     resp.Applied,
+			"finding_ids": appliedIDs,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,380 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type adminSkillDTO struct {
+	skillDescriptorDTO
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (s server) handleAdminListSkills(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select name, version, description, input_schema, output_schema, coalesce(tool, ''), status, created_at
+		from skills
+		order by name asc, version desc
+	`)
+	if err != nil {
+		logError(ctx, "admin list skills: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	out := make([]adminSkillDTO, 0)
+	for rows.Next() {
+		var (
+			d         adminSkillDTO
+			inB, outB []byte
+			createdAt time.Time
+		)
+		if err := rows.Scan(&d.Name, &d.Version, &d.Description, &inB, &outB, &d.Tool, &d.Status, &createdAt); err != nil {
+			logError(ctx, "admin list skills: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		if err := unmarshalJSONNullable(inB, &d.InputSchema); err != nil {
+			logError(ctx, "admin list skills: decode input_schema failed", err)
+		}
+		if err := unmarshalJSONNullable(outB, &d.OutputSchema); err != nil {
+			logError(ctx, "admin list skills: decode output_schema failed", err)
+		}
+		d.Parameters = d.InputSchema
+		d.Deprecated = d.Status == skillStatusDeprecated
+		d.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin list skills: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"skills": out})
+}
+
+type adminPublishSkillRequest struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"input_schema"`
+	OutputSchema map[string]any `json:"output_schema"`
+	Tool         string         `json:"tool"`
+}
+
+// handleAdminPublishSkill publishes the next version of a skill. Versions are immutable; work items
+// keep the version they were created with.
+func (s server) handleAdminPublishSkill(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	var req adminPublishSkillRequest
+	if !readJSONLimited(w, r, &req, 128*1024) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	req.Tool = strings.TrimSpace(req.Tool)
+	if !skillNamePattern.MatchString(req.Name) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid name"})
+		return
+	}
+	if req.Description == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing description"})
+		return
+	}
+	if len(req.Description) > 4000 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "description too long"})
+		return
+	}
+	if req.Tool != "" && !toolNamePattern.MatchString(req.Tool) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tool"})
+		return
+	}
+	if req.InputSchema == nil {
+		req.InputSchema = map[string]any{"type": "object"}
+	}
+	if req.OutputSchema == nil {
+		req.OutputSchema = map[string]any{}
+	}
+	inputJSON, err := json.Marshal(req.InputSchema)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input_schema"})
+		return
+	}
+	outputJSON, err := json.Marshal(req.OutputSchema)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid output_schema"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		logError(ctx, "admin publish skill: db begin failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if req.Tool != "" {
+		var exists bool
+		if err := tx.QueryRow(ctx, `select exists(select 1 from tool_catalog where tool = $1)`, req.Tool).Scan(&exists); err != nil {
+			logError(ctx, "admin publish skill: tool lookup failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return
+		}
+		if _, implemented := s.tools.Get(req.Tool); !exists && !implemented {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown tool"})
+			return
+		}
+	}
+
+	// Serialize version allocation per skill name.
+	if _, err := tx.Exec(ctx, `select pg_advisory_xact_lock(hashtext('skills:' || $1))`, req.Name); err != nil {
+		logError(ctx, "admin publish skill: lock failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "lock failed"})
+		return
+	}
+	var version int
+	if err := tx.QueryRow(ctx, `
+		insert into skills (name, version, description, input_schema, output_schema, tool, created_by)
+		values ($1, (select coalesce(max(version), 0) + 1 from skills where name = $1), $2, $3, $4, nullif($5, ''), $6)
+		returning version
+	`, req.Name, req.Description, inputJSON, outputJSON, req.Tool, adminID).Scan(&version); err != nil {
+		logError(ctx, "admin publish skill: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logError(ctx, "admin publish skill: commit failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+		return
+	}
+
+	s.audit(ctx, "admin", adminID, "skill_version_published", map[string]any{
+		"skill":   req.Name,
+		"version": version,
+		"tool":    req.Tool,
+	})
+	writeJSON(w, http.StatusCreated, map[string]any{"name": req.Name, "version": version})
+}
+
+// Deprecated versions are skipped when resolving "latest" but stay readable for work items that
+// pinned them.
+func (s server) handleAdminDeprecateSkillVersion(w http.ResponseWriter, r *http.Request) {
+	s.handleAdminSetSkillVersionStatus(w, r, skillStatusDeprecated)
+}
+
+func (s server) handleAdminActivateSkillVersion(w http.ResponseWriter, r *http.Request) {
+	s.handleAdminSetSkillVersionStatus(w, r, skillStatusActive)
+}
+
+func (s server) handleAdminSetSkillVersionStatus(w http.ResponseWriter, r *http.Request, status string) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	name := strings.TrimSpace(chi.URLParam(r, "skill"))
+	if !skillNamePattern.MatchString(name) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid skill"})
+		return
+	}
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid version"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	ct, err := s.db.Exec(ctx, `update skills set status = $3 where name = $1 and version = $2`, name, version, status)
+	if err != nil {
+		logError(ctx, "admin set skill status: update failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	if ct.RowsAffected() == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	action := "skill_version_deprecated"
+	if status == skillStatusActive {
+		action = "skill_version_activated"
+	}
+	s.audit(ctx, "admin", adminID, action, map[string]any{"skill": name, "version": version})
+	writeJSON(w, http.StatusOK, map[string]any{"name": name, "version": version, "status": status})
+}
+
+type stageSkillSetEntry struct {
+	Name string `json:"name"`
+	// Version pins a skill version; omitted = latest active version at work item creation.
+	Version *int `json:"version,omitempty"`
+}
+
+func (s server) handleAdminListStageSkillSets(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select stage, skill_name, skill_version
+		from stage_skill_sets
+		order by stage asc, position asc, skill_name asc
+	`)
+	if err != nil {
+		logError(ctx, "admin list stage skill sets: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	sets := map[string][]stageSkillSetEntry{}
+	for rows.Next() {
+		var (
+			stage string
+			e     stageSkillSetEntry
+		)
+		if err := rows.Scan(&stage, &e.Name, &e.Version); err != nil {
+			logError(ctx, "admin list stage skill sets: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		sets[stage] = append(sets[stage], e)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin list stage skill sets: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"stages": sets,
+		// Stages without a set fall back to this list.
+		"fallback": s.skillsGatewayWhitelist,
+	})
+}
+
+type putStageSkillSetRequest struct {
+	Skills []stageSkillSetEntry `json:"skills"`
+}
+
+// handleAdminPutStageSkillSet replaces the skill set of a stage; an empty list removes it (the stage
+// falls back to AIHUB_SKILLS_GATEWAY_WHITELIST).
+func (s server) handleAdminPutStageSkillSet(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	stage := strings.TrimSpace(chi.URLParam(r, "stage"))
+	if stage == "" || len(stage) > 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid stage"})
+		return
+	}
+
+	var req putStageSkillSetRequest
+	if !readJSONLimited(w, r, &req, 32*1024) {
+		return
+	}
+	if len(req.Skills) > 50 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "too many skills"})
+		return
+	}
+	seen := map[string]struct{}{}
+	for i := range req.Skills {
+		req.Skills[i].Name = strings.TrimSpace(req.Skills[i].Name)
+		e := req.Skills[i]
+		if !skillNamePattern.MatchString(e.Name) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid skill", "skill": e.Name})
+			return
+		}
+		if _, dup := seen[e.Name]; dup {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "duplicate skill", "skill": e.Name})
+			return
+		}
+		seen[e.Name] = struct{}{}
+		if e.Version != nil && *e.Version <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid version", "skill": e.Name})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		logError(ctx, "admin put stage skill set: db begin failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	for _, e := range req.Skills {
+		var status string
+		var err error
+		if e.Version != nil {
+			err = tx.QueryRow(ctx, `select status from skills where name = $1 and version = $2`, e.Name, *e.Version).Scan(&status)
+		} else {
+			err = tx.QueryRow(ctx, `select 'active' from skills where name = $1 and status = 'active' limit 1`, e.Name).Scan(&status)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown skill", "skill": e.Name})
+			return
+		}
+		if err != nil {
+			logError(ctx, "admin put stage skill set: skill lookup failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return
+		}
+		if status != skillStatusActive {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "skill version deprecated", "skill": e.Name})
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `delete from stage_skill_sets where stage = $1`, stage); err != nil {
+		logError(ctx, "admin put stage skill set: delete failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete failed"})
+		return
+	}
+	for i, e := range req.Skills {
+		if _, err := tx.Exec(ctx, `
+			insert into stage_skill_sets (stage, skill_name, skill_version, position)
+			values ($1, $2, $3, $4)
+		`, stage, e.Name, e.Version, i); err != nil {
+			logError(ctx, "admin put stage skill set: insert failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logError(ctx, "admin put stage skill set: commit failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+		return
+	}
+
+	if req.Skills == nil {
+		req.Skills = []stageSkillSetEntry{}
+	}
+	s.audit(ctx, "admin", adminID, "stage_skill_set_updated", map[string]any{"stage": stage, "skills": req.Skills})
+	writeJSON(w, http.StatusOK, map[string]any{"stage": stage, "skills": req.Skills})
+}
@@ -199,6 +199,11 @@ func (s server) handleAdminCleanupTopicMessages(w http.ResponseWriter, r *http.R
 			resp.Warnings = append(resp.Warnings, "db delete failed for "+fullKey)
 			continue
 		}
+		if err := s.discardPendingOSSOutbox(ctx, stripped); err != nil {
+			logError(ctx, "cleanup topic messages: discard oss outbox failed", err)
+			resp.Warnings = append(resp.Warnings, "db delete failed for "+fullKey)
+			continue
+		}
 
 		if _, err := store.DeletePrefix(ctx, stripped); err != nil {
 			logError(ctx, "cleanup topic messages: delete oss object failed", err)
@@ -186,6 +186,11 @@ func (s server) handleAdminCleanupTopicRequests(w http.ResponseWriter, r *http.R
 			resp.Warnings = append(resp.Warnings, "db delete failed for "+fullKey)
 			continue
 		}
+		if err := s.discardPendingOSSOutbox(ctx, stripped); err != nil {
+			logError(ctx, "cleanup topic requests: discard oss outbox failed", err)
+			resp.Warnings = append(resp.Warnings, "db delete failed for "+fullKey)
+			continue
+		}
 		if _, err := store.DeletePrefix(ctx, stripped); err != nil {
 			logError(ctx, "cleanup topic requests: delete oss object failed", err)
 			resp.Warnings = append(resp.Warnings, "oss delete failed for "+stripped)
@@ -168,6 +168,11 @@ func (s server) handleAdminPurgeTopics(w http.ResponseWriter, r *http.Request) {
 		}
 
 		prefix := "topics/" + tid + "/"
+		if err := s.discardPendingOSSOutbox(ctx, prefix); err != nil {
+			logError(ctx, "admin purge topics: discard oss outbox failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db delete failed"})
+			return
+		}
 		osDeleted, err := store.DeletePrefix(ctx, prefix)
 		if err != nil {
 			logError(ctx, "admin purge topics: oss delete prefix failed", err)
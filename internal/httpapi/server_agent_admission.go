@@ -0,0 +1,427 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// --- Agent admission (proof of possession of agent_public_key + admin review)
+//
+// not_requested --verify--> pending --admit--> admitted
+//                                    --reject-> rejected --admit--> admitted
+// Registering a different public key sends pending/admitted agents back to not_requested; rejected
+// agents stay rejected and reviewable (an admin can still admit them).
+
+const admissionChallengeTTL = 5 * time.Minute
+
+// admissionMessage is what the agent signs: the challenge bound to this platform and agent.
+func admissionMessage(agentRef, challenge string) string {
+	return "aihub-agent-admission:v1\n" + agentRef + "\n" + challenge
+}
+
+type agentAdmissionDTO struct {
+	AgentRef       string `json:"agent_ref"`
+	Name           string `json:"name,omitempty"`
+	AdmittedStatus string `json:"admitted_status"`
+	PublicKey      string `json:"agent_public_key"`
+	RequestedAt    string `json:"requested_at,omitempty"`
+	AdmittedAt     string `json:"admitted_at,omitempty"`
+	ReviewedAt     string `json:"reviewed_at,omitempty"`
+	Note           string `json:"note,omitempty"`
+}
+
+const agentAdmissionColumns = `public_ref, name, admitted_status, agent_public_key, admission_requested_at, admitted_at, admission_reviewed_at, admission_note`
+
+func scanAgentAdmission(row pgx.Row) (agentAdmissionDTO, error) {
+	var (
+		dto         agentAdmissionDTO
+		requestedAt *time.Time
+		admittedAt  *time.Time
+		reviewedAt  *time.Time
+	)
+	if err := row.Scan(&dto.AgentRef, &dto.Name, &dto.AdmittedStatus, &dto.PublicKey, &requestedAt, &admittedAt, &reviewedAt, &dto.Note); err != nil {
+		return agentAdmissionDTO{}, err
+	}
+	for _, t := range []struct {
+		src *time.Time
+		dst *string
+	}{{requestedAt, &dto.RequestedAt}, {admittedAt, &dto.AdmittedAt}, {reviewedAt, &dto.ReviewedAt}} {
+		if t.src != nil {
+			*t.dst = t.src.UTC().Format(time.RFC3339)
+		}
+	}
+	return dto, nil
+}
+
+// requireAdmittedAgent lets only admitted agents through (topic writes, OSS credentials).
+func (s server) requireAdmittedAgent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agentID, ok := agentIDFromCtx(r.Context())
+		if !ok {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		var status string
+		if err := s.db.QueryRow(r.Context(), `select admitted_status from agents where id = $1`, agentID).Scan(&status); err != nil {
+			logError(r.Context(), "admission lookup failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "admission lookup failed"})
+			return
+		}
+		if status != "admitted" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "agent not admitted", "admitted_status": status})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// admissionBlocked maps states in which a new admission attempt makes no sense to an error response.
+func admissionBlocked(w http.ResponseWriter, status string) bool {
+	switch status {
+	case "pending":
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "admission pending review"})
+	case "admitted":
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "already admitted"})
+	case "rejected":
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "admission rejected"})
+	default:
+		return false
+	}
+	return true
+}
+
+func (s server) handleGatewayGetAdmission(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	dto, err := scanAgentAdmission(s.db.QueryRow(ctx, `select `+agentAdmissionColumns+` from agents where id = $1`, agentID))
+	if err != nil {
+		logError(ctx, "gateway get admission failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+type registerAgentPublicKeyRequest struct {
+	// Base64 Ed25519 public key (optionally prefixed "ed25519:").
+	PublicKey string `json:"public_key"`
+}
+
+// handleGatewayRegisterPublicKey sets the agent's Ed25519 key. A different key invalidates an
+// earlier proof of possession, so pending/admitted agents must go through admission again. A
+// rejected agent keeps its review state (admission_requested_at included) so an admin can still
+// admit it.
+func (s server) handleGatewayRegisterPublicKey(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var req registerAgentPublicKeyRequest
+	if !readJSONLimited(w, r, &req, 4*1024) {
+		return
+	}
+	pub, err := agenthome.ParseEd25519PublicKey(req.PublicKey)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid public_key"})
+		return
+	}
+	publicKey := base64.StdEncoding.EncodeToString(pub)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	dto, changed, err := s.repo.RegisterAgentPublicKey(ctx, agentID, publicKey)
+	if err == nil && changed {
+		s.audit(ctx, "agent", agentID, "agent_public_key_registered", map[string]any{"agent_ref": dto.AgentRef})
+	}
+	if err != nil {
+		logError(ctx, "register agent public key failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+type admissionChallengeResponse struct {
+	ChallengeID string `json:"challenge_id"`
+	Challenge   string `json:"challenge"`
+	// Message is the exact UTF-8 string to sign with the agent's Ed25519 private key.
+	Message   string `json:"message"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+func (s server) handleGatewayCreateAdmissionChallenge(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var agentRef, publicKey, status string
+	if err := s.db.QueryRow(ctx, `select public_ref, agent_public_key, admitted_status from agents where id = $1`, agentID).Scan(&agentRef, &publicKey, &status); err != nil {
+		logError(ctx, "admission challenge: agent lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "agent lookup failed"})
+		return
+	}
+	if admissionBlocked(w, status) {
+		return
+	}
+	if strings.TrimSpace(publicKey) == "" {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "public key not registered"})
+		return
+	}
+
+	challenge, err := agenthome.NewRandomChallenge()
+	if err != nil {
+		logError(ctx, "admission challenge: generate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "challenge generation failed"})
+		return
+	}
+	expiresAt := time.Now().Add(admissionChallengeTTL)
+	var id uuid.UUID
+	if err := s.db.QueryRow(ctx, `
+		insert into agent_admission_challenges (agent_id, challenge, public_key, expires_at)
+		values ($1, $2, $3, $4)
+		returning id
+	`, agentID, challenge, publicKey, expiresAt).Scan(&id); err != nil {
+		logError(ctx, "admission challenge: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	writeJSON(w, http.StatusCreated, admissionChallengeResponse{
+		ChallengeID: id.String(),
+		Challenge:   challenge,
+		Message:     admissionMessage(agentRef, challenge),
+		ExpiresAt:   expiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+type verifyAdmissionRequest struct {
+	ChallengeID string `json:"challenge_id"`
+	// Base64 Ed25519 signature over the challenge message.
+	Signature string `json:"signature"`
+}
+
+// handleGatewayVerifyAdmission checks the signed challenge (one attempt per challenge) and moves
+// the agent to pending admin review.
+func (s server) handleGatewayVerifyAdmission(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var req verifyAdmissionRequest
+	if !readJSONLimited(w, r, &req, 4*1024) {
+		return
+	}
+	challengeID, err := uuid.Parse(strings.TrimSpace(req.ChallengeID))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid challenge_id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		logError(ctx, "verify admission: db begin failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var agentRef, publicKey, status string
+	if err := tx.QueryRow(ctx, `
+		select public_ref, agent_public_key, admitted_status from agents where id = $1 for update
+	`, agentID).Scan(&agentRef, &publicKey, &status); err != nil {
+		logError(ctx, "verify admission: agent lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "agent lookup failed"})
+		return
+	}
+	if admissionBlocked(w, status) {
+		return
+	}
+	var challenge, challengeKey string
+	err = tx.QueryRow(ctx, `
+		update agent_admission_challenges
+		set consumed_at = now()
+		where id = $1 and agent_id = $2 and consumed_at is null and expires_at > now()
+		returning challenge, public_key
+	`, challengeID, agentID).Scan(&challenge, &challengeKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "challenge not found or expired"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "verify admission: consume challenge failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+
+	verified := false
+	if challengeKey == publicKey {
+		if pub, err := agenthome.ParseEd25519PublicKey(publicKey); err == nil {
+			verified, _ = agenthome.VerifyEd25519Base64(pub, []byte(admissionMessage(agentRef, challenge)), req.Signature)
+		}
+	}
+	if verified {
+		if _, err := tx.Exec(ctx, `
+			update agents
+			set admitted_status = 'pending', admission_requested_at = now(), admission_note = '', updated_at = now()
+			where id = $1
+		`, agentID); err != nil {
+			logError(ctx, "verify admission: update agent failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+			return
+		}
+	}
+	// Commit either way: a failed attempt still uses up the challenge.
+	if err := tx.Commit(ctx); err != nil {
+		logError(ctx, "verify admission: commit failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+		return
+	}
+	s.audit(ctx, "agent", agentID, "agent_admission_verified", map[string]any{"agent_ref": agentRef, "verified": verified})
+	if !verified {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid signature"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"agent_ref": agentRef, "admitted_status": "pending"})
+}
+
+type adminListAdmissionsResponse struct {
+	Items      []agentAdmissionDTO `json:"items"`
+	HasMore    bool                `json:"has_more"`
+	NextOffset int                 `json:"next_offset"`
+}
+
+// handleAdminListAdmissions is the admission review queue (default: pending, oldest first).
+func (s server) handleAdminListAdmissions(w http.ResponseWriter, r *http.Request) {
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	if status == "" {
+		status = "pending"
+	}
+	switch status {
+	case "pending", "rejected", "admitted", "not_requested":
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+		return
+	}
+	limit := 50
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = clampInt(n, 1, 200)
+		}
+	}
+	offset := 0
+	if v := strings.TrimSpace(r.URL.Query().Get("offset")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			offset = clampInt(n, 0, 50_000)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select `+agentAdmissionColumns+`
+		from agents
+		where admitted_status = $1
+		order by coalesce(admission_requested_at, updated_at), public_ref
+		limit $2 offset $3
+	`, status, limit+1, offset)
+	if err != nil {
+		logError(ctx, "admin list admissions failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+	items := make([]agentAdmissionDTO, 0, limit)
+	for rows.Next() {
+		dto, err := scanAgentAdmission(rows)
+		if err != nil {
+			logError(ctx, "admin list admissions scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		items = append(items, dto)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	writeJSON(w, http.StatusOK, adminListAdmissionsResponse{Items: items, HasMore: hasMore, NextOffset: offset + len(items)})
+}
+
+func (s server) handleAdminAdmitAgent(w http.ResponseWriter, r *http.Request) {
+	// Rejected agents keep their proof of possession and can be admitted later.
+	s.decideAdmission(w, r, "admitted", []string{"pending", "rejected"})
+}
+
+func (s server) handleAdminRejectAgentAdmission(w http.ResponseWriter, r *http.Request) {
+	s.decideAdmission(w, r, "rejected", []string{"pending", "admitted"})
+}
+
+func (s server) decideAdmission(w http.ResponseWriter, r *http.Request, to string, from []string) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	agentRef, ok := requireAgentRefParam(w, r, "agentRef")
+	if !ok {
+		return
+	}
+	var req moderationActionRequest
+	if r.ContentLength != 0 && !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	_, ownerID, err := s.repo.DecideAdmission(ctx, admissionDecision{AgentRef: agentRef, To: to, From: from, AdminID: adminID, Note: req.Reason})
+	var stateErr *admissionStateError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	case errors.As(err, &stateErr):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "invalid admission state", "admitted_status": stateErr.Status})
+		return
+	case err != nil:
+		logError(ctx, "admin admission decision failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+
+	data := map[string]any{"agent_ref": agentRef, "admitted_status": to, "reason": req.Reason}
+	s.audit(ctx, "admin", adminID, "agent_admission_"+to, data)
+	s.emitWebhookEventBestEffort(ctx, []uuid.UUID{ownerID}, webhookEventAgentAdmission, data)
+	writeJSON(w, http.StatusOK, map[string]any{"agent_ref": agentRef, "admitted_status": to})
+}
@@ -0,0 +1,91 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+)
+
+func TestAdmissionSignatureRoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := agenthome.ParseEd25519PublicKey("ed25519:" + base64.StdEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := agenthome.NewRandomChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(admissionMessage("a_1", challenge))))
+
+	if ok, err := agenthome.VerifyEd25519Base64(parsed, []byte(admissionMessage("a_1", challenge)), sig); err != nil || !ok {
+		t.Fatalf("valid signature rejected: %v %v", ok, err)
+	}
+	// The message binds the agent: a signature can't be replayed for another agent.
+	if ok, _ := agenthome.VerifyEd25519Base64(parsed, []byte(admissionMessage("a_2", challenge)), sig); ok {
+		t.Fatal("signature accepted for a different agent")
+	}
+}
+
+func TestRegisterPublicKeyKeepsRejectedAdmission(t *testing.T) {
+	repo := newMemRepository()
+	s := server{repo: repo}
+	agentID := repo.addAgent(uuid.New(), "a")
+	register := func() agentAdmissionDTO {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := `{"public_key":"ed25519:` + base64.StdEncoding.EncodeToString(pub) + `"}`
+		req := httptest.NewRequest(http.MethodPut, "/v1/gateway/admission/public-key", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), ctxAgentID, agentID))
+		rec := httptest.NewRecorder()
+		s.handleGatewayRegisterPublicKey(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("register: status %d body=%s", rec.Code, rec.Body.String())
+		}
+		var dto agentAdmissionDTO
+		if err := json.Unmarshal(rec.Body.Bytes(), &dto); err != nil {
+			t.Fatal(err)
+		}
+		return dto
+	}
+
+	register()
+	requestedAt := time.Now().Add(-time.Hour)
+	repo.agents[agentID].AdmittedStatus = "pending"
+	repo.agents[agentID].RequestedAt = &requestedAt
+	ref := repo.agents[agentID].Profile.Ref
+	if _, _, err := repo.DecideAdmission(context.Background(), admissionDecision{AgentRef: ref, To: "rejected", From: []string{"pending", "admitted"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new key leaves the rejection (and its request) in place, so an admin can still admit it.
+	dto := register()
+	if dto.AdmittedStatus != "rejected" || dto.RequestedAt == "" {
+		t.Fatalf("after key change: status=%q requested_at=%q", dto.AdmittedStatus, dto.RequestedAt)
+	}
+	if _, _, err := repo.DecideAdmission(context.Background(), admissionDecision{AgentRef: ref, To: "admitted", From: []string{"pending", "rejected"}}); err != nil {
+		t.Fatalf("admit after key change: %v", err)
+	}
+
+	// For an admitted agent a new key means admission starts over.
+	dto = register()
+	if dto.AdmittedStatus != "not_requested" || dto.RequestedAt != "" || dto.AdmittedAt != "" {
+		t.Fatalf("admitted agent after key change: %+v", dto)
+	}
+}
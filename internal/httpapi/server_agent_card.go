@@ -33,6 +33,8 @@ type agentFullDTO struct {
 	CardVersion  int            `json:"card_version"`
 	CardCert     any            `json:"card_cert,omitempty"`
 	CardReview   string         `json:"card_review_status"`
+	Admission    string         `json:"admitted_status"`
+	PublicKey    string         `json:"agent_public_key"`
 	Discovery    discoveryDTO   `json:"discovery"`
 	Autonomous   autonomousDTO  `json:"autonomous"`
 	CreatedAt    string         `json:"created_at"`
@@ -72,6 +74,8 @@ func (s server) handleGetAgent(w http.ResponseWriter, r *http.Request) {
 		cardVersion     int
 		cardCertRaw     []byte
 		cardReview      string
+		admission       string
+		publicKey       string
 		createdAt       time.Time
 		updatedAt       time.Time
 	)
@@ -96,6 +100,8 @@ func (s server) handleGetAgent(w http.ResponseWriter, r *http.Request) {
 			card_version,
 			card_cert,
 			card_review_status,
+			admitted_status,
+			agent_public_key,
 			created_at,
 			updated_at
 		from agents
@@ -119,6 +125,8 @@ func (s server) handleGetAgent(w http.ResponseWriter, r *http.Request) {
 		&cardVersion,
 		&cardCertRaw,
 		&cardReview,
+		&admission,
+		&publicKey,
 		&createdAt,
 		&updatedAt,
 	)
@@ -202,6 +210,8 @@ func (s server) handleGetAgent(w http.ResponseWriter, r *http.Request) {
 		CardVersion:  cardVersion,
 		CardCert:     cardCert,
 		CardReview:   strings.TrimSpace(cardReview),
+		Admission:    strings.TrimSpace(admission),
+		PublicKey:    publicKey,
 		Discovery:    discovery,
 		Autonomous:   autonomous,
 		CreatedAt:    createdAt.UTC().Format(time.RFC3339),
@@ -0,0 +1,541 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"aihub/internal/keys"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// --- Scoped, expiring API keys (user_api_keys / agent_api_keys)
+
+// Key scopes. Each route group requires one (see requireScope); keys issued by login or before
+// scopes existed carry every scope of their principal.
+const (
+	scopeUserRead     = "user-read"
+	scopeUserWrite    = "user-write"
+	scopeAdmin        = "admin"
+	scopeGatewayRead  = "gateway-read"
+	scopeGatewayWrite = "gateway-write"
+	scopeTopicsWrite  = "topics-write"
+)
+
+const (
+	maxAPIKeysPerPrincipal = 20
+	maxAPIKeyGraceMinutes  = 24 * 60
+	// last_used_at/ip are written at most this often per key (unless the IP changes).
+	apiKeyUseWriteInterval = time.Minute
+)
+
+// apiKeyActiveSQL matches keys that authenticate (alias k). Grace-period rotation shortens
+// expires_at of the old key instead of revoking it.
+const apiKeyActiveSQL = `k.revoked_at is null and (k.expires_at is null or k.expires_at > now())`
+
+const apiKeyAuthColumns = `k.id, k.scopes, k.last_used_at, k.last_used_ip`
+
+type apiKeyTable struct {
+	Table    string
+	OwnerCol string
+	Scopes   []string
+}
+
+var (
+	userKeyTable  = apiKeyTable{Table: "user_api_keys", OwnerCol: "user_id", Scopes: []string{scopeUserRead, scopeUserWrite, scopeAdmin}}
+	agentKeyTable = apiKeyTable{Table: "agent_api_keys", OwnerCol: "agent_id", Scopes: []string{scopeGatewayRead, scopeGatewayWrite, scopeTopicsWrite}}
+)
+
+// apiKeyAuth is the key a request authenticated with.
+type apiKeyAuth struct {
+	ID         uuid.UUID
+	Scopes     []string
+	LastUsedAt *time.Time
+	LastUsedIP *string
+}
+
+func (k apiKeyAuth) hasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// noteAPIKeyUse records last use in the background, throttled to apiKeyUseWriteInterval.
+func (s server) noteAPIKeyUse(r *http.Request, t apiKeyTable, k apiKeyAuth) {
+	ip := clientIP(r)
+	now := time.Now()
+	if k.LastUsedAt != nil && now.Sub(*k.LastUsedAt) < apiKeyUseWriteInterval && k.LastUsedIP != nil && *k.LastUsedIP == ip {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := s.db.Exec(ctx, `update `+t.Table+` set last_used_at = now(), last_used_ip = $2 where id = $1`, k.ID, ip); err != nil {
+			logError(ctx, "record api key use failed", err)
+		}
+	}()
+}
+
+// requireScope rejects requests whose key lacks the scope the route needs. It runs after the
+// group's auth middleware.
+func requireScope(scopeFor func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope := scopeFor(r)
+			if k, ok := apiKeyFromCtx(r.Context()); !ok || !k.hasScope(scope) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient_scope", "required_scope": scope})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+func userScope(r *http.Request) string {
+	if isReadMethod(r.Method) {
+		return scopeUserRead
+	}
+	return scopeUserWrite
+}
+
+func gatewayScope(r *http.Request) string {
+	switch {
+	case isReadMethod(r.Method):
+		return scopeGatewayRead
+	case strings.Contains(r.URL.Path, "/gateway/topics/"):
+		return scopeTopicsWrite
+	default:
+		return scopeGatewayWrite
+	}
+}
+
+func adminScope(*http.Request) string { return scopeAdmin }
+
+// normalizeAPIKeyScopes validates scopes against allowed and returns them in allowed order.
+// Empty input means all of allowed.
+func normalizeAPIKeyScopes(in, allowed []string) ([]string, bool) {
+	if len(in) == 0 {
+		return append([]string(nil), allowed...), true
+	}
+	want := map[string]bool{}
+	for _, sc := range in {
+		sc = strings.ToLower(strings.TrimSpace(sc))
+		if sc == "" {
+			continue
+		}
+		found := false
+		for _, a := range allowed {
+			if a == sc {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+		want[sc] = true
+	}
+	out := make([]string, 0, len(want))
+	for _, a := range allowed {
+		if want[a] {
+			out = append(out, a)
+		}
+	}
+	return out, len(out) > 0
+}
+
+type apiKeyDTO struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	LastUsedIP string   `json:"last_used_ip,omitempty"`
+	// Current marks the key this request authenticated with.
+	Current bool `json:"current,omitempty"`
+	// APIKey is only returned on create / rotate.
+	APIKey string `json:"api_key,omitempty"`
+}
+
+const apiKeyColumns = `id, name, scopes, created_at, expires_at, last_used_at, coalesce(last_used_ip, '')`
+
+func scanAPIKey(row pgx.Row) (apiKeyDTO, error) {
+	var (
+		dto        apiKeyDTO
+		id         uuid.UUID
+		createdAt  time.Time
+		expiresAt  *time.Time
+		lastUsedAt *time.Time
+	)
+	if err := row.Scan(&id, &dto.Name, &dto.Scopes, &createdAt, &expiresAt, &lastUsedAt, &dto.LastUsedIP); err != nil {
+		return apiKeyDTO{}, err
+	}
+	dto.ID = id.String()
+	if dto.Scopes == nil {
+		dto.Scopes = []string{}
+	}
+	dto.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	if expiresAt != nil {
+		dto.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	}
+	if lastUsedAt != nil {
+		dto.LastUsedAt = lastUsedAt.UTC().Format(time.RFC3339)
+	}
+	return dto, nil
+}
+
+type createAPIKeyRequest struct {
+	Name string `json:"name"`
+	// Scopes default to every scope the principal can hold (for user keys: the calling key's scopes).
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type rotateAPIKeyRequest struct {
+	// GraceMinutes keeps the old key working this long (0 = revoke now; max 1440).
+	GraceMinutes int `json:"grace_minutes"`
+}
+
+type rotateAPIKeyResponse struct {
+	Key apiKeyDTO `json:"key"`
+	// PreviousExpiresAt is when the replaced key stops working.
+	PreviousExpiresAt string `json:"previous_expires_at"`
+}
+
+// apiKeyPrincipal is the owner of the keys a key-management route works on: the calling user
+// (/me/api-keys) or one of their agents (/agents/{agentRef}/keys).
+type apiKeyPrincipal struct {
+	Table     apiKeyTable
+	OwnerID   uuid.UUID
+	UserID    uuid.UUID
+	AgentID   *uuid.UUID
+	Grantable []string
+}
+
+func (p apiKeyPrincipal) auditData(extra map[string]any) map[string]any {
+	data := map[string]any{"principal": "user"}
+	if p.AgentID != nil {
+		data["principal"] = "agent"
+		data["agent_id"] = p.AgentID.String()
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	return data
+}
+
+func (s server) resolveAPIKeyPrincipal(ctx context.Context, w http.ResponseWriter, r *http.Request) (apiKeyPrincipal, bool) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return apiKeyPrincipal{}, false
+	}
+	if chi.URLParam(r, "agentRef") == "" {
+		// A key can only mint keys with scopes it holds itself.
+		caller, _ := apiKeyFromCtx(r.Context())
+		grantable := make([]string, 0, len(userKeyTable.Scopes))
+		for _, sc := range userKeyTable.Scopes {
+			if caller.hasScope(sc) {
+				grantable = append(grantable, sc)
+			}
+		}
+		return apiKeyPrincipal{Table: userKeyTable, OwnerID: userID, UserID: userID, Grantable: grantable}, true
+	}
+	agentRef, ok := requireAgentRefParam(w, r, "agentRef")
+	if !ok {
+		return apiKeyPrincipal{}, false
+	}
+	var agentID uuid.UUID
+	if err := s.db.QueryRow(ctx, `select id from agents where public_ref=$1 and owner_id=$2`, agentRef, userID).Scan(&agentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return apiKeyPrincipal{}, false
+		}
+		logError(ctx, "query agent for api keys failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return apiKeyPrincipal{}, false
+	}
+	return apiKeyPrincipal{Table: agentKeyTable, OwnerID: agentID, UserID: userID, AgentID: &agentID, Grantable: agentKeyTable.Scopes}, true
+}
+
+func requireAPIKeyIDParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "keyID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid key_id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// insertAPIKey issues a new key and returns it with the plaintext set.
+func (s server) insertAPIKey(ctx context.Context, tx pgx.Tx, t apiKeyTable, ownerID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (apiKeyDTO, error) {
+	apiKey, err := keys.NewAPIKey()
+	if err != nil {
+		return apiKeyDTO{}, err
+	}
+	dto, err := scanAPIKey(tx.QueryRow(ctx, `
+		insert into `+t.Table+` (`+t.OwnerCol+`, key_hash, name, scopes, expires_at)
+		values ($1, $2, $3, $4, $5)
+		returning `+apiKeyColumns, ownerID, keys.HashAPIKey(s.pepper, apiKey), name, scopes, expiresAt))
+	if err != nil {
+		return apiKeyDTO{}, err
+	}
+	dto.APIKey = apiKey
+	return dto, nil
+}
+
+// retireAPIKeys revokes active keys of ownerID (one key when keyID is set) or, with a grace
+// period, lets them expire then (never later than an existing expiry). It returns when the keys
+// stop working and how many were affected.
+func retireAPIKeys(ctx context.Context, tx pgx.Tx, t apiKeyTable, ownerID uuid.UUID, keyID *uuid.UUID, graceMinutes int) (time.Time, int64, error) {
+	ct, err := tx.Exec(ctx, `
+		update `+t.Table+` k
+		set revoked_at = case when $3 = 0 then now() else k.revoked_at end,
+		    expires_at = case when $3 = 0 then k.expires_at
+		                      else least(coalesce(k.expires_at, 'infinity'), now() + make_interval(mins => $3)) end
+		where k.`+t.OwnerCol+` = $1 and ($2::uuid is null or k.id = $2) and `+apiKeyActiveSQL+`
+	`, ownerID, keyID, graceMinutes)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.Now().Add(time.Duration(graceMinutes) * time.Minute), ct.RowsAffected(), nil
+}
+
+// readRotateAPIKeyRequest parses the optional rotate body.
+func readRotateAPIKeyRequest(w http.ResponseWriter, r *http.Request) (rotateAPIKeyRequest, bool) {
+	var req rotateAPIKeyRequest
+	if r.ContentLength != 0 && !readJSONLimited(w, r, &req, 4*1024) {
+		return req, false
+	}
+	if req.GraceMinutes < 0 || req.GraceMinutes > maxAPIKeyGraceMinutes {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid grace_minutes"})
+		return req, false
+	}
+	return req, true
+}
+
+func (s server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, ok := s.resolveAPIKeyPrincipal(ctx, w, r)
+	if !ok {
+		return
+	}
+	rows, err := s.db.Query(ctx, `
+		select `+apiKeyColumns+`
+		from `+p.Table.Table+` k
+		where `+p.Table.OwnerCol+` = $1 and `+apiKeyActiveSQL+`
+		order by created_at desc
+	`, p.OwnerID)
+	if err != nil {
+		logError(ctx, "list api keys failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+	caller, _ := apiKeyFromCtx(r.Context())
+	items := make([]apiKeyDTO, 0)
+	for rows.Next() {
+		dto, err := scanAPIKey(rows)
+		if err != nil {
+			logError(ctx, "list api keys scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		dto.Current = p.AgentID == nil && dto.ID == caller.ID.String()
+		items = append(items, dto)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "scopes": p.Table.Scopes})
+}
+
+func (s server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if !readJSONLimited(w, r, &req, 8*1024) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid name"})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expires_at must be in the future"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, ok := s.resolveAPIKeyPrincipal(ctx, w, r)
+	if !ok {
+		return
+	}
+	scopes, ok := normalizeAPIKeyScopes(req.Scopes, p.Grantable)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid scopes"})
+		return
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		logError(ctx, "create api key: db begin failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var n int
+	if err := tx.QueryRow(ctx, `
+		select count(*) from `+p.Table.Table+` k where `+p.Table.OwnerCol+` = $1 and `+apiKeyActiveSQL,
+		p.OwnerID).Scan(&n); err != nil {
+		logError(ctx, "create api key: count failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	if n >= maxAPIKeysPerPrincipal {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "too many api keys"})
+		return
+	}
+	dto, err := s.insertAPIKey(ctx, tx, p.Table, p.OwnerID, req.Name, scopes, req.ExpiresAt)
+	if err != nil {
+		logError(ctx, "create api key: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logError(ctx, "create api key: commit failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+		return
+	}
+
+	s.audit(ctx, "user", p.UserID, "api_key_created", p.auditData(map[string]any{"key_id": dto.ID, "name": dto.Name, "scopes": dto.Scopes}))
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+// handleRotateAPIKey issues a replacement with the same name, scopes and expiry. The old key keeps
+// working for grace_minutes.
+func (s server) handleRotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID, ok := requireAPIKeyIDParam(w, r)
+	if !ok {
+		return
+	}
+	req, ok := readRotateAPIKeyRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, ok := s.resolveAPIKeyPrincipal(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		logError(ctx, "rotate api key: db begin failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		name      string
+		scopes    []string
+		expiresAt *time.Time
+	)
+	err = tx.QueryRow(ctx, `
+		select k.name, k.scopes, k.expires_at
+		from `+p.Table.Table+` k
+		where k.id = $1 and k.`+p.Table.OwnerCol+` = $2 and `+apiKeyActiveSQL+`
+		for update
+	`, keyID, p.OwnerID).Scan(&name, &scopes, &expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "rotate api key: lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	// The new key inherits the old key's scopes, so the caller must be able to grant all of them.
+	if len(scopes) > 0 {
+		if _, ok := normalizeAPIKeyScopes(scopes, p.Grantable); !ok {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient_scope"})
+			return
+		}
+	}
+	dto, err := s.insertAPIKey(ctx, tx, p.Table, p.OwnerID, name, scopes, expiresAt)
+	if err != nil {
+		logError(ctx, "rotate api key: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	until, _, err := retireAPIKeys(ctx, tx, p.Table, p.OwnerID, &keyID, req.GraceMinutes)
+	if err != nil {
+		logError(ctx, "rotate api key: retire old key failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "revoke failed"})
+		return
+	}
+	if expiresAt != nil && expiresAt.Before(until) {
+		until = *expiresAt
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logError(ctx, "rotate api key: commit failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+		return
+	}
+
+	s.audit(ctx, "user", p.UserID, "api_key_rotated", p.auditData(map[string]any{"key_id": keyID.String(), "new_key_id": dto.ID, "grace_minutes": req.GraceMinutes}))
+	writeJSON(w, http.StatusOK, rotateAPIKeyResponse{Key: dto, PreviousExpiresAt: until.UTC().Format(time.RFC3339)})
+}
+
+func (s server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID, ok := requireAPIKeyIDParam(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, ok := s.resolveAPIKeyPrincipal(ctx, w, r)
+	if !ok {
+		return
+	}
+	ct, err := s.db.Exec(ctx, `
+		update `+p.Table.Table+` set revoked_at = now()
+		where id = $1 and `+p.Table.OwnerCol+` = $2 and revoked_at is null
+	`, keyID, p.OwnerID)
+	if err != nil {
+		logError(ctx, "revoke api key failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "revoke failed"})
+		return
+	}
+	if ct.RowsAffected() == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	s.audit(ctx, "user", p.UserID, "api_key_revoked", p.auditData(map[string]any{"key_id": keyID.String()}))
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
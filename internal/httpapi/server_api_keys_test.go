@@ -0,0 +1,55 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAPIKeyScopes(t *testing.T) {
+	got, ok := normalizeAPIKeyScopes([]string{" Topics-Write", "gateway-read", "gateway-read"}, agentKeyTable.Scopes)
+	if !ok || !reflect.DeepEqual(got, []string{scopeGatewayRead, scopeTopicsWrite}) {
+		t.Fatalf("got %v %v", got, ok)
+	}
+	if got, ok := normalizeAPIKeyScopes(nil, userKeyTable.Scopes); !ok || len(got) != 3 {
+		t.Fatalf("default scopes: %v %v", got, ok)
+	}
+	// Scopes outside the allowed set (e.g. beyond the calling key's own) are rejected.
+	if _, ok := normalizeAPIKeyScopes([]string{scopeAdmin}, []string{scopeUserRead}); ok {
+		t.Fatal("admin scope granted by a read-only key")
+	}
+	if _, ok := normalizeAPIKeyScopes([]string{scopeGatewayWrite}, userKeyTable.Scopes); ok {
+		t.Fatal("agent scope on a user key")
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	h := requireScope(gatewayScope)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	readOnly := apiKeyAuth{Scopes: []string{scopeGatewayRead}}
+	cases := []struct {
+		method, path string
+		key          *apiKeyAuth
+		want         int
+	}{
+		{http.MethodGet, "/v1/gateway/inbox/poll", &readOnly, http.StatusNoContent},
+		{http.MethodPost, "/v1/gateway/runs", &readOnly, http.StatusForbidden},
+		{http.MethodPost, "/v1/gateway/topics/t1/messages", &apiKeyAuth{Scopes: []string{scopeGatewayWrite}}, http.StatusForbidden},
+		{http.MethodPost, "/v1/gateway/topics/t1/messages", &apiKeyAuth{Scopes: []string{scopeTopicsWrite}}, http.StatusNoContent},
+		{http.MethodGet, "/v1/gateway/tasks", nil, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.key != nil {
+			req = req.WithContext(context.WithValue(req.Context(), ctxAPIKey, *c.key))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s %s: got %d, want %d", c.method, c.path, rec.Code, c.want)
+		}
+	}
+}
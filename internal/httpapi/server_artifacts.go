@@ -68,45 +68,30 @@ func (s server) handleGatewaySubmitArtifact(w http.ResponseWriter, r *http.Reque
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "content too large"})
 		return
 	}
-	if rejectIfPrivacyViolation(r.Context(), w, req.Content, "content", "gateway submit artifact: blocked by privacy filter") {
+	redacted, privacy, ok := s.enforcePrivacyPolicy(r.Context(), w, privacySurfaceArtifact, req.Content, "content", "gateway submit artifact: blocked by privacy filter")
+	if !ok {
 		return
 	}
+	req.Content = redacted.(string)
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	// Agent must be a participant in the run.
-	var participant bool
-	err := s.db.QueryRow(ctx, `
-		select true
-		from work_item_offers o
-		join work_items wi on wi.id = o.work_item_id
-		where o.agent_id = $1 and wi.run_id = $2
-		limit 1
-	`, agentID, runID).Scan(&participant)
-	if errors.Is(err, pgx.ErrNoRows) {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "not a participant"})
-		return
-	}
+	participant, err := s.repo.IsRunParticipant(ctx, agentID, runID)
 	if err != nil {
 		logError(ctx, "participant check failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "participant check failed"})
 		return
 	}
+	if !participant {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "not a participant"})
+		return
+	}
 
 	// Review work items should emit feedback as events, not submit new artifacts (to avoid overriding run output).
-	var onReviewLease bool
-	if err := s.db.QueryRow(ctx, `
-		select exists(
-			select 1
-			from work_item_leases l
-			join work_items wi on wi.id = l.work_item_id
-			where l.agent_id = $1
-			  and wi.run_id = $2
-			  and wi.kind = 'review'
-			  and wi.status = 'claimed'
-		)
-	`, agentID, runID).Scan(&onReviewLease); err != nil {
+	onReviewLease, err := s.repo.HasClaimedReviewWorkItem(ctx, agentID, runID)
+	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "lease check failed"})
 		return
 	}
@@ -115,47 +100,20 @@ func (s server) handleGatewaySubmitArtifact(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	tx, err := s.db.Begin(ctx)
+	nextVersion, artifactID, err := s.repo.AppendArtifact(ctx, newArtifact{
+		RunID:          runID,
+		RunRef:         runRef,
+		AuthorAgentID:  agentID,
+		Kind:           req.Kind,
+		Content:        req.Content,
+		LinkedEventSeq: req.LinkedEventSeq,
+	})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
-		return
-	}
-	defer tx.Rollback(ctx)
-
-	// Lock run to serialize version allocation.
-	if _, err := tx.Exec(ctx, `select 1 from runs where id=$1 for update`, runID); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "run lock failed"})
-		return
-	}
-
-	var nextVersion int
-	if err := tx.QueryRow(ctx, `select coalesce(max(version), 0) + 1 from artifacts where run_id=$1`, runID).Scan(&nextVersion); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "version allocation failed"})
-		return
-	}
-
-	var linkedSeq any
-	if req.LinkedEventSeq != nil && *req.LinkedEventSeq > 0 {
-		linkedSeq = *req.LinkedEventSeq
-	} else {
-		linkedSeq = nil
-	}
-
-	var artifactID uuid.UUID
-	if err := tx.QueryRow(ctx, `
-		insert into artifacts (run_id, version, kind, content, linked_event_seq)
-		values ($1, $2, $3, $4, $5)
-		returning id
-	`, runID, nextVersion, req.Kind, req.Content, linkedSeq).Scan(&artifactID); err != nil {
+		logError(ctx, "gateway submit artifact: insert failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
 		return
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
-		return
-	}
-
 	// Best-effort: for checkin-stage final artifacts that include a structured proposal,
 	// allow selected agents to auto-generate a follow-up run (no admin required).
 	if req.Kind == "final" {
@@ -169,134 +127,28 @@ func (s server) handleGatewaySubmitArtifact(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	s.recordPrivacyDecision(ctx, privacySurfaceArtifact, privacy, "artifact", artifactID.String(), "agent", agentID)
+	go s.autoModerate(moderationItem{TargetType: "artifact", TargetID: artifactID, ActorType: "agent", ActorID: agentID, Value: req.Content})
 	s.audit(ctx, "agent", agentID, "artifact_submitted", map[string]any{"run_id": runID.String(), "version": nextVersion, "kind": req.Kind, "artifact_id": artifactID.String()})
 	writeJSON(w, http.StatusCreated, submitArtifactResponse{RunRef: runRef, Version: nextVersion, Kind: req.Kind, ArtifactID: artifactID.String()})
 }
 
 func (s server) maybeCreateReviewWorkItem(ctx context.Context, runID uuid.UUID, artifactID uuid.UUID, authorAgentID uuid.UUID) error {
 	// Avoid duplicate review items for the same target artifact.
-	var exists bool
-	if err := s.db.QueryRow(ctx, `
-		select exists(
-			select 1
-			from work_items
-			where run_id = $1
-			  and kind = 'review'
-			  and review_context->>'target_artifact_id' = $2
-		)
-	`, runID, artifactID.String()).Scan(&exists); err != nil {
+	exists, err := s.repo.ReviewWorkItemExists(ctx, runID, artifactID)
+	if err != nil {
 		return err
 	}
 	if exists {
 		return nil
 	}
 
-	// Pick any other enabled participant as reviewer.
-	rows, err := s.db.Query(ctx, `
-		select distinct o.agent_id
-		from work_item_offers o
-		join work_items wi on wi.id = o.work_item_id
-		join agents a on a.id = o.agent_id
-		where wi.run_id = $1
-		  and o.agent_id <> $2
-		  and a.status = 'enabled'
-	`, runID, authorAgentID)
+	reviewerID, err := s.repo.PickReviewer(ctx, runID, authorAgentID)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	var candidates []uuid.UUID
-	for rows.Next() {
-		var id uuid.UUID
-		if err := rows.Scan(&id); err != nil {
-			return err
-		}
-		candidates = append(candidates, id)
-	}
-	if err := rows.Err(); err != nil {
-		return err
-	}
-	if len(candidates) == 0 {
-		// Cold-start friendly fallback: if this run currently has no other offered participants
-		// (e.g. matchingParticipantCount=1), pick any other enabled agent.
-		// Prefer agents that best match the run's required tags, but do not hard-exclude
-		// agents that don't match (early-stage matching should be permissive).
-		rows, err := s.db.Query(ctx, `
-			select a.id
-			from agents a
-			left join run_required_tags rt on rt.run_id = $1
-			left join agent_tags at on at.agent_id = a.id and at.tag = rt.tag
-			where a.status = 'enabled'
-			  and a.id <> $2
-			group by a.id
-			order by count(distinct at.tag) desc, random()
-			limit 50
-		`, runID, authorAgentID)
-		if err != nil {
-			return err
-		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var id uuid.UUID
-			if err := rows.Scan(&id); err != nil {
-				return err
-			}
-			candidates = append(candidates, id)
-		}
-		if err := rows.Err(); err != nil {
-			return err
-		}
-		if len(candidates) == 0 {
-			return nil
-		}
-	}
-
-	// Prefer reviewers that best match the run's required tags (then random as tie-breaker).
-	// This makes review assignment more stable in environments with many enabled agents.
-	requiredTags := []string{}
-	if tagRows, err := s.db.Query(ctx, `select tag from run_required_tags where run_id=$1 order by tag asc`, runID); err == nil {
-		for tagRows.Next() {
-			var t string
-			if err := tagRows.Scan(&t); err != nil {
-				logError(ctx, "scan required tags failed for review work item", err)
-				break
-			}
-			t = strings.TrimSpace(t)
-			if t != "" {
-				requiredTags = append(requiredTags, t)
-			}
-		}
-		if err := tagRows.Err(); err != nil {
-			logError(ctx, "required tags rows error for review work item", err)
-		}
-		tagRows.Close()
-	} else {
-		logError(ctx, "query required tags failed for review work item", err)
-	}
-
-	reviewerID := uuid.Nil
-	if len(requiredTags) > 0 {
-		var picked uuid.UUID
-		err := s.db.QueryRow(ctx, `
-			select a.id
-			from agents a
-			left join agent_tags at on at.agent_id = a.id and at.tag = any($2)
-			where a.id = any($1)
-			group by a.id
-			order by count(distinct at.tag) desc, random()
-			limit 1
-		`, candidates, requiredTags).Scan(&picked)
-		if err == nil {
-			reviewerID = picked
-		} else {
-			logError(ctx, "pick reviewer by tags failed", err)
-		}
-	}
 	if reviewerID == uuid.Nil {
-		shuffleUUIDs(ctx, candidates)
-		reviewerID = candidates[0]
+		return nil
 	}
 
 	authorTag := ""
@@ -316,9 +168,10 @@ func (s server) maybeCreateReviewWorkItem(ctx context.Context, runID uuid.UUID,
 		return err
 	}
 
-	skills := s.skillsGatewayWhitelist
-	if skills == nil {
-		skills = []string{}
+	skills, skillRefsJSON, err := s.repo.ResolveStageSkills(ctx, "review")
+	if err != nil {
+		logError(ctx, "resolve review skills failed", err)
+		return err
 	}
 	availableSkillsJSON, err := json.Marshal(skills)
 	if err != nil {
@@ -331,30 +184,19 @@ func (s server) maybeCreateReviewWorkItem(ctx context.Context, runID uuid.UUID,
 		return err
 	}
 
-	tx, err := s.db.Begin(ctx)
+	workItemID, err := s.repo.CreateWorkItem(ctx, newWorkItem{
+		RunID:           runID,
+		Stage:           "review",
+		Kind:            "review",
+		Status:          "offered",
+		Context:         stageContextJSON,
+		AvailableSkills: availableSkillsJSON,
+		SkillRefs:       skillRefsJSON,
+		ReviewContext:   reviewContextJSON,
+	}, []uuid.UUID{reviewerID})
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback(ctx)
-
-	var workItemID uuid.UUID
-	if err := tx.QueryRow(ctx, `
-		insert into work_items (run_id, stage, kind, status, context, available_skills, review_context)
-		values ($1, 'review', 'review', 'offered', $2, $3, $4)
-		returning id
-	`, runID, stageContextJSON, availableSkillsJSON, reviewContextJSON).Scan(&workItemID); err != nil {
-		return err
-	}
-	if _, err := tx.Exec(ctx, `
-		insert into work_item_offers (work_item_id, agent_id) values ($1, $2)
-		on conflict do nothing
-	`, workItemID, reviewerID); err != nil {
-		return err
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return err
-	}
 
 	s.audit(ctx, "system", platformUserID, "review_work_item_created", map[string]any{
 		"run_id":             runID.String(),
@@ -0,0 +1,443 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// --- Audit log query + export (admin: all rows; owner: own actions + own agents' actions)
+
+const (
+	auditLogPageMaxLimit  = 200
+	auditLogExportPage    = 500
+	auditLogExportMaxRows = 100_000
+)
+
+type auditLogDTO struct {
+	ID        string         `json:"id"`
+	ActorType string         `json:"actor_type"`
+	ActorID   string         `json:"actor_id"`
+	ActorRef  string         `json:"actor_ref,omitempty"`
+	Action    string         `json:"action"`
+	RunRef    string         `json:"run_ref,omitempty"`
+	Data      map[string]any `json:"data"`
+	CreatedAt string         `json:"created_at"`
+}
+
+type listAuditLogsResponse struct {
+	Items      []auditLogDTO `json:"items"`
+	HasMore    bool          `json:"has_more"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type auditLogCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type auditLogFilter struct {
+	// Scope restricts rows to (user actor = ScopeUserID) or (agent actor owned by ScopeUserID).
+	// Nil ScopeUserID means unscoped (admin).
+	ScopeUserID *uuid.UUID
+
+	ActorType string
+	ActorID   *uuid.UUID
+	Actions   []string
+	RunID     *uuid.UUID
+	Since     *time.Time
+	Until     *time.Time
+	After     *auditLogCursor
+}
+
+func encodeAuditLogCursor(c auditLogCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeAuditLogCursor(s string) (auditLogCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return auditLogCursor{}, err
+	}
+	parts := strings.SplitN(string(b), "|", 2)
+	if len(parts) != 2 {
+		return auditLogCursor{}, errors.New("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return auditLogCursor{}, err
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return auditLogCursor{}, err
+	}
+	return auditLogCursor{CreatedAt: t, ID: id}, nil
+}
+
+// parseAuditLogFilter reads the shared query parameters. It writes a 4xx and returns false on bad input.
+// Agent refs are resolved to IDs; for owner scope the agent must belong to the owner.
+func (s server) parseAuditLogFilter(ctx context.Context, w http.ResponseWriter, r *http.Request, f *auditLogFilter) bool {
+	q := r.URL.Query()
+
+	if v := strings.TrimSpace(q.Get("actor_type")); v != "" {
+		switch v {
+		case "user", "agent", "admin", "system":
+			f.ActorType = v
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid actor_type"})
+			return false
+		}
+	}
+	if v := strings.TrimSpace(q.Get("actor_id")); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid actor_id"})
+			return false
+		}
+		f.ActorID = &id
+	}
+	if v := strings.TrimSpace(q.Get("agent_ref")); v != "" {
+		agentRef, err := parseAgentRef(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid agent_ref"})
+			return false
+		}
+		var agentID uuid.UUID
+		if f.ScopeUserID != nil {
+			agentID, err = s.lookupOwnerAgentIDByRef(ctx, *f.ScopeUserID, agentRef)
+		} else {
+			agentID, err = s.lookupAgentIDByRef(ctx, agentRef)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "agent not found"})
+			return false
+		}
+		if err != nil {
+			logError(ctx, "audit logs: lookup agent failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return false
+		}
+		f.ActorType = "agent"
+		f.ActorID = &agentID
+	}
+	if v := strings.TrimSpace(q.Get("action")); v != "" {
+		for _, a := range strings.Split(v, ",") {
+			a = strings.TrimSpace(a)
+			if a == "" {
+				continue
+			}
+			if len(a) > 100 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid action"})
+				return false
+			}
+			f.Actions = append(f.Actions, a)
+		}
+		if len(f.Actions) > 20 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "too many actions"})
+			return false
+		}
+	}
+	if v := strings.TrimSpace(q.Get("run_ref")); v != "" {
+		runRef, err := parseRunRef(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid run_ref"})
+			return false
+		}
+		runID, err := s.lookupRunIDByRef(ctx, runRef)
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "run not found"})
+			return false
+		}
+		if err != nil {
+			logError(ctx, "audit logs: lookup run failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return false
+		}
+		f.RunID = &runID
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		v := strings.TrimSpace(q.Get(p.name))
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + p.name + " (RFC3339)"})
+			return false
+		}
+		t = t.UTC()
+		*p.dst = &t
+	}
+	if v := strings.TrimSpace(q.Get("cursor")); v != "" {
+		c, err := decodeAuditLogCursor(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid cursor"})
+			return false
+		}
+		f.After = &c
+	}
+	return true
+}
+
+// queryAuditLogs returns up to limit rows newest-first, plus whether more rows exist.
+func (s server) queryAuditLogs(ctx context.Context, f auditLogFilter, limit int) ([]auditLogDTO, bool, *auditLogCursor, error) {
+	args := make([]any, 0, 12)
+	where := make([]string, 0, 8)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if f.ScopeUserID != nil {
+		owner := arg(*f.ScopeUserID)
+		where = append(where, "((l.actor_type = 'user' and l.actor_id = "+owner+
+			") or (l.actor_type = 'agent' and l.actor_id in (select id from agents where owner_id = "+owner+")))")
+	}
+	if f.ActorType != "" {
+		where = append(where, "l.actor_type = "+arg(f.ActorType))
+	}
+	if f.ActorID != nil {
+		where = append(where, "l.actor_id = "+arg(*f.ActorID))
+	}
+	if len(f.Actions) > 0 {
+		where = append(where, "l.action = any("+arg(f.Actions)+")")
+	}
+	if f.RunID != nil {
+		where = append(where, "l.run_id = "+arg(*f.RunID))
+	}
+	if f.Since != nil {
+		where = append(where, "l.created_at >= "+arg(*f.Since))
+	}
+	if f.Until != nil {
+		where = append(where, "l.created_at < "+arg(*f.Until))
+	}
+	if f.After != nil {
+		where = append(where, "(l.created_at, l.id) < ("+arg(f.After.CreatedAt)+", "+arg(f.After.ID)+")")
+	}
+
+	sql := `
+		select l.id, l.actor_type, l.actor_id, coalesce(a.public_ref, ''), l.action,
+		       coalesce(r.public_ref, ''), l.data, l.created_at
+		from audit_logs l
+		left join agents a on l.actor_type = 'agent' and a.id = l.actor_id
+		left join runs r on r.id = l.run_id
+	`
+	if len(where) > 0 {
+		sql += " where " + strings.Join(where, " and ")
+	}
+	sql += " order by l.created_at desc, l.id desc limit " + arg(limit+1)
+
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	defer rows.Close()
+
+	out := make([]auditLogDTO, 0, limit)
+	var last auditLogCursor
+	hasMore := false
+	for rows.Next() {
+		if len(out) >= limit {
+			hasMore = true
+			break
+		}
+		var (
+			id        uuid.UUID
+			actorType string
+			actorID   uuid.UUID
+			actorRef  string
+			action    string
+			runRef    string
+			dataB     []byte
+			createdAt time.Time
+		)
+		if err := rows.Scan(&id, &actorType, &actorID, &actorRef, &action, &runRef, &dataB, &createdAt); err != nil {
+			return nil, false, nil, err
+		}
+		data := map[string]any{}
+		if err := unmarshalJSONNullable(dataB, &data); err != nil {
+			logError(ctx, "audit logs: decode data failed", err)
+		}
+		out = append(out, auditLogDTO{
+			ID:        id.String(),
+			ActorType: actorType,
+			ActorID:   actorID.String(),
+			ActorRef:  actorRef,
+			Action:    action,
+			RunRef:    runRef,
+			Data:      data,
+			CreatedAt: createdAt.UTC().Format(time.RFC3339Nano),
+		})
+		last = auditLogCursor{CreatedAt: createdAt, ID: id}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, nil, err
+	}
+	if !hasMore || len(out) == 0 {
+		return out, hasMore, nil, nil
+	}
+	return out, true, &last, nil
+}
+
+func (s server) handleAdminListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	s.serveAuditLogList(w, r, auditLogFilter{})
+}
+
+func (s server) handleAdminExportAuditLogs(w http.ResponseWriter, r *http.Request) {
+	s.serveAuditLogExport(w, r, auditLogFilter{})
+}
+
+func (s server) handleOwnerListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.requireOwnerAuditLogScope(w, r)
+	if !ok {
+		return
+	}
+	s.serveAuditLogList(w, r, f)
+}
+
+func (s server) handleOwnerExportAuditLogs(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.requireOwnerAuditLogScope(w, r)
+	if !ok {
+		return
+	}
+	s.serveAuditLogExport(w, r, f)
+}
+
+// requireOwnerAuditLogScope scopes to the user's own actions and actions by their agents.
+func (s server) requireOwnerAuditLogScope(w http.ResponseWriter, r *http.Request) (auditLogFilter, bool) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return auditLogFilter{}, false
+	}
+	return auditLogFilter{ScopeUserID: &userID}, true
+}
+
+func (s server) serveAuditLogList(w http.ResponseWriter, r *http.Request, f auditLogFilter) {
+	limit := 50
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = clampInt(n, 1, auditLogPageMaxLimit)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if !s.parseAuditLogFilter(ctx, w, r, &f) {
+		return
+	}
+	items, hasMore, next, err := s.queryAuditLogs(ctx, f, limit)
+	if err != nil {
+		logError(ctx, "audit logs: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	resp := listAuditLogsResponse{Items: items, HasMore: hasMore}
+	if next != nil {
+		resp.NextCursor = encodeAuditLogCursor(*next)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// serveAuditLogExport streams matching rows as JSONL (default) or CSV, paging internally.
+func (s server) serveAuditLogExport(w http.ResponseWriter, r *http.Request, f auditLogFilter) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = "jsonl"
+	}
+	if format != "jsonl" && format != "csv" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid format (jsonl|csv)"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	if !s.parseAuditLogFilter(ctx, w, r, &f) {
+		return
+	}
+
+	// Fetch the first page before writing headers so query errors can still produce a JSON error.
+	items, hasMore, next, err := s.queryAuditLogs(ctx, f, auditLogExportPage)
+	if err != nil {
+		logError(ctx, "audit logs export: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+
+	filename := "audit_logs_" + time.Now().UTC().Format("20060102_150405") + "." + format
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	bw := bufio.NewWriter(w)
+	var cw *csv.Writer
+	if format == "csv" {
+		cw = csv.NewWriter(bw)
+		if err := cw.Write([]string{"id", "created_at", "actor_type", "actor_id", "actor_ref", "action", "run_ref", "data"}); err != nil {
+			logError(ctx, "audit logs export: write csv header failed", err)
+			return
+		}
+	}
+	enc := json.NewEncoder(bw)
+
+	written := 0
+	for {
+		for _, it := range items {
+			if cw != nil {
+				dataB, err := json.Marshal(it.Data)
+				if err != nil {
+					dataB = []byte("{}")
+				}
+				if err := cw.Write([]string{it.ID, it.CreatedAt, it.ActorType, it.ActorID, it.ActorRef, it.Action, it.RunRef, string(dataB)}); err != nil {
+					logError(ctx, "audit logs export: write csv row failed", err)
+					return
+				}
+			} else if err := enc.Encode(it); err != nil {
+				logError(ctx, "audit logs export: write jsonl row failed", err)
+				return
+			}
+			written++
+		}
+		if !hasMore || next == nil || written >= auditLogExportMaxRows {
+			break
+		}
+		f.After = next
+		items, hasMore, next, err = s.queryAuditLogs(ctx, f, auditLogExportPage)
+		if err != nil {
+			// Headers are already sent; truncate the export and log.
+			logError(ctx, "audit logs export: query next page failed", err)
+			break
+		}
+	}
+
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			logError(ctx, "audit logs export: flush csv failed", err)
+			return
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		logError(ctx, "audit logs export: flush failed", err)
+	}
+}
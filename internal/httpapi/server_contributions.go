@@ -0,0 +1,426 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// --- Contribution ledger (append-only) + public leaderboards
+
+const (
+	contributionWorkItemCompleted = "work_item_completed"
+	contributionReviewGiven       = "review_given"
+	contributionArtifactApproved  = "artifact_approved"
+	contributionVoteWon           = "vote_won"
+)
+
+var contributionPoints = map[string]int{
+	contributionWorkItemCompleted: 1,
+	contributionReviewGiven:       1,
+	contributionArtifactApproved:  2,
+	contributionVoteWon:           1,
+}
+
+var contributionKinds = []string{
+	contributionWorkItemCompleted,
+	contributionReviewGiven,
+	contributionArtifactApproved,
+	contributionVoteWon,
+}
+
+type contributionEntry struct {
+	AgentID      uuid.UUID
+	Kind         string
+	Stage        string
+	WorkItemKind string
+	RunID        *uuid.UUID
+	TopicID      string
+	// SourceKey makes the entry idempotent per kind (work item id, artifact id, voter|topic|round).
+	SourceKey string
+}
+
+// recordContribution appends a ledger entry for the agent and its current owner.
+// Re-recording the same (kind, source_key) is a no-op.
+func recordContribution(ctx context.Context, q dbExecer, e contributionEntry) error {
+	_, err := q.Exec(ctx, `
+		insert into contribution_ledger (agent_id, owner_id, kind, points, stage, work_item_kind, run_id, topic_id, source_key)
+		select a.id, a.owner_id, $2, $3, $4, $5, $6, $7, $8
+		from agents a
+		where a.id = $1
+		on conflict (kind, source_key) do nothing
+	`, e.AgentID, e.Kind, contributionPoints[e.Kind], e.Stage, e.WorkItemKind, e.RunID, e.TopicID, e.SourceKey)
+	return err
+}
+
+// recordArtifactApproved credits the artifact's author (if known).
+func recordArtifactApproved(ctx context.Context, q dbExecer, artifactID uuid.UUID) error {
+	_, err := q.Exec(ctx, `
+		insert into contribution_ledger (agent_id, owner_id, kind, points, run_id, source_key)
+		select a.id, a.owner_id, $2, $3, ar.run_id, ar.id::text
+		from artifacts ar
+		join agents a on a.id = ar.author_agent_id
+		where ar.id = $1
+		on conflict (kind, source_key) do nothing
+	`, artifactID, contributionArtifactApproved, contributionPoints[contributionArtifactApproved])
+	return err
+}
+
+// topicMessageAuthorRef extracts <agent_ref> from a topics/<topic_id>/messages/<agent_ref>/<id>.json key.
+func topicMessageAuthorRef(basePrefix string, topicID string, objectKey string) (string, bool) {
+	key := strings.TrimLeft(stripBasePrefix(strings.TrimLeft(strings.TrimSpace(objectKey), "/"), basePrefix), "/")
+	prefix := "topics/" + topicID + "/messages/"
+	if !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(key, prefix), "/")
+	if len(parts) != 2 || !strings.HasSuffix(parts[1], ".json") {
+		return "", false
+	}
+	ref, err := parseAgentRef(parts[0])
+	if err != nil {
+		return "", false
+	}
+	return ref, true
+}
+
+// topicMessageVoteRound is the vote round a topic message belongs to: meta.round_id, else
+// meta.round_no, else "" (topics without rounds have a single vote round).
+func topicMessageVoteRound(body []byte) string {
+	var obj struct {
+		Meta map[string]any `json:"meta"`
+	}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return ""
+	}
+	if v, ok := obj.Meta["round_id"].(string); ok && strings.TrimSpace(v) != "" {
+		return strings.TrimSpace(v)
+	}
+	if v, ok := obj.Meta["round_no"].(float64); ok {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return ""
+}
+
+// recordVoteWonInTx credits the author of the topic message voted for by an approved vote request
+// (reviewID is its topic_content_reviews row); it runs with the approval, so unreviewed and rejected
+// votes never count. The target must be a live, non-rejected message of the same topic; self-votes
+// and votes between agents of the same owner do not count, and each voter earns the author at most
+// one point per (topic, vote round).
+func recordVoteWonInTx(ctx context.Context, tx pgx.Tx, basePrefix string, reviewID uuid.UUID) error {
+	var topicID, voterRef string
+	var body []byte
+	err := tx.QueryRow(ctx, `
+		select v.topic_id, v.agent_ref, e.payload
+		from topic_content_reviews v
+		join lateral (
+			select event_type, payload
+			from oss_events
+			where object_key = v.object_key
+			order by id desc
+			limit 1
+		) e on e.event_type = 'put'
+		where v.id = $1 and v.target_type = 'topic_request'
+	`, reviewID).Scan(&topicID, &voterRef, &body)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var req struct {
+		Type    string `json:"type"`
+		Payload struct {
+			TargetObjectKey string `json:"target_object_key"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || strings.TrimSpace(req.Type) != "vote" {
+		return nil
+	}
+	target := req.Payload.TargetObjectKey
+	authorRef, ok := topicMessageAuthorRef(basePrefix, topicID, target)
+	if !ok || authorRef == voterRef {
+		return nil
+	}
+	target = strings.TrimLeft(stripBasePrefix(strings.TrimLeft(strings.TrimSpace(target), "/"), basePrefix), "/")
+
+	var authorID uuid.UUID
+	var targetBody []byte
+	err = tx.QueryRow(ctx, `
+		select author.id, e.payload
+		from agents author
+		join agents voter on voter.public_ref = $2
+		join lateral (
+			select event_type, payload
+			from oss_events
+			where object_key = $3
+			order by id desc
+			limit 1
+		) e on true
+		where author.public_ref = $1
+		  and author.owner_id <> voter.owner_id
+		  and e.event_type = 'put'
+		  and `+topicNotRejectedSQL("$3", basePrefix)+`
+	`, authorRef, voterRef, target).Scan(&authorID, &targetBody)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return recordContribution(ctx, tx, contributionEntry{
+		AgentID:   authorID,
+		Kind:      contributionVoteWon,
+		TopicID:   topicID,
+		SourceKey: voterRef + "|" + topicID + "|" + topicMessageVoteRound(targetBody),
+	})
+}
+
+// contributionPeriodStart maps week|month|all to the (UTC) start of the current period.
+func contributionPeriodStart(period string, now time.Time) (time.Time, bool) {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case "week":
+		offset := (int(day.Weekday()) + 6) % 7 // Monday-based weeks.
+		return day.AddDate(0, 0, -offset), true
+	case "month":
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), true
+	case "all":
+		return time.Time{}, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+type contributionBreakdownDTO map[string]int
+
+type leaderboardItemDTO struct {
+	Rank      int                      `json:"rank"`
+	AgentRef  string                   `json:"agent_ref"`
+	Name      string                   `json:"name,omitempty"`
+	AvatarURL string                   `json:"avatar_url,omitempty"`
+	Points    int                      `json:"points"`
+	Breakdown contributionBreakdownDTO `json:"breakdown"`
+}
+
+type leaderboardResponse struct {
+	Period string               `json:"period"`
+	Since  string               `json:"since,omitempty"`
+	Stage  string               `json:"stage,omitempty"`
+	Tag    string               `json:"tag,omitempty"`
+	Kind   string               `json:"kind,omitempty"`
+	Items  []leaderboardItemDTO `json:"items"`
+}
+
+// handleGetLeaderboard is public and lists only agents that opted into public discovery with an
+// approved card. Contributions made in unlisted (non-public) runs are excluded.
+func (s server) handleGetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	period := strings.TrimSpace(q.Get("period"))
+	if period == "" {
+		period = "week"
+	}
+	since, ok := contributionPeriodStart(period, time.Now())
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid period (week|month|all)"})
+		return
+	}
+	stage := strings.TrimSpace(q.Get("stage"))
+	if len(stage) > 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid stage"})
+		return
+	}
+	tag := strings.TrimSpace(q.Get("tag"))
+	if len(tag) > 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tag"})
+		return
+	}
+	kind := strings.TrimSpace(q.Get("kind"))
+	if kind != "" {
+		if _, ok := contributionPoints[kind]; !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid kind"})
+			return
+		}
+	}
+	limit := 50
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = clampInt(n, 1, 100)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	args := []any{since}
+	where := []string{
+		"l.created_at >= $1",
+		"a.status = 'enabled'",
+		"coalesce(a.discovery->>'public','false') = 'true'",
+		"a.card_review_status = 'approved'",
+		"(l.run_id is null or r.is_public)",
+	}
+	if stage != "" {
+		args = append(args, stage)
+		where = append(where, "l.stage = $"+strconv.Itoa(len(args)))
+	}
+	if kind != "" {
+		args = append(args, kind)
+		where = append(where, "l.kind = $"+strconv.Itoa(len(args)))
+	}
+	if tag != "" {
+		args = append(args, tag)
+		where = append(where, "exists (select 1 from agent_tags t where t.agent_id = a.id and t.tag = $"+strconv.Itoa(len(args))+")")
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Query(ctx, `
+		select a.public_ref, a.name, a.avatar_url,
+		       sum(l.points)::int,
+		       count(*) filter (where l.kind = 'work_item_completed')::int,
+		       count(*) filter (where l.kind = 'review_given')::int,
+		       count(*) filter (where l.kind = 'artifact_approved')::int,
+		       count(*) filter (where l.kind = 'vote_won')::int
+		from contribution_ledger l
+		join agents a on a.id = l.agent_id
+		left join runs r on r.id = l.run_id
+		where `+strings.Join(where, " and ")+`
+		group by a.id
+		order by sum(l.points) desc, a.public_ref asc
+		limit $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		logError(ctx, "leaderboard query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	items := make([]leaderboardItemDTO, 0, limit)
+	for rows.Next() {
+		var it leaderboardItemDTO
+		var completed, reviews, approved, votes int
+		if err := rows.Scan(&it.AgentRef, &it.Name, &it.AvatarURL, &it.Points, &completed, &reviews, &approved, &votes); err != nil {
+			logError(ctx, "leaderboard scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		it.Rank = len(items) + 1
+		it.Name = strings.TrimSpace(it.Name)
+		it.AvatarURL = strings.TrimSpace(it.AvatarURL)
+		it.Breakdown = contributionBreakdownDTO{
+			contributionWorkItemCompleted: completed,
+			contributionReviewGiven:       reviews,
+			contributionArtifactApproved:  approved,
+			contributionVoteWon:           votes,
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "leaderboard iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	resp := leaderboardResponse{Period: period, Stage: stage, Tag: tag, Kind: kind, Items: items}
+	if !since.IsZero() {
+		resp.Since = since.Format(time.RFC3339)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type ownerContributionAgentDTO struct {
+	AgentRef  string                   `json:"agent_ref"`
+	Name      string                   `json:"name"`
+	Points    int                      `json:"points"`
+	Breakdown contributionBreakdownDTO `json:"breakdown"`
+}
+
+type ownerContributionsResponse struct {
+	Period    string                      `json:"period"`
+	Since     string                      `json:"since,omitempty"`
+	Points    int                         `json:"points"`
+	Breakdown contributionBreakdownDTO    `json:"breakdown"`
+	Agents    []ownerContributionAgentDTO `json:"agents"`
+}
+
+// handleOwnerGetContributions returns the owner's ledger totals, split per agent.
+func (s server) handleOwnerGetContributions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	period := strings.TrimSpace(r.URL.Query().Get("period"))
+	if period == "" {
+		period = "all"
+	}
+	since, ok := contributionPeriodStart(period, time.Now())
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid period (week|month|all)"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select a.public_ref, a.name, l.kind, count(*)::int, sum(l.points)::int
+		from contribution_ledger l
+		join agents a on a.id = l.agent_id
+		where l.owner_id = $1 and l.created_at >= $2
+		group by a.public_ref, a.name, l.kind
+		order by a.public_ref asc
+	`, userID, since)
+	if err != nil {
+		logError(ctx, "owner contributions query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	resp := ownerContributionsResponse{Period: period, Breakdown: contributionBreakdownDTO{}, Agents: []ownerContributionAgentDTO{}}
+	for _, k := range contributionKinds {
+		resp.Breakdown[k] = 0
+	}
+	byRef := map[string]int{}
+	for rows.Next() {
+		var agentRef, name, kind string
+		var count, points int
+		if err := rows.Scan(&agentRef, &name, &kind, &count, &points); err != nil {
+			logError(ctx, "owner contributions scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		idx, ok := byRef[agentRef]
+		if !ok {
+			idx = len(resp.Agents)
+			byRef[agentRef] = idx
+			bd := contributionBreakdownDTO{}
+			for _, k := range contributionKinds {
+				bd[k] = 0
+			}
+			resp.Agents = append(resp.Agents, ownerContributionAgentDTO{AgentRef: agentRef, Name: strings.TrimSpace(name), Breakdown: bd})
+		}
+		resp.Agents[idx].Points += points
+		resp.Agents[idx].Breakdown[kind] += count
+		resp.Points += points
+		resp.Breakdown[kind] += count
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "owner contributions iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	if !since.IsZero() {
+		resp.Since = since.Format(time.RFC3339)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,33 @@
+package httpapi
+
+import "testing"
+
+func TestTopicMessageAuthorRef(t *testing.T) {
+	ref, ok := topicMessageAuthorRef("aihub", "t1", "/aihub/topics/t1/messages/a_0123456789abcdef/m1.json")
+	if !ok || ref != "a_0123456789abcdef" {
+		t.Fatalf("author ref %q %v", ref, ok)
+	}
+	for _, key := range []string{
+		"topics/t2/messages/a_0123456789abcdef/m1.json",
+		"topics/t1/requests/a_0123456789abcdef/r1.json",
+		"topics/t1/messages/a_0123456789abcdef/sub/m1.json",
+	} {
+		if _, ok := topicMessageAuthorRef("", "t1", key); ok {
+			t.Fatalf("accepted %q", key)
+		}
+	}
+}
+
+func TestTopicMessageVoteRound(t *testing.T) {
+	cases := map[string]string{
+		`{"meta":{"round_id":"round_0001"}}`: "round_0001",
+		`{"meta":{"round_no":3}}`:            "3",
+		`{"meta":{"reply_to":"x"}}`:          "",
+		`not json`:                           "",
+	}
+	for body, want := range cases {
+		if got := topicMessageVoteRound([]byte(body)); got != want {
+			t.Fatalf("round of %s = %q, want %q", body, got, want)
+		}
+	}
+}
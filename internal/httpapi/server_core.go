@@ -11,6 +11,7 @@ import (
 	"unicode"
 
 	"aihub/internal/keys"
+	"aihub/internal/toolruntime"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -20,6 +21,7 @@ import (
 
 type server struct {
 	db                     *pgxpool.Pool
+	repo                   repository // run pipeline storage (see repository.go)
 	pepper                 string
 	publicBaseURL          string
 	appDownloadURL         string
@@ -32,6 +34,13 @@ type server struct {
 
 	br *broker
 
+	// Privacy policies per content surface + findings log (see privacy_policy.go).
+	privacy *privacyGuard
+	// Rule-based pre-moderation of new content (see auto_moderation.go).
+	moderator *autoModerator
+	// Token-bucket rate limits per route class and caller (see ratelimit.go).
+	limiter *rateLimiter
+
 	platformKeysEncryptionKey string
 	platformCertIssuer        string
 	platformCertTTLSeconds    int
@@ -46,6 +55,7 @@ type server struct {
 	ossAccessKeySecret    string
 	ossSTSRoleARN         string
 	ossSTSDurationSeconds int
+	ossSTSHourlyLimit     int
 	ossLocalDir           string
 	ossEventsIngestToken  string
 
@@ -61,6 +71,12 @@ type server struct {
 	// Platform-issued "topic play" work items (topic-first self-play; agents claim via inbox).
 	topicPlayActorTags          []string
 	topicPlayDailyLimitPerAgent int
+
+	// Outbound webhooks (signed deliveries; see webhooks.go).
+	webhookClient *http.Client
+
+	// Gateway tool runtime (built-in platform tools; see gateway_tools.go).
+	tools *toolruntime.Registry
 }
 
 type eventDTO struct {
@@ -145,11 +161,8 @@ func unmarshalJSONNullable(b []byte, dst any) error {
 }
 
 func (s server) audit(ctx context.Context, actorType string, actorID uuid.UUID, action string, data map[string]any) {
-	// Best-effort for MVP.
-	if _, err := s.db.Exec(ctx, `
-		insert into audit_logs (actor_type, actor_id, action, data)
-		values ($1, $2, $3, $4)
-	`, actorType, actorID, action, data); err != nil {
+	// Best-effort for MVP. Rows are appended to the audit hash chain (see audit_chain.go).
+	if err := s.repo.AppendAudit(ctx, actorType, actorID, action, data); err != nil {
 		logError(ctx, "audit insert failed", err)
 	}
 }
@@ -364,6 +377,16 @@ func (s server) handleCreateAgent(w http.ResponseWriter, r *http.Request) {
 		autonomous = *req.Autonomous
 	}
 
+	card, privacy, ok := s.enforceAgentCardPrivacy(r.Context(), w, agentCardText{
+		Name: req.Name, Description: req.Description, Bio: bio, Greeting: greeting,
+		Interests: interests, Capabilities: capabilities,
+	})
+	if !ok {
+		return
+	}
+	req.Name, req.Description, bio, greeting = card.Name, card.Description, card.Bio, card.Greeting
+	interests, capabilities = card.Interests, card.Capabilities
+
 	var personaAny any
 	var personaJSON []byte
 	if strings.TrimSpace(req.PersonaTemplateID) != "" {
@@ -460,6 +483,9 @@ func (s server) handleCreateAgent(w http.ResponseWriter, r *http.Request) {
 	} else if isPureCatalogCard(catalogs, strings.TrimSpace(req.PersonaTemplateID), interests, capabilities, bio, greeting, req.Name) {
 		cardReviewStatus = "approved"
 	}
+	if len(privacy.Flagged) > 0 {
+		cardReviewStatus = "pending"
+	}
 
 	var agentID uuid.UUID
 	agentRef := ""
@@ -548,6 +574,7 @@ func (s server) handleCreateAgent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordPrivacyDecision(ctx, privacySurfaceAgentCard, privacy, "agent_card", agentRef, "user", userID)
 	s.audit(ctx, "user", userID, "agent_api_key_issued", map[string]any{"agent_id": agentID.String()})
 	writeJSON(w, http.StatusCreated, createAgentResponse{
 		AgentRef: agentRef,
@@ -608,21 +635,32 @@ func (s server) createOnboardingOffer(ctx context.Context, tx pgx.Tx, agentID uu
 		return uuid.Nil, uuid.Nil, err
 	}
 
-	skills := s.skillsGatewayWhitelist
-	if skills == nil {
-		skills = []string{}
+	onboardingSkills, onboardingSkillRefsJSON, err := s.resolveStageSkills(ctx, tx, "onboarding")
+	if err != nil {
+		logError(ctx, "resolve onboarding skills failed", err)
+		return uuid.Nil, uuid.Nil, err
 	}
-	availableSkillsJSON, err := json.Marshal(skills)
+	onboardingSkillsJSON, err := json.Marshal(onboardingSkills)
 	if err != nil {
 		logError(ctx, "marshal available_skills failed", err)
 		return uuid.Nil, uuid.Nil, err
 	}
-	onboardingContextJSON, err := json.Marshal(s.stageContextForStage("onboarding", skills))
+	checkinSkills, checkinSkillRefsJSON, err := s.resolveStageSkills(ctx, tx, "checkin")
+	if err != nil {
+		logError(ctx, "resolve checkin skills failed", err)
+		return uuid.Nil, uuid.Nil, err
+	}
+	checkinSkillsJSON, err := json.Marshal(checkinSkills)
+	if err != nil {
+		logError(ctx, "marshal available_skills failed", err)
+		return uuid.Nil, uuid.Nil, err
+	}
+	onboardingContextJSON, err := json.Marshal(s.stageContextForStage("onboarding", onboardingSkills))
 	if err != nil {
 		logError(ctx, "marshal stage_context failed", err)
 		return uuid.Nil, uuid.Nil, err
 	}
-	checkinContextJSON, err := json.Marshal(s.stageContextForStage("checkin", skills))
+	checkinContextJSON, err := json.Marshal(s.stageContextForStage("checkin", checkinSkills))
 	if err != nil {
 		logError(ctx, "marshal stage_context failed", err)
 		return uuid.Nil, uuid.Nil, err
@@ -630,10 +668,10 @@ func (s server) createOnboardingOffer(ctx context.Context, tx pgx.Tx, agentID uu
 
 	var introWorkItemID uuid.UUID
 	if err := tx.QueryRow(ctx, `
-		insert into work_items (run_id, stage, kind, status, context, available_skills)
-		values ($1, 'onboarding', 'contribute', 'offered', $2, $3)
+		insert into work_items (run_id, stage, kind, status, context, available_skills, skill_refs)
+		values ($1, 'onboarding', 'contribute', 'offered', $2, $3, $4)
 		returning id
-	`, platformIntroRunID, onboardingContextJSON, availableSkillsJSON).Scan(&introWorkItemID); err != nil {
+	`, platformIntroRunID, onboardingContextJSON, onboardingSkillsJSON, onboardingSkillRefsJSON).Scan(&introWorkItemID); err != nil {
 		return uuid.Nil, uuid.Nil, err
 	}
 	if _, err := tx.Exec(ctx, `
@@ -645,10 +683,10 @@ func (s server) createOnboardingOffer(ctx context.Context, tx pgx.Tx, agentID uu
 
 	var checkinWorkItemID uuid.UUID
 	if err := tx.QueryRow(ctx, `
-		insert into work_items (run_id, stage, kind, status, context, available_skills)
-		values ($1, 'checkin', 'contribute', 'offered', $2, $3)
+		insert into work_items (run_id, stage, kind, status, context, available_skills, skill_refs)
+		values ($1, 'checkin', 'contribute', 'offered', $2, $3, $4)
 		returning id
-	`, platformCheckinRunID, checkinContextJSON, availableSkillsJSON).Scan(&checkinWorkItemID); err != nil {
+	`, platformCheckinRunID, checkinContextJSON, checkinSkillsJSON, checkinSkillRefsJSON).Scan(&checkinWorkItemID); err != nil {
 		return uuid.Nil, uuid.Nil, err
 	}
 	if _, err := tx.Exec(ctx, `
@@ -784,6 +822,7 @@ func (s server) handleDisableAgent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.audit(ctx, "user", userID, "agent_disabled", map[string]any{"agent_id": agentID.String()})
+	s.emitWebhookEventBestEffort(ctx, []uuid.UUID{userID}, webhookEventAgentDisabled, map[string]any{"agent_ref": agentRef, "disabled_by": "owner"})
 	writeJSON(w, http.StatusOK, map[string]string{"status": "disabled"})
 }
 
@@ -1174,6 +1213,20 @@ func (s server) handleUpdateAgent(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var privacy privacyDecision
+	if cardChanged {
+		card, d, ok := s.enforceAgentCardPrivacy(ctx, w, agentCardText{
+			Name: name, Description: description, Bio: bio, Greeting: greeting,
+			Interests: interests, Capabilities: capabilities,
+		})
+		if !ok {
+			return
+		}
+		name, description, bio, greeting = card.Name, card.Description, card.Bio, card.Greeting
+		interests, capabilities = card.Interests, card.Capabilities
+		privacy = d
+	}
+
 	personalityJSON, err := marshalJSONB(personality)
 	if err != nil {
 		logError(ctx, "marshal personality failed", err)
@@ -1236,6 +1289,9 @@ func (s server) handleUpdateAgent(w http.ResponseWriter, r *http.Request) {
 		} else if isPureCatalogCard(catalogs, "", interests, capabilities, bio, greeting, name) {
 			cardReviewStatus = "approved"
 		}
+		if len(privacy.Flagged) > 0 {
+			cardReviewStatus = "pending"
+		}
 	}
 
 	discoveryJSON, err := marshalJSONB(discovery)
@@ -1301,6 +1357,7 @@ func (s server) handleUpdateAgent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordPrivacyDecision(ctx, privacySurfaceAgentCard, privacy, "agent_card", agentRef, "user", userID)
 	s.audit(ctx, "user", userID, "agent_updated", map[string]any{"agent_id": agentID.String()})
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
@@ -1399,6 +1456,8 @@ func (s server) handleDeleteAgentTag(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
+// handleRotateAgentKey replaces all active keys of the agent with one new full-scope key. The old
+// keys keep working for grace_minutes (optional body; default: revoked now).
 func (s server) handleRotateAgentKey(w http.ResponseWriter, r *http.Request) {
 	userID, ok := userIDFromCtx(r.Context())
 	if !ok {
@@ -1410,14 +1469,10 @@ func (s server) handleRotateAgentKey(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
-
-	apiKey, err := keys.NewAPIKey()
-	if err != nil {
-		logError(r.Context(), "generate agent api key failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "key generation failed"})
+	req, ok := readRotateAPIKeyRequest(w, r)
+	if !ok {
 		return
 	}
-	hash := keys.HashAPIKey(s.pepper, apiKey)
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
@@ -1441,17 +1496,14 @@ func (s server) handleRotateAgentKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := tx.Exec(ctx, `
-		update agent_api_keys set revoked_at = now()
-		where agent_id = $1 and revoked_at is null
-	`, agentID); err != nil {
+	until, _, err := retireAPIKeys(ctx, tx, agentKeyTable, agentID, nil, req.GraceMinutes)
+	if err != nil {
 		logError(ctx, "revoke agent api keys failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "revoke failed"})
 		return
 	}
-	if _, err := tx.Exec(ctx, `
-		insert into agent_api_keys (agent_id, key_hash) values ($1, $2)
-	`, agentID, hash); err != nil {
+	key, err := s.insertAPIKey(ctx, tx, agentKeyTable, agentID, "default", agentKeyTable.Scopes, nil)
+	if err != nil {
 		logError(ctx, "insert agent api key failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
 		return
@@ -1463,8 +1515,8 @@ func (s server) handleRotateAgentKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.audit(ctx, "user", userID, "agent_api_key_rotated", map[string]any{"agent_id": agentID.String()})
-	writeJSON(w, http.StatusOK, map[string]string{"api_key": apiKey})
+	s.audit(ctx, "user", userID, "agent_api_key_rotated", map[string]any{"agent_id": agentID.String(), "key_id": key.ID, "grace_minutes": req.GraceMinutes})
+	writeJSON(w, http.StatusOK, map[string]string{"api_key": key.APIKey, "previous_expires_at": until.UTC().Format(time.RFC3339)})
 }
 
 type replaceTagsRequest struct {
@@ -1476,6 +1528,8 @@ type createRunRequest struct {
 	Constraints  string     `json:"constraints"`
 	RequiredTags []string   `json:"required_tags"`
 	ScheduledAt  *time.Time `json:"scheduled_at,omitempty"`
+	// AllowedTools must be available tool_catalog entries (run_allowed_tools).
+	AllowedTools []string `json:"allowed_tools,omitempty"`
 }
 
 type createRunResponse struct {
@@ -1508,33 +1562,42 @@ func (s server) handleCreateRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	req.RequiredTags = normalizeTags(req.RequiredTags)
+	allowedTools, badTool := normalizeToolNames(req.AllowedTools)
+	if badTool != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tool", "tool": badTool})
+		return
+	}
+	if len(allowedTools) > maxToolsPerGrant {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "too many tools"})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		logError(ctx, "create run: db begin failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+	run, err := s.repo.CreateRun(ctx, newRun{
+		PublisherUserID: userID,
+		Goal:            req.Goal,
+		Constraints:     req.Constraints,
+		RequiredTags:    req.RequiredTags,
+		ScheduledAt:     req.ScheduledAt,
+		IsPublic:        true,
+		AllowedTools:    allowedTools,
+	})
+	var unavailable *unavailableToolError
+	if errors.As(err, &unavailable) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "tool not available", "tool": unavailable.Tool})
 		return
 	}
-	defer tx.Rollback(ctx)
-
-	runID, runRef, workItemID, err := s.createRunInTx(ctx, tx, userID, req.Goal, req.Constraints, req.RequiredTags, req.ScheduledAt, true)
 	if err != nil {
 		logError(ctx, "create run: create failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "create run failed"})
 		return
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		logError(ctx, "create run: db commit failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db commit failed"})
-		return
-	}
-
-	s.audit(ctx, "user", userID, "run_created", map[string]any{"run_id": runID.String(), "initial_work_item_id": workItemID.String()})
-	writeJSON(w, http.StatusCreated, createRunResponse{RunRef: runRef})
+	s.audit(ctx, "user", userID, "run_created", map[string]any{"run_id": run.RunID.String(), "initial_work_item_id": run.WorkItemID.String(), "allowed_tools": allowedTools})
+	go s.autoModerate(moderationItem{TargetType: "run", TargetID: run.RunID, ActorType: "user", ActorID: userID, Value: []any{req.Goal, req.Constraints}})
+	writeJSON(w, http.StatusCreated, createRunResponse{RunRef: run.RunRef})
 }
 
 type stageTemplate struct {
@@ -1602,9 +1665,10 @@ func (s server) createInitialWorkItemAndOffers(ctx context.Context, tx pgx.Tx, r
 		return uuid.Nil, err
 	}
 
-	skills := s.skillsGatewayWhitelist
-	if skills == nil {
-		skills = []string{}
+	skills, skillRefsJSON, err := s.resolveStageSkills(ctx, tx, "ideation")
+	if err != nil {
+		logError(ctx, "resolve ideation skills failed", err)
+		return uuid.Nil, err
 	}
 	availableSkillsJSON, err := json.Marshal(skills)
 	if err != nil {
@@ -1625,10 +1689,10 @@ func (s server) createInitialWorkItemAndOffers(ctx context.Context, tx pgx.Tx, r
 
 	var workItemID uuid.UUID
 	if err := tx.QueryRow(ctx, `
-		insert into work_items (run_id, stage, kind, status, context, available_skills, scheduled_at)
-		values ($1, 'ideation', 'draft', $2, $3, $4, $5)
+		insert into work_items (run_id, stage, kind, status, context, available_skills, skill_refs, scheduled_at)
+		values ($1, 'ideation', 'draft', $2, $3, $4, $5, $6)
 		returning id
-	`, runID, status, stageContextJSON, availableSkillsJSON, scheduledAt).Scan(&workItemID); err != nil {
+	`, runID, status, stageContextJSON, availableSkillsJSON, skillRefsJSON, scheduledAt).Scan(&workItemID); err != nil {
 		return uuid.Nil, err
 	}
 
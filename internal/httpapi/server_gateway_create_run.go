@@ -159,5 +159,6 @@ func (s server) handleGatewayCreateRun(w http.ResponseWriter, r *http.Request) {
 		"run_ref":              runRef,
 		"initial_work_item_id": workItemID.String(),
 	})
+	go s.autoModerate(moderationItem{TargetType: "run", TargetID: runID, ActorType: "agent", ActorID: agentID, Value: []any{req.Goal, req.Constraints}})
 	writeJSON(w, http.StatusCreated, gatewayCreateRunResponse{RunRef: runRef})
 }
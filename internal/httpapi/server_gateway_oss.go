@@ -0,0 +1,343 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+)
+
+// --- Direct OSS access for admitted agents (STS credentials scoped to the agent's own prefixes)
+
+// ossCredentialKinds mirrors the credential classes of the OSS registry spec.
+const (
+	ossCredentialRegistry          = "registry"
+	ossCredentialTopicRead         = "topic_read"
+	ossCredentialTopicMessageWrite = "topic_message_write"
+	ossCredentialTopicRequestWrite = "topic_request_write"
+)
+
+func isOSSWriteCredential(kind string) bool {
+	return kind == ossCredentialTopicMessageWrite || kind == ossCredentialTopicRequestWrite
+}
+
+// isDirectWriteTopicMode reports whether agents may write a topic of this mode straight to OSS.
+// turn_queue and limited_slots are enforced by the gateway (turn order, slot counts), which a
+// direct write would bypass; unknown modes stay read-only.
+func isDirectWriteTopicMode(mode string) bool {
+	return isKnownTopicMode(mode) && mode != "turn_queue" && mode != "limited_slots"
+}
+
+func isKnownTopicMode(mode string) bool {
+	switch mode {
+	case "intro_once", "daily_checkin", "freeform", "threaded", "turn_queue", "limited_slots", "debate", "collab_roles",
+		"roast_banter", "crosstalk", "skit_chain", "drum_pass", "idiom_chain", "poetry_duel":
+		return true
+	}
+	return false
+}
+
+// ossHeartbeatKey is agents/heartbeats/{shard}/{agent_ref}.last; shard = first byte of sha256(agent_ref), hex.
+func ossHeartbeatKey(agentRef string) string {
+	sum := sha256.Sum256([]byte(agentRef))
+	return "agents/heartbeats/" + hex.EncodeToString(sum[:1]) + "/" + agentRef + ".last"
+}
+
+// validOSSPathSegment rejects ids that would escape their prefix in an object key or STS policy.
+func validOSSPathSegment(v string) bool {
+	return v != "" && len(v) <= 200 && !strings.ContainsAny(v, "/\\*?") && v != "." && v != ".."
+}
+
+// topicManifestAccess is the part of topics/{topic_id}/manifest.json that decides who may see or write.
+type topicManifestAccess struct {
+	Visibility        string   `json:"visibility"`
+	CircleID          string   `json:"circle_id,omitempty"`
+	AllowlistAgentIDs []string `json:"allowlist_agent_ids,omitempty"`
+	OwnerAgentID      string   `json:"owner_agent_id,omitempty"`
+	Mode              string   `json:"mode"`
+}
+
+func readTopicManifestAccess(ctx context.Context, store agenthome.OSSObjectStore, topicID string) (topicManifestAccess, error) {
+	var mf topicManifestAccess
+	raw, err := store.GetObject(ctx, "topics/"+topicID+"/manifest.json")
+	if err != nil {
+		return mf, err
+	}
+	err = json.Unmarshal(raw, &mf)
+	return mf, err
+}
+
+// allowsAgent is the read rule: the visibility/allowlist rules of the gateway writes, plus circle
+// membership (circles/{circle_id}/members/{agent_ref}.json) for circle topics. Writes use
+// allowsWriter.
+func (m topicManifestAccess) allowsAgent(ctx context.Context, store agenthome.OSSObjectStore, agentRef string) (bool, error) {
+	if isTopicAllowedForAgent(m.Visibility, m.OwnerAgentID, m.AllowlistAgentIDs, agentRef) {
+		return true, nil
+	}
+	circleID := strings.TrimSpace(m.CircleID)
+	if strings.ToLower(strings.TrimSpace(m.Visibility)) != "circle" || !validOSSPathSegment(circleID) {
+		return false, nil
+	}
+	return store.Exists(ctx, "circles/"+circleID+"/members/"+agentRef+".json")
+}
+
+// allowsWriter applies exactly the rules of the gateway topic writes (isTopicAllowedForAgent), so
+// direct writes are never open to more agents than the API is.
+func (m topicManifestAccess) allowsWriter(agentRef string) bool {
+	return isTopicAllowedForAgent(m.Visibility, m.OwnerAgentID, m.AllowlistAgentIDs, agentRef)
+}
+
+type ossCredentialsRequest struct {
+	// Kind is registry (default), topic_read, topic_message_write or topic_request_write.
+	Kind    string `json:"kind,omitempty"`
+	TopicID string `json:"topic_id,omitempty"`
+	// DurationSeconds is capped at AIHUB_OSS_STS_DURATION_SECONDS (also the default).
+	DurationSeconds int `json:"duration_seconds,omitempty"`
+}
+
+// ossCredentialScope lists full object-key prefixes (base prefix included); entries without a
+// trailing "/" are exact keys.
+type ossCredentialScope struct {
+	List  []string `json:"list"`
+	Read  []string `json:"read"`
+	Write []string `json:"write"`
+}
+
+type ossCredentialsResponse struct {
+	Kind        string                   `json:"kind"`
+	TopicID     string                   `json:"topic_id,omitempty"`
+	Scope       ossCredentialScope       `json:"scope"`
+	Credentials agenthome.STSCredentials `json:"credentials"`
+	ExpiresAt   string                   `json:"expires_at"`
+}
+
+// ossCredentialScopeFor builds the relative prefixes for a credential kind.
+func ossCredentialScopeFor(kind, agentRef, topicID string) ossCredentialScope {
+	scope := ossCredentialScope{List: []string{}, Read: []string{}, Write: []string{}}
+	switch kind {
+	case ossCredentialRegistry:
+		scope.List = []string{"agents/all/", "agents/heartbeats/"}
+		scope.Read = []string{"agents/all/", "agents/heartbeats/", "agents/prompts/" + agentRef + "/"}
+		scope.Write = []string{ossHeartbeatKey(agentRef)}
+	case ossCredentialTopicRead:
+		scope.List = []string{"topics/" + topicID + "/"}
+		scope.Read = []string{"topics/" + topicID + "/"}
+	case ossCredentialTopicMessageWrite:
+		scope.Write = []string{"topics/" + topicID + "/messages/" + agentRef + "/"}
+	case ossCredentialTopicRequestWrite:
+		scope.Write = []string{"topics/" + topicID + "/requests/" + agentRef + "/"}
+	}
+	return scope
+}
+
+func (sc ossCredentialScope) withBasePrefix(basePrefix string) ossCredentialScope {
+	join := func(in []string) []string {
+		out := make([]string, 0, len(in))
+		for _, p := range in {
+			k := agenthome.JoinKey(basePrefix, p)
+			if strings.HasSuffix(p, "/") && !strings.HasSuffix(k, "/") {
+				k += "/"
+			}
+			out = append(out, k)
+		}
+		return out
+	}
+	return ossCredentialScope{List: join(sc.List), Read: join(sc.Read), Write: join(sc.Write)}
+}
+
+// handleGatewayOSSCredentials issues short-lived STS credentials so admitted agents can read the
+// registry and write their own heartbeat / topic objects without the API proxying each request.
+// Topic kinds are derived from the topic manifest. Write kinds need the topics-write scope and a
+// configured OSS event ingest (without it direct writes would skip the privacy policy and
+// moderation). Every issuance is recorded in oss_credential_issuances (reserved before the STS
+// call), which also backs the per-agent hourly limit.
+func (s server) handleGatewayOSSCredentials(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var req ossCredentialsRequest
+	if r.ContentLength != 0 && !readJSONLimited(w, r, &req, 4*1024) {
+		return
+	}
+	kind := strings.TrimSpace(req.Kind)
+	if kind == "" {
+		kind = ossCredentialRegistry
+	}
+	topicID := strings.TrimSpace(req.TopicID)
+	switch kind {
+	case ossCredentialRegistry:
+		topicID = ""
+	case ossCredentialTopicRead, ossCredentialTopicMessageWrite, ossCredentialTopicRequestWrite:
+		if !validOSSPathSegment(topicID) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid topic_id"})
+			return
+		}
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid kind"})
+		return
+	}
+	if isOSSWriteCredential(kind) {
+		if k, ok := apiKeyFromCtx(r.Context()); !ok || !k.hasScope(scopeTopicsWrite) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scopeTopicsWrite+`"`)
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient_scope", "required_scope": scopeTopicsWrite})
+			return
+		}
+		if strings.TrimSpace(s.ossEventsIngestToken) == "" {
+			writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "oss event ingest not configured"})
+			return
+		}
+	}
+	duration := s.ossSTSDurationSeconds
+	if req.DurationSeconds > 0 {
+		duration = clampInt(req.DurationSeconds, 60, s.ossSTSDurationSeconds)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	cfg := s.ossCfg()
+	store, err := agenthome.NewOSSObjectStore(cfg)
+	if err != nil {
+		logError(ctx, "gateway oss credentials: init oss store failed", err)
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "oss not configured"})
+		return
+	}
+	assumer, err := agenthome.NewSTSAssumer(cfg)
+	if err != nil {
+		logError(ctx, "gateway oss credentials: init sts failed", err)
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "sts not configured"})
+		return
+	}
+
+	var agentRef string
+	if err := s.db.QueryRow(ctx, `select public_ref from agents where id = $1`, agentID).Scan(&agentRef); err != nil {
+		logError(ctx, "gateway oss credentials: agent lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "agent lookup failed"})
+		return
+	}
+
+	if topicID != "" {
+		mf, err := readTopicManifestAccess(ctx, store, topicID)
+		if isOSSNotFound(err) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "topic not found"})
+			return
+		}
+		if err != nil {
+			logError(ctx, "gateway oss credentials: read manifest failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "oss read failed"})
+			return
+		}
+		var allowed bool
+		if isOSSWriteCredential(kind) {
+			allowed = mf.allowsWriter(agentRef) && isDirectWriteTopicMode(strings.TrimSpace(mf.Mode))
+		} else if allowed, err = mf.allowsAgent(ctx, store, agentRef); err != nil {
+			logError(ctx, "gateway oss credentials: circle membership check failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "oss read failed"})
+			return
+		}
+		if !allowed {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "not allowed"})
+			return
+		}
+	}
+
+	scope := ossCredentialScopeFor(kind, agentRef, topicID).withBasePrefix(cfg.BasePrefix)
+	policy, err := agenthome.BuildOSSPolicy(cfg.Bucket, scope.List, scope.Read, scope.Write)
+	if err != nil {
+		logError(ctx, "gateway oss credentials: build policy failed", err)
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "oss not configured"})
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(duration) * time.Second).UTC()
+	scopeJSON, _ := json.Marshal(map[string]any{
+		"topic_id":         topicID,
+		"list":             scope.List,
+		"read":             scope.Read,
+		"write":            scope.Write,
+		"duration_seconds": duration,
+	})
+	issuanceID, limited, err := s.reserveOSSCredentialIssuance(ctx, agentID, kind, scopeJSON, expiresAt)
+	if err != nil {
+		logError(ctx, "gateway oss credentials: reserve issuance failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "quota check failed"})
+		return
+	}
+	if limited {
+		w.Header().Set("Retry-After", "3600")
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "credential issuance limit reached"})
+		return
+	}
+
+	creds, err := assumer.AssumeRole(ctx, "aihub-"+agentRef, policy, duration)
+	if err != nil {
+		logError(ctx, "gateway oss credentials: assume role failed", err)
+		// Nothing was issued; give the reservation back (even if the request context is done).
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if _, err := s.db.Exec(releaseCtx, `delete from oss_credential_issuances where id = $1`, issuanceID); err != nil {
+			logError(ctx, "gateway oss credentials: release reservation failed", err)
+		}
+		releaseCancel()
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "sts request failed"})
+		return
+	}
+	creds.Bucket = cfg.Bucket
+	creds.Endpoint = cfg.Endpoint
+	creds.Region = cfg.Region
+	creds.BasePrefix = strings.Trim(strings.TrimSpace(cfg.BasePrefix), "/")
+	creds.Prefixes = append(append(append([]string{}, scope.List...), scope.Read...), scope.Write...)
+	if t, err := time.Parse(time.RFC3339, creds.Expiration); err == nil {
+		expiresAt = t.UTC()
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, ossCredentialsResponse{
+		Kind:        kind,
+		TopicID:     topicID,
+		Scope:       scope,
+		Credentials: creds,
+		ExpiresAt:   expiresAt.Format(time.RFC3339),
+	})
+}
+
+// reserveOSSCredentialIssuance records an issuance up front, under a per-agent advisory lock so
+// concurrent requests cannot overshoot the hourly limit. The lock is released on commit, before
+// the (slow, remote) STS call.
+func (s server) reserveOSSCredentialIssuance(ctx context.Context, agentID uuid.UUID, kind string, scope []byte, expiresAt time.Time) (id uuid.UUID, limited bool, err error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `select pg_advisory_xact_lock(hashtext('oss_credentials:' || $1::text))`, agentID); err != nil {
+		return uuid.Nil, false, err
+	}
+	var issuedLastHour int
+	if err := tx.QueryRow(ctx, `
+		select count(1) from oss_credential_issuances
+		where agent_id = $1 and created_at > now() - interval '1 hour'
+	`, agentID).Scan(&issuedLastHour); err != nil {
+		return uuid.Nil, false, err
+	}
+	if issuedLastHour >= s.ossSTSHourlyLimit {
+		return uuid.Nil, true, nil
+	}
+	if err := tx.QueryRow(ctx, `
+		insert into oss_credential_issuances (agent_id, kind, scope, expires_at)
+		values ($1, $2, $3, $4)
+		returning id
+	`, agentID, kind, scope, expiresAt).Scan(&id); err != nil {
+		return uuid.Nil, false, err
+	}
+	return id, false, tx.Commit(ctx)
+}
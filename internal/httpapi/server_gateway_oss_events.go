@@ -0,0 +1,258 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aihub/internal/agenthome"
+)
+
+// --- Agent-facing OSS event feed: topic events (new topics, state changes, messages, requests,
+// results) the agent can read, in oss_events id order, with a per-agent ack cursor in oss_event_acks.
+// Inserts hold lockOSSEventsInTx until commit, so ids become visible in order and the cursor never
+// skips a row that commits late.
+
+type gatewayOSSEventDTO struct {
+	ID        int64  `json:"id"`
+	ObjectKey string `json:"object_key"`
+	EventType string `json:"event_type"`
+	TopicID   string `json:"topic_id"`
+	// Kind is manifest, state, summary, messages, requests, results or other.
+	Kind       string          `json:"kind"`
+	ActorRef   string          `json:"actor_ref,omitempty"`
+	OccurredAt string          `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+type gatewayOSSEventsResponse struct {
+	Items []gatewayOSSEventDTO `json:"items"`
+	// NextAfter is the cursor for the next page; it also skips events the agent cannot read.
+	NextAfter int64 `json:"next_after"`
+	HasMore   bool  `json:"has_more"`
+	// AckedEventID is the agent's stored cursor (default for "after").
+	AckedEventID int64 `json:"acked_event_id"`
+}
+
+// parseTopicEventKey splits topics/{topic_id}/... (base prefix already stripped).
+func parseTopicEventKey(key string) (topicID, kind, actorRef string, ok bool) {
+	rest, found := strings.CutPrefix(strings.TrimLeft(key, "/"), "topics/")
+	if !found {
+		return "", "", "", false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) < 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", "", "", false
+	}
+	topicID = parts[0]
+	switch {
+	case len(parts) == 2 && parts[1] == "manifest.json":
+		kind = "manifest"
+	case len(parts) == 2 && parts[1] == "state.json":
+		kind = "state"
+	case len(parts) == 2 && parts[1] == "summary.json":
+		kind = "summary"
+	case len(parts) >= 4 && (parts[1] == "messages" || parts[1] == "requests" || parts[1] == "results"):
+		kind, actorRef = parts[1], parts[2]
+	default:
+		kind = "other"
+	}
+	return topicID, kind, actorRef, true
+}
+
+func (s server) handleGatewayListOSSEvents(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	limit := 50
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = clampInt(n, 1, 100)
+		}
+	}
+	after := int64(-1)
+	if v := strings.TrimSpace(r.URL.Query().Get("after")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid after"})
+			return
+		}
+		after = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	var agentRef string
+	var acked int64
+	if err := s.db.QueryRow(ctx, `
+		select a.public_ref, coalesce(k.last_event_id, 0)
+		from agents a
+		left join oss_event_acks k on k.agent_id = a.id
+		where a.id = $1
+	`, agentID).Scan(&agentRef, &acked); err != nil {
+		logError(ctx, "gateway oss events: agent lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "agent lookup failed"})
+		return
+	}
+	if after < 0 {
+		after = acked
+	}
+
+	store, err := agenthome.NewOSSObjectStore(s.ossCfg())
+	if err != nil {
+		logError(ctx, "gateway oss events: init oss store failed", err)
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "oss not configured"})
+		return
+	}
+
+	// Scan more rows than we return to account for access filtering.
+	scanLimit := clampInt(limit*10, limit, 1000)
+	rows, err := s.db.Query(ctx, `
+		select id, object_key, event_type, occurred_at, payload
+		from oss_events
+		where id > $1
+		  and object_key like '%topics/%'
+		  and `+topicNotRejectedSQL("oss_events.object_key", s.ossBasePrefix)+`
+		order by id asc
+		limit $2
+	`, after, scanLimit)
+	if err != nil {
+		if isContextCanceled(ctx, err) {
+			return
+		}
+		logError(ctx, "gateway oss events: query oss_events failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	type rawRow struct {
+		id         int64
+		objectKey  string
+		eventType  string
+		occurredAt time.Time
+		payload    []byte
+	}
+	raw := make([]rawRow, 0, scanLimit)
+	for rows.Next() {
+		var rr rawRow
+		if err := rows.Scan(&rr.id, &rr.objectKey, &rr.eventType, &rr.occurredAt, &rr.payload); err != nil {
+			logError(ctx, "gateway oss events: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		raw = append(raw, rr)
+	}
+	if err := rows.Err(); err != nil {
+		if isContextCanceled(ctx, err) {
+			return
+		}
+		logError(ctx, "gateway oss events: iterate oss_events failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	rows.Close()
+
+	allowedTopic := map[string]bool{}
+	isAllowed := func(topicID string) bool {
+		if v, ok := allowedTopic[topicID]; ok {
+			return v
+		}
+		allowed := false
+		// Deleted or unreadable manifests hide the topic's events.
+		if mf, err := readTopicManifestAccess(ctx, store, topicID); err == nil {
+			if allowed, err = mf.allowsAgent(ctx, store, agentRef); err != nil {
+				logError(ctx, "gateway oss events: circle membership check failed", err)
+			}
+		} else if !isOSSNotFound(err) {
+			logError(ctx, "gateway oss events: read manifest failed", err)
+		}
+		allowedTopic[topicID] = allowed
+		return allowed
+	}
+
+	resp := gatewayOSSEventsResponse{Items: make([]gatewayOSSEventDTO, 0, limit), NextAfter: after, AckedEventID: acked}
+	for i, rr := range raw {
+		if len(resp.Items) >= limit {
+			resp.HasMore = true
+			break
+		}
+		resp.NextAfter = rr.id
+		if i == len(raw)-1 && len(raw) == scanLimit {
+			resp.HasMore = true
+		}
+		key := strings.TrimLeft(stripBasePrefix(strings.TrimLeft(rr.objectKey, "/"), s.ossBasePrefix), "/")
+		topicID, kind, actorRef, ok := parseTopicEventKey(key)
+		if !ok || !validOSSPathSegment(topicID) || !isAllowed(topicID) {
+			continue
+		}
+		item := gatewayOSSEventDTO{
+			ID:         rr.id,
+			ObjectKey:  key,
+			EventType:  rr.eventType,
+			TopicID:    topicID,
+			Kind:       kind,
+			ActorRef:   actorRef,
+			OccurredAt: rr.occurredAt.UTC().Format(time.RFC3339),
+		}
+		if len(rr.payload) > 0 && string(rr.payload) != "{}" {
+			item.Payload = rr.payload
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type ackOSSEventsRequest struct {
+	LastEventID int64 `json:"last_event_id"`
+}
+
+// handleGatewayAckOSSEvents advances the agent's cursor; it never moves backwards.
+func (s server) handleGatewayAckOSSEvents(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var req ackOSSEventsRequest
+	if !readJSONLimited(w, r, &req, 1024) {
+		return
+	}
+	if req.LastEventID <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid last_event_id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var latest int64
+	if err := s.db.QueryRow(ctx, `select coalesce(max(id), 0) from oss_events`).Scan(&latest); err != nil {
+		logError(ctx, "gateway oss events ack: latest id query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	if req.LastEventID > latest {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "last_event_id beyond latest event"})
+		return
+	}
+	var acked int64
+	if err := s.db.QueryRow(ctx, `
+		insert into oss_event_acks (agent_id, last_event_id)
+		values ($1, $2)
+		on conflict (agent_id) do update
+		set last_event_id = greatest(oss_event_acks.last_event_id, excluded.last_event_id), updated_at = now()
+		returning last_event_id
+	`, agentID, req.LastEventID).Scan(&acked); err != nil {
+		logError(ctx, "gateway oss events ack: upsert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"last_event_id": acked})
+}
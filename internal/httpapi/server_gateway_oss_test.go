@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+)
+
+func TestOSSCredentialScope(t *testing.T) {
+	hb := ossHeartbeatKey("a_1")
+	if !strings.HasPrefix(hb, "agents/heartbeats/") || !strings.HasSuffix(hb, "/a_1.last") || len(strings.Split(hb, "/")) != 4 {
+		t.Fatalf("heartbeat key %q", hb)
+	}
+	if hb != ossHeartbeatKey("a_1") {
+		t.Fatal("heartbeat key not stable")
+	}
+
+	scope := ossCredentialScopeFor(ossCredentialTopicMessageWrite, "a_1", "t1").withBasePrefix("aihub/")
+	if !reflect.DeepEqual(scope.Write, []string{"aihub/topics/t1/messages/a_1/"}) || len(scope.Read) != 0 || len(scope.List) != 0 {
+		t.Fatalf("message write scope: %+v", scope)
+	}
+	policy, err := agenthome.BuildOSSPolicy("bucket", scope.List, scope.Read, scope.Write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(policy, "acs:oss:*:*:bucket/aihub/topics/t1/messages/a_1/*") || strings.Contains(policy, "GetObject") {
+		t.Fatalf("policy: %s", policy)
+	}
+
+	// Registry credentials may write only the agent's own heartbeat (an exact key).
+	reg := ossCredentialScopeFor(ossCredentialRegistry, "a_1", "")
+	if !reflect.DeepEqual(reg.Write, []string{hb}) {
+		t.Fatalf("registry write scope: %+v", reg.Write)
+	}
+
+	for _, id := range []string{"", "a/b", "..", "t*"} {
+		if validOSSPathSegment(id) {
+			t.Errorf("accepted topic id %q", id)
+		}
+	}
+}
+
+func TestParseTopicEventKey(t *testing.T) {
+	cases := []struct {
+		key, topic, kind, actor string
+		ok                      bool
+	}{
+		{"topics/t1/manifest.json", "t1", "manifest", "", true},
+		{"topics/t1/state.json", "t1", "state", "", true},
+		{"topics/t1/messages/a_1/m1.json", "t1", "messages", "a_1", true},
+		{"topics/t1/results/a_1/r1.json", "t1", "results", "a_1", true},
+		{"topics/t1/messages/a_1", "t1", "other", "", true},
+		{"agents/all/a_1.json", "", "", "", false},
+		{"topics/", "", "", "", false},
+	}
+	for _, c := range cases {
+		topic, kind, actor, ok := parseTopicEventKey(c.key)
+		if topic != c.topic || kind != c.kind || actor != c.actor || ok != c.ok {
+			t.Errorf("%s: got %q %q %q %v", c.key, topic, kind, actor, ok)
+		}
+	}
+}
+
+func TestOSSWriteCredentialsRequireScopeAndIngest(t *testing.T) {
+	request := func(s server, scopes ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/gateway/oss/credentials", strings.NewReader(`{"kind":"topic_message_write","topic_id":"t1"}`))
+		ctx := context.WithValue(req.Context(), ctxAgentID, uuid.New())
+		ctx = context.WithValue(ctx, ctxAPIKey, apiKeyAuth{Scopes: scopes})
+		rec := httptest.NewRecorder()
+		s.handleGatewayOSSCredentials(rec, req.WithContext(ctx))
+		return rec
+	}
+	if rec := request(server{ossEventsIngestToken: "secret"}, scopeGatewayRead, scopeGatewayWrite); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "insufficient_scope") {
+		t.Fatalf("without topics-write: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := request(server{}, scopeTopicsWrite); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("without ingest: status %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTopicManifestWriteRules(t *testing.T) {
+	circle := topicManifestAccess{Visibility: "circle", CircleID: "c1", OwnerAgentID: "a_owner", AllowlistAgentIDs: []string{"a_2"}}
+	// Circle membership grants reads only; writers are the owner and the allowlist, as in the gateway.
+	if circle.allowsWriter("a_member") || !circle.allowsWriter("a_2") || !circle.allowsWriter("a_owner") {
+		t.Fatal("circle writers must follow isTopicAllowedForAgent")
+	}
+	for mode, want := range map[string]bool{
+		"freeform": true, "threaded": true, "turn_queue": false, "limited_slots": false, "unknown": false, "": false,
+	} {
+		if got := isDirectWriteTopicMode(mode); got != want {
+			t.Fatalf("isDirectWriteTopicMode(%q) = %v, want %v", mode, got, want)
+		}
+	}
+}
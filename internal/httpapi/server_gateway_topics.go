@@ -52,9 +52,11 @@ func (s server) handleGatewayWriteTopicMessage(w http.ResponseWriter, r *http.Re
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing content.text"})
 		return
 	}
-	if rejectIfPrivacyViolation(r.Context(), w, req.Content, "content", "gateway topic message: blocked by privacy filter") {
+	redacted, privacy, ok := s.enforcePrivacyPolicy(r.Context(), w, privacySurfaceTopicMessage, req.Content, "content", "gateway topic message: blocked by privacy filter")
+	if !ok {
 		return
 	}
+	req.Content = redacted.(map[string]any)
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
@@ -132,15 +134,14 @@ func (s server) handleGatewayWriteTopicMessage(w http.ResponseWriter, r *http.Re
 	}
 
 	key := "topics/" + topicID + "/messages/" + agentRef + "/" + msgID + ".json"
-	if err := store.PutObject(ctx, key, "application/json", body); err != nil {
-		logError(ctx, "gateway topic message: put object failed", err)
+	if err := s.writeOSSObjectWithEvent(ctx, store, key, body); err != nil {
+		logError(ctx, "gateway topic message: enqueue oss write failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "oss write failed"})
 		return
 	}
-	if err := s.insertOSSEvent(ctx, key, "put", time.Now().UTC(), body); err != nil {
-		logError(ctx, "gateway topic message: insert oss_event failed", err)
-		// Don't fail the write; UI feed can lag.
-	}
+	s.recordPrivacyDecision(ctx, privacySurfaceTopicMessage, privacy, "topic_message", key, "agent", agentID)
+	s.recordTopicContentForReview(ctx, key, topicID, agentID, agentRef, body)
+	s.notifyTopicReply(ctx, topicID, msgID, agentRef, parseTopicMessageRef(req.Meta["reply_to"]))
 
 	writeJSON(w, http.StatusCreated, map[string]any{
 		"ok":         true,
@@ -173,9 +174,11 @@ func (s server) handleGatewayWriteTopicMessageText(w http.ResponseWriter, r *htt
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing text"})
 		return
 	}
-	if rejectIfPrivacyViolation(r.Context(), w, text, "text", "gateway topic message:text: blocked by privacy filter") {
+	redacted, privacy, ok := s.enforcePrivacyPolicy(r.Context(), w, privacySurfaceTopicMessage, text, "text", "gateway topic message:text: blocked by privacy filter")
+	if !ok {
 		return
 	}
+	text = redacted.(string)
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
@@ -303,14 +306,14 @@ func (s server) handleGatewayWriteTopicMessageText(w http.ResponseWriter, r *htt
 		return
 	}
 	key := "topics/" + topicID + "/messages/" + agentRef + "/" + msgID + ".json"
-	if err := store.PutObject(ctx, key, "application/json", body); err != nil {
-		logError(ctx, "gateway topic message text: put object failed", err)
+	if err := s.writeOSSObjectWithEvent(ctx, store, key, body); err != nil {
+		logError(ctx, "gateway topic message text: enqueue oss write failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "oss write failed"})
 		return
 	}
-	if err := s.insertOSSEvent(ctx, key, "put", time.Now().UTC(), body); err != nil {
-		logError(ctx, "gateway topic message text: insert oss_event failed", err)
-	}
+	s.recordPrivacyDecision(ctx, privacySurfaceTopicMessage, privacy, "topic_message", key, "agent", agentID)
+	s.recordTopicContentForReview(ctx, key, topicID, agentID, agentRef, body)
+	s.notifyTopicReply(ctx, topicID, msgID, agentRef, replyTo)
 	writeJSON(w, http.StatusCreated, map[string]any{"ok": true})
 }
 
@@ -371,9 +374,11 @@ func (s server) handleGatewayProposeTopicText(w http.ResponseWriter, r *http.Req
 	if strings.TrimSpace(category) != "" {
 		req.Payload["category"] = strings.TrimSpace(category)
 	}
-	if rejectIfPrivacyViolation(r.Context(), w, req.Payload, "payload", "gateway propose topic text: blocked by privacy filter") {
+	redacted, privacy, ok := s.enforcePrivacyPolicy(r.Context(), w, privacySurfaceTopicMessage, req.Payload, "payload", "gateway propose topic text: blocked by privacy filter")
+	if !ok {
 		return
 	}
+	req.Payload = redacted.(map[string]any)
 	// Encode and reuse the same handler logic by inlining it here (avoid depending on request body rewind semantics).
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
@@ -438,14 +443,13 @@ func (s server) handleGatewayProposeTopicText(w http.ResponseWriter, r *http.Req
 		return
 	}
 	key := "topics/" + topicID + "/requests/" + agentRef + "/" + requestID + ".json"
-	if err := store.PutObject(ctx, key, "application/json", body); err != nil {
-		logError(ctx, "gateway propose topic text: put object failed", err)
+	if err := s.writeOSSObjectWithEvent(ctx, store, key, body); err != nil {
+		logError(ctx, "gateway propose topic text: enqueue oss write failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "oss write failed"})
 		return
 	}
-	if err := s.insertOSSEvent(ctx, key, "put", time.Now().UTC(), body); err != nil {
-		logError(ctx, "gateway propose topic text: insert oss_event failed", err)
-	}
+	s.recordPrivacyDecision(ctx, privacySurfaceTopicMessage, privacy, "topic_request", key, "agent", agentID)
+	s.recordTopicContentForReview(ctx, key, topicID, agentID, agentRef, body)
 	writeJSON(w, http.StatusCreated, map[string]any{"ok": true})
 }
 
@@ -473,9 +477,11 @@ func (s server) handleGatewayWriteTopicRequest(w http.ResponseWriter, r *http.Re
 	if req.Payload == nil {
 		req.Payload = map[string]any{}
 	}
-	if rejectIfPrivacyViolation(r.Context(), w, req.Payload, "payload", "gateway topic request: blocked by privacy filter") {
+	redacted, privacy, ok := s.enforcePrivacyPolicy(r.Context(), w, privacySurfaceTopicMessage, req.Payload, "payload", "gateway topic request: blocked by privacy filter")
+	if !ok {
 		return
 	}
+	req.Payload = redacted.(map[string]any)
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
@@ -550,14 +556,13 @@ func (s server) handleGatewayWriteTopicRequest(w http.ResponseWriter, r *http.Re
 		return
 	}
 	key := "topics/" + topicID + "/requests/" + agentRef + "/" + requestID + ".json"
-	if err := store.PutObject(ctx, key, "application/json", body); err != nil {
-		logError(ctx, "gateway topic request: put object failed", err)
+	if err := s.writeOSSObjectWithEvent(ctx, store, key, body); err != nil {
+		logError(ctx, "gateway topic request: enqueue oss write failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "oss write failed"})
 		return
 	}
-	if err := s.insertOSSEvent(ctx, key, "put", time.Now().UTC(), body); err != nil {
-		logError(ctx, "gateway topic request: insert oss_event failed", err)
-	}
+	s.recordPrivacyDecision(ctx, privacySurfaceTopicMessage, privacy, "topic_request", key, "agent", agentID)
+	s.recordTopicContentForReview(ctx, key, topicID, agentID, agentRef, body)
 
 	writeJSON(w, http.StatusCreated, map[string]any{
 		"ok":         true,
@@ -586,3 +591,23 @@ func isTopicAllowedForAgent(visibility string, ownerAgentID string, allowlist []
 		return false
 	}
 }
+
+// notifyTopicReply sends topic.reply to the owner of the agent being replied to (not for self-replies).
+func (s server) notifyTopicReply(ctx context.Context, topicID, messageID, agentRef string, replyTo *topicMessageRef) {
+	if replyTo == nil || replyTo.AgentRef == agentRef {
+		return
+	}
+	var ownerID uuid.UUID
+	if err := s.db.QueryRow(ctx, `select owner_id from agents where public_ref = $1`, replyTo.AgentRef).Scan(&ownerID); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logError(ctx, "topic reply webhook: owner lookup failed", err)
+		}
+		return
+	}
+	s.emitWebhookEventBestEffort(ctx, []uuid.UUID{ownerID}, webhookEventTopicReply, map[string]any{
+		"topic_id":   topicID,
+		"message_id": messageID,
+		"agent_ref":  agentRef,
+		"reply_to":   map[string]any{"agent_ref": replyTo.AgentRef, "message_id": replyTo.MessageID},
+	})
+}
@@ -0,0 +1,486 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// --- Moderation appeals: owners see their rejected content and appeal; admins uphold or overturn.
+
+const (
+	appealStatusOpen       = "open"
+	appealStatusUpheld     = "upheld"
+	appealStatusOverturned = "overturned"
+)
+
+// ownerRejectedItemsSQL selects (target_type, id, ref, summary) of the rejected content owned by
+// user $1: runs they published, their agents' artifacts, topic content and cards, and their
+// persona templates. Events carry no author and cannot be appealed.
+const ownerRejectedItemsSQL = `
+	select 'run'::text as target_type, r.id as id, r.public_ref as ref, left(r.goal, 200) as summary
+	from runs r
+	where r.publisher_user_id = $1 and r.review_status = 'rejected'
+	union all
+	select 'artifact'::text, a.id, r.public_ref, left(a.content, 200)
+	from artifacts a
+	join agents ag on ag.id = a.author_agent_id
+	join runs r on r.id = a.run_id
+	where ag.owner_id = $1 and a.review_status = 'rejected'
+	union all
+	select v.target_type, v.id, v.object_key, v.summary
+	from topic_content_reviews v
+	join agents ag on ag.id = v.agent_id
+	where ag.owner_id = $1 and v.review_status = 'rejected'
+	union all
+	select 'agent_card'::text, ag.id, ag.public_ref, left(ag.name || ' ' || ag.bio, 200)
+	from agents ag
+	where ag.owner_id = $1 and ag.card_review_status = 'rejected'
+	union all
+	select 'persona_template'::text, pt.moderation_id, pt.id, left(pt.persona::text, 200)
+	from persona_templates pt
+	where pt.owner_id = $1 and pt.review_status = 'rejected'
+`
+
+// latestRejectionSQL is a lateral subquery for the most recent rejection of item i.
+const latestRejectionSQL = `
+	select m.id, m.reason, m.created_at
+	from moderation_actions m
+	where m.target_type = i.target_type and m.target_id = i.id and m.action in ('reject', 'auto_reject')
+	order by m.created_at desc
+	limit 1
+`
+
+type ownerRejectedItemDTO struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	// Ref is the run_ref (runs, artifacts), agent_ref, OSS object key or template id.
+	Ref          string `json:"ref"`
+	Summary      string `json:"summary"`
+	Reason       string `json:"reason"`
+	RejectedAt   string `json:"rejected_at,omitempty"`
+	AppealID     string `json:"appeal_id,omitempty"`
+	AppealStatus string `json:"appeal_status,omitempty"`
+	// CanAppeal is false while an appeal is open or once this rejection has been appealed.
+	CanAppeal bool `json:"can_appeal"`
+}
+
+type ownerListRejectedResponse struct {
+	Items      []ownerRejectedItemDTO `json:"items"`
+	HasMore    bool                   `json:"has_more"`
+	NextOffset int                    `json:"next_offset"`
+}
+
+func (s server) handleOwnerListRejectedContent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	q := r.URL.Query()
+	limit := 50
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	limit = clampInt(limit, 1, 200)
+	offset := 0
+	if v := strings.TrimSpace(q.Get("offset")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid offset"})
+			return
+		}
+		offset = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		with i as (`+ownerRejectedItemsSQL+`)
+		select i.target_type, i.id, i.ref, i.summary, ma.id, coalesce(ma.reason, ''), ma.created_at, ap.id, coalesce(ap.status, ''), ap.rejection_action_id
+		from i
+		left join lateral (`+latestRejectionSQL+`) ma on true
+		left join lateral (
+			select p.id, p.status, p.rejection_action_id
+			from moderation_appeals p
+			where p.target_type = i.target_type and p.target_id = i.id
+			order by p.created_at desc
+			limit 1
+		) ap on true
+		order by ma.created_at desc nulls last, i.id
+		limit $2 offset $3
+	`, userID, limit+1, offset)
+	if err != nil {
+		logError(ctx, "owner list rejected content: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	out := make([]ownerRejectedItemDTO, 0)
+	for rows.Next() {
+		var (
+			it                ownerRejectedItemDTO
+			id                uuid.UUID
+			rejectionID       *uuid.UUID
+			rejectedAt        *time.Time
+			appealID          *uuid.UUID
+			appealRejectionID *uuid.UUID
+		)
+		if err := rows.Scan(&it.TargetType, &id, &it.Ref, &it.Summary, &rejectionID, &it.Reason, &rejectedAt, &appealID, &it.AppealStatus, &appealRejectionID); err != nil {
+			logError(ctx, "owner list rejected content: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		it.TargetID = id.String()
+		if rejectedAt != nil {
+			it.RejectedAt = rejectedAt.UTC().Format(time.RFC3339)
+		}
+		if appealID != nil {
+			it.AppealID = appealID.String()
+		}
+		it.CanAppeal = it.AppealStatus != appealStatusOpen &&
+			(appealID == nil || rejectionID == nil || appealRejectionID == nil || *appealRejectionID != *rejectionID)
+		out = append(out, it)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "owner list rejected content: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	hasMore := len(out) > limit
+	if hasMore {
+		out = out[:limit]
+	}
+	writeJSON(w, http.StatusOK, ownerListRejectedResponse{Items: out, HasMore: hasMore, NextOffset: offset + len(out)})
+}
+
+type moderationAppealDTO struct {
+	ID              string `json:"id"`
+	OwnerID         string `json:"owner_id,omitempty"`
+	TargetType      string `json:"target_type"`
+	TargetID        string `json:"target_id"`
+	RejectionReason string `json:"rejection_reason"`
+	Message         string `json:"message"`
+	Status          string `json:"status"`
+	ResolutionNote  string `json:"resolution_note,omitempty"`
+	ResolvedAt      string `json:"resolved_at,omitempty"`
+	CreatedAt       string `json:"created_at"`
+}
+
+type listModerationAppealsResponse struct {
+	Items      []moderationAppealDTO `json:"items"`
+	HasMore    bool                  `json:"has_more"`
+	NextOffset int                   `json:"next_offset"`
+}
+
+const moderationAppealColumns = `p.id, p.owner_id, p.target_type, p.target_id, coalesce(m.reason, ''), p.message, p.status, p.resolution_note, p.resolved_at, p.created_at`
+
+func scanModerationAppealDTO(row pgx.Row) (moderationAppealDTO, error) {
+	var (
+		d                     moderationAppealDTO
+		id, ownerID, targetID uuid.UUID
+		resolvedAt            *time.Time
+		createdAt             time.Time
+	)
+	if err := row.Scan(&id, &ownerID, &d.TargetType, &targetID, &d.RejectionReason, &d.Message, &d.Status, &d.ResolutionNote, &resolvedAt, &createdAt); err != nil {
+		return d, err
+	}
+	d.ID, d.OwnerID, d.TargetID = id.String(), ownerID.String(), targetID.String()
+	if resolvedAt != nil {
+		d.ResolvedAt = resolvedAt.UTC().Format(time.RFC3339)
+	}
+	d.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	return d, nil
+}
+
+type ownerCreateAppealRequest struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Message    string `json:"message"`
+}
+
+func (s server) handleOwnerCreateAppeal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var req ownerCreateAppealRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	req.TargetType = strings.TrimSpace(req.TargetType)
+	targetID, err := uuid.Parse(strings.TrimSpace(req.TargetID))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid target id"})
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing message"})
+		return
+	}
+	if len(req.Message) > 4000 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "message too long"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// Only the owner's currently rejected content can be appealed; anything else is a 404 so the
+	// endpoint does not reveal other users' content.
+	var rejectionID *uuid.UUID
+	err = s.db.QueryRow(ctx, `
+		with i as (`+ownerRejectedItemsSQL+`)
+		select ma.id
+		from i
+		left join lateral (`+latestRejectionSQL+`) ma on true
+		where i.target_type = $2 and i.id = $3
+	`, userID, req.TargetType, targetID).Scan(&rejectionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "owner create appeal: target lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+
+	d, err := scanModerationAppealDTO(s.db.QueryRow(ctx, `
+		with p as (
+			insert into moderation_appeals (owner_id, target_type, target_id, rejection_action_id, message)
+			values ($1, $2, $3, $4, $5)
+			returning *
+		)
+		select `+moderationAppealColumns+`
+		from p
+		left join moderation_actions m on m.id = p.rejection_action_id
+	`, userID, req.TargetType, targetID, rejectionID, req.Message))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "already appealed"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "owner create appeal: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+
+	s.audit(ctx, "user", userID, "moderation_appeal_created", map[string]any{"appeal_id": d.ID, "target_type": d.TargetType, "target_id": d.TargetID})
+	writeJSON(w, http.StatusCreated, d)
+}
+
+func (s server) handleOwnerListAppeals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	s.listModerationAppeals(w, r, &userID)
+}
+
+func (s server) handleAdminListAppeals(w http.ResponseWriter, r *http.Request) {
+	s.listModerationAppeals(w, r, nil)
+}
+
+// listModerationAppeals serves both appeal lists: the owner's own appeals (newest first), or the
+// admin queue (oldest first, open by default).
+func (s server) listModerationAppeals(w http.ResponseWriter, r *http.Request, ownerID *uuid.UUID) {
+	q := r.URL.Query()
+	status := strings.TrimSpace(q.Get("status"))
+	if status == "" && ownerID == nil {
+		status = appealStatusOpen
+	}
+	switch status {
+	case "", appealStatusOpen, appealStatusUpheld, appealStatusOverturned:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+		return
+	}
+	limit := 50
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	limit = clampInt(limit, 1, 200)
+	offset := 0
+	if v := strings.TrimSpace(q.Get("offset")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid offset"})
+			return
+		}
+		offset = parsed
+	}
+	order := "p.created_at asc"
+	if ownerID != nil {
+		order = "p.created_at desc"
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select `+moderationAppealColumns+`
+		from moderation_appeals p
+		left join moderation_actions m on m.id = p.rejection_action_id
+		where ($1 = '' or p.status = $1)
+		  and ($2::uuid is null or p.owner_id = $2)
+		order by `+order+`
+		limit $3 offset $4
+	`, status, ownerID, limit+1, offset)
+	if err != nil {
+		logError(ctx, "list moderation appeals: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	out := make([]moderationAppealDTO, 0)
+	for rows.Next() {
+		d, err := scanModerationAppealDTO(rows)
+		if err != nil {
+			logError(ctx, "list moderation appeals: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		if ownerID != nil {
+			d.OwnerID = ""
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "list moderation appeals: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	hasMore := len(out) > limit
+	if hasMore {
+		out = out[:limit]
+	}
+	writeJSON(w, http.StatusOK, listModerationAppealsResponse{Items: out, HasMore: hasMore, NextOffset: offset + len(out)})
+}
+
+type adminResolveAppealRequest struct {
+	Outcome string `json:"outcome"` // upheld | overturned
+	Note    string `json:"note"`
+}
+
+// handleAdminResolveAppeal records the outcome of an open appeal. Overturning approves the target
+// (recorded in moderation_actions like any approval); the owner is notified either way.
+func (s server) handleAdminResolveAppeal(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	appealID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "appealID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid appeal id"})
+		return
+	}
+	var req adminResolveAppealRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	req.Outcome = strings.ToLower(strings.TrimSpace(req.Outcome))
+	if req.Outcome != appealStatusUpheld && req.Outcome != appealStatusOverturned {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid outcome"})
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if len(req.Note) > 2000 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "note too long"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	// Claim the appeal first so two admins cannot resolve it differently.
+	d, err := scanModerationAppealDTO(s.db.QueryRow(ctx, `
+		with p as (
+			update moderation_appeals
+			set status = $2, resolution_note = $3, resolved_by = $4, resolved_at = now()
+			where id = $1 and status = 'open'
+			returning *
+		)
+		select `+moderationAppealColumns+`
+		from p
+		left join moderation_actions m on m.id = p.rejection_action_id
+	`, appealID, req.Outcome, req.Note, adminID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		if err := s.db.QueryRow(ctx, `select exists(select 1 from moderation_appeals where id = $1)`, appealID).Scan(&exists); err == nil && exists {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "appeal already resolved"})
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "admin resolve appeal: update failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+
+	if req.Outcome == appealStatusOverturned {
+		targetID, _ := uuid.Parse(d.TargetID)
+		reason := "appeal overturned"
+		if req.Note != "" {
+			reason += ": " + req.Note
+		}
+		err := s.setModerationStatusFrom(ctx, "admin", adminID, d.TargetType, targetID, "rejected", "approved", "approve", reason)
+		if err != nil && !errors.Is(err, errModerationNotFound) {
+			// Reopen so the decision can be retried.
+			if _, rerr := s.db.Exec(ctx, `
+				update moderation_appeals set status = 'open', resolution_note = '', resolved_by = null, resolved_at = null where id = $1
+			`, appealID); rerr != nil {
+				logError(ctx, "admin resolve appeal: reopen failed", rerr)
+			}
+			logError(ctx, "admin resolve appeal: approve target failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+			return
+		}
+	}
+
+	ownerID, _ := uuid.Parse(d.OwnerID)
+	s.emitWebhookEventBestEffort(ctx, []uuid.UUID{ownerID}, webhookEventModerationAppealResolved, map[string]any{
+		"appeal_id":   d.ID,
+		"target_type": d.TargetType,
+		"target_id":   d.TargetID,
+		"outcome":     d.Status,
+		"note":        d.ResolutionNote,
+	})
+	s.audit(ctx, "admin", adminID, "moderation_appeal_resolved", map[string]any{
+		"appeal_id":   d.ID,
+		"target_type": d.TargetType,
+		"target_id":   d.TargetID,
+		"outcome":     d.Status,
+	})
+	writeJSON(w, http.StatusOK, d)
+}
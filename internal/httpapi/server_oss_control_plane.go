@@ -510,10 +510,7 @@ func (s server) handleAdminCreateTopicManifest(w http.ResponseWriter, r *http.Re
 	}
 
 	mode := strings.TrimSpace(req.Mode)
-	switch mode {
-	case "intro_once", "daily_checkin", "freeform", "threaded", "turn_queue", "limited_slots", "debate", "collab_roles",
-		"roast_banter", "crosstalk", "skit_chain", "drum_pass", "idiom_chain", "poetry_duel":
-	default:
+	if !isKnownTopicMode(mode) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid mode"})
 		return
 	}
@@ -721,6 +718,11 @@ func (s server) handleAdminDeleteTopic(w http.ResponseWriter, r *http.Request) {
 	}
 
 	prefix := "topics/" + topicID + "/"
+	if err := s.discardPendingOSSOutbox(ctx, prefix); err != nil {
+		logError(ctx, "delete topic: discard oss outbox failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db delete failed"})
+		return
+	}
 	deleted, err := store.DeletePrefix(ctx, prefix)
 	if err != nil {
 		logError(ctx, "delete topic prefix failed", err)
@@ -0,0 +1,432 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// --- OSS notification ingest: objects written directly to OSS (agents holding STS credentials)
+// become oss_events rows just like platform-originated writes, so the privacy policy, activity
+// feeds, topic proposals, moderation (and with it vote contributions) and reply webhooks apply to
+// them too.
+
+const (
+	ossIngestHeader        = "X-AIHub-Oss-Ingest-Token"
+	ossIngestMaxBodyBytes  = 1 << 20
+	ossIngestMaxEvents     = 500
+	ossIngestMaxObjectSize = 256 * 1024
+)
+
+type ingestedOSSEvent struct {
+	Bucket     string
+	ObjectKey  string // bucket-relative, may carry the OSS base prefix
+	EventType  string // put | delete
+	OccurredAt time.Time
+	ETag       string
+}
+
+// ossNotificationEvent accepts both the Aliyun OSS event notification record and a generic shape.
+type ossNotificationEvent struct {
+	EventName string `json:"eventName"`
+	EventTime string `json:"eventTime"`
+	OSS       struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key  string `json:"key"`
+			ETag string `json:"eTag"`
+		} `json:"object"`
+	} `json:"oss"`
+
+	Bucket     string `json:"bucket"`
+	ObjectKey  string `json:"object_key"`
+	EventType  string `json:"event_type"`
+	OccurredAt string `json:"occurred_at"`
+	ETag       string `json:"etag"`
+}
+
+func (e ossNotificationEvent) normalize() (ingestedOSSEvent, bool) {
+	out := ingestedOSSEvent{
+		Bucket:    strings.TrimSpace(e.Bucket),
+		ObjectKey: strings.TrimLeft(strings.TrimSpace(e.ObjectKey), "/"),
+		ETag:      strings.Trim(strings.TrimSpace(e.ETag), `"`),
+	}
+	kind, at := strings.ToLower(strings.TrimSpace(e.EventType)), e.OccurredAt
+	if strings.TrimSpace(e.EventName) != "" {
+		out.Bucket = strings.TrimSpace(e.OSS.Bucket.Name)
+		out.ObjectKey = strings.TrimLeft(strings.TrimSpace(e.OSS.Object.Key), "/")
+		out.ETag = strings.Trim(strings.TrimSpace(e.OSS.Object.ETag), `"`)
+		kind, at = strings.TrimSpace(e.EventName), e.EventTime
+	}
+	switch {
+	case kind == "put" || strings.HasPrefix(kind, "ObjectCreated:"):
+		out.EventType = "put"
+	case kind == "delete" || strings.HasPrefix(kind, "ObjectRemoved:"):
+		out.EventType = "delete"
+	default:
+		return ingestedOSSEvent{}, false
+	}
+	if out.ObjectKey == "" {
+		return ingestedOSSEvent{}, false
+	}
+	out.OccurredAt = time.Now().UTC()
+	if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(at)); err == nil {
+		out.OccurredAt = t.UTC()
+	}
+	return out, true
+}
+
+// parseOSSNotification decodes an ingest body: an MNS HTTP-push Notification (XML) or JSON
+// envelope whose Message carries the OSS notification (optionally base64), {"events": [...]}, a
+// bare array of events, or a single generic event. Events of other types are dropped.
+func parseOSSNotification(body []byte) ([]ingestedOSSEvent, error) {
+	body = bytes.TrimSpace(body)
+	for depth := 0; depth < 3; depth++ {
+		if len(body) > 0 && body[0] == '<' {
+			var n struct {
+				Message string `xml:"Message"`
+			}
+			if err := xml.Unmarshal(body, &n); err != nil {
+				return nil, err
+			}
+			body = bytes.TrimSpace([]byte(n.Message))
+			continue
+		}
+		if len(body) > 0 && body[0] != '{' && body[0] != '[' {
+			decoded, err := base64.StdEncoding.DecodeString(string(body))
+			if err != nil {
+				return nil, errors.New("unrecognized notification body")
+			}
+			body = bytes.TrimSpace(decoded)
+			continue
+		}
+
+		var raw []ossNotificationEvent
+		if len(body) > 0 && body[0] == '[' {
+			if err := json.Unmarshal(body, &raw); err != nil {
+				return nil, err
+			}
+		} else {
+			var env struct {
+				ossNotificationEvent
+				Message string                 `json:"Message"`
+				Events  []ossNotificationEvent `json:"events"`
+			}
+			if err := json.Unmarshal(body, &env); err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(env.Message) != "" {
+				body = bytes.TrimSpace([]byte(env.Message))
+				continue
+			}
+			raw = env.Events
+			if len(raw) == 0 {
+				raw = []ossNotificationEvent{env.ossNotificationEvent}
+			}
+		}
+		out := make([]ingestedOSSEvent, 0, len(raw))
+		for _, e := range raw {
+			if ev, ok := e.normalize(); ok {
+				out = append(out, ev)
+			}
+		}
+		return out, nil
+	}
+	return nil, errors.New("notification nested too deeply")
+}
+
+type ossIngestResponse struct {
+	Received   int `json:"received"`
+	Inserted   int `json:"inserted"`
+	Duplicates int `json:"duplicates"`
+	Ignored    int `json:"ignored"`
+}
+
+func (s server) handleOSSEventsIngest(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimSpace(s.ossEventsIngestToken) == "" {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "oss event ingest disabled"})
+		return
+	}
+	token := strings.TrimSpace(r.Header.Get(ossIngestHeader))
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.ossEventsIngestToken)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ossIngestMaxBodyBytes))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "body too large"})
+		return
+	}
+	events, err := parseOSSNotification(body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid notification"})
+		return
+	}
+	if len(events) > ossIngestMaxEvents {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "too many events"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	store, err := agenthome.NewOSSObjectStore(s.ossCfg())
+	if err != nil {
+		logError(ctx, "oss ingest: init oss store failed", err)
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "oss not configured"})
+		return
+	}
+
+	resp := ossIngestResponse{Received: len(events)}
+	for _, ev := range events {
+		inserted, duplicate, err := s.ingestOSSEvent(ctx, store, ev)
+		if err != nil {
+			if isContextCanceled(ctx, err) {
+				return
+			}
+			logError(ctx, "oss ingest: ingest event failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "ingest failed"})
+			return
+		}
+		switch {
+		case inserted:
+			resp.Inserted++
+		case duplicate:
+			resp.Duplicates++
+		default:
+			resp.Ignored++
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ingestOSSEvent records one notification. It returns duplicate=true when the event was already
+// recorded: a redelivered notification (dedupe_key) or the echo of a write whose latest oss_events
+// row carries the same content (platform writes are recorded before they reach OSS).
+func (s server) ingestOSSEvent(ctx context.Context, store agenthome.OSSObjectStore, ev ingestedOSSEvent) (inserted bool, duplicate bool, err error) {
+	if ev.Bucket != "" && strings.TrimSpace(s.ossBucket) != "" && ev.Bucket != strings.TrimSpace(s.ossBucket) {
+		return false, false, nil
+	}
+	key := ev.ObjectKey
+	if base := strings.Trim(strings.TrimSpace(s.ossBasePrefix), "/"); base != "" {
+		if !strings.HasPrefix(key, base+"/") {
+			return false, false, nil
+		}
+		key = stripBasePrefix(key, base)
+	}
+	// Heartbeats carry liveness via last-modified time, not events.
+	if key == "" || strings.HasPrefix(key, "agents/heartbeats/") {
+		return false, false, nil
+	}
+
+	payload := []byte(`{}`)
+	if ev.EventType == "put" {
+		obj, err := store.GetObject(ctx, key)
+		if isOSSNotFound(err) {
+			// Deleted again before we got here; the delete notification follows.
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		if len(obj) <= ossIngestMaxObjectSize && json.Valid(obj) {
+			payload = obj
+		} else {
+			payload, _ = json.Marshal(map[string]any{"etag": ev.ETag, "size": len(obj)})
+		}
+	}
+
+	var lastType string
+	var samePayload bool
+	err = s.db.QueryRow(ctx, `
+		select event_type, payload = $2::jsonb
+		from oss_events
+		where object_key = $1
+		order by id desc
+		limit 1
+	`, key, payload).Scan(&lastType, &samePayload)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, false, err
+	}
+	if err == nil && lastType == ev.EventType && (ev.EventType == "delete" || samePayload) {
+		return false, true, nil
+	}
+
+	dedupeKey := ev.EventType + ":" + key + ":" + ev.ETag
+	if ev.ETag == "" {
+		dedupeKey += ev.OccurredAt.Format(time.RFC3339Nano)
+	}
+	// Direct writes get the same topic_message privacy policy as gateway writes: rejected content
+	// is recorded but hidden (moderation reject), redacted content replaces the object in OSS.
+	// An object whose agent_ref/topic_id/id fields disagree with its key (say, a message claiming
+	// another author) is rejected as well.
+	p := parseTopicKeyFromObjectKey(key)
+	topicWrite := ev.EventType == "put" && p.TopicID != "" && (p.Kind == "messages" || p.Kind == "requests")
+	var privacy privacyDecision
+	redacted := false
+	rejectReason := ""
+	if topicWrite {
+		if !topicObjectMatchesKey(p, payload) {
+			rejectReason = "identity_mismatch"
+		} else if payload, privacy, redacted = s.applyIngestPrivacyPolicy(ctx, p.Kind, payload); len(privacy.Rejected) > 0 {
+			rejectReason = "privacy: " + strings.Join(privacyKinds(privacy.Rejected), ",")
+		}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockOSSEventsInTx(ctx, tx); err != nil {
+		return false, false, err
+	}
+	var id int64
+	err = tx.QueryRow(ctx, `
+		insert into oss_events (object_key, event_type, occurred_at, payload, source, dedupe_key)
+		values ($1, $2, $3, $4, 'oss_notification', $5)
+		on conflict (dedupe_key) where dedupe_key is not null do nothing
+		returning id
+	`, key, ev.EventType, ev.OccurredAt, payload, dedupeKey).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, true, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	if rejectReason != "" {
+		if err := rejectIngestedTopicObjectInTx(ctx, tx, key, p, payload, rejectReason); err != nil {
+			return false, false, err
+		}
+	}
+	var outboxID int64
+	if redacted {
+		// The event already carries the redacted body; the outbox overwrites the object with it.
+		if outboxID, err = enqueueOSSOutboxInTx(ctx, tx, key, "application/json", payload, id); err != nil {
+			return false, false, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, false, err
+	}
+	if outboxID != 0 {
+		if _, err := s.relayOSSOutbox(ctx, store, outboxID, 1); err != nil {
+			logError(ctx, "oss ingest: immediate redaction write failed (will retry)", err)
+		}
+	}
+	if topicWrite && rejectReason == "identity_mismatch" {
+		logMsg(ctx, "oss ingest: rejected topic object not matching its key: "+key)
+	} else if topicWrite {
+		s.processIngestedTopicObject(ctx, key, p, payload, privacy)
+	}
+	return true, false, nil
+}
+
+// topicObjectMatchesKey reports whether a directly written message/request names the topic, author
+// and id its key (topics/{topic_id}/{kind}/{agent_ref}/{id}.json) was written under, as the gateway
+// writes always do. The STS policy only pins the key, so the body has to be checked here.
+func topicObjectMatchesKey(p parsedTopicKey, body []byte) bool {
+	var obj struct {
+		TopicID   string `json:"topic_id"`
+		AgentRef  string `json:"agent_ref"`
+		MessageID string `json:"message_id"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return false
+	}
+	id := obj.MessageID
+	if p.Kind == "requests" {
+		id = obj.RequestID
+	}
+	return strings.TrimSpace(obj.TopicID) == p.TopicID &&
+		strings.ToLower(strings.TrimSpace(obj.AgentRef)) == p.ActorRef &&
+		strings.TrimSpace(id) == p.ObjectID
+}
+
+// applyIngestPrivacyPolicy applies the topic_message policy to the agent-written field of a topic
+// object. On reject the body is returned unchanged; changed reports a redaction.
+func (s server) applyIngestPrivacyPolicy(ctx context.Context, kind string, body []byte) (out []byte, d privacyDecision, changed bool) {
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return body, d, false
+	}
+	field := topicObjectScanField(kind)
+	v, ok := obj[field]
+	if !ok {
+		return body, d, false
+	}
+	redacted, d := applyPrivacyPolicy(s.privacy.policy(ctx, privacySurfaceTopicMessage), v, field)
+	if len(d.Rejected) > 0 || len(d.Redacted) == 0 {
+		return body, d, false
+	}
+	obj[field] = redacted
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return body, d, false
+	}
+	return out, d, true
+}
+
+// rejectIngestedTopicObjectInTx records a rejected object (privacy or identity mismatch) as rejected
+// content, in the same tx as its oss_events row so no reader ever sees it unfiltered.
+func rejectIngestedTopicObjectInTx(ctx context.Context, tx pgx.Tx, key string, p parsedTopicKey, body []byte, reason string) error {
+	targetType := topicObjectTargetType(p.Kind)
+	var reviewID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		insert into topic_content_reviews (object_key, target_type, topic_id, agent_id, agent_ref, summary, review_status)
+		values ($1, $2, $3, (select id from agents where public_ref = $4), $4, $5, 'rejected')
+		on conflict (object_key) do update
+		set summary = excluded.summary, review_status = 'rejected', updated_at = now()
+		returning id
+	`, key, targetType, p.TopicID, p.ActorRef, topicObjectSummary(p.Kind, body)).Scan(&reviewID); err != nil {
+		return err
+	}
+	return insertModerationActionInTx(ctx, tx, "system", uuid.Nil, targetType, reviewID, "auto_reject", reason)
+}
+
+// processIngestedTopicObject runs what the gateway write handlers do after a topic write: log
+// privacy findings, queue the object for moderation (votes are credited on approval) and notify the
+// owner of a replied-to agent. Privacy-rejected objects were already recorded as rejected.
+func (s server) processIngestedTopicObject(ctx context.Context, key string, p parsedTopicKey, body []byte, privacy privacyDecision) {
+	var agentID uuid.UUID
+	var agentRef string
+	if err := s.db.QueryRow(ctx, `select id, public_ref from agents where public_ref = $1`, p.ActorRef).Scan(&agentID, &agentRef); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logError(ctx, "oss ingest: agent lookup failed", err)
+		}
+		return
+	}
+	if len(privacy.Rejected) > 0 {
+		// Do not log raw content; only kinds + fields.
+		logError(ctx, "oss ingest: blocked by privacy filter", privacyViolationError{Findings: privacy.Rejected})
+		return
+	}
+	s.recordPrivacyDecision(ctx, privacySurfaceTopicMessage, privacy, topicObjectTargetType(p.Kind), key, "agent", agentID)
+	s.recordTopicContentForReview(ctx, key, p.TopicID, agentID, agentRef, body)
+	switch p.Kind {
+	case "messages":
+		var obj struct {
+			MessageID string         `json:"message_id"`
+			Meta      map[string]any `json:"meta"`
+		}
+		if err := json.Unmarshal(body, &obj); err == nil && strings.TrimSpace(obj.MessageID) != "" {
+			s.notifyTopicReply(ctx, p.TopicID, strings.TrimSpace(obj.MessageID), agentRef, parseTopicMessageRef(obj.Meta["reply_to"]))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseOSSNotification(t *testing.T) {
+	aliyun := `{"events":[
+		{"eventName":"ObjectCreated:PutObject","eventTime":"2026-10-18T08:00:00.000Z","oss":{"bucket":{"name":"b"},"object":{"key":"aihub/topics/t1/messages/a_1/m1.json","eTag":"\"E1\""}}},
+		{"eventName":"ObjectRemoved:DeleteObject","eventTime":"2026-10-18T08:01:00.000Z","oss":{"bucket":{"name":"b"},"object":{"key":"aihub/topics/t1/messages/a_1/m0.json"}}},
+		{"eventName":"ObjectReplication:ObjectCreated","oss":{"object":{"key":"x"}}}
+	]}`
+	mnsXML := `<?xml version="1.0" encoding="utf-8"?><Notification><TopicOwner>1</TopicOwner><Message>` +
+		base64.StdEncoding.EncodeToString([]byte(aliyun)) + `</Message></Notification>`
+
+	for name, body := range map[string]string{
+		"aliyun":       aliyun,
+		"mns xml":      mnsXML,
+		"mns json":     `{"TopicName":"t","Message":"` + base64.StdEncoding.EncodeToString([]byte(aliyun)) + `"}`,
+		"plain base64": base64.StdEncoding.EncodeToString([]byte(aliyun)),
+	} {
+		events, err := parseOSSNotification([]byte(body))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("%s: got %d events", name, len(events))
+		}
+		put, del := events[0], events[1]
+		if put.EventType != "put" || put.ObjectKey != "aihub/topics/t1/messages/a_1/m1.json" || put.ETag != "E1" || put.Bucket != "b" || put.OccurredAt.Minute() != 0 {
+			t.Errorf("%s: put %+v", name, put)
+		}
+		if del.EventType != "delete" || del.OccurredAt.Minute() != 1 {
+			t.Errorf("%s: delete %+v", name, del)
+		}
+	}
+
+	generic, err := parseOSSNotification([]byte(`{"object_key":"/topics/t1/state.json","event_type":"put","etag":"x"}`))
+	if err != nil || len(generic) != 1 || generic[0].ObjectKey != "topics/t1/state.json" {
+		t.Fatalf("generic: %+v %v", generic, err)
+	}
+	if _, err := parseOSSNotification([]byte("not a notification!")); err == nil {
+		t.Fatal("garbage accepted")
+	}
+}
+
+func TestOSSEventsIngestAuth(t *testing.T) {
+	body := `{"object_key":"topics/t1/state.json","event_type":"put"}`
+	rec := httptest.NewRecorder()
+	server{}.handleOSSEventsIngest(rec, httptest.NewRequest(http.MethodPost, "/v1/oss/events/ingest", strings.NewReader(body)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without configured token: %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/oss/events/ingest", strings.NewReader(body))
+	req.Header.Set(ossIngestHeader, "wrong")
+	rec = httptest.NewRecorder()
+	server{ossEventsIngestToken: "secret"}.handleOSSEventsIngest(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", rec.Code)
+	}
+}
+
+func TestApplyIngestPrivacyPolicy(t *testing.T) {
+	s := server{}
+	msg := []byte(`{"kind":"topic_message","message_id":"m1","content":{"text":"call 13812345678"}}`)
+	out, d, changed := s.applyIngestPrivacyPolicy(context.Background(), "messages", msg)
+	if changed || len(d.Rejected) == 0 || string(out) != string(msg) {
+		t.Fatalf("default policy must reject: %+v changed=%v", d, changed)
+	}
+
+	// Requests are checked on payload, not content.
+	req := []byte(`{"kind":"topic_request","type":"vote","content":"13812345678","payload":{"target_object_key":"topics/t1/messages/a_1/m1.json"}}`)
+	if _, d, changed := s.applyIngestPrivacyPolicy(context.Background(), "requests", req); changed || len(d.Rejected) != 0 {
+		t.Fatalf("request payload is clean: %+v", d)
+	}
+}
+
+func TestTopicObjectMatchesKey(t *testing.T) {
+	msgKey := parseTopicKeyFromObjectKey("topics/t1/messages/a_1/m1.json")
+	reqKey := parseTopicKeyFromObjectKey("topics/t1/requests/a_1/r1.json")
+	cases := []struct {
+		name string
+		p    parsedTopicKey
+		body string
+		want bool
+	}{
+		{"message", msgKey, `{"topic_id":"t1","message_id":"m1","agent_ref":"a_1"}`, true},
+		{"request", reqKey, `{"topic_id":"t1","request_id":"r1","agent_ref":"a_1"}`, true},
+		{"other_author", msgKey, `{"topic_id":"t1","message_id":"m1","agent_ref":"a_2"}`, false},
+		{"other_topic", msgKey, `{"topic_id":"t2","message_id":"m1","agent_ref":"a_1"}`, false},
+		{"other_id", msgKey, `{"topic_id":"t1","message_id":"m2","agent_ref":"a_1"}`, false},
+		{"request_id_on_message", msgKey, `{"topic_id":"t1","request_id":"m1","agent_ref":"a_1"}`, false},
+		{"missing_fields", msgKey, `{"content":{"text":"hi"}}`, false},
+		{"not_an_object", msgKey, `[1]`, false},
+	}
+	for _, tc := range cases {
+		if got := topicObjectMatchesKey(tc.p, []byte(tc.body)); got != tc.want {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type personaTemplateDTO struct {
@@ -256,25 +258,34 @@ func (s server) handleAdminSetPersonaTemplateStatus(w http.ResponseWriter, r *ht
 		return
 	}
 
+	var req moderationActionRequest
+	if r.ContentLength > 0 {
+		if !readJSONLimited(w, r, &req, 32*1024) {
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	ct, err := s.db.Exec(ctx, `
-		update persona_templates
-		set review_status = $1, updated_at = now()
-		where id = $2
-	`, status, templateID)
-	if err != nil {
-		logError(ctx, "update persona_templates failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+	// Same decision path (and moderation_actions trail) as the unified moderation queue.
+	var moderationID uuid.UUID
+	if err := s.db.QueryRow(ctx, `select moderation_id from persona_templates where id = $1`, templateID).Scan(&moderationID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		logError(ctx, "query persona_templates failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
 		return
 	}
-	if ct.RowsAffected() == 0 {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	action := "approve"
+	if status == "rejected" {
+		action = "reject"
+	}
+	if !s.writeModerationDecision(ctx, w, "admin", uuid.Nil, "persona_template", moderationID, status, action, strings.TrimSpace(req.Reason)) {
 		return
 	}
-
-	s.audit(ctx, "admin", uuid.Nil, "persona_template_"+status, map[string]any{"template_id": templateID})
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
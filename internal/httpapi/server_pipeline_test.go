@@ -0,0 +1,208 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// pipelineHarness mounts the run pipeline handlers on an in-memory repository. Bearer tokens map
+// straight to a user or agent id instead of going through the key tables.
+type pipelineHarness struct {
+	t    *testing.T
+	srv  *httptest.Server
+	repo *memRepository
+	br   *broker
+}
+
+func newPipelineHarness(t *testing.T, users, agents map[string]uuid.UUID, repo *memRepository) *pipelineHarness {
+	t.Helper()
+	s := server{repo: repo, br: newBroker(), workItemLeaseSeconds: 300}
+
+	auth := func(ids map[string]uuid.UUID, key ctxKey) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, ok := ids[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+				if !ok {
+					writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key, id)))
+			})
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Route("/v1", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(auth(users, ctxUserID))
+			r.Post("/runs", s.handleCreateRun)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(auth(agents, ctxAgentID))
+			r.Get("/gateway/inbox/poll", s.handleGatewayPoll)
+			r.Post("/gateway/inbox/claim-next", s.handleGatewayClaimNextWorkItem)
+			r.Post("/gateway/work-items/{workItemID}/claim", s.handleGatewayClaimWorkItem)
+			r.Post("/gateway/work-items/{workItemID}/complete", s.handleGatewayCompleteWorkItem)
+			r.Post("/gateway/runs/{runRef}/events", s.handleGatewayEmitEvent)
+			r.Post("/gateway/runs/{runRef}/artifacts", s.handleGatewaySubmitArtifact)
+		})
+	})
+	h := &pipelineHarness{t: t, srv: httptest.NewServer(r), repo: repo, br: s.br}
+	t.Cleanup(h.srv.Close)
+	return h
+}
+
+// do sends body (JSON-encoded unless nil) and decodes the response into out (if non-nil).
+func (h *pipelineHarness) do(token, method, path string, body any, wantStatus int, out any) {
+	h.t.Helper()
+	var rd *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		rd = bytes.NewReader(b)
+	} else {
+		rd = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, h.srv.URL+path, rd)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.srv.Client().Do(req)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var raw json.RawMessage
+	_ = json.NewDecoder(resp.Body).Decode(&raw)
+	if resp.StatusCode != wantStatus {
+		h.t.Fatalf("%s %s: status %d, want %d (body %s)", method, path, resp.StatusCode, wantStatus, raw)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			h.t.Fatalf("%s %s: decode %s: %v", method, path, raw, err)
+		}
+	}
+}
+
+type pipelineOffer struct {
+	WorkItemID    string         `json:"work_item_id"`
+	RunRef        string         `json:"run_ref"`
+	Stage         string         `json:"stage"`
+	Kind          string         `json:"kind"`
+	Status        string         `json:"status"`
+	StageContext  map[string]any `json:"stage_context"`
+	ReviewContext map[string]any `json:"review_context"`
+}
+
+func (h *pipelineHarness) poll(token string) []pipelineOffer {
+	h.t.Helper()
+	var resp struct {
+		Offers []pipelineOffer `json:"offers"`
+	}
+	h.do(token, http.MethodGet, "/v1/gateway/inbox/poll", nil, http.StatusOK, &resp)
+	return resp.Offers
+}
+
+func TestRunPipelineWithMemRepository(t *testing.T) {
+	publisher, otherOwner := uuid.New(), uuid.New()
+	repo := newMemRepository()
+	author := repo.addAgent(publisher, "执笔者", "writing")
+	reviewer := repo.addAgent(otherOwner, "", "review", "writing")
+	h := newPipelineHarness(t,
+		map[string]uuid.UUID{"pub": publisher},
+		map[string]uuid.UUID{"author": author, "reviewer": reviewer},
+		repo)
+
+	// Publish: only the publisher's own agent is matched (matchLimit 1, owner first).
+	var created createRunResponse
+	h.do("pub", http.MethodPost, "/v1/runs", map[string]any{"goal": "写一首关于秋天的短诗", "required_tags": []string{"writing"}}, http.StatusCreated, &created)
+	if !strings.HasPrefix(created.RunRef, runRefPrefix) {
+		t.Fatalf("unexpected run_ref %q", created.RunRef)
+	}
+	if got := h.poll("reviewer"); len(got) != 0 {
+		t.Fatalf("reviewer should have no offers yet, got %+v", got)
+	}
+	offers := h.poll("author")
+	if len(offers) != 1 || offers[0].RunRef != created.RunRef || offers[0].Stage != "ideation" || offers[0].Status != "offered" {
+		t.Fatalf("unexpected offers %+v", offers)
+	}
+	if offers[0].StageContext["self_agent_name"] != "执笔者" {
+		t.Fatalf("stage_context missing self prompt context: %+v", offers[0].StageContext)
+	}
+	draftItem := offers[0].WorkItemID
+
+	// Claim; a second claim and a claim by a non-offered agent fail.
+	h.do("author", http.MethodPost, "/v1/gateway/work-items/"+draftItem+"/claim", nil, http.StatusOK, nil)
+	h.do("author", http.MethodPost, "/v1/gateway/work-items/"+draftItem+"/claim", nil, http.StatusConflict, nil)
+	h.do("reviewer", http.MethodPost, "/v1/gateway/work-items/"+draftItem+"/claim", nil, http.StatusNotFound, nil)
+	h.do("reviewer", http.MethodPost, "/v1/gateway/work-items/"+draftItem+"/complete", nil, http.StatusForbidden, nil)
+
+	// Emit: participants only; events fan out through the broker.
+	runID, err := repo.RunIDByRef(context.Background(), created.RunRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := h.br.subscribe(runID)
+	defer h.br.unsubscribe(runID, sub)
+	var ev eventDTO
+	h.do("author", http.MethodPost, "/v1/gateway/runs/"+created.RunRef+"/events", map[string]any{"kind": "message", "payload": map[string]any{"text": "先定个基调"}}, http.StatusCreated, &ev)
+	if ev.Seq != 1 || ev.Persona != "执笔者" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if got := <-sub; got.Seq != 1 {
+		t.Fatalf("broker delivered %+v", got)
+	}
+	h.do("reviewer", http.MethodPost, "/v1/gateway/runs/"+created.RunRef+"/events", map[string]any{"kind": "message", "payload": map[string]any{}}, http.StatusForbidden, nil)
+
+	// A final artifact opens a review work item for the other agent.
+	var submitted submitArtifactResponse
+	h.do("author", http.MethodPost, "/v1/gateway/runs/"+created.RunRef+"/artifacts", map[string]any{"kind": "final", "content": "秋风起，落叶归根。"}, http.StatusCreated, &submitted)
+	if submitted.Version != 1 {
+		t.Fatalf("unexpected artifact %+v", submitted)
+	}
+	h.do("author", http.MethodPost, "/v1/gateway/work-items/"+draftItem+"/complete", nil, http.StatusOK, nil)
+	h.do("author", http.MethodPost, "/v1/gateway/work-items/"+draftItem+"/complete", nil, http.StatusConflict, nil)
+
+	offers = h.poll("reviewer")
+	if len(offers) != 1 || offers[0].Kind != "review" || offers[0].ReviewContext["target_artifact_id"] != submitted.ArtifactID {
+		t.Fatalf("unexpected review offers %+v", offers)
+	}
+	if prev, _ := offers[0].StageContext["previous_artifacts"].([]any); len(prev) != 1 {
+		t.Fatalf("expected one previous artifact, got %+v", offers[0].StageContext["previous_artifacts"])
+	}
+
+	// Review: claim-next, feedback as an event (not an artifact), complete.
+	h.do("reviewer", http.MethodPost, "/v1/gateway/inbox/claim-next", nil, http.StatusOK, nil)
+	h.do("reviewer", http.MethodPost, "/v1/gateway/inbox/claim-next", nil, http.StatusNotFound, nil)
+	h.do("reviewer", http.MethodPost, "/v1/gateway/runs/"+created.RunRef+"/artifacts", map[string]any{"kind": "final", "content": "改写版"}, http.StatusConflict, nil)
+	h.do("reviewer", http.MethodPost, "/v1/gateway/runs/"+created.RunRef+"/events", map[string]any{"kind": "summary", "payload": map[string]any{"text": "意象清楚，可以再收束结尾", "target_artifact_id": submitted.ArtifactID}}, http.StatusCreated, &ev)
+	if ev.Seq != 2 || ev.Persona != "review / writing" {
+		t.Fatalf("unexpected review event %+v", ev)
+	}
+	h.do("reviewer", http.MethodPost, "/v1/gateway/work-items/"+offers[0].WorkItemID+"/complete", nil, http.StatusOK, nil)
+
+	if got := h.poll("author"); len(got) != 0 {
+		t.Fatalf("author inbox should be empty, got %+v", got)
+	}
+	if got := h.poll("reviewer"); len(got) != 0 {
+		t.Fatalf("reviewer inbox should be empty, got %+v", got)
+	}
+	audited := strings.Join(repo.audits, ",")
+	for _, action := range []string{"run_created", "work_item_claimed", "event_emitted", "review_work_item_created", "artifact_submitted", "work_item_completed"} {
+		if !strings.Contains(audited, action) {
+			t.Fatalf("audit %q missing from %v", action, repo.audits)
+		}
+	}
+}
@@ -782,9 +782,11 @@ func (s server) handleOwnerCreatePreReviewEvaluation(w http.ResponseWriter, r *h
 		return
 	}
 
-	skills := s.skillsGatewayWhitelist
-	if skills == nil {
-		skills = []string{}
+	skills, skillRefsJSON, err := s.resolveStageSkills(ctx, tx, "review")
+	if err != nil {
+		logError(ctx, "create pre-review evaluation: resolve skills failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "skills lookup failed"})
+		return
 	}
 	stageContext := s.stageContextForStage("review", skills)
 	preReviewCtx := map[string]any{
@@ -874,10 +876,10 @@ func (s server) handleOwnerCreatePreReviewEvaluation(w http.ResponseWriter, r *h
 
 	var workItemID uuid.UUID
 	if err := tx.QueryRow(ctx, `
-		insert into work_items (run_id, stage, kind, status, context, available_skills)
-		values ($1, 'review', 'draft', 'offered', $2, $3)
+		insert into work_items (run_id, stage, kind, status, context, available_skills, skill_refs)
+		values ($1, 'review', 'draft', 'offered', $2, $3, $4)
 		returning id
-	`, runID, stageContextJSON, availableSkillsJSON).Scan(&workItemID); err != nil {
+	`, runID, stageContextJSON, availableSkillsJSON, skillRefsJSON).Scan(&workItemID); err != nil {
 		logError(ctx, "create pre-review evaluation: create work item failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "create work item failed"})
 		return
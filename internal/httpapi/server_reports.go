@@ -0,0 +1,241 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"aihub/internal/keys"
+)
+
+// --- Viewer reports on public content
+
+// reportCategoryWeights is the severity each report adds to its target.
+var reportCategoryWeights = map[string]int{
+	"spam":           1,
+	"other":          1,
+	"misinformation": 2,
+	"harassment":     3,
+	"privacy":        3,
+	"hate":           4,
+	"sexual":         4,
+	"violence":       4,
+	"illegal":        5,
+}
+
+var (
+	rateLimitReportAnon = rateLimitPolicy{Name: "report_anon", Limit: 10, Window: time.Hour, Burst: 10}
+	rateLimitReportUser = rateLimitPolicy{Name: "report_user", Limit: 60, Window: time.Hour, Burst: 60}
+)
+
+type createReportRequest struct {
+	// run | event | artifact | topic_message
+	TargetType string `json:"target_type"`
+	RunRef     string `json:"run_ref,omitempty"`
+	Seq        int64  `json:"seq,omitempty"`     // event
+	Version    int    `json:"version,omitempty"` // artifact
+	TopicID    string `json:"topic_id,omitempty"`
+	MessageID  string `json:"message_id,omitempty"`
+	Category   string `json:"category"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// handleCreateReport files a viewer report. It works without login (stricter per-IP limit). The
+// response is the same for new and repeated reports so it does not reveal earlier reports.
+func (s server) handleCreateReport(w http.ResponseWriter, r *http.Request) {
+	var req createReportRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	req.TargetType = strings.TrimSpace(req.TargetType)
+	req.Category = strings.ToLower(strings.TrimSpace(req.Category))
+	if _, ok := reportCategoryWeights[req.Category]; !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid category"})
+		return
+	}
+	req.Detail = strings.TrimSpace(req.Detail)
+	if len(req.Detail) > 2000 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "detail too long"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID, hasUser, err := s.maybeUserIDFromRequest(ctx, r)
+	if err != nil {
+		logError(ctx, "create report: auth lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "auth lookup failed"})
+		return
+	}
+	var (
+		reporterKey string
+		reporterID  *uuid.UUID
+		policy      = rateLimitReportAnon
+	)
+	if hasUser {
+		reporterKey, reporterID, policy = "user:"+userID.String(), &userID, rateLimitReportUser
+	} else {
+		reporterKey = "ip:" + keys.HashAPIKey(s.pepper, "report-ip:"+clientIP(r))
+	}
+	if !s.limiter.allow(w, r, reporterKey, policy) {
+		return
+	}
+
+	targetID, err := s.resolveReportTarget(ctx, req)
+	if errors.Is(err, errModerationTargetType) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid target type"})
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "create report: target lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "target lookup failed"})
+		return
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		logError(ctx, "create report: db begin failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	ct, err := tx.Exec(ctx, `
+		insert into content_reports (target_type, target_id, category, detail, reporter_user_id, reporter_key)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (target_type, target_id, reporter_key) do nothing
+	`, req.TargetType, targetID, req.Category, req.Detail, reporterID, reporterKey)
+	if err != nil {
+		logError(ctx, "create report: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	if ct.RowsAffected() == 0 {
+		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true})
+		return
+	}
+	var t reportTotals
+	if err := tx.QueryRow(ctx, `
+		insert into content_report_targets (target_type, target_id, report_count, severity)
+		values ($1, $2, 1, $3)
+		on conflict (target_type, target_id) do update
+		set report_count = content_report_targets.report_count + 1,
+		    severity = content_report_targets.severity + excluded.severity,
+		    last_reported_at = now()
+		returning report_count, severity, escalated_count, escalated_severity
+	`, req.TargetType, targetID, reportCategoryWeights[req.Category]).Scan(&t.Count, &t.Severity, &t.EscalatedCount, &t.EscalatedSeverity); err != nil {
+		logError(ctx, "create report: totals update failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logError(ctx, "create report: commit failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+		return
+	}
+
+	if s.moderator != nil && t.shouldEscalate(s.moderator.config(ctx).Settings) {
+		s.escalateReportedContent(ctx, req.TargetType, targetID, t)
+	}
+	if hasUser {
+		s.audit(ctx, "user", userID, "content_reported", map[string]any{"target_type": req.TargetType, "target_id": targetID.String(), "category": req.Category})
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"ok": true})
+}
+
+// resolveReportTarget maps the public handle of a report target to its id. Only publicly visible,
+// not rejected content can be reported.
+func (s server) resolveReportTarget(ctx context.Context, req createReportRequest) (uuid.UUID, error) {
+	var (
+		id  uuid.UUID
+		err error
+	)
+	runRef := strings.TrimSpace(req.RunRef)
+	switch req.TargetType {
+	case "run":
+		err = s.db.QueryRow(ctx, `
+			select id from runs where public_ref = $1 and is_public = true and review_status <> 'rejected'
+		`, runRef).Scan(&id)
+	case "event":
+		err = s.db.QueryRow(ctx, `
+			select e.id
+			from events e
+			join runs r on r.id = e.run_id
+			where r.public_ref = $1 and r.is_public = true and r.review_status <> 'rejected'
+			  and e.seq = $2 and e.review_status <> 'rejected'
+		`, runRef, req.Seq).Scan(&id)
+	case "artifact":
+		err = s.db.QueryRow(ctx, `
+			select a.id
+			from artifacts a
+			join runs r on r.id = a.run_id
+			where r.public_ref = $1 and r.is_public = true and r.review_status <> 'rejected'
+			  and a.version = $2 and a.review_status <> 'rejected'
+		`, runRef, req.Version).Scan(&id)
+	case "topic_message":
+		messageID := strings.TrimSpace(req.MessageID)
+		if messageID == "" || strings.Contains(messageID, "/") {
+			return uuid.Nil, pgx.ErrNoRows
+		}
+		err = s.db.QueryRow(ctx, `
+			select id
+			from topic_content_reviews
+			where topic_id = $1 and target_type = 'topic_message' and review_status <> 'rejected'
+			  and right(object_key, length($2) + 6) = '/' || $2 || '.json'
+			order by created_at desc
+			limit 1
+		`, strings.TrimSpace(req.TopicID), messageID).Scan(&id)
+	default:
+		return uuid.Nil, errModerationTargetType
+	}
+	return id, err
+}
+
+type reportTotals struct {
+	Count, Severity                   int
+	EscalatedCount, EscalatedSeverity int
+}
+
+// shouldEscalate reports whether the reports received since the last escalation cross either
+// threshold.
+func (t reportTotals) shouldEscalate(st autoModerationSettings) bool {
+	return (st.ReportEscalateCount > 0 && t.Count-t.EscalatedCount >= st.ReportEscalateCount) ||
+		(st.ReportEscalateSeverity > 0 && t.Severity-t.EscalatedSeverity >= st.ReportEscalateSeverity)
+}
+
+// escalateReportedContent sends approved content back to pending (pending content just gets the
+// escalate record) and restarts the threshold count. Rejected content is left alone.
+func (s server) escalateReportedContent(ctx context.Context, targetType string, id uuid.UUID, t reportTotals) {
+	ct, err := s.db.Exec(ctx, `
+		update content_report_targets
+		set escalated_count = $3, escalated_severity = $4, escalated_at = now()
+		where target_type = $1 and target_id = $2 and escalated_count < $3
+	`, targetType, id, t.Count, t.Severity)
+	if err != nil {
+		logError(ctx, "escalate reported content: update totals failed", err)
+		return
+	}
+	if ct.RowsAffected() == 0 {
+		// A concurrent report already escalated.
+		return
+	}
+	reason := fmt.Sprintf("viewer reports: %d (severity %d)", t.Count, t.Severity)
+	err = s.setModerationStatusFrom(ctx, "system", uuid.Nil, targetType, id, "approved", "pending", "escalate", reason)
+	if errors.Is(err, errModerationNotFound) {
+		err = s.setModerationStatusFrom(ctx, "system", uuid.Nil, targetType, id, "pending", "pending", "escalate", reason)
+	}
+	if err != nil && !errors.Is(err, errModerationNotFound) {
+		logError(ctx, "escalate reported content: set status failed", err)
+	}
+}
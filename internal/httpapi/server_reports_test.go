@@ -0,0 +1,27 @@
+package httpapi
+
+import "testing"
+
+func TestReportTotalsShouldEscalate(t *testing.T) {
+	st := autoModerationSettings{ReportEscalateCount: 3, ReportEscalateSeverity: 8}
+	cases := []struct {
+		name string
+		t    reportTotals
+		want bool
+	}{
+		{"below both", reportTotals{Count: 2, Severity: 5}, false},
+		{"count", reportTotals{Count: 3, Severity: 3}, true},
+		{"severity", reportTotals{Count: 2, Severity: 9}, true},
+		// Only reports since the last escalation count.
+		{"after escalation", reportTotals{Count: 5, Severity: 12, EscalatedCount: 3, EscalatedSeverity: 9}, false},
+		{"again", reportTotals{Count: 6, Severity: 12, EscalatedCount: 3, EscalatedSeverity: 9}, true},
+	}
+	for _, c := range cases {
+		if got := c.t.shouldEscalate(st); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+	if (reportTotals{Count: 100, Severity: 500}).shouldEscalate(autoModerationSettings{}) {
+		t.Error("escalated with thresholds disabled")
+	}
+}
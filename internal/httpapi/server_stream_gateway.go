@@ -12,6 +12,8 @@ import (
 	"strings"
 	"time"
 
+	"aihub/internal/toolruntime"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -326,26 +328,12 @@ func (s server) handleGatewayPoll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := s.db.Query(ctx, `
-		select wi.id, wi.run_id, r.public_ref, r.goal, r.constraints, wi.stage, wi.kind, wi.status, wi.context, wi.available_skills, wi.review_context
-		from work_item_offers o
-		join work_items wi on wi.id = o.work_item_id
-		join runs r on r.id = wi.run_id
-		left join work_item_leases l on l.work_item_id = wi.id
-		where o.agent_id = $1
-		  and (
-		    wi.status = 'offered'
-		    or (wi.status = 'claimed' and l.agent_id = $1 and l.lease_expires_at > now())
-		  )
-		order by wi.created_at asc
-		limit 50
-	`, agentID)
+	items, err := s.repo.ListOffers(ctx, agentID, 50)
 	if err != nil {
 		logError(ctx, "gateway poll: query offers failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
 		return
 	}
-	defer rows.Close()
 
 	type offerDTO struct {
 		WorkItemID      string         `json:"work_item_id"`
@@ -361,54 +349,35 @@ func (s server) handleGatewayPoll(w http.ResponseWriter, r *http.Request) {
 	}
 	offers := make([]offerDTO, 0)
 	artifactRefsCache := map[uuid.UUID][]artifactRefDTO{}
-	for rows.Next() {
-		var (
-			workItemID      uuid.UUID
-			runID           uuid.UUID
-			runRef          string
-			goal            string
-			constraints     string
-			stage           string
-			kind            string
-			status          string
-			context         []byte
-			availableSkills []byte
-			reviewContext   []byte
-		)
-		if err := rows.Scan(&workItemID, &runID, &runRef, &goal, &constraints, &stage, &kind, &status, &context, &availableSkills, &reviewContext); err != nil {
-			logError(ctx, "gateway poll: scan offer failed", err)
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
-			return
-		}
-
+	for _, wi := range items {
 		// Parse JSON fields
 		var stageContext map[string]any
 		var skills []string
 		var revCtx map[string]any
-		if err := unmarshalJSONNullable(context, &stageContext); err != nil {
+		if err := unmarshalJSONNullable(wi.Context, &stageContext); err != nil {
 			logError(ctx, "unmarshal work item context failed", err)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "context decode failed"})
 			return
 		}
-		if err := unmarshalJSONNullable(availableSkills, &skills); err != nil {
+		if err := unmarshalJSONNullable(wi.AvailableSkills, &skills); err != nil {
 			logError(ctx, "unmarshal work item available_skills failed", err)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "available skills decode failed"})
 			return
 		}
-		if err := unmarshalJSONNullable(reviewContext, &revCtx); err != nil {
+		if err := unmarshalJSONNullable(wi.ReviewContext, &revCtx); err != nil {
 			logError(ctx, "unmarshal work item review_context failed", err)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "review context decode failed"})
 			return
 		}
 
-		refs, ok := artifactRefsCache[runID]
+		refs, ok := artifactRefsCache[wi.RunID]
 		if !ok {
-			refs, err = s.listArtifactRefs(ctx, runID, runRef)
+			refs, err = s.listArtifactRefs(ctx, wi.RunID, wi.RunRef)
 			if err != nil {
 				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "artifact refs lookup failed"})
 				return
 			}
-			artifactRefsCache[runID] = refs
+			artifactRefsCache[wi.RunID] = refs
 		}
 		if stageContext == nil {
 			stageContext = map[string]any{}
@@ -420,13 +389,13 @@ func (s server) handleGatewayPoll(w http.ResponseWriter, r *http.Request) {
 		}
 
 		offers = append(offers, offerDTO{
-			WorkItemID:      workItemID.String(),
-			RunRef:          strings.TrimSpace(runRef),
-			Stage:           stage,
-			Kind:            kind,
-			Status:          status,
-			Goal:            strings.TrimSpace(goal),
-			Constraints:     strings.TrimSpace(constraints),
+			WorkItemID:      wi.ID.String(),
+			RunRef:          strings.TrimSpace(wi.RunRef),
+			Stage:           wi.Stage,
+			Kind:            wi.Kind,
+			Status:          wi.Status,
+			Goal:            strings.TrimSpace(wi.Goal),
+			Constraints:     strings.TrimSpace(wi.Constraints),
 			StageContext:    stageContext,
 			AvailableSkills: skills,
 			ReviewContext:   revCtx,
@@ -660,15 +629,9 @@ func (s server) handleGatewayGetWorkItem(w http.ResponseWriter, r *http.Request)
 }
 
 type workItemSkillsResponse struct {
-	WorkItemID string     `json:"work_item_id"`
-	RunRef     string     `json:"run_ref"`
-	Skills     []skillDTO `json:"skills"`
-}
-
-type skillDTO struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Parameters  map[string]any `json:"parameters"`
+	WorkItemID string               `json:"work_item_id"`
+	RunRef     string               `json:"run_ref"`
+	Skills     []skillDescriptorDTO `json:"skills"`
 }
 
 func (s server) handleGatewayWorkItemSkills(w http.ResponseWriter, r *http.Request) {
@@ -701,13 +664,14 @@ func (s server) handleGatewayWorkItemSkills(w http.ResponseWriter, r *http.Reque
 		runID           uuid.UUID
 		runRef          string
 		availableSkills []byte
+		skillRefsB      []byte
 	)
 	if err := s.db.QueryRow(ctx, `
-		select wi.run_id, r.public_ref, wi.available_skills
+		select wi.run_id, r.public_ref, wi.available_skills, wi.skill_refs
 		from work_items wi
 		join runs r on r.id = wi.run_id
 		where wi.id = $1
-	`, workItemID).Scan(&runID, &runRef, &availableSkills); err != nil {
+	`, workItemID).Scan(&runID, &runRef, &availableSkills, &skillRefsB); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
 			return
@@ -723,17 +687,27 @@ func (s server) handleGatewayWorkItemSkills(w http.ResponseWriter, r *http.Reque
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "available skills decode failed"})
 		return
 	}
-	out := make([]skillDTO, 0, len(skills))
-	for _, name := range skills {
-		name = strings.TrimSpace(name)
-		if name == "" {
-			continue
+	var refs []skillRef
+	if err := unmarshalJSONNullable(skillRefsB, &refs); err != nil {
+		logError(ctx, "unmarshal work item skill_refs failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "skill refs decode failed"})
+		return
+	}
+	if len(refs) == 0 {
+		// Work items created before the skills catalog: resolve names to the latest active version.
+		for _, name := range skills {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			refs = append(refs, skillRef{Name: name})
 		}
-		out = append(out, skillDTO{
-			Name:        name,
-			Description: "",
-			Parameters:  map[string]any{},
-		})
+	}
+	out, err := describeSkills(ctx, s.db, refs)
+	if err != nil {
+		logError(ctx, "gateway work item skills: describe skills failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "skills lookup failed"})
+		return
 	}
 
 	writeJSON(w, http.StatusOK, workItemSkillsResponse{
@@ -751,32 +725,17 @@ type artifactRefDTO struct {
 }
 
 func (s server) listArtifactRefs(ctx context.Context, runID uuid.UUID, runRef string) ([]artifactRefDTO, error) {
-	rows, err := s.db.Query(ctx, `
-		select version, kind, created_at
-		from artifacts
-		where run_id = $1 and review_status <> 'rejected'
-		order by version asc
-	`, runID)
+	artifacts, err := s.repo.ListArtifacts(ctx, runID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	out := make([]artifactRefDTO, 0)
-	for rows.Next() {
-		var (
-			version   int
-			kind      string
-			createdAt time.Time
-		)
-		if err := rows.Scan(&version, &kind, &createdAt); err != nil {
-			return nil, err
-		}
+	out := make([]artifactRefDTO, 0, len(artifacts))
+	for _, a := range artifacts {
 		out = append(out, artifactRefDTO{
-			Version:   version,
-			Kind:      kind,
-			URL:       "/v1/runs/" + strings.TrimSpace(runRef) + "/artifacts/" + strconv.Itoa(version),
-			CreatedAt: createdAt.UTC().Format(time.RFC3339),
+			Version:   a.Version,
+			Kind:      a.Kind,
+			URL:       "/v1/runs/" + strings.TrimSpace(runRef) + "/artifacts/" + strconv.Itoa(a.Version),
+			CreatedAt: a.CreatedAt.UTC().Format(time.RFC3339),
 		})
 	}
 	return out, nil
@@ -814,23 +773,14 @@ func (s server) attachSelfPromptContext(ctx context.Context, agentID uuid.UUID,
 		stageContext = map[string]any{}
 	}
 
-	var (
-		agentRef     string
-		name         string
-		promptView   string
-		personaRaw   []byte
-		identityMode string
-	)
-	if err := s.db.QueryRow(ctx, `
-		select public_ref, name, prompt_view, persona, identity_mode
-		from agents
-		where id = $1
-	`, agentID).Scan(&agentRef, &name, &promptView, &personaRaw, &identityMode); err != nil {
+	profile, err := s.repo.AgentProfile(ctx, agentID)
+	if err != nil {
 		return nil, err
 	}
+	agentRef, name, promptView, identityMode := profile.Ref, profile.Name, profile.PromptView, profile.IdentityMode
 
 	var persona any
-	if err := unmarshalJSONNullable(personaRaw, &persona); err != nil {
+	if err := unmarshalJSONNullable(profile.Persona, &persona); err != nil {
 		logError(ctx, "gateway: unmarshal agent persona failed", err)
 		persona = nil
 	}
@@ -862,39 +812,24 @@ func (s server) attachSelfPromptContext(ctx context.Context, agentID uuid.UUID,
 }
 
 func (s server) buildClaimResponse(ctx context.Context, agentID uuid.UUID, workItemID uuid.UUID, leaseExpiresAt time.Time) (claimResponse, error) {
-	var (
-		runID           uuid.UUID
-		runRef          string
-		stage           string
-		kind            string
-		goal            string
-		constraints     string
-		contextB        []byte
-		availableSkills []byte
-		reviewContextB  []byte
-	)
-	if err := s.db.QueryRow(ctx, `
-		select wi.run_id, r.public_ref, wi.stage, wi.kind, r.goal, r.constraints, wi.context, wi.available_skills, wi.review_context
-		from work_items wi
-		join runs r on r.id = wi.run_id
-		where wi.id = $1
-	`, workItemID).Scan(&runID, &runRef, &stage, &kind, &goal, &constraints, &contextB, &availableSkills, &reviewContextB); err != nil {
+	wi, err := s.repo.GetWorkItem(ctx, workItemID)
+	if err != nil {
 		return claimResponse{}, err
 	}
 
 	var stageContext map[string]any
 	var skills []string
 	var revCtx map[string]any
-	if err := unmarshalJSONNullable(contextB, &stageContext); err != nil {
+	if err := unmarshalJSONNullable(wi.Context, &stageContext); err != nil {
 		return claimResponse{}, err
 	}
-	if err := unmarshalJSONNullable(availableSkills, &skills); err != nil {
+	if err := unmarshalJSONNullable(wi.AvailableSkills, &skills); err != nil {
 		return claimResponse{}, err
 	}
-	if err := unmarshalJSONNullable(reviewContextB, &revCtx); err != nil {
+	if err := unmarshalJSONNullable(wi.ReviewContext, &revCtx); err != nil {
 		return claimResponse{}, err
 	}
-	stageContext, err := s.enrichStageContextForOffer(ctx, runID, runRef, stageContext, skills)
+	stageContext, err = s.enrichStageContextForOffer(ctx, wi.RunID, wi.RunRef, stageContext, skills)
 	if err != nil {
 		return claimResponse{}, err
 	}
@@ -905,12 +840,12 @@ func (s server) buildClaimResponse(ctx context.Context, agentID uuid.UUID, workI
 
 	return claimResponse{
 		WorkItemID:      workItemID.String(),
-		RunRef:          strings.TrimSpace(runRef),
-		Stage:           strings.TrimSpace(stage),
-		Kind:            strings.TrimSpace(kind),
+		RunRef:          strings.TrimSpace(wi.RunRef),
+		Stage:           strings.TrimSpace(wi.Stage),
+		Kind:            strings.TrimSpace(wi.Kind),
 		Status:          "claimed",
-		Goal:            strings.TrimSpace(goal),
-		Constraints:     strings.TrimSpace(constraints),
+		Goal:            strings.TrimSpace(wi.Goal),
+		Constraints:     strings.TrimSpace(wi.Constraints),
 		StageContext:    stageContext,
 		AvailableSkills: skills,
 		ReviewContext:   revCtx,
@@ -933,58 +868,21 @@ func (s server) handleGatewayClaimWorkItem(w http.ResponseWriter, r *http.Reques
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
-		return
-	}
-	defer tx.Rollback(ctx)
-
-	// Must be offered to this agent.
-	var offered bool
-	if err := tx.QueryRow(ctx, `select true from work_item_offers where work_item_id=$1 and agent_id=$2`, workItemID, agentID).Scan(&offered); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not offered"})
-			return
-		}
-		logError(ctx, "gateway claim: offer check failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "offer check failed"})
-		return
-	}
-
-	// Only allow claiming if currently offered.
-	var status string
-	if err := tx.QueryRow(ctx, `select status from work_items where id=$1 for update`, workItemID).Scan(&status); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
-			return
-		}
-		logError(ctx, "gateway claim: work item lookup failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "work item lookup failed"})
-		return
-	}
-	if status != "offered" {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "not claimable"})
-		return
-	}
-
 	expiresAt := time.Now().UTC().Add(time.Duration(s.workItemLeaseSeconds) * time.Second)
-	if _, err := tx.Exec(ctx, `
-		insert into work_item_leases (work_item_id, agent_id, lease_expires_at)
-		values ($1, $2, $3)
-	`, workItemID, agentID, expiresAt); err != nil {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "already claimed"})
+	err = s.repo.ClaimWorkItem(ctx, agentID, workItemID, expiresAt)
+	switch {
+	case errors.Is(err, errNotOffered):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not offered"})
 		return
-	}
-	if _, err := tx.Exec(ctx, `update work_items set status='claimed', updated_at=now() where id=$1`, workItemID); err != nil {
-		logError(ctx, "gateway claim: update work item failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+	case errors.Is(err, pgx.ErrNoRows):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
 		return
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		logError(ctx, "gateway claim: commit failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+	case errors.Is(err, errNotClaimable), errors.Is(err, errAlreadyClaimed):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		logError(ctx, "gateway claim: claim failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "claim failed"})
 		return
 	}
 
@@ -1008,52 +906,18 @@ func (s server) handleGatewayClaimNextWorkItem(w http.ResponseWriter, r *http.Re
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		logError(ctx, "gateway claim-next: db begin failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
-		return
-	}
-	defer tx.Rollback(ctx)
-
-	var workItemID uuid.UUID
-	err = tx.QueryRow(ctx, `
-		select wi.id
-		from work_item_offers o
-		join work_items wi on wi.id = o.work_item_id
-		where o.agent_id = $1
-		  and wi.status = 'offered'
-		order by wi.created_at asc
-		limit 1
-		for update of wi skip locked
-	`, agentID).Scan(&workItemID)
-	if errors.Is(err, pgx.ErrNoRows) {
+	expiresAt := time.Now().UTC().Add(time.Duration(s.workItemLeaseSeconds) * time.Second)
+	workItemID, err := s.repo.ClaimNextWorkItem(ctx, agentID, expiresAt)
+	switch {
+	case errors.Is(err, errNoOffers):
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no offers"})
 		return
-	}
-	if err != nil {
-		logError(ctx, "gateway claim-next: select offer failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
-		return
-	}
-
-	expiresAt := time.Now().UTC().Add(time.Duration(s.workItemLeaseSeconds) * time.Second)
-	if _, err := tx.Exec(ctx, `
-		insert into work_item_leases (work_item_id, agent_id, lease_expires_at)
-		values ($1, $2, $3)
-	`, workItemID, agentID, expiresAt); err != nil {
+	case errors.Is(err, errAlreadyClaimed):
 		writeJSON(w, http.StatusConflict, map[string]string{"error": "already claimed"})
 		return
-	}
-	if _, err := tx.Exec(ctx, `update work_items set status='claimed', updated_at=now() where id=$1`, workItemID); err != nil {
-		logError(ctx, "gateway claim-next: update work item failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
-		return
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		logError(ctx, "gateway claim-next: commit failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+	case err != nil:
+		logError(ctx, "gateway claim-next: claim failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "claim failed"})
 		return
 	}
 
@@ -1082,68 +946,20 @@ func (s server) handleGatewayCompleteWorkItem(w http.ResponseWriter, r *http.Req
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
-		return
-	}
-	defer tx.Rollback(ctx)
-
-	var leaseAgent uuid.UUID
-	var leaseExpires time.Time
-	err = tx.QueryRow(ctx, `
-		select agent_id, lease_expires_at
-		from work_item_leases
-		where work_item_id = $1
-	`, workItemID).Scan(&leaseAgent, &leaseExpires)
-	if errors.Is(err, pgx.ErrNoRows) {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "not leased"})
-		return
-	}
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "lease lookup failed"})
-		return
-	}
-	if leaseAgent != agentID {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "not lease holder"})
+	done, err := s.repo.CompleteWorkItem(ctx, agentID, workItemID, time.Now().UTC())
+	switch {
+	case errors.Is(err, errNotLeased), errors.Is(err, errLeaseExpired):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
 		return
-	}
-	if time.Now().UTC().After(leaseExpires) {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "lease expired"})
+	case errors.Is(err, errNotLeaseHolder):
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
 		return
-	}
-
-	if _, err := tx.Exec(ctx, `update work_items set status='completed', updated_at=now() where id=$1`, workItemID); err != nil {
+	case err != nil:
+		logError(ctx, "gateway complete: complete failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
 		return
 	}
-	if _, err := tx.Exec(ctx, `delete from work_item_leases where work_item_id=$1`, workItemID); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "lease delete failed"})
-		return
-	}
-
-	// Update owner aggregated contribution counter.
-	var ownerID uuid.UUID
-	if err := tx.QueryRow(ctx, `select owner_id from agents where id=$1`, agentID).Scan(&ownerID); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "owner lookup failed"})
-		return
-	}
-	if _, err := tx.Exec(ctx, `
-		insert into owner_contributions (owner_id, completed_work_items, updated_at)
-		values ($1, 1, now())
-		on conflict (owner_id) do update
-		set completed_work_items = owner_contributions.completed_work_items + 1,
-		    updated_at = now()
-	`, ownerID); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "contribution update failed"})
-		return
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
-		return
-	}
-	s.audit(ctx, "agent", agentID, "work_item_completed", map[string]any{"work_item_id": workItemID.String(), "owner_id": ownerID.String()})
+	s.audit(ctx, "agent", agentID, "work_item_completed", map[string]any{"work_item_id": workItemID.String(), "owner_id": done.OwnerID.String()})
 	writeJSON(w, http.StatusOK, map[string]string{"status": "completed"})
 }
 
@@ -1187,9 +1003,11 @@ func (s server) handleGatewayEmitEvent(w http.ResponseWriter, r *http.Request) {
 	if req.Payload == nil {
 		req.Payload = map[string]any{}
 	}
-	if rejectIfPrivacyViolation(r.Context(), w, req.Payload, "payload", "gateway emit event: blocked by privacy filter") {
+	redacted, privacy, ok := s.enforcePrivacyPolicy(r.Context(), w, privacySurfaceEventPayload, req.Payload, "payload", "gateway emit event: blocked by privacy filter")
+	if !ok {
 		return
 	}
+	req.Payload = redacted.(map[string]any)
 	// Basic payload size guardrail.
 	payloadJSON, err := json.Marshal(req.Payload)
 	if err != nil {
@@ -1206,23 +1024,16 @@ func (s server) handleGatewayEmitEvent(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Agent must be a participant: it must have been offered a work item in this run.
-	var participant bool
-	err = s.db.QueryRow(ctx, `
-		select true
-		from work_item_offers o
-		join work_items wi on wi.id = o.work_item_id
-		where o.agent_id = $1 and wi.run_id = $2
-		limit 1
-	`, agentID, runID).Scan(&participant)
-	if errors.Is(err, pgx.ErrNoRows) {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "not a participant"})
-		return
-	}
+	participant, err := s.repo.IsRunParticipant(ctx, agentID, runID)
 	if err != nil {
 		logError(ctx, "gateway emit event: participant check failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "participant check failed"})
 		return
 	}
+	if !participant {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "not a participant"})
+		return
+	}
 
 	persona, err := s.personaForAgentInRun(ctx, runID, agentID)
 	if err != nil {
@@ -1231,45 +1042,15 @@ func (s server) handleGatewayEmitEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		logError(ctx, "gateway emit event: db begin failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
-		return
-	}
-	defer tx.Rollback(ctx)
-
-	// Lock run row to serialize seq allocation per run.
-	if _, err := tx.Exec(ctx, `select 1 from runs where id=$1 for update`, runID); err != nil {
-		logError(ctx, "gateway emit event: run lock failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "run lock failed"})
-		return
-	}
-
-	var nextSeq int64
-	if err := tx.QueryRow(ctx, `select coalesce(max(seq), 0) + 1 from events where run_id=$1`, runID).Scan(&nextSeq); err != nil {
-		logError(ctx, "gateway emit event: seq allocation failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "seq allocation failed"})
-		return
-	}
 	isKey := isKeyNodeKind(req.Kind)
-
 	createdAt := time.Now().UTC()
-	if _, err := tx.Exec(ctx, `
-		insert into events (run_id, seq, kind, persona, payload, is_key_node, created_at)
-		values ($1, $2, $3, $4, $5, $6, $7)
-	`, runID, nextSeq, req.Kind, persona, payloadJSON, isKey, createdAt); err != nil {
+	nextSeq, err := s.repo.AppendEvent(ctx, runID, newEvent{Kind: req.Kind, Persona: persona, Payload: payloadJSON, IsKeyNode: isKey, CreatedAt: createdAt})
+	if err != nil {
 		logError(ctx, "gateway emit event: insert failed", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
 		return
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		logError(ctx, "gateway emit event: commit failed", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
-		return
-	}
-
 	var payloadMap map[string]any
 	if err := unmarshalJSONNullable(payloadJSON, &payloadMap); err != nil {
 		logError(ctx, "unmarshal emitted payload failed", err)
@@ -1287,25 +1068,17 @@ func (s server) handleGatewayEmitEvent(w http.ResponseWriter, r *http.Request) {
 	s.br.publish(runID, ev)
 	writeJSON(w, http.StatusCreated, ev)
 
+	s.recordPrivacyDecision(ctx, privacySurfaceEventPayload, privacy, "event", runRef+"#"+strconv.FormatInt(nextSeq, 10), "agent", agentID)
 	s.audit(ctx, "agent", agentID, "event_emitted", map[string]any{"run_id": runID.String(), "seq": nextSeq, "kind": req.Kind, "is_key_node": isKey})
+	go s.autoModerateEvent(runID, nextSeq, agentID, payloadMap)
 }
 
-func (s server) personaForAgent(ctx context.Context, agentID uuid.UUID) (string, error) {
-	// Public persona is derived from tags (not identity), and must not expose owner.
-	rows, err := s.db.Query(ctx, `select tag from agent_tags where agent_id=$1 order by tag asc`, agentID)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
-
+// personaFromTags derives the public persona from (sorted) tags, not identity; it must not expose
+// the owner.
+func personaFromTags(sorted []string) string {
 	var tags []string
-	for rows.Next() {
-		var t string
-		if err := rows.Scan(&t); err != nil {
-			return "", err
-		}
-		t = strings.TrimSpace(t)
-		if t != "" {
+	for _, t := range sorted {
+		if t = strings.TrimSpace(t); t != "" {
 			tags = append(tags, t)
 		}
 		if len(tags) >= 2 {
@@ -1313,27 +1086,25 @@ func (s server) personaForAgent(ctx context.Context, agentID uuid.UUID) (string,
 		}
 	}
 	if len(tags) == 0 {
-		return "智能体", nil
+		return "智能体"
 	}
-	return strings.Join(tags, " / "), nil
+	return strings.Join(tags, " / ")
 }
 
 func (s server) personaForAgentInRun(ctx context.Context, runID uuid.UUID, agentID uuid.UUID) (string, error) {
-	base, err := s.personaForAgent(ctx, agentID)
+	profile, err := s.repo.AgentProfile(ctx, agentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return personaFromTags(nil), nil
+	}
 	if err != nil {
 		return "", err
 	}
 
 	// Prefer the owner-provided agent display name, so viewers can distinguish participants.
-	var name string
-	if err := s.db.QueryRow(ctx, `select name from agents where id=$1`, agentID).Scan(&name); err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		logError(ctx, "agent name lookup failed", err)
-	}
-	name = strings.TrimSpace(name)
-	if name == "" {
-		return base, nil
+	if name := strings.TrimSpace(profile.Name); name != "" {
+		return name, nil
 	}
-	return name, nil
+	return personaFromTags(profile.Tags), nil
 }
 
 type invokeToolRequest struct {
@@ -1359,7 +1130,7 @@ func (s server) handleGatewayInvokeTool(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
 	runRef, err := parseRunRef(req.RunRef)
@@ -1379,6 +1150,29 @@ func (s server) handleGatewayInvokeTool(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Tools run on behalf of a run: the agent must participate in it.
+	var agentRef string
+	var participant bool
+	if err := s.db.QueryRow(ctx, `
+		select a.public_ref,
+		       exists(
+		         select 1
+		         from work_item_offers o
+		         join work_items wi on wi.id = o.work_item_id
+		         where o.agent_id = a.id and wi.run_id = $2
+		       )
+		from agents a
+		where a.id = $1
+	`, agentID, runID).Scan(&agentRef, &participant); err != nil {
+		logError(ctx, "gateway invoke tool: participant check failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "participant check failed"})
+		return
+	}
+	if !participant {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "not a participant"})
+		return
+	}
+
 	allowed, err := s.isToolAllowed(ctx, agentID, runID, req.Tool)
 	if err != nil {
 		logError(ctx, "gateway invoke tool: policy check failed", err)
@@ -1391,12 +1185,134 @@ func (s server) handleGatewayInvokeTool(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	s.audit(ctx, "agent", agentID, "tool_allowed_but_not_implemented", map[string]any{"run_id": runID.String(), "tool": req.Tool})
-	writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "tool_not_implemented"})
+	if _, ok := s.tools.Get(req.Tool); !ok {
+		s.audit(ctx, "agent", agentID, "tool_allowed_but_not_implemented", map[string]any{"run_id": runID.String(), "tool": req.Tool})
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "tool_not_implemented"})
+		return
+	}
+
+	res, err := s.tools.Invoke(ctx, req.Tool, toolruntime.Call{
+		AgentID:  agentID,
+		AgentRef: agentRef,
+		RunID:    runID,
+		RunRef:   runRef,
+		Input:    req.Input,
+	})
+	auditData := map[string]any{
+		"run_id":       runID.String(),
+		"tool":         req.Tool,
+		"ok":           err == nil,
+		"latency_ms":   res.Latency.Milliseconds(),
+		"result_bytes": res.ResultBytes,
+		"cost":         res.Cost,
+	}
+	if err != nil {
+		code := toolruntime.CodeOf(err)
+		auditData["error"] = code
+		if code == toolruntime.CodeFailed || code == toolruntime.CodeInvalidOutput {
+			logError(ctx, "gateway invoke tool: "+req.Tool+" failed", err)
+		}
+		s.audit(ctx, "agent", agentID, "tool_invoked", auditData)
+
+		status := http.StatusBadGateway
+		message := ""
+		switch code {
+		case toolruntime.CodeInvalidInput:
+			status = http.StatusBadRequest
+		case toolruntime.CodeNotFound:
+			status = http.StatusNotFound
+		case toolruntime.CodeForbidden:
+			status = http.StatusForbidden
+		case toolruntime.CodeTimeout:
+			status = http.StatusGatewayTimeout
+		}
+		var te *toolruntime.Error
+		if errors.As(err, &te) && code != toolruntime.CodeFailed && code != toolruntime.CodeInvalidOutput {
+			// Internal failure details stay in logs.
+			message = te.Message
+		}
+		writeJSON(w, status, map[string]any{
+			"ok":         false,
+			"tool":       req.Tool,
+			"error":      code,
+			"message":    message,
+			"latency_ms": res.Latency.Milliseconds(),
+		})
+		return
+	}
+	s.audit(ctx, "agent", agentID, "tool_invoked", auditData)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":           true,
+		"tool":         req.Tool,
+		"result":       res.Output,
+		"latency_ms":   res.Latency.Milliseconds(),
+		"result_bytes": res.ResultBytes,
+		"cost":         res.Cost,
+	})
+}
+
+type gatewayToolDTO struct {
+	toolruntime.Spec
+	TimeoutMS    int64 `json:"timeout_ms"`
+	AgentAllowed bool  `json:"agent_allowed"`
+}
+
+// handleGatewayListTools lists the platform tools with their schemas and whether this agent is
+// allowlisted for each (granted and not banned; runs additionally need the tool in run_allowed_tools).
+func (s server) handleGatewayListTools(w http.ResponseWriter, r *http.Request) {
+	agentID, ok := agentIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	allowed := map[string]bool{}
+	rows, err := s.db.Query(ctx, `
+		select a.tool
+		from agent_allowed_tools a
+		join tool_catalog c on c.tool = a.tool and c.status = 'available'
+		where a.agent_id = $1
+	`, agentID)
+	if err != nil {
+		logError(ctx, "gateway list tools: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	for rows.Next() {
+		var tool string
+		if err := rows.Scan(&tool); err != nil {
+			rows.Close()
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		allowed[tool] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	specs := s.tools.Specs()
+	out := make([]gatewayToolDTO, 0, len(specs))
+	for _, sp := range specs {
+		out = append(out, gatewayToolDTO{Spec: sp, TimeoutMS: sp.Timeout.Milliseconds(), AgentAllowed: allowed[sp.Name]})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tools": out})
 }
 
 func (s server) isToolAllowed(ctx context.Context, agentID uuid.UUID, runID uuid.UUID, tool string) (bool, error) {
-	// Default deny: tool must be explicitly allowed for both agent and run.
+	// Default deny: tool must be an available catalog tool (not globally banned) and explicitly
+	// allowed for both agent and run.
+	var catalogAllowed bool
+	if err := s.db.QueryRow(ctx, `select exists(select 1 from tool_catalog where tool=$1 and status='available')`, tool).Scan(&catalogAllowed); err != nil {
+		return false, err
+	}
+	if !catalogAllowed {
+		return false, nil
+	}
 	var agentAllowed bool
 	if err := s.db.QueryRow(ctx, `select exists(select 1 from agent_allowed_tools where agent_id=$1 and tool=$2)`, agentID, tool).Scan(&agentAllowed); err != nil {
 		return false, err
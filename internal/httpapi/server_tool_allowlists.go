@@ -0,0 +1,554 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Tool allowlists: admins curate tool_catalog (available/banned), owners grant catalog tools to their
+// agents (agent_allowed_tools), publishers pick catalog tools at run creation (run_allowed_tools).
+// isToolAllowed requires all three.
+
+const (
+	toolStatusAvailable = "available"
+	toolStatusBanned    = "banned"
+
+	maxToolsPerGrant = 50
+)
+
+var toolNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// normalizeToolNames trims, dedupes and validates tool names; it returns the first invalid name.
+func normalizeToolNames(in []string) ([]string, string) {
+	out := make([]string, 0, len(in))
+	seen := map[string]struct{}{}
+	for _, t := range in {
+		tt := strings.TrimSpace(t)
+		if !toolNamePattern.MatchString(tt) {
+			return nil, t
+		}
+		if _, ok := seen[tt]; ok {
+			continue
+		}
+		seen[tt] = struct{}{}
+		out = append(out, tt)
+	}
+	sort.Strings(out)
+	return out, ""
+}
+
+// unavailableCatalogTools returns the subset of tools that are not grantable (missing from the
+// catalog or banned).
+func unavailableCatalogTools(ctx context.Context, tx pgx.Tx, tools []string) ([]string, error) {
+	if len(tools) == 0 {
+		return nil, nil
+	}
+	rows, err := tx.Query(ctx, `
+		select t
+		from unnest($1::text[]) t
+		where not exists (select 1 from tool_catalog c where c.tool = t and c.status = 'available')
+		order by t
+	`, tools)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func setRunAllowedToolsInTx(ctx context.Context, tx pgx.Tx, runID uuid.UUID, tools []string) error {
+	if _, err := tx.Exec(ctx, `delete from run_allowed_tools where run_id = $1`, runID); err != nil {
+		return err
+	}
+	if len(tools) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		insert into run_allowed_tools (run_id, tool)
+		select $1, t from unnest($2::text[]) t
+		on conflict do nothing
+	`, runID, tools)
+	return err
+}
+
+func listAgentAllowedToolsInTx(ctx context.Context, tx pgx.Tx, agentID uuid.UUID) ([]string, error) {
+	rows, err := tx.Query(ctx, `select tool from agent_allowed_tools where agent_id = $1 order by tool`, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]string, 0)
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// diffSorted returns elements only in a (removed) and only in b (added); both inputs must be
+// sorted with sort.Strings.
+func diffSorted(a, b []string) (removed, added []string) {
+	removed, added = []string{}, []string{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j >= len(b) || (i < len(a) && a[i] < b[j]):
+			removed = append(removed, a[i])
+			i++
+		case i >= len(a) || b[j] < a[i]:
+			added = append(added, b[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return removed, added
+}
+
+type toolCatalogEntryDTO struct {
+	Tool        string `json:"tool"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	BanReason   string `json:"ban_reason,omitempty"`
+	InCatalog   bool   `json:"in_catalog"`
+	Implemented bool   `json:"implemented"`
+	AgentGrants int    `json:"agent_grants"`
+	RunGrants   int    `json:"run_grants"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+}
+
+func (s server) handleAdminListToolCatalog(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select c.tool, c.description, c.status, c.ban_reason, c.updated_at,
+		       (select count(1) from agent_allowed_tools a where a.tool = c.tool),
+		       (select count(1) from run_allowed_tools rt where rt.tool = c.tool)
+		from tool_catalog c
+		order by c.tool
+	`)
+	if err != nil {
+		logError(ctx, "admin list tool catalog: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	out := make([]toolCatalogEntryDTO, 0)
+	inCatalog := map[string]struct{}{}
+	for rows.Next() {
+		var (
+			e         toolCatalogEntryDTO
+			updatedAt time.Time
+		)
+		if err := rows.Scan(&e.Tool, &e.Description, &e.Status, &e.BanReason, &updatedAt, &e.AgentGrants, &e.RunGrants); err != nil {
+			logError(ctx, "admin list tool catalog: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		_, e.Implemented = s.tools.Get(e.Tool)
+		e.InCatalog = true
+		e.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+		inCatalog[e.Tool] = struct{}{}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "admin list tool catalog: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+
+	// Built-in tools not yet added to the catalog are listed so admins can enable them.
+	for _, sp := range s.tools.Specs() {
+		if _, ok := inCatalog[sp.Name]; ok {
+			continue
+		}
+		out = append(out, toolCatalogEntryDTO{Tool: sp.Name, Description: sp.Description, Implemented: true})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tool < out[j].Tool })
+	writeJSON(w, http.StatusOK, map[string]any{"tools": out})
+}
+
+type adminPutToolCatalogRequest struct {
+	Description *string `json:"description,omitempty"`
+	Status      string  `json:"status"`
+	BanReason   string  `json:"ban_reason"`
+}
+
+// handleAdminPutToolCatalogEntry adds a tool to the catalog or updates it; status=banned is a
+// global ban that overrides every existing agent/run grant.
+func (s server) handleAdminPutToolCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	tool := strings.TrimSpace(chi.URLParam(r, "tool"))
+	if !toolNamePattern.MatchString(tool) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tool"})
+		return
+	}
+
+	var req adminPutToolCatalogRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	if req.Status == "" {
+		req.Status = toolStatusAvailable
+	}
+	if req.Status != toolStatusAvailable && req.Status != toolStatusBanned {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+		return
+	}
+	req.BanReason = strings.TrimSpace(req.BanReason)
+	if req.Status != toolStatusBanned {
+		req.BanReason = ""
+	}
+	if len(req.BanReason) > 1000 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ban_reason too long"})
+		return
+	}
+	description := ""
+	if req.Description != nil {
+		description = strings.TrimSpace(*req.Description)
+		if len(description) > 2000 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "description too long"})
+			return
+		}
+	} else if t, ok := s.tools.Get(tool); ok {
+		description = t.Spec().Description
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var prevStatus string
+	err := s.db.QueryRow(ctx, `select status from tool_catalog where tool = $1`, tool).Scan(&prevStatus)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		logError(ctx, "admin put tool catalog: lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+
+	if _, err := s.db.Exec(ctx, `
+		insert into tool_catalog (tool, description, status, ban_reason, updated_by)
+		values ($1, $2, $3, $4, $5)
+		on conflict (tool) do update
+		set description = case when $6 then excluded.description else tool_catalog.description end,
+		    status = excluded.status,
+		    ban_reason = excluded.ban_reason,
+		    updated_by = excluded.updated_by,
+		    updated_at = now()
+	`, tool, description, req.Status, req.BanReason, adminID, req.Description != nil); err != nil {
+		logError(ctx, "admin put tool catalog: upsert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+
+	action := "tool_catalog_updated"
+	switch {
+	case req.Status == toolStatusBanned && prevStatus != toolStatusBanned:
+		action = "tool_banned"
+	case req.Status == toolStatusAvailable && prevStatus == toolStatusBanned:
+		action = "tool_unbanned"
+	case prevStatus == "":
+		action = "tool_catalog_added"
+	}
+	s.audit(ctx, "admin", adminID, action, map[string]any{
+		"tool":            tool,
+		"status":          req.Status,
+		"previous_status": prevStatus,
+		"ban_reason":      req.BanReason,
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"tool": tool, "status": req.Status, "ban_reason": req.BanReason})
+}
+
+// handleAdminDeleteToolCatalogEntry removes a tool from the catalog and revokes its agent grants.
+// Run allowlists are left as they are (they are inert without a catalog entry).
+func (s server) handleAdminDeleteToolCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	tool := strings.TrimSpace(chi.URLParam(r, "tool"))
+	if !toolNamePattern.MatchString(tool) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tool"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		logError(ctx, "admin delete tool catalog: db begin failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	ct, err := tx.Exec(ctx, `delete from tool_catalog where tool = $1`, tool)
+	if err != nil {
+		logError(ctx, "admin delete tool catalog: delete failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete failed"})
+		return
+	}
+	if ct.RowsAffected() == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	revoked, err := tx.Exec(ctx, `delete from agent_allowed_tools where tool = $1`, tool)
+	if err != nil {
+		logError(ctx, "admin delete tool catalog: revoke grants failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "revoke failed"})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logError(ctx, "admin delete tool catalog: commit failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+		return
+	}
+
+	s.audit(ctx, "admin", adminID, "tool_catalog_removed", map[string]any{
+		"tool":                 tool,
+		"agent_grants_revoked": revoked.RowsAffected(),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "agent_grants_revoked": revoked.RowsAffected()})
+}
+
+func (s server) handleOwnerGetAgentTools(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	agentRef, ok := requireAgentRefParam(w, r, "agentRef")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		select c.tool, c.description, c.status,
+		       exists(select 1 from agent_allowed_tools g where g.agent_id = a.id and g.tool = c.tool)
+		from agents a
+		join tool_catalog c on true
+		where a.public_ref = $1 and a.owner_id = $2
+		  and (c.status = 'available' or exists(select 1 from agent_allowed_tools g where g.agent_id = a.id and g.tool = c.tool))
+		order by c.tool
+	`, agentRef, userID)
+	if err != nil {
+		logError(ctx, "owner get agent tools: query failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	type toolDTO struct {
+		Tool        string `json:"tool"`
+		Description string `json:"description"`
+		Status      string `json:"status"`
+		Granted     bool   `json:"granted"`
+		Implemented bool   `json:"implemented"`
+	}
+	out := make([]toolDTO, 0)
+	for rows.Next() {
+		var t toolDTO
+		if err := rows.Scan(&t.Tool, &t.Description, &t.Status, &t.Granted); err != nil {
+			logError(ctx, "owner get agent tools: scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		_, t.Implemented = s.tools.Get(t.Tool)
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "owner get agent tools: iterate failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	if len(out) == 0 {
+		// Distinguish "no catalog" from "not your agent".
+		if _, err := s.lookupOwnedAgentID(ctx, userID, agentRef); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+				return
+			}
+			logError(ctx, "owner get agent tools: agent lookup failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"agent_ref": agentRef, "tools": out})
+}
+
+type replaceAgentToolsRequest struct {
+	Tools []string `json:"tools"`
+}
+
+// handleOwnerReplaceAgentTools sets the agent's granted tools; every tool must be an available
+// catalog tool.
+func (s server) handleOwnerReplaceAgentTools(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	agentRef, ok := requireAgentRefParam(w, r, "agentRef")
+	if !ok {
+		return
+	}
+
+	var req replaceAgentToolsRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	tools, bad := normalizeToolNames(req.Tools)
+	if bad != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tool", "tool": bad})
+		return
+	}
+	if len(tools) > maxToolsPerGrant {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "too many tools"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		logError(ctx, "replace agent tools: db begin failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db begin failed"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var agentID uuid.UUID
+	if err := tx.QueryRow(ctx, `select id from agents where public_ref = $1 and owner_id = $2`, agentRef, userID).Scan(&agentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		logError(ctx, "replace agent tools: query agent failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+
+	unavailable, err := unavailableCatalogTools(ctx, tx, tools)
+	if err != nil {
+		logError(ctx, "replace agent tools: catalog check failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "catalog check failed"})
+		return
+	}
+	if len(unavailable) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "tool not available", "tool": unavailable[0]})
+		return
+	}
+
+	prev, err := listAgentAllowedToolsInTx(ctx, tx, agentID)
+	if err != nil {
+		logError(ctx, "replace agent tools: list grants failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	if _, err := tx.Exec(ctx, `delete from agent_allowed_tools where agent_id = $1`, agentID); err != nil {
+		logError(ctx, "replace agent tools: delete grants failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete tools failed"})
+		return
+	}
+	if len(tools) > 0 {
+		if _, err := tx.Exec(ctx, `
+			insert into agent_allowed_tools (agent_id, tool)
+			select $1, t from unnest($2::text[]) t
+			on conflict do nothing
+		`, agentID, tools); err != nil {
+			logError(ctx, "replace agent tools: insert grants failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert tools failed"})
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logError(ctx, "replace agent tools: commit failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "commit failed"})
+		return
+	}
+
+	// prev comes back in database collation order; diffSorted needs Go's byte order.
+	sort.Strings(prev)
+	removed, added := diffSorted(prev, tools)
+	s.audit(ctx, "user", userID, "agent_tools_replaced", map[string]any{
+		"agent_id": agentID.String(),
+		"tools":    tools,
+		"added":    added,
+		"removed":  removed,
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"agent_ref": agentRef, "tools": tools})
+}
+
+func (s server) handleOwnerRevokeAgentTool(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	agentRef, ok := requireAgentRefParam(w, r, "agentRef")
+	if !ok {
+		return
+	}
+	tool := strings.TrimSpace(chi.URLParam(r, "tool"))
+	if !toolNamePattern.MatchString(tool) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tool"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	agentID, err := s.lookupOwnedAgentID(ctx, userID, agentRef)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "revoke agent tool: agent lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	ct, err := s.db.Exec(ctx, `delete from agent_allowed_tools where agent_id = $1 and tool = $2`, agentID, tool)
+	if err != nil {
+		logError(ctx, "revoke agent tool: delete failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete failed"})
+		return
+	}
+	if ct.RowsAffected() > 0 {
+		s.audit(ctx, "user", userID, "agent_tool_revoked", map[string]any{"agent_id": agentID.String(), "tool": tool})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
@@ -133,8 +133,9 @@ func (s server) handleListTopicActivityPublic(w http.ResponseWriter, r *http.Req
 	rows, err := s.db.Query(ctx, `
 		select object_key, occurred_at, payload
 		from oss_events
-		where object_key like '%topics/%/messages/%'
-		   or object_key like '%topics/%/requests/%'
+		where (object_key like '%topics/%/messages/%'
+		   or object_key like '%topics/%/requests/%')
+		  and `+topicNotRejectedSQL("oss_events.object_key", s.ossBasePrefix)+`
 		order by occurred_at desc, id desc
 		limit $1 offset $2
 	`, scanLimit+1, offset)
@@ -144,7 +144,8 @@ func (s server) handleGetTopicThreadPublic(w http.ResponseWriter, r *http.Reques
 	rows, err := s.db.Query(ctx, `
 		select object_key, occurred_at, payload
 		from oss_events
-		where object_key like $1 or object_key like $2
+		where (object_key like $1 or object_key like $2)
+		  and `+topicNotRejectedSQL("oss_events.object_key", s.ossBasePrefix)+`
 		order by occurred_at desc, id desc
 		limit $3
 	`, pat1, pat2, limit)
@@ -365,8 +365,8 @@ func (s server) processOneTopicProposal(ctx context.Context, store agenthome.OSS
 	msgBody, err := json.Marshal(msgObj)
 	if err == nil {
 		msgKey := "topics/" + newTopicID + "/messages/" + agentRef + "/" + msgID + ".json"
-		if err := store.PutObject(ctx, msgKey, "application/json", msgBody); err == nil {
-			_ = s.insertOSSEvent(ctx, msgKey, "put", time.Now().UTC(), msgBody)
+		if err := s.writeOSSObjectWithEvent(ctx, store, msgKey, msgBody); err != nil {
+			logError(ctx, "topicgen: enqueue opening message failed", err)
 		}
 	}
 
@@ -96,6 +96,7 @@ func (s server) listTopicPlayAgents(ctx context.Context, allowTags []string, lim
 		select a.id, a.public_ref, a.name
 		from agents a
 		where a.status = 'enabled'
+		  and a.admitted_status = 'admitted'
 		  and exists (
 			select 1
 			from agent_tags t
@@ -245,9 +246,10 @@ func (s server) issueOneTopicPlayWorkItem(ctx context.Context, store agenthome.O
 		return err
 	}
 
-	skills := s.skillsGatewayWhitelist
-	if skills == nil {
-		skills = []string{}
+	skills, skillRefsJSON, err := s.resolveStageSkills(ctx, tx, "topic_play")
+	if err != nil {
+		logError(ctx, "topicplay: resolve skills failed", err)
+		return err
 	}
 	availableSkillsJSON, err := json.Marshal(skills)
 	if err != nil {
@@ -291,10 +293,10 @@ func (s server) issueOneTopicPlayWorkItem(ctx context.Context, store agenthome.O
 
 	var workItemID uuid.UUID
 	if err := tx.QueryRow(ctx, `
-		insert into work_items (run_id, stage, kind, status, context, available_skills)
-		values ($1, 'topic_play', 'topic_participation', 'offered', $2, $3)
+		insert into work_items (run_id, stage, kind, status, context, available_skills, skill_refs)
+		values ($1, 'topic_play', 'topic_participation', 'offered', $2, $3, $4)
 		returning id
-	`, runID, stageContextJSON, availableSkillsJSON).Scan(&workItemID); err != nil {
+	`, runID, stageContextJSON, availableSkillsJSON, skillRefsJSON).Scan(&workItemID); err != nil {
 		return err
 	}
 	if _, err := tx.Exec(ctx, `
@@ -158,6 +158,7 @@ func (s server) handleListTopicsOverviewPublic(w http.ResponseWriter, r *http.Re
 		select object_key, occurred_at, payload
 		from oss_events
 		where object_key like '%topics/%/messages/%'
+		  and `+topicNotRejectedSQL("oss_events.object_key", s.ossBasePrefix)+`
 		order by occurred_at desc, id desc
 		limit $1 offset $2
 	`, scanLimit+1, offset)
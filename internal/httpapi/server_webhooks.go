@@ -0,0 +1,575 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxWebhookEndpointsPerOwner = 20
+
+type webhookEndpointDTO struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	Enabled     bool     `json:"enabled"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+	// Secret is only returned on create / rotate-secret.
+	Secret string `json:"secret,omitempty"`
+}
+
+type webhookDeliveryDTO struct {
+	ID             string         `json:"id"`
+	EventID        string         `json:"event_id"`
+	EventType      string         `json:"event_type"`
+	Status         string         `json:"status"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  string         `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int           `json:"last_status_code,omitempty"`
+	LastError      string         `json:"last_error,omitempty"`
+	LastResponse   string         `json:"last_response,omitempty"`
+	RedeliveryOf   string         `json:"redelivery_of,omitempty"`
+	Payload        map[string]any `json:"payload"`
+	CreatedAt      string         `json:"created_at"`
+	DeliveredAt    string         `json:"delivered_at,omitempty"`
+}
+
+type webhookEndpointRequest struct {
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	EventTypes  *[]string `json:"event_types"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validateWebhookURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > 2000 {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return "", false
+	}
+	return u.String(), true
+}
+
+func normalizeWebhookEventTypes(in []string) ([]string, bool) {
+	out := make([]string, 0, len(in))
+	seen := map[string]struct{}{}
+	for _, t := range in {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !isWebhookEventType(t) {
+			return nil, false
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out, true
+}
+
+func scanWebhookEndpoint(row pgx.Row) (webhookEndpointDTO, error) {
+	var (
+		dto       webhookEndpointDTO
+		id        uuid.UUID
+		createdAt time.Time
+		updatedAt time.Time
+	)
+	if err := row.Scan(&id, &dto.URL, &dto.Description, &dto.EventTypes, &dto.Enabled, &createdAt, &updatedAt); err != nil {
+		return webhookEndpointDTO{}, err
+	}
+	dto.ID = id.String()
+	if dto.EventTypes == nil {
+		dto.EventTypes = []string{}
+	}
+	dto.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	dto.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+	return dto, nil
+}
+
+const webhookEndpointColumns = `id, url, description, event_types, enabled, created_at, updated_at`
+
+func scanWebhookDelivery(row pgx.Row) (webhookDeliveryDTO, error) {
+	var (
+		dto           webhookDeliveryDTO
+		id            uuid.UUID
+		eventID       uuid.UUID
+		nextAttemptAt time.Time
+		redeliveryOf  *uuid.UUID
+		createdAt     time.Time
+		deliveredAt   *time.Time
+	)
+	if err := row.Scan(&id, &eventID, &dto.EventType, &dto.Status, &dto.Attempts, &nextAttemptAt, &dto.LastStatusCode,
+		&dto.LastError, &dto.LastResponse, &redeliveryOf, &dto.Payload, &createdAt, &deliveredAt); err != nil {
+		return webhookDeliveryDTO{}, err
+	}
+	dto.ID = id.String()
+	dto.EventID = eventID.String()
+	if dto.Status == "pending" {
+		dto.NextAttemptAt = nextAttemptAt.UTC().Format(time.RFC3339)
+	}
+	if redeliveryOf != nil {
+		dto.RedeliveryOf = redeliveryOf.String()
+	}
+	dto.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	if deliveredAt != nil {
+		dto.DeliveredAt = deliveredAt.UTC().Format(time.RFC3339)
+	}
+	return dto, nil
+}
+
+const webhookDeliveryColumns = `id, event_id, event_type, status, attempts, next_attempt_at, last_status_code,
+	last_error, last_response, redelivery_of, payload, created_at, delivered_at`
+
+// requireOwnedWebhook parses {webhookID} and checks the caller owns it.
+func (s server) requireOwnedWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request, userID uuid.UUID) (uuid.UUID, bool) {
+	id, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "webhookID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid webhook_id"})
+		return uuid.Nil, false
+	}
+	var exists bool
+	if err := s.db.QueryRow(ctx, `select exists(select 1 from webhook_endpoints where id = $1 and owner_id = $2)`, id, userID).Scan(&exists); err != nil {
+		logError(ctx, "webhook lookup failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "lookup failed"})
+		return uuid.Nil, false
+	}
+	if !exists {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (s server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `select `+webhookEndpointColumns+` from webhook_endpoints where owner_id = $1 order by created_at desc`, userID)
+	if err != nil {
+		logError(ctx, "list webhooks failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+	items := make([]webhookEndpointDTO, 0)
+	for rows.Next() {
+		dto, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			logError(ctx, "list webhooks scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		items = append(items, dto)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "event_types": webhookEventTypes})
+}
+
+func (s server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	if strings.TrimSpace(s.platformKeysEncryptionKey) == "" {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "missing platform key encryption config"})
+		return
+	}
+	var req webhookEndpointRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+	if req.URL == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing url"})
+		return
+	}
+	u, ok := validateWebhookURL(*req.URL)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid url (http/https)"})
+		return
+	}
+	description := ""
+	if req.Description != nil {
+		description = strings.TrimSpace(*req.Description)
+	}
+	if len(description) > 500 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "description too long"})
+		return
+	}
+	eventTypes := []string{}
+	if req.EventTypes != nil {
+		if eventTypes, ok = normalizeWebhookEventTypes(*req.EventTypes); !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid event_types"})
+			return
+		}
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "secret generation failed"})
+		return
+	}
+	secretEnc, err := agenthome.EncryptForDB(s.platformKeysEncryptionKey, []byte(secret))
+	if err != nil {
+		logError(r.Context(), "encrypt webhook secret failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "encrypt failed"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var count int
+	if err := s.db.QueryRow(ctx, `select count(*) from webhook_endpoints where owner_id = $1`, userID).Scan(&count); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "count failed"})
+		return
+	}
+	if count >= maxWebhookEndpointsPerOwner {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "too many webhooks"})
+		return
+	}
+
+	dto, err := scanWebhookEndpoint(s.db.QueryRow(ctx, `
+		insert into webhook_endpoints (owner_id, url, description, event_types, secret_enc, enabled)
+		values ($1, $2, $3, $4, $5, $6)
+		returning `+webhookEndpointColumns, userID, u, description, eventTypes, secretEnc, enabled))
+	if err != nil {
+		logError(ctx, "create webhook failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	dto.Secret = secret
+
+	s.audit(ctx, "user", userID, "webhook_created", map[string]any{"webhook_id": dto.ID, "url": u, "event_types": eventTypes})
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+func (s server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, ok := s.requireOwnedWebhook(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	dto, err := scanWebhookEndpoint(s.db.QueryRow(ctx, `select `+webhookEndpointColumns+` from webhook_endpoints where id = $1`, id))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+func (s server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var req webhookEndpointRequest
+	if !readJSONLimited(w, r, &req, 16*1024) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, ok := s.requireOwnedWebhook(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	current, err := scanWebhookEndpoint(s.db.QueryRow(ctx, `select `+webhookEndpointColumns+` from webhook_endpoints where id = $1`, id))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	if req.URL != nil {
+		u, ok := validateWebhookURL(*req.URL)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid url (http/https)"})
+			return
+		}
+		current.URL = u
+	}
+	if req.Description != nil {
+		current.Description = strings.TrimSpace(*req.Description)
+		if len(current.Description) > 500 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "description too long"})
+			return
+		}
+	}
+	if req.EventTypes != nil {
+		if current.EventTypes, ok = normalizeWebhookEventTypes(*req.EventTypes); !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid event_types"})
+			return
+		}
+	}
+	if req.Enabled != nil {
+		current.Enabled = *req.Enabled
+	}
+
+	dto, err := scanWebhookEndpoint(s.db.QueryRow(ctx, `
+		update webhook_endpoints
+		set url = $2, description = $3, event_types = $4, enabled = $5, updated_at = now()
+		where id = $1
+		returning `+webhookEndpointColumns, id, current.URL, current.Description, current.EventTypes, current.Enabled))
+	if err != nil {
+		logError(ctx, "update webhook failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	s.audit(ctx, "user", userID, "webhook_updated", map[string]any{"webhook_id": dto.ID, "url": dto.URL, "event_types": dto.EventTypes, "enabled": dto.Enabled})
+	writeJSON(w, http.StatusOK, dto)
+}
+
+func (s server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, ok := s.requireOwnedWebhook(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	if _, err := s.db.Exec(ctx, `delete from webhook_endpoints where id = $1`, id); err != nil {
+		logError(ctx, "delete webhook failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "delete failed"})
+		return
+	}
+	s.audit(ctx, "user", userID, "webhook_deleted", map[string]any{"webhook_id": id.String()})
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+func (s server) handleRotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	if strings.TrimSpace(s.platformKeysEncryptionKey) == "" {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "missing platform key encryption config"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, ok := s.requireOwnedWebhook(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "secret generation failed"})
+		return
+	}
+	secretEnc, err := agenthome.EncryptForDB(s.platformKeysEncryptionKey, []byte(secret))
+	if err != nil {
+		logError(ctx, "encrypt webhook secret failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "encrypt failed"})
+		return
+	}
+	dto, err := scanWebhookEndpoint(s.db.QueryRow(ctx, `
+		update webhook_endpoints set secret_enc = $2, updated_at = now()
+		where id = $1
+		returning `+webhookEndpointColumns, id, secretEnc))
+	if err != nil {
+		logError(ctx, "rotate webhook secret failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "update failed"})
+		return
+	}
+	dto.Secret = secret
+	s.audit(ctx, "user", userID, "webhook_secret_rotated", map[string]any{"webhook_id": id.String()})
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// handlePingWebhook sends a signed "ping" event right away and returns the recorded delivery.
+func (s server) handlePingWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	id, ok := s.requireOwnedWebhook(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	eventID := uuid.New()
+	envelope := map[string]any{
+		"id":         eventID.String(),
+		"type":       webhookEventPing,
+		"created_at": time.Now().UTC().Format(time.RFC3339),
+		"data":       map[string]any{"webhook_id": id.String()},
+	}
+	var deliveryID uuid.UUID
+	if err := s.db.QueryRow(ctx, `
+		insert into webhook_deliveries (endpoint_id, event_id, event_type, payload)
+		values ($1, $2, $3, $4)
+		returning id
+	`, id, eventID, webhookEventPing, envelope).Scan(&deliveryID); err != nil {
+		logError(ctx, "ping webhook: insert delivery failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	s.respondWithDeliveryAttempt(ctx, w, deliveryID)
+}
+
+// handleRedeliverWebhook queues a copy of a past delivery (same event id and payload) and sends it now.
+func (s server) handleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	deliveryID, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "deliveryID")))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid delivery_id"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	id, ok := s.requireOwnedWebhook(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	var newID uuid.UUID
+	err = s.db.QueryRow(ctx, `
+		insert into webhook_deliveries (endpoint_id, event_id, event_type, payload, redelivery_of)
+		select endpoint_id, event_id, event_type, payload, id
+		from webhook_deliveries
+		where id = $1 and endpoint_id = $2
+		returning id
+	`, deliveryID, id).Scan(&newID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "delivery not found"})
+		return
+	}
+	if err != nil {
+		logError(ctx, "redeliver webhook: insert failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "insert failed"})
+		return
+	}
+	s.audit(ctx, "user", userID, "webhook_redelivered", map[string]any{"webhook_id": id.String(), "delivery_id": deliveryID.String()})
+	s.respondWithDeliveryAttempt(ctx, w, newID)
+}
+
+func (s server) respondWithDeliveryAttempt(ctx context.Context, w http.ResponseWriter, deliveryID uuid.UUID) {
+	if err := s.attemptWebhookDelivery(ctx, deliveryID); err != nil {
+		// The row stays pending; the background worker retries it.
+		logError(ctx, "webhook delivery attempt failed", err)
+	}
+	dto, err := scanWebhookDelivery(s.db.QueryRow(ctx, `select `+webhookDeliveryColumns+` from webhook_deliveries where id = $1`, deliveryID))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+func (s server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromCtx(r.Context())
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	switch status {
+	case "", "pending", "succeeded", "failed":
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+		return
+	}
+	limit := 50
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = clampInt(n, 1, 200)
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, ok := s.requireOwnedWebhook(ctx, w, r, userID)
+	if !ok {
+		return
+	}
+	rows, err := s.db.Query(ctx, `
+		select `+webhookDeliveryColumns+`
+		from webhook_deliveries
+		where endpoint_id = $1 and ($2 = '' or status = $2)
+		order by created_at desc
+		limit $3
+	`, id, status, limit)
+	if err != nil {
+		logError(ctx, "list webhook deliveries failed", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+	items := make([]webhookDeliveryDTO, 0)
+	for rows.Next() {
+		dto, err := scanWebhookDelivery(rows)
+		if err != nil {
+			logError(ctx, "list webhook deliveries scan failed", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "scan failed"})
+			return
+		}
+		items = append(items, dto)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "iterate failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
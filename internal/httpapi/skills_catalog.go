@@ -0,0 +1,179 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Skills catalog: versioned skill descriptors (skills) and per-stage skill sets (stage_skill_sets).
+// Work items pin the resolved versions in work_items.skill_refs; stages without a skill set fall
+// back to AIHUB_SKILLS_GATEWAY_WHITELIST (latest active version of each listed name).
+
+const (
+	skillStatusActive     = "active"
+	skillStatusDeprecated = "deprecated"
+)
+
+var skillNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// dbQuerier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type dbQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// skillRef pins one skill version; Version 0 means the name is not in the catalog (legacy whitelist
+// entry without a descriptor).
+type skillRef struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+type skillDescriptorDTO struct {
+	Name         string         `json:"name"`
+	Version      int            `json:"version"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"input_schema"`
+	OutputSchema map[string]any `json:"output_schema"`
+	Tool         string         `json:"tool,omitempty"`
+	Deprecated   bool           `json:"deprecated,omitempty"`
+	// Parameters mirrors InputSchema (function-calling style) for connectors built on the old shape.
+	Parameters map[string]any `json:"parameters"`
+}
+
+// resolveStageSkills returns the skill names for a new work item in stage (for available_skills)
+// and the pinned refs (for skill_refs), both JSON-ready.
+func (s server) resolveStageSkills(ctx context.Context, q dbQuerier, stage string) ([]string, []byte, error) {
+	rows, err := q.Query(ctx, `
+		select ss.skill_name,
+		       coalesce(ss.skill_version,
+		                (select max(k.version) from skills k where k.name = ss.skill_name and k.status = 'active'),
+		                0)
+		from stage_skill_sets ss
+		where ss.stage = $1
+		order by ss.position asc, ss.skill_name asc
+	`, stage)
+	if err != nil {
+		return nil, nil, err
+	}
+	refs, err := scanSkillRefs(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(refs) == 0 && len(s.skillsGatewayWhitelist) > 0 {
+		rows, err := q.Query(ctx, `
+			select w.name, coalesce((select max(k.version) from skills k where k.name = w.name and k.status = 'active'), 0)
+			from unnest($1::text[]) with ordinality as w(name, ord)
+			order by w.ord
+		`, s.skillsGatewayWhitelist)
+		if err != nil {
+			return nil, nil, err
+		}
+		refs, err = scanSkillRefs(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+	}
+	refsJSON, err := json.Marshal(refs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return names, refsJSON, nil
+}
+
+func scanSkillRefs(rows pgx.Rows) ([]skillRef, error) {
+	defer rows.Close()
+	out := make([]skillRef, 0)
+	seen := map[string]struct{}{}
+	for rows.Next() {
+		var ref skillRef
+		if err := rows.Scan(&ref.Name, &ref.Version); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[ref.Name]; ok || ref.Name == "" {
+			continue
+		}
+		seen[ref.Name] = struct{}{}
+		out = append(out, ref)
+	}
+	return out, rows.Err()
+}
+
+// describeSkills loads descriptors for refs (in order). Refs with Version 0 resolve to the latest
+// active version, or to a bare name-only descriptor when the name is not in the catalog.
+func describeSkills(ctx context.Context, q dbQuerier, refs []skillRef) ([]skillDescriptorDTO, error) {
+	if len(refs) == 0 {
+		return []skillDescriptorDTO{}, nil
+	}
+	names := make([]string, 0, len(refs))
+	versions := make([]int32, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+		versions = append(versions, int32(ref.Version))
+	}
+	rows, err := q.Query(ctx, `
+		select r.name, k.version, k.description, k.input_schema, k.output_schema, coalesce(k.tool, ''), k.status
+		from unnest($1::text[], $2::int[]) with ordinality as r(name, version, ord)
+		join lateral (
+		  select *
+		  from skills k
+		  where k.name = r.name
+		    and (k.version = r.version or (r.version = 0 and k.status = 'active'))
+		  order by k.version desc
+		  limit 1
+		) k on true
+		order by r.ord
+	`, names, versions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := map[string]skillDescriptorDTO{}
+	for rows.Next() {
+		var (
+			d         skillDescriptorDTO
+			inB, outB []byte
+			status    string
+		)
+		if err := rows.Scan(&d.Name, &d.Version, &d.Description, &inB, &outB, &d.Tool, &status); err != nil {
+			return nil, err
+		}
+		if err := unmarshalJSONNullable(inB, &d.InputSchema); err != nil {
+			return nil, err
+		}
+		if err := unmarshalJSONNullable(outB, &d.OutputSchema); err != nil {
+			return nil, err
+		}
+		d.Deprecated = status == skillStatusDeprecated
+		found[d.Name] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]skillDescriptorDTO, 0, len(refs))
+	for _, ref := range refs {
+		d, ok := found[ref.Name]
+		if !ok {
+			d = skillDescriptorDTO{Name: ref.Name}
+		}
+		if d.InputSchema == nil {
+			d.InputSchema = map[string]any{}
+		}
+		if d.OutputSchema == nil {
+			d.OutputSchema = map[string]any{}
+		}
+		d.Parameters = d.InputSchema
+		out = append(out, d)
+	}
+	return out, nil
+}
@@ -49,7 +49,8 @@ func (s server) listRecentTopicSnapshotMessagesFromEvents(ctx context.Context, t
 	rows, err := s.db.Query(ctx, `
 		select object_key, occurred_at, payload
 		from oss_events
-		where object_key like $1 or object_key like $2
+		where (object_key like $1 or object_key like $2)
+		  and `+topicNotRejectedSQL("oss_events.object_key", s.ossBasePrefix)+`
 		order by occurred_at desc
 		limit $3
 	`, pat1, pat2, limit)
@@ -131,6 +132,10 @@ func (s server) listRecentTopicSnapshotMessagesFromOSS(ctx context.Context, stor
 	if err != nil {
 		return nil, err
 	}
+	rejected, err := s.rejectedTopicObjectKeys(ctx, topicID)
+	if err != nil {
+		return nil, err
+	}
 
 	out := make([]topicSnapshotMessage, 0, limit)
 	cands := make([]topicSnapshotMessage, 0, 64)
@@ -139,7 +144,7 @@ func (s server) listRecentTopicSnapshotMessagesFromOSS(ctx context.Context, stor
 
 	for _, rawKey := range keys {
 		key := stripBasePrefix(rawKey)
-		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, ".json") {
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, ".json") || rejected[key] {
 			continue
 		}
 
@@ -0,0 +1,472 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"aihub/internal/agenthome"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// --- Outbound webhooks (owner/publisher-registered, HMAC-SHA256 signed)
+//
+// Events are fanned out into webhook_deliveries (inside the emitting tx when there is one);
+// deliverWebhooksTick sends due rows and retries failures with exponential backoff; finished rows
+// are swept after webhookDeliveryRetentionDays.
+//
+// Every request carries:
+//   X-AIHub-Event:     event type (e.g. run.status_changed)
+//   X-AIHub-Event-Id:  stable per event (shared by redeliveries)
+//   X-AIHub-Delivery:  unique per delivery row
+//   X-AIHub-Signature: t=<unix seconds>,v1=<hex hmac_sha256(secret, "<t>.<body>")>
+
+const (
+	webhookEventRunStatusChanged   = "run.status_changed"
+	webhookEventArtifactSubmitted  = "artifact.submitted"
+	webhookEventReviewCompleted    = "review.completed"
+	webhookEventModerationApproved = "moderation.approved"
+	webhookEventModerationRejected = "moderation.rejected"
+	webhookEventAgentDisabled      = "agent.disabled"
+	webhookEventTopicReply         = "topic.reply"
+
+	webhookEventModerationAppealResolved = "moderation.appeal_resolved"
+	webhookEventAgentAdmission           = "agent.admission_updated"
+
+	// webhookEventPing is only sent by the test-ping action (not subscribable).
+	webhookEventPing = "ping"
+)
+
+var webhookEventTypes = []string{
+	webhookEventRunStatusChanged,
+	webhookEventArtifactSubmitted,
+	webhookEventReviewCompleted,
+	webhookEventModerationApproved,
+	webhookEventModerationRejected,
+	webhookEventAgentDisabled,
+	webhookEventTopicReply,
+	webhookEventModerationAppealResolved,
+	webhookEventAgentAdmission,
+}
+
+const (
+	webhookSignatureHeader = "X-AIHub-Signature"
+	webhookMaxAttempts     = 8
+	webhookMaxResponseLen  = 2048
+	webhookMaxErrorLen     = 500
+
+	// Each tick claims up to webhookDeliveryBatch due rows, at most webhookDeliveriesPerEndpoint
+	// per endpoint. Endpoints are served concurrently (up to webhookDeliveryWorkers), each one's
+	// rows in order, so a slow endpoint only delays its own deliveries.
+	webhookDeliveryBatch         = 50
+	webhookDeliveriesPerEndpoint = 2
+	webhookDeliveryWorkers       = 8
+	webhookDeliveryRetentionDays = 30
+)
+
+var errWebhookTargetNotAllowed = errors.New("webhook target address not allowed")
+
+type dbExecer interface {
+	Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
+}
+
+func isWebhookEventType(t string) bool {
+	for _, v := range webhookEventTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// signWebhookPayload returns the X-AIHub-Signature header value for body at ts.
+func signWebhookPayload(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + strconv.FormatInt(ts, 10) + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyWebhookSignature is the receiver-side check (reference implementation for integrators).
+func verifyWebhookSignature(secret string, header string, body []byte, now time.Time, tolerance time.Duration) bool {
+	var ts int64
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return false
+			}
+			ts = n
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	if ts == 0 || len(sigs) == 0 {
+		return false
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return false
+	}
+	_, want, _ := strings.Cut(signWebhookPayload(secret, ts, body), ",v1=")
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookBackoff is the delay before retry number attempt+1 (30s, 1m, 2m, ... capped at 6h).
+func webhookBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > 20 {
+		attempt = 20
+	}
+	d := 30 * time.Second << (attempt - 1)
+	if d > 6*time.Hour {
+		d = 6 * time.Hour
+	}
+	return d
+}
+
+// webhookBlockedPrefixes are never valid webhook targets: non-routable, private, shared (CGNAT,
+// which also hosts cloud metadata services such as 100.100.100.200), reserved and translated ranges.
+var webhookBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+func isPublicWebhookIP(ip netip.Addr) bool {
+	if !ip.IsValid() {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range webhookBlockedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// newWebhookHTTPClient refuses to connect to webhookBlockedPrefixes (checked on the resolved
+// address, so DNS rebinding is covered) unless allowPrivate is set. Redirects are not followed.
+func newWebhookHTTPClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !isPublicWebhookIP(ip) {
+				return errWebhookTargetNotAllowed
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 15 * time.Second,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			MaxIdleConnsPerHost:   2,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+type webhookAttemptResult struct {
+	StatusCode int
+	Response   string
+	Err        error
+}
+
+func (r webhookAttemptResult) ok() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// sendWebhook performs one signed POST.
+func sendWebhook(ctx context.Context, client *http.Client, url string, secret string, deliveryID, eventID uuid.UUID, eventType string, body []byte, now time.Time) webhookAttemptResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return webhookAttemptResult{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aihub-webhooks/1")
+	req.Header.Set("X-AIHub-Event", eventType)
+	req.Header.Set("X-AIHub-Event-Id", eventID.String())
+	req.Header.Set("X-AIHub-Delivery", deliveryID.String())
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(secret, now.Unix(), body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return webhookAttemptResult{Err: err}
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseLen))
+	res := webhookAttemptResult{StatusCode: resp.StatusCode, Response: strings.ToValidUTF8(string(b), "")}
+	if !res.ok() {
+		res.Err = errors.New("non-2xx response: " + strconv.Itoa(resp.StatusCode))
+	}
+	return res
+}
+
+// emitWebhookEvent fans an event out to the enabled endpoints of ownerIDs that subscribe to it.
+// Pass the caller's tx so deliveries commit (or roll back) with the change they describe.
+func emitWebhookEvent(ctx context.Context, q dbExecer, ownerIDs []uuid.UUID, eventType string, data map[string]any) error {
+	owners := make([]uuid.UUID, 0, len(ownerIDs))
+	seen := map[uuid.UUID]struct{}{}
+	for _, id := range ownerIDs {
+		if id == uuid.Nil || id == platformUserID {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		owners = append(owners, id)
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+	if data == nil {
+		data = map[string]any{}
+	}
+	eventID := uuid.New()
+	envelope := map[string]any{
+		"id":         eventID.String(),
+		"type":       eventType,
+		"created_at": time.Now().UTC().Format(time.RFC3339),
+		"data":       data,
+	}
+	_, err := q.Exec(ctx, `
+		insert into webhook_deliveries (endpoint_id, event_id, event_type, payload)
+		select e.id, $2, $3, $4
+		from webhook_endpoints e
+		where e.owner_id = any($1)
+		  and e.enabled
+		  and (cardinality(e.event_types) = 0 or $3 = any(e.event_types))
+	`, owners, eventID, eventType, envelope)
+	return err
+}
+
+// emitWebhookEventBestEffort is for call sites without a surrounding tx.
+func (s server) emitWebhookEventBestEffort(ctx context.Context, ownerIDs []uuid.UUID, eventType string, data map[string]any) {
+	if err := emitWebhookEvent(ctx, s.db, ownerIDs, eventType, data); err != nil {
+		logError(ctx, "emit webhook event failed", err)
+	}
+}
+
+// attemptWebhookDelivery sends one pending delivery and records the outcome (retry schedule or
+// terminal status).
+func (s server) attemptWebhookDelivery(ctx context.Context, deliveryID uuid.UUID) error {
+	var (
+		endpointID uuid.UUID
+		eventID    uuid.UUID
+		eventType  string
+		payload    []byte
+		attempts   int
+		status     string
+		url        string
+		secretEnc  []byte
+		enabled    bool
+	)
+	if err := s.db.QueryRow(ctx, `
+		select d.endpoint_id, d.event_id, d.event_type, d.payload, d.attempts, d.status, e.url, e.secret_enc, e.enabled
+		from webhook_deliveries d
+		join webhook_endpoints e on e.id = d.endpoint_id
+		where d.id = $1
+	`, deliveryID).Scan(&endpointID, &eventID, &eventType, &payload, &attempts, &status, &url, &secretEnc, &enabled); err != nil {
+		return err
+	}
+	if status != "pending" {
+		return nil
+	}
+	if !enabled {
+		_, err := s.db.Exec(ctx, `
+			update webhook_deliveries set status = 'failed', last_error = 'endpoint disabled'
+			where id = $1 and status = 'pending'
+		`, deliveryID)
+		return err
+	}
+	secret, err := agenthome.DecryptFromDB(s.platformKeysEncryptionKey, secretEnc)
+	if err != nil {
+		return err
+	}
+
+	res := sendWebhook(ctx, s.webhookClient, url, string(secret), deliveryID, eventID, eventType, payload, time.Now())
+	attempts++
+	var statusCode *int
+	if res.StatusCode != 0 {
+		statusCode = &res.StatusCode
+	}
+	if res.ok() {
+		_, err = s.db.Exec(ctx, `
+			update webhook_deliveries
+			set status = 'succeeded', attempts = $2, last_status_code = $3, last_error = '', last_response = $4, delivered_at = now()
+			where id = $1
+		`, deliveryID, attempts, statusCode, res.Response)
+		return err
+	}
+
+	errMsg := truncateUTF8Bytes(res.Err.Error(), webhookMaxErrorLen)
+	nextStatus := "pending"
+	if attempts >= webhookMaxAttempts {
+		nextStatus = "failed"
+	}
+	_, err = s.db.Exec(ctx, `
+		update webhook_deliveries
+		set status = $2, attempts = $3, last_status_code = $4, last_error = $5, last_response = $6,
+		    next_attempt_at = now() + make_interval(secs => $7)
+		where id = $1
+	`, deliveryID, nextStatus, attempts, statusCode, errMsg, res.Response, webhookBackoff(attempts).Seconds())
+	return err
+}
+
+// deliverWebhooksTick claims a batch of due deliveries (pushing next_attempt_at out as a lease so
+// concurrent instances skip them) and sends them, one worker per endpoint.
+func (s server) deliverWebhooksTick(ctx context.Context) {
+	if strings.TrimSpace(s.platformKeysEncryptionKey) == "" {
+		return
+	}
+	rows, err := s.db.Query(ctx, `
+		update webhook_deliveries
+		set next_attempt_at = now() + interval '5 minutes'
+		where id in (
+			select id
+			from (
+				select id, next_attempt_at,
+				       row_number() over (partition by endpoint_id order by next_attempt_at, id) as rn
+				from (
+					select id, endpoint_id, next_attempt_at
+					from webhook_deliveries
+					where status = 'pending' and next_attempt_at <= now()
+					order by next_attempt_at asc
+					limit $3
+					for update skip locked
+				) due
+			) ranked
+			where rn <= $2
+			order by next_attempt_at asc
+			limit $1
+		)
+		returning id, endpoint_id
+	`, webhookDeliveryBatch, webhookDeliveriesPerEndpoint, webhookDeliveryBatch*10)
+	if err != nil {
+		logError(ctx, "webhook deliveries: claim failed", err)
+		return
+	}
+	type claimed struct {
+		id         uuid.UUID
+		endpointID uuid.UUID
+	}
+	var batch []claimed
+	for rows.Next() {
+		var c claimed
+		if err := rows.Scan(&c.id, &c.endpointID); err != nil {
+			rows.Close()
+			logError(ctx, "webhook deliveries: scan failed", err)
+			return
+		}
+		batch = append(batch, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logError(ctx, "webhook deliveries: iterate failed", err)
+		return
+	}
+
+	var endpoints []uuid.UUID
+	byEndpoint := map[uuid.UUID][]uuid.UUID{}
+	for _, c := range batch {
+		if _, ok := byEndpoint[c.endpointID]; !ok {
+			endpoints = append(endpoints, c.endpointID)
+		}
+		byEndpoint[c.endpointID] = append(byEndpoint[c.endpointID], c.id)
+	}
+	sem := make(chan struct{}, webhookDeliveryWorkers)
+	var wg sync.WaitGroup
+	for _, endpointID := range endpoints {
+		ids := byEndpoint[endpointID]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			for _, id := range ids {
+				if err := s.attemptWebhookDelivery(ctx, id); err != nil {
+					logError(ctx, "webhook delivery attempt failed", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.sweepWebhookDeliveries(ctx)
+}
+
+// sweepWebhookDeliveries drops finished deliveries once they are past retention.
+func (s server) sweepWebhookDeliveries(ctx context.Context) {
+	if _, err := s.db.Exec(ctx, `
+		delete from webhook_deliveries
+		where status <> 'pending'
+		  and created_at < now() - make_interval(days => $1::int)
+	`, webhookDeliveryRetentionDays); err != nil {
+		logError(ctx, "webhook deliveries: sweep failed", err)
+	}
+}
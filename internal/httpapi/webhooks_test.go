@@ -0,0 +1,112 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWebhookSignatureRoundTrip(t *testing.T) {
+	body := []byte(`{"type":"ping"}`)
+	now := time.Unix(1_700_000_000, 0)
+	header := signWebhookPayload("whsec_test", now.Unix(), body)
+
+	if !verifyWebhookSignature("whsec_test", header, body, now.Add(10*time.Second), 5*time.Minute) {
+		t.Fatal("expected signature to verify")
+	}
+	if verifyWebhookSignature("whsec_other", header, body, now, 5*time.Minute) {
+		t.Fatal("wrong secret must not verify")
+	}
+	if verifyWebhookSignature("whsec_test", header, []byte(`{"type":"pong"}`), now, 5*time.Minute) {
+		t.Fatal("modified body must not verify")
+	}
+	if verifyWebhookSignature("whsec_test", header, body, now.Add(time.Hour), 5*time.Minute) {
+		t.Fatal("stale timestamp must not verify")
+	}
+}
+
+func TestSendWebhookToLocalStandIn(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: b}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client := newWebhookHTTPClient(true)
+	deliveryID, eventID := uuid.New(), uuid.New()
+	body := []byte(`{"id":"` + eventID.String() + `","type":"run.status_changed","data":{"run_ref":"r_1"}}`)
+
+	res := sendWebhook(context.Background(), client, srv.URL, "whsec_test", deliveryID, eventID, webhookEventRunStatusChanged, body, time.Now())
+	if !res.ok() || res.StatusCode != http.StatusOK || res.Response != "ok" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	rcv := <-got
+	if rcv.header.Get("X-AIHub-Event") != webhookEventRunStatusChanged ||
+		rcv.header.Get("X-AIHub-Event-Id") != eventID.String() ||
+		rcv.header.Get("X-AIHub-Delivery") != deliveryID.String() {
+		t.Fatalf("unexpected headers: %v", rcv.header)
+	}
+	if !verifyWebhookSignature("whsec_test", rcv.header.Get(webhookSignatureHeader), rcv.body, time.Now(), time.Minute) {
+		t.Fatal("stand-in could not verify signature")
+	}
+
+	status = http.StatusInternalServerError
+	res = sendWebhook(context.Background(), client, srv.URL, "whsec_test", deliveryID, eventID, webhookEventRunStatusChanged, body, time.Now())
+	<-got
+	if res.ok() || res.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected failure on 500, got %+v", res)
+	}
+}
+
+func TestWebhookClientBlocksPrivateTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	res := sendWebhook(context.Background(), newWebhookHTTPClient(false), srv.URL, "s", uuid.New(), uuid.New(), webhookEventPing, []byte(`{}`), time.Now())
+	if res.ok() || !errors.Is(res.Err, errWebhookTargetNotAllowed) {
+		t.Fatalf("expected loopback target to be refused, got %+v", res)
+	}
+}
+
+func TestIsPublicWebhookIP(t *testing.T) {
+	for _, addr := range []string{
+		"100.100.100.200", "100.64.0.1", "0.1.2.3", "192.0.0.170", "198.18.0.1", "240.0.0.1",
+		"255.255.255.255", "10.0.0.1", "127.0.0.1", "169.254.169.254", "::1", "fe80::1", "fd00::1",
+		"64:ff9b::a64:64c8", "::ffff:100.100.100.200",
+	} {
+		if isPublicWebhookIP(netip.MustParseAddr(addr)) {
+			t.Fatalf("expected %s to be refused", addr)
+		}
+	}
+	for _, addr := range []string{"8.8.8.8", "100.128.0.1", "2606:4700::1111"} {
+		if !isPublicWebhookIP(netip.MustParseAddr(addr)) {
+			t.Fatalf("expected %s to be allowed", addr)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	if webhookBackoff(1) != 30*time.Second || webhookBackoff(2) != time.Minute || webhookBackoff(3) != 2*time.Minute {
+		t.Fatalf("unexpected backoff progression")
+	}
+	if webhookBackoff(webhookMaxAttempts+10) != 6*time.Hour {
+		t.Fatalf("expected backoff cap")
+	}
+}
@@ -0,0 +1,206 @@
+// Package toolruntime is the gateway tool runtime: a registry of platform tools with declared
+// input/output schemas, per-tool timeouts and costs, and a single Invoke entry point that
+// validates input, enforces the timeout, validates output and measures the result.
+package toolruntime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultTimeout = 5 * time.Second
+	// MaxResultBytes caps the encoded output returned to an agent.
+	MaxResultBytes = 256 * 1024
+)
+
+// Error codes returned to agents in structured tool results.
+const (
+	CodeUnknownTool   = "unknown_tool"
+	CodeInvalidInput  = "invalid_input"
+	CodeInvalidOutput = "invalid_output"
+	CodeTimeout       = "timeout"
+	CodeNotFound      = "not_found"
+	CodeForbidden     = "forbidden"
+	CodeTooLarge      = "result_too_large"
+	CodeFailed        = "tool_failed"
+)
+
+// Spec describes a tool. Schemas use the JSON Schema subset understood by ValidateSchema.
+type Spec struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"input_schema"`
+	OutputSchema map[string]any `json:"output_schema"`
+	Timeout      time.Duration  `json:"-"`
+	// Cost is the abstract cost units charged per invocation.
+	Cost int `json:"cost"`
+}
+
+// Call carries the invocation context. Tools must only expose data the calling agent may see.
+type Call struct {
+	AgentID  uuid.UUID
+	AgentRef string
+	RunID    uuid.UUID
+	RunRef   string
+	Input    map[string]any
+}
+
+type Tool interface {
+	Spec() Spec
+	Invoke(ctx context.Context, call Call) (map[string]any, error)
+}
+
+// Error is a tool failure with a stable code that is safe to return to the agent.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Message
+}
+
+// Errorf builds a *Error; tools use it for expected failures (not_found, forbidden, ...).
+func Errorf(code string, format string, args ...any) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// CodeOf returns the structured code of err (tool_failed for unstructured errors).
+func CodeOf(err error) string {
+	var te *Error
+	if errors.As(err, &te) {
+		return te.Code
+	}
+	return CodeFailed
+}
+
+type Registry struct {
+	tools map[string]Tool
+}
+
+func NewRegistry(tools ...Tool) (*Registry, error) {
+	r := &Registry{tools: map[string]Tool{}}
+	for _, t := range tools {
+		name := t.Spec().Name
+		if name == "" {
+			return nil, errors.New("toolruntime: tool with empty name")
+		}
+		if _, dup := r.tools[name]; dup {
+			return nil, fmt.Errorf("toolruntime: duplicate tool %q", name)
+		}
+		r.tools[name] = t
+	}
+	return r, nil
+}
+
+func (r *Registry) Get(name string) (Tool, bool) {
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// Specs lists the registered tools sorted by name.
+func (r *Registry) Specs() []Spec {
+	out := make([]Spec, 0, len(r.tools))
+	for _, t := range r.tools {
+		out = append(out, t.Spec())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Result is what one invocation produced (Output is nil on error).
+type Result struct {
+	Tool        string
+	Output      map[string]any
+	Latency     time.Duration
+	ResultBytes int
+	Cost        int
+}
+
+// Invoke runs the named tool. The returned error is always a *Error.
+func (r *Registry) Invoke(ctx context.Context, name string, call Call) (Result, error) {
+	res := Result{Tool: name}
+	t, ok := r.tools[name]
+	if !ok {
+		return res, &Error{Code: CodeUnknownTool, Message: name}
+	}
+	spec := t.Spec()
+	res.Cost = spec.Cost
+
+	if call.Input == nil {
+		call.Input = map[string]any{}
+	}
+	input, err := normalizeJSON(call.Input)
+	if err != nil {
+		return res, &Error{Code: CodeInvalidInput, Message: err.Error()}
+	}
+	if err := ValidateSchema(spec.InputSchema, input); err != nil {
+		return res, &Error{Code: CodeInvalidInput, Message: err.Error()}
+	}
+	call.Input, _ = input.(map[string]any)
+
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	out, err := t.Invoke(ctx, call)
+	res.Latency = time.Since(start)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return res, &Error{Code: CodeTimeout, Message: "exceeded " + timeout.String()}
+		}
+		var te *Error
+		if errors.As(err, &te) {
+			return res, te
+		}
+		return res, &Error{Code: CodeFailed, Message: err.Error()}
+	}
+	if out == nil {
+		out = map[string]any{}
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return res, &Error{Code: CodeInvalidOutput, Message: err.Error()}
+	}
+	res.ResultBytes = len(b)
+	if len(b) > MaxResultBytes {
+		return res, &Error{Code: CodeTooLarge, Message: fmt.Sprintf("%d bytes", len(b))}
+	}
+	var normalized any
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return res, &Error{Code: CodeInvalidOutput, Message: err.Error()}
+	}
+	if err := ValidateSchema(spec.OutputSchema, normalized); err != nil {
+		return res, &Error{Code: CodeInvalidOutput, Message: err.Error()}
+	}
+	res.Output, _ = normalized.(map[string]any)
+	return res, nil
+}
+
+// normalizeJSON round-trips v through encoding/json so schema validation sees JSON types.
+func normalizeJSON(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
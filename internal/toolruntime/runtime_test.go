@@ -0,0 +1,115 @@
+package toolruntime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type funcTool struct {
+	spec Spec
+	fn   func(ctx context.Context, call Call) (map[string]any, error)
+}
+
+func (t funcTool) Spec() Spec { return t.spec }
+
+func (t funcTool) Invoke(ctx context.Context, call Call) (map[string]any, error) {
+	return t.fn(ctx, call)
+}
+
+func echoTool() funcTool {
+	return funcTool{
+		spec: Spec{
+			Name:         "echo",
+			InputSchema:  Object([]string{"text"}, map[string]any{"text": String(1, 10), "n": Integer(1, 3)}),
+			OutputSchema: Object([]string{"text"}, map[string]any{"text": String(0, 100)}),
+			Cost:         2,
+		},
+		fn: func(ctx context.Context, call Call) (map[string]any, error) {
+			return map[string]any{"text": call.Input["text"]}, nil
+		},
+	}
+}
+
+func TestRegistryInvoke(t *testing.T) {
+	reg, err := NewRegistry(echoTool())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := reg.Invoke(context.Background(), "echo", Call{Input: map[string]any{"text": "hi", "n": 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Output["text"] != "hi" || res.Cost != 2 || res.ResultBytes == 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	cases := []struct {
+		name  string
+		tool  string
+		input map[string]any
+		code  string
+	}{
+		{"unknown tool", "nope", nil, CodeUnknownTool},
+		{"missing required", "echo", map[string]any{}, CodeInvalidInput},
+		{"too long", "echo", map[string]any{"text": strings.Repeat("x", 11)}, CodeInvalidInput},
+		{"not an integer", "echo", map[string]any{"text": "a", "n": 1.5}, CodeInvalidInput},
+		{"out of range", "echo", map[string]any{"text": "a", "n": 4}, CodeInvalidInput},
+		{"unknown property", "echo", map[string]any{"text": "a", "x": true}, CodeInvalidInput},
+	}
+	for _, tc := range cases {
+		_, err := reg.Invoke(context.Background(), tc.tool, Call{Input: tc.input})
+		if CodeOf(err) != tc.code {
+			t.Errorf("%s: got %v, want %s", tc.name, err, tc.code)
+		}
+	}
+}
+
+func TestRegistryInvokeFailures(t *testing.T) {
+	slow := funcTool{
+		spec: Spec{Name: "slow", Timeout: 20 * time.Millisecond},
+		fn: func(ctx context.Context, call Call) (map[string]any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	bad := funcTool{
+		spec: Spec{Name: "bad", OutputSchema: Object([]string{"ok"}, map[string]any{"ok": map[string]any{"type": "boolean"}})},
+		fn: func(ctx context.Context, call Call) (map[string]any, error) {
+			return map[string]any{"ok": "yes"}, nil
+		},
+	}
+	broken := funcTool{
+		spec: Spec{Name: "broken"},
+		fn: func(ctx context.Context, call Call) (map[string]any, error) {
+			return nil, errors.New("db down")
+		},
+	}
+	missing := funcTool{
+		spec: Spec{Name: "missing"},
+		fn: func(ctx context.Context, call Call) (map[string]any, error) {
+			return nil, Errorf(CodeNotFound, "topic %s", "t_1")
+		},
+	}
+	reg, err := NewRegistry(slow, bad, broken, missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tool, want := range map[string]string{
+		"slow":    CodeTimeout,
+		"bad":     CodeInvalidOutput,
+		"broken":  CodeFailed,
+		"missing": CodeNotFound,
+	} {
+		_, err := reg.Invoke(context.Background(), tool, Call{})
+		if CodeOf(err) != want {
+			t.Errorf("%s: got %v, want %s", tool, err, want)
+		}
+	}
+
+	if _, err := NewRegistry(slow, slow); err == nil {
+		t.Fatal("expected duplicate tool names to be rejected")
+	}
+}
@@ -0,0 +1,169 @@
+package toolruntime
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// ValidateSchema checks v (decoded JSON) against the JSON Schema subset used by tool specs:
+// type (object|array|string|integer|number|boolean|null), properties, required,
+// additionalProperties (bool), items, enum, minLength/maxLength, minimum/maximum, maxItems.
+// A nil schema accepts anything.
+func ValidateSchema(schema map[string]any, v any) error {
+	return validateAt("$", schema, v)
+}
+
+func validateAt(path string, schema map[string]any, v any) error {
+	if schema == nil {
+		return nil
+	}
+	if typ, _ := schema["type"].(string); typ != "" {
+		if !matchesType(typ, v) {
+			return fmt.Errorf("%s: expected %s", path, typ)
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: not one of the allowed values", path)
+		}
+	}
+
+	switch x := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(x)
+		if min, ok := number(schema["minLength"]); ok && float64(n) < min {
+			return fmt.Errorf("%s: shorter than %v", path, min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && float64(n) > max {
+			return fmt.Errorf("%s: longer than %v", path, max)
+		}
+	case float64:
+		if min, ok := number(schema["minimum"]); ok && x < min {
+			return fmt.Errorf("%s: less than %v", path, min)
+		}
+		if max, ok := number(schema["maximum"]); ok && x > max {
+			return fmt.Errorf("%s: greater than %v", path, max)
+		}
+	case []any:
+		if max, ok := number(schema["maxItems"]); ok && float64(len(x)) > max {
+			return fmt.Errorf("%s: more than %v items", path, max)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, it := range x {
+				if err := validateAt(fmt.Sprintf("%s[%d]", path, i), items, it); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		for _, k := range requiredKeys(schema["required"]) {
+			if _, ok := x[k]; !ok {
+				return fmt.Errorf("%s.%s: required", path, k)
+			}
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ps, known := props[k].(map[string]any)
+			if !known {
+				if ap, ok := schema["additionalProperties"].(bool); ok && !ap {
+					return fmt.Errorf("%s.%s: unknown property", path, k)
+				}
+				continue
+			}
+			if err := validateAt(path+"."+k, ps, x[k]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func matchesType(typ string, v any) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	default:
+		return false
+	}
+}
+
+func requiredKeys(v any) []string {
+	switch r := v.(type) {
+	case []string:
+		return r
+	case []any:
+		out := make([]string, 0, len(r))
+		for _, k := range r {
+			if s, ok := k.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// Helpers for declaring schemas in Go.
+
+func Object(required []string, props map[string]any) map[string]any {
+	if required == nil {
+		required = []string{}
+	}
+	return map[string]any{"type": "object", "required": required, "properties": props, "additionalProperties": false}
+}
+
+func String(minLen, maxLen int) map[string]any {
+	return map[string]any{"type": "string", "minLength": minLen, "maxLength": maxLen}
+}
+
+func Integer(min, max int) map[string]any {
+	return map[string]any{"type": "integer", "minimum": min, "maximum": max}
+}
+
+func ArrayOf(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
@@ -0,0 +1,22 @@
+-- Transactional outbox for platform-originated OSS writes.
+-- The intended object write and its oss_events row are committed in one DB transaction;
+-- a relay performs (and retries) the actual PutObject so the bucket converges with the feed.
+
+create table if not exists oss_outbox (
+  id bigserial primary key,
+  object_key text not null,
+  content_type text not null default 'application/json',
+  body bytea not null,
+  oss_event_id bigint references oss_events(id) on delete set null,
+
+  status text not null default 'pending' check (status in ('pending', 'delivered')),
+  attempts int not null default 0,
+  last_error text not null default '',
+  next_attempt_at timestamptz not null default now(),
+
+  created_at timestamptz not null default now(),
+  delivered_at timestamptz
+);
+
+create index if not exists oss_outbox_pending_idx on oss_outbox(next_attempt_at) where status = 'pending';
+create index if not exists oss_outbox_object_key_idx on oss_outbox(object_key);
@@ -0,0 +1,9 @@
+-- Audit log query/export: keyset pagination index + backfill run_id from data.run_id.
+
+create index if not exists audit_logs_created_idx on audit_logs(created_at desc, id desc);
+create index if not exists audit_logs_action_idx on audit_logs(action, created_at desc);
+
+update audit_logs
+set run_id = (data->>'run_id')::uuid
+where run_id is null
+  and data->>'run_id' ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$';
@@ -0,0 +1,56 @@
+-- Tamper-evident hash chains over audit_logs and moderation_actions.
+-- Rows written before this migration stay unchained (chain_seq is null); see internal/auditchain.
+
+alter table audit_logs add column if not exists chain_seq bigint;
+alter table audit_logs add column if not exists prev_hash text;
+alter table audit_logs add column if not exists row_hash text;
+create unique index if not exists audit_logs_chain_seq_uidx on audit_logs(chain_seq) where chain_seq is not null;
+
+alter table moderation_actions add column if not exists chain_seq bigint;
+alter table moderation_actions add column if not exists prev_hash text;
+alter table moderation_actions add column if not exists row_hash text;
+create unique index if not exists moderation_actions_chain_seq_uidx on moderation_actions(chain_seq) where chain_seq is not null;
+
+-- One head per chain; writers lock it (select ... for update) to append in order.
+create table if not exists audit_chain_heads (
+  chain text primary key,
+  last_seq bigint not null default 0,
+  last_hash text not null default '',
+  updated_at timestamptz not null default now()
+);
+
+insert into audit_chain_heads (chain) values ('audit_logs'), ('moderation_actions')
+on conflict (chain) do nothing;
+
+-- Periodic checkpoints of each chain head, signed with the platform signing key.
+create table if not exists audit_chain_checkpoints (
+  id bigserial primary key,
+  chain text not null references audit_chain_heads(chain),
+  seq bigint not null,
+  head_hash text not null,
+  payload jsonb not null,
+  key_id text not null,
+  created_at timestamptz not null default now()
+);
+create index if not exists audit_chain_checkpoints_chain_idx on audit_chain_checkpoints(chain, seq desc);
+
+-- Chained rows are append-only. (The hash chain still detects edits made with triggers disabled.)
+create or replace function audit_chain_rows_immutable() returns trigger as $$
+begin
+  if old.chain_seq is not null then
+    raise exception '% rows are append-only (chain_seq=%)', tg_table_name, old.chain_seq;
+  end if;
+  if tg_op = 'DELETE' then
+    return old;
+  end if;
+  return new;
+end;
+$$ language plpgsql;
+
+drop trigger if exists audit_logs_immutable on audit_logs;
+create trigger audit_logs_immutable before update or delete on audit_logs
+  for each row execute function audit_chain_rows_immutable();
+
+drop trigger if exists moderation_actions_immutable on moderation_actions;
+create trigger moderation_actions_immutable before update or delete on moderation_actions
+  for each row execute function audit_chain_rows_immutable();
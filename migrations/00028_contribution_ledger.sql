@@ -0,0 +1,56 @@
+-- Append-only contribution ledger (per agent + owner) backing public leaderboards.
+-- owner_contributions.completed_work_items stays as the legacy aggregate.
+
+alter table artifacts add column if not exists author_agent_id uuid references agents(id) on delete set null;
+
+-- Backfill artifact authors from the artifact_submitted audit trail.
+update artifacts a
+set author_agent_id = l.actor_id
+from audit_logs l
+where a.author_agent_id is null
+  and l.action = 'artifact_submitted'
+  and l.actor_type = 'agent'
+  and l.data->>'artifact_id' = a.id::text
+  and exists (select 1 from agents ag where ag.id = l.actor_id);
+
+create table if not exists contribution_ledger (
+  id bigserial primary key,
+  agent_id uuid not null references agents(id) on delete cascade,
+  owner_id uuid not null references users(id) on delete cascade,
+  kind text not null check (kind in ('work_item_completed', 'review_given', 'artifact_approved', 'vote_won')),
+  points int not null default 1,
+
+  -- Dimensions for leaderboards / breakdowns.
+  stage text not null default '',
+  work_item_kind text not null default '',
+  run_id uuid references runs(id) on delete set null,
+  topic_id text not null default '',
+
+  -- Idempotency: one entry per (kind, source_key), e.g. work item id, artifact id, vote object key.
+  source_key text not null,
+
+  created_at timestamptz not null default now()
+);
+
+create unique index if not exists contribution_ledger_source_uidx on contribution_ledger(kind, source_key);
+create index if not exists contribution_ledger_created_idx on contribution_ledger(created_at desc);
+create index if not exists contribution_ledger_agent_idx on contribution_ledger(agent_id, created_at desc);
+create index if not exists contribution_ledger_owner_idx on contribution_ledger(owner_id, created_at desc);
+create index if not exists contribution_ledger_stage_idx on contribution_ledger(stage, created_at desc);
+
+-- Seed completed work items from history so all-time boards are not empty after deploy.
+insert into contribution_ledger (agent_id, owner_id, kind, stage, work_item_kind, run_id, source_key, created_at)
+select a.id, a.owner_id, 'work_item_completed', wi.stage, wi.kind, wi.run_id, wi.id::text, l.created_at
+from audit_logs l
+join work_items wi on wi.id::text = l.data->>'work_item_id'
+join agents a on a.id = l.actor_id
+where l.action = 'work_item_completed' and l.actor_type = 'agent'
+on conflict do nothing;
+
+insert into contribution_ledger (agent_id, owner_id, kind, stage, work_item_kind, run_id, source_key, created_at)
+select a.id, a.owner_id, 'review_given', wi.stage, wi.kind, wi.run_id, wi.id::text, l.created_at
+from audit_logs l
+join work_items wi on wi.id::text = l.data->>'work_item_id' and wi.kind = 'review'
+join agents a on a.id = l.actor_id
+where l.action = 'work_item_completed' and l.actor_type = 'agent'
+on conflict do nothing;
@@ -0,0 +1,36 @@
+-- Owner/publisher-registered outbound webhooks (HMAC-SHA256 signed) + delivery log.
+
+create table if not exists webhook_endpoints (
+  id uuid primary key default gen_random_uuid(),
+  owner_id uuid not null references users(id) on delete cascade,
+  url text not null,
+  description text not null default '',
+  -- Empty = all event types.
+  event_types text[] not null default '{}',
+  -- Signing secret, encrypted with AIHUB_PLATFORM_KEYS_ENCRYPTION_KEY (nonce || ciphertext).
+  secret_enc bytea not null,
+  enabled boolean not null default true,
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+create index if not exists webhook_endpoints_owner_idx on webhook_endpoints(owner_id, created_at desc);
+
+create table if not exists webhook_deliveries (
+  id uuid primary key default gen_random_uuid(),
+  endpoint_id uuid not null references webhook_endpoints(id) on delete cascade,
+  -- Stable per emitted event (shared by redeliveries) so receivers can dedupe.
+  event_id uuid not null,
+  event_type text not null,
+  payload jsonb not null,
+  status text not null default 'pending' check (status in ('pending', 'succeeded', 'failed')),
+  attempts int not null default 0,
+  next_attempt_at timestamptz not null default now(),
+  last_status_code int,
+  last_error text not null default '',
+  last_response text not null default '',
+  redelivery_of uuid references webhook_deliveries(id) on delete set null,
+  created_at timestamptz not null default now(),
+  delivered_at timestamptz
+);
+create index if not exists webhook_deliveries_endpoint_idx on webhook_deliveries(endpoint_id, created_at desc);
+create index if not exists webhook_deliveries_pending_idx on webhook_deliveries(next_attempt_at) where status = 'pending';
@@ -0,0 +1,21 @@
+-- Admin-defined gateway tool catalog + global bans.
+-- Owners may only grant catalog tools with status 'available' to their agents (agent_allowed_tools);
+-- publishers may only list such tools at run creation (run_allowed_tools). A 'banned' tool is denied
+-- everywhere regardless of existing grants.
+
+create table if not exists tool_catalog (
+  tool text primary key,
+  description text not null default '',
+  status text not null default 'available' check (status in ('available', 'banned')),
+  ban_reason text not null default '',
+  updated_by uuid references users(id) on delete set null,
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+
+-- Keep grants made before the catalog existed (raw SQL) effective.
+insert into tool_catalog (tool)
+select tool from agent_allowed_tools
+union
+select tool from run_allowed_tools
+on conflict (tool) do nothing;
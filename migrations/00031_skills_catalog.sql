@@ -0,0 +1,57 @@
+-- Versioned skills catalog + per-stage skill sets.
+-- Work items pin resolved (name, version) pairs in skill_refs at creation so connectors keep seeing
+-- the contract they were offered; available_skills stays the plain name list for older connectors.
+
+create table if not exists skills (
+  name text not null,
+  version int not null check (version > 0),
+  description text not null default '',
+  input_schema jsonb not null default '{}'::jsonb,
+  output_schema jsonb not null default '{}'::jsonb,
+  -- Owning gateway tool (tool_catalog.tool); null = performed by the connector itself.
+  tool text,
+  status text not null default 'active' check (status in ('active', 'deprecated')),
+  created_by uuid references users(id) on delete set null,
+  created_at timestamptz not null default now(),
+  primary key (name, version)
+);
+
+create table if not exists stage_skill_sets (
+  stage text not null,
+  skill_name text not null,
+  -- null = latest active version at work item creation time.
+  skill_version int,
+  position int not null default 0,
+  created_at timestamptz not null default now(),
+  primary key (stage, skill_name)
+);
+
+alter table work_items add column if not exists skill_refs jsonb not null default '[]'::jsonb;
+
+-- v1 of the skills named by the default AIHUB_SKILLS_GATEWAY_WHITELIST plus the built-in gateway tools.
+insert into skills (name, version, description, input_schema, output_schema, tool) values
+  ('write', 1, 'Write the work item output text (submitted as an artifact when completing the work item).',
+   '{"type":"object","required":["content"],"properties":{"content":{"type":"string","minLength":1},"kind":{"type":"string","enum":["draft","final"]}},"additionalProperties":false}'::jsonb,
+   '{"type":"object","properties":{"version":{"type":"integer"}}}'::jsonb,
+   null),
+  ('emit', 1, 'Emit a progress event to the run timeline.',
+   '{"type":"object","required":["kind","payload"],"properties":{"kind":{"type":"string","minLength":1,"maxLength":64},"payload":{"type":"object"}},"additionalProperties":false}'::jsonb,
+   '{"type":"object","properties":{"seq":{"type":"integer"}}}'::jsonb,
+   null),
+  ('search', 1, 'Search public runs by goal text.',
+   '{"type":"object","required":["query"],"properties":{"query":{"type":"string","minLength":1,"maxLength":200},"limit":{"type":"integer","minimum":1,"maximum":20}},"additionalProperties":false}'::jsonb,
+   '{"type":"object","required":["runs"],"properties":{"runs":{"type":"array"}}}'::jsonb,
+   'search_public_runs'),
+  ('read_topic_thread', 1, 'Read the latest messages of a topic thread visible to the agent.',
+   '{"type":"object","required":["topic_id"],"properties":{"topic_id":{"type":"string","minLength":1,"maxLength":200},"limit":{"type":"integer","minimum":1,"maximum":100}},"additionalProperties":false}'::jsonb,
+   '{"type":"object","required":["topic","messages"],"properties":{"topic":{"type":"object"},"messages":{"type":"array"}}}'::jsonb,
+   'read_topic_thread'),
+  ('fetch_run_artifacts', 1, 'Fetch previous artifacts of the current run or of a public run.',
+   '{"type":"object","properties":{"run_ref":{"type":"string","minLength":1,"maxLength":64},"limit":{"type":"integer","minimum":1,"maximum":10}},"additionalProperties":false}'::jsonb,
+   '{"type":"object","required":["run_ref","artifacts"],"properties":{"run_ref":{"type":"string"},"artifacts":{"type":"array"}}}'::jsonb,
+   'fetch_run_artifacts'),
+  ('read_agent_card', 1, 'Read the public card of a listed agent.',
+   '{"type":"object","required":["agent_ref"],"properties":{"agent_ref":{"type":"string","minLength":1,"maxLength":64}},"additionalProperties":false}'::jsonb,
+   '{"type":"object","required":["agent_ref","name"],"properties":{"agent_ref":{"type":"string"},"name":{"type":"string"}}}'::jsonb,
+   'read_agent_card')
+on conflict (name, version) do nothing;
@@ -0,0 +1,15 @@
+-- Idempotency-Key support for agent gateway writes: the first response per (agent, key) is stored
+-- and replayed to retries. Rows older than 24h are treated as expired and overwritten.
+
+create table if not exists gateway_idempotency_keys (
+  agent_id uuid not null references agents(id) on delete cascade,
+  key text not null,
+  -- "METHOD /path" of the first request; reusing a key for another request is rejected.
+  request text not null,
+  -- Null while the first request is in flight.
+  status_code int,
+  response_body bytea,
+  created_at timestamptz not null default now(),
+  primary key (agent_id, key)
+);
+create index if not exists gateway_idempotency_keys_created_idx on gateway_idempotency_keys(created_at);
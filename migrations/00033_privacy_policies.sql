@@ -0,0 +1,31 @@
+-- Admin-configurable privacy policies per content surface + log of redacted/flagged writes.
+-- Surfaces without a row use the built-in defaults (see privacy_policy.go).
+
+create table if not exists privacy_policies (
+  surface text primary key check (surface in ('event_payload', 'artifact', 'topic_message', 'agent_card')),
+  -- Action for findings of any kind without an entry in kind_actions.
+  action text not null check (action in ('reject', 'redact', 'flag', 'allow')),
+  -- {"<kind>": "<action>"} overrides, e.g. {"private_key": "reject", "email": "redact"}.
+  kind_actions jsonb not null default '{}'::jsonb,
+  updated_by uuid references users(id) on delete set null,
+  updated_at timestamptz not null default now()
+);
+
+-- Writes that were accepted with redactions or flagged for moderation. Rejected writes are not
+-- stored (only logged). Raw content is never copied here, only kinds and field paths.
+create table if not exists privacy_findings (
+  id uuid primary key default gen_random_uuid(),
+  surface text not null,
+  action text not null check (action in ('redact', 'flag')),
+  -- event: "<run_ref>#<seq>", artifact: artifact id, topic_message/topic_request: OSS key,
+  -- agent_card: agent_ref.
+  target_type text not null,
+  target_ref text not null,
+  kinds text[] not null default '{}',
+  fields text[] not null default '{}',
+  actor_type text not null,
+  actor_id uuid,
+  created_at timestamptz not null default now()
+);
+create index if not exists privacy_findings_created_idx on privacy_findings(created_at desc);
+create index if not exists privacy_findings_surface_idx on privacy_findings(surface, action, created_at desc);
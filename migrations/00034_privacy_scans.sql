@@ -0,0 +1,38 @@
+-- Admin-triggered retroactive privacy scans over stored content, plus their findings report.
+-- A scan runs in the background of the API process that accepted it; updated_at is its heartbeat.
+
+create table if not exists privacy_scans (
+  id uuid primary key default gen_random_uuid(),
+  status text not null default 'running' check (status in ('running', 'completed', 'failed')),
+  surfaces text[] not null,
+  -- Only content created at or after this time is scanned; null scans everything.
+  since timestamptz,
+  scanned int not null default 0,
+  matched int not null default 0,
+  error text not null default '',
+  requested_by uuid references users(id) on delete set null,
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now(),
+  finished_at timestamptz
+);
+create index if not exists privacy_scans_created_idx on privacy_scans(created_at desc);
+
+-- One row per stored item with findings. As in privacy_findings, raw content is never copied here.
+create table if not exists privacy_scan_findings (
+  id bigserial primary key,
+  scan_id uuid not null references privacy_scans(id) on delete cascade,
+  surface text not null,
+  -- event / artifact / agent_card: row id; topic_message / topic_request: OSS object key.
+  target_type text not null check (target_type in ('event', 'artifact', 'agent_card', 'topic_message', 'topic_request')),
+  target_id text not null,
+  -- Human-readable location: "<run_ref>#<seq>", "<run_ref> v<version>", agent_ref or OSS key.
+  location text not null default '',
+  kinds text[] not null default '{}',
+  fields text[] not null default '{}',
+  -- Set once an admin applies an action: pending (sent back to moderation) or redacted.
+  resolution text not null default '' check (resolution in ('', 'pending', 'redacted')),
+  resolved_by uuid references users(id) on delete set null,
+  resolved_at timestamptz,
+  created_at timestamptz not null default now()
+);
+create index if not exists privacy_scan_findings_scan_idx on privacy_scan_findings(scan_id, id);
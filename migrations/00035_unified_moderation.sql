@@ -0,0 +1,29 @@
+-- One moderation queue for all content: runs/events/artifacts plus OSS topic messages/requests,
+-- agent cards and persona templates. moderation_actions stays the single audit trail.
+
+-- Topic messages/requests live in OSS; this table gives each object a stable id and a review status.
+-- Objects written before this migration have no row and stay visible.
+create table if not exists topic_content_reviews (
+  id uuid primary key default gen_random_uuid(),
+  -- Key as written by the platform (without OSS base prefix).
+  object_key text not null unique,
+  target_type text not null check (target_type in ('topic_message', 'topic_request')),
+  topic_id text not null,
+  agent_id uuid references agents(id) on delete set null,
+  agent_ref text not null default '',
+  summary text not null default '',
+  review_status text not null default 'pending' check (review_status in ('pending', 'approved', 'rejected')),
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+create index if not exists topic_content_reviews_review_created_idx on topic_content_reviews(review_status, created_at);
+create index if not exists topic_content_reviews_rejected_idx on topic_content_reviews(object_key) where review_status = 'rejected';
+
+-- Persona template ids are free-form text; moderation_actions.target_id is a uuid.
+alter table persona_templates add column if not exists moderation_id uuid not null default gen_random_uuid();
+create unique index if not exists persona_templates_moderation_id_uidx on persona_templates(moderation_id);
+
+alter table moderation_actions drop constraint if exists moderation_actions_target_type_chk;
+alter table moderation_actions add constraint moderation_actions_target_type_chk check (
+  target_type in ('run', 'event', 'artifact', 'topic_message', 'topic_request', 'agent_card', 'persona_template')
+);
@@ -0,0 +1,40 @@
+-- Rule-based pre-moderation: new runs/events/artifacts/topic content are checked against admin
+-- rules and signals and auto-approved, auto-rejected or escalated to a human (see auto_moderation.go).
+
+create table if not exists moderation_rules (
+  id uuid primary key default gen_random_uuid(),
+  name text not null default '',
+  -- keyword: matched against normalized text with separators removed; regex: RE2 over normalized text.
+  match_type text not null check (match_type in ('keyword', 'regex')),
+  pattern text not null,
+  decision text not null check (decision in ('reject', 'escalate')),
+  -- Empty = all content types.
+  target_types text[] not null default '{}',
+  enabled boolean not null default true,
+  created_by uuid references users(id) on delete set null,
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+create index if not exists moderation_rules_enabled_idx on moderation_rules(enabled);
+
+-- Single-row pipeline settings. The pipeline is off until an admin enables it.
+create table if not exists auto_moderation_settings (
+  id int primary key default 1 check (id = 1),
+  enabled boolean not null default false,
+  -- Actors with at least trusted_min_approved approved items and no rejections get auto-approved.
+  auto_approve_trusted boolean not null default true,
+  trusted_min_approved int not null default 20,
+  -- Actors with at least restricted_min_rejected rejected items always go to a human.
+  restricted_min_rejected int not null default 3,
+  -- More than rate_max_items new items within rate_window_seconds escalates.
+  rate_window_seconds int not null default 600,
+  rate_max_items int not null default 30,
+  updated_by uuid references users(id) on delete set null,
+  updated_at timestamptz not null default now()
+);
+insert into auto_moderation_settings (id) values (1) on conflict (id) do nothing;
+
+alter table moderation_actions drop constraint if exists moderation_actions_action_chk;
+alter table moderation_actions add constraint moderation_actions_action_chk check (
+  action in ('approve', 'reject', 'unreject', 'auto_approve', 'auto_reject', 'escalate')
+);
@@ -0,0 +1,22 @@
+-- Owners can appeal the rejection of their own content (runs they published, their agents'
+-- artifacts/topic content/cards, their persona templates). Admins resolve appeals in a separate
+-- queue: upheld keeps the rejection, overturned approves the target.
+
+create table if not exists moderation_appeals (
+  id uuid primary key default gen_random_uuid(),
+  owner_id uuid not null references users(id) on delete cascade,
+  target_type text not null check (target_type in ('run', 'artifact', 'topic_message', 'topic_request', 'agent_card', 'persona_template')),
+  target_id uuid not null,
+  -- The rejection being appealed; one appeal per rejection.
+  rejection_action_id uuid references moderation_actions(id) on delete set null,
+  message text not null,
+  status text not null default 'open' check (status in ('open', 'upheld', 'overturned')),
+  resolution_note text not null default '',
+  resolved_by uuid references users(id) on delete set null,
+  resolved_at timestamptz,
+  created_at timestamptz not null default now()
+);
+create unique index if not exists moderation_appeals_open_uidx on moderation_appeals(target_type, target_id) where status = 'open';
+create unique index if not exists moderation_appeals_rejection_uidx on moderation_appeals(rejection_action_id);
+create index if not exists moderation_appeals_status_created_idx on moderation_appeals(status, created_at);
+create index if not exists moderation_appeals_owner_created_idx on moderation_appeals(owner_id, created_at desc);
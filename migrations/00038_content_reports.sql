@@ -0,0 +1,35 @@
+-- Viewer reports on public content. One report per reporter (user or hashed IP) and target; totals
+-- per target drive escalation back to pending and the severity order of the moderation queue.
+
+create table if not exists content_reports (
+  id uuid primary key default gen_random_uuid(),
+  target_type text not null check (target_type in ('run', 'event', 'artifact', 'topic_message')),
+  target_id uuid not null,
+  category text not null check (category in ('spam', 'harassment', 'hate', 'sexual', 'violence', 'illegal', 'privacy', 'misinformation', 'other')),
+  detail text not null default '',
+  reporter_user_id uuid references users(id) on delete set null,
+  -- "user:<id>" or "ip:<hash>"; raw IPs are not stored.
+  reporter_key text not null,
+  created_at timestamptz not null default now(),
+  unique (target_type, target_id, reporter_key)
+);
+create index if not exists content_reports_target_idx on content_reports(target_type, target_id, created_at desc);
+create index if not exists content_reports_created_idx on content_reports(created_at desc);
+
+create table if not exists content_report_targets (
+  target_type text not null,
+  target_id uuid not null,
+  report_count int not null default 0,
+  -- Sum of category weights (see reportCategoryWeights).
+  severity int not null default 0,
+  -- Totals at the last escalation; escalation triggers on reports received since.
+  escalated_count int not null default 0,
+  escalated_severity int not null default 0,
+  last_reported_at timestamptz not null default now(),
+  escalated_at timestamptz,
+  primary key (target_type, target_id)
+);
+create index if not exists content_report_targets_severity_idx on content_report_targets(severity desc);
+
+alter table auto_moderation_settings add column if not exists report_escalate_count int not null default 3;
+alter table auto_moderation_settings add column if not exists report_escalate_severity int not null default 8;
@@ -0,0 +1,42 @@
+-- Shared token buckets for API rate limiting (AIHUB_RATE_LIMIT_STORE=postgres), so limits hold
+-- across replicas. Keys are "<policy>:<ip|user|agent>:<id>"; see internal/httpapi/ratelimit.go.
+
+create table if not exists rate_limit_buckets (
+  key text primary key,
+  tokens double precision not null,
+  updated_at timestamptz not null default clock_timestamp(),
+  -- When the bucket will have refilled completely; later rows can be deleted (missing = full).
+  full_at timestamptz not null default clock_timestamp()
+);
+create index if not exists rate_limit_buckets_full_at_idx on rate_limit_buckets(full_at);
+
+-- Refills the bucket to now and spends one token if available (atomic per key).
+create or replace function rate_limit_take(p_key text, p_burst double precision, p_rate double precision)
+returns table (allowed boolean, tokens double precision) as $$
+#variable_conflict use_column
+declare
+  t double precision;
+  ts timestamptz;
+  now_ts timestamptz := clock_timestamp();
+  ok boolean;
+begin
+  insert into rate_limit_buckets as b (key, tokens, updated_at, full_at)
+  values (p_key, p_burst, now_ts, now_ts)
+  on conflict (key) do nothing;
+
+  select b.tokens, b.updated_at into t, ts from rate_limit_buckets b where b.key = p_key for update;
+  t := least(p_burst, t + greatest(0, extract(epoch from now_ts - ts)) * p_rate);
+  ok := t >= 1;
+  if ok then
+    t := t - 1;
+  end if;
+
+  update rate_limit_buckets b
+  set tokens = t,
+      updated_at = now_ts,
+      full_at = now_ts + make_interval(secs => (p_burst - t) / p_rate)
+  where b.key = p_key;
+
+  return query select ok, t;
+end;
+$$ language plpgsql;
@@ -0,0 +1,15 @@
+-- Named, scoped, optionally expiring API keys for users and agents. Existing keys (and keys issued
+-- without explicit scopes, e.g. on login) get every scope of their principal. Grace-period
+-- rotation moves expires_at of the old key forward instead of revoking it.
+
+alter table user_api_keys add column if not exists name text not null default 'default';
+alter table user_api_keys add column if not exists scopes text[] not null default '{user-read,user-write,admin}';
+alter table user_api_keys add column if not exists expires_at timestamptz;
+alter table user_api_keys add column if not exists last_used_at timestamptz;
+alter table user_api_keys add column if not exists last_used_ip text;
+
+alter table agent_api_keys add column if not exists name text not null default 'default';
+alter table agent_api_keys add column if not exists scopes text[] not null default '{gateway-read,gateway-write,topics-write}';
+alter table agent_api_keys add column if not exists expires_at timestamptz;
+alter table agent_api_keys add column if not exists last_used_at timestamptz;
+alter table agent_api_keys add column if not exists last_used_ip text;
@@ -0,0 +1,9 @@
+-- Agent admission: proof of possession of agents.agent_public_key (signed challenge) followed by
+-- admin review. Challenges remember the key they were issued for so a key change voids them.
+
+alter table agents add column if not exists admission_requested_at timestamptz;
+alter table agents add column if not exists admission_reviewed_by uuid references users(id) on delete set null;
+alter table agents add column if not exists admission_reviewed_at timestamptz;
+alter table agents add column if not exists admission_note text not null default '';
+
+alter table agent_admission_challenges add column if not exists public_key text not null default '';
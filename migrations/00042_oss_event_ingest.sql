@@ -0,0 +1,9 @@
+-- OSS notification ingest (POST /v1/oss/events/ingest): bucket notifications are normalized into
+-- oss_events. source tells platform writes from ingested ones; dedupe_key makes redelivered
+-- notifications idempotent.
+
+alter table oss_events add column if not exists source text not null default 'platform';
+alter table oss_events add column if not exists dedupe_key text;
+
+create unique index if not exists oss_events_dedupe_key_idx on oss_events(dedupe_key) where dedupe_key is not null;
+create index if not exists oss_events_object_key_id_idx on oss_events(object_key, id desc);
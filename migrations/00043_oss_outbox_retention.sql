@@ -0,0 +1,3 @@
+-- Delivered outbox rows are swept after a retention window; index the sweep.
+
+create index if not exists oss_outbox_delivered_idx on oss_outbox(delivered_at) where status = 'delivered';
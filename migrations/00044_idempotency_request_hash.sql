@@ -0,0 +1,3 @@
+-- Idempotency keys also fingerprint the request body; a retry with a different body is rejected.
+
+alter table gateway_idempotency_keys add column if not exists request_hash text not null default '';
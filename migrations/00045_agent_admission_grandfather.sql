@@ -0,0 +1,11 @@
+-- Agents that were enabled before admission existed are grandfathered as admitted, so gating topic
+-- writes on admission does not lock them out. admission_requested_at is set so admins can still
+-- reject them through the normal review endpoints.
+
+update agents
+set admitted_status = 'admitted',
+    admitted_at = now(),
+    admission_requested_at = coalesce(admission_requested_at, created_at),
+    admission_note = 'grandfathered'
+where status = 'enabled'
+  and admitted_status = 'not_requested';
@@ -0,0 +1,3 @@
+-- Finished webhook deliveries are swept after a retention window; index the sweep.
+
+create index if not exists webhook_deliveries_finished_idx on webhook_deliveries(created_at) where status <> 'pending';
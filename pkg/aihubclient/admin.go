@@ -0,0 +1,42 @@
+package aihubclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// Admin APIs (user API key of an admin).
+
+// IssuedUserKey is a freshly created user and its API key (shown only once).
+type IssuedUserKey struct {
+	UserID string `json:"user_id"`
+	APIKey string `json:"api_key"`
+}
+
+// IssueUserKey creates a user and returns its API key, bypassing OAuth (tests and tooling).
+func (c *Client) IssueUserKey(ctx context.Context) (*IssuedUserKey, error) {
+	var out IssuedUserKey
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/users/issue-key"}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminCreateRun publishes a run as the platform.
+func (c *Client) AdminCreateRun(ctx context.Context, req CreateRunRequest) (string, error) {
+	if req.RequiredTags == nil {
+		req.RequiredTags = []string{}
+	}
+	var out struct {
+		RunRef string `json:"run_ref"`
+	}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/runs", body: req}, &out); err != nil {
+		return "", err
+	}
+	return out.RunRef, nil
+}
+
+// AdminDeleteRun deletes a run and its events/artifacts.
+func (c *Client) AdminDeleteRun(ctx context.Context, runRef string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: pathf("/admin/runs/%s", runRef)}, nil)
+}
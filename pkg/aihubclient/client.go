@@ -0,0 +1,309 @@
+// Package aihubclient is a Go client for the AIHub HTTP API: the agent gateway (poll, claim, emit,
+// artifacts, complete, topic messages, tools), owner agent management, a few admin calls and public
+// reads.
+//
+// Agent calls use an agent API key, owner calls a user API key; create one Client per key.
+// Requests are retried on network errors, 429 and 5xx when that is safe: reads, PUT/DELETE, and
+// POSTs sent with an Idempotency-Key (every gateway write gets one automatically and keeps it
+// across attempts, so the server replays the first response instead of writing twice).
+package aihubclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader is honored by the gateway for POST requests (24h window).
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	defaultTimeout     = 30 * time.Second
+	defaultMaxRetries  = 3
+	defaultBackoffBase = 250 * time.Millisecond
+	defaultBackoffMax  = 5 * time.Second
+	maxErrorBodyBytes  = 4 * 1024
+
+	idempotencyInFlightCode = "idempotent request in progress"
+)
+
+// Client calls one AIHub deployment with one API key. It is safe for concurrent use.
+type Client struct {
+	baseURL     *url.URL
+	apiKey      string
+	httpClient  *http.Client
+	userAgent   string
+	maxRetries  int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey sets the bearer key (agent key for gateway calls, user key for owner calls).
+func WithAPIKey(key string) Option { return func(c *Client) { c.apiKey = strings.TrimSpace(key) } }
+
+// WithHTTPClient replaces the default http.Client (30s timeout).
+func WithHTTPClient(hc *http.Client) Option { return func(c *Client) { c.httpClient = hc } }
+
+// WithUserAgent sets the User-Agent header.
+func WithUserAgent(ua string) Option { return func(c *Client) { c.userAgent = ua } }
+
+// WithRetry sets the retry budget (retries after the first attempt; 0 disables) and the
+// exponential backoff bounds.
+func WithRetry(maxRetries int, base, max time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		if base > 0 {
+			c.backoffBase = base
+		}
+		if max > 0 {
+			c.backoffMax = max
+		}
+	}
+}
+
+// New returns a Client for baseURL (e.g. "https://aihub.example.com"; "/v1" is added per call).
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(strings.TrimSpace(baseURL), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("aihubclient: invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("aihubclient: base url must be http(s): %q", baseURL)
+	}
+	c := &Client{
+		baseURL:     u,
+		httpClient:  &http.Client{Timeout: defaultTimeout},
+		userAgent:   "aihubclient-go",
+		maxRetries:  defaultMaxRetries,
+		backoffBase: defaultBackoffBase,
+		backoffMax:  defaultBackoffMax,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Error is a non-2xx API response. Code is the server's {"error": "..."} message.
+type Error struct {
+	StatusCode int
+	Code       string
+	Body       string
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("aihub: http %d: %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("aihub: http %d", e.StatusCode)
+}
+
+// StatusCode returns the HTTP status of an *Error in err's chain, or 0.
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// NewIdempotencyKey returns a random key suitable for the Idempotency-Key header.
+func NewIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand does not fail on supported platforms; fall back to time to stay unique enough.
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+type idempotencyKeyCtxKey struct{}
+
+// WithIdempotencyKey makes gateway writes made with ctx use key instead of a generated one, so a
+// caller can repeat a whole operation (e.g. after a process restart) safely. Use one key per write.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+type request struct {
+	method      string
+	path        string // under /v1, already escaped
+	query       url.Values
+	body        any    // JSON-encoded unless rawBody is set
+	rawBody     []byte // sent as-is with contentType
+	contentType string
+	idempotent  bool // attach an Idempotency-Key (POST gateway writes)
+}
+
+// do sends req (with retries) and decodes a 2xx JSON response into out (nil = discard).
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	var (
+		payload     []byte
+		contentType = req.contentType
+	)
+	switch {
+	case req.rawBody != nil:
+		payload = req.rawBody
+	case req.body != nil:
+		b, err := json.Marshal(req.body)
+		if err != nil {
+			return fmt.Errorf("aihubclient: encode request: %w", err)
+		}
+		payload = b
+		contentType = "application/json"
+	}
+
+	var idemKey string
+	if req.idempotent {
+		idemKey, _ = ctx.Value(idempotencyKeyCtxKey{}).(string)
+		if idemKey == "" {
+			idemKey = NewIdempotencyKey()
+		}
+	}
+	retryable := req.method == http.MethodGet || req.method == http.MethodPut || req.method == http.MethodDelete || idemKey != ""
+
+	u := *c.baseURL
+	u.Path = strings.TrimRight(u.Path, "/") + "/v1" + req.path
+	if len(req.query) > 0 {
+		u.RawQuery = req.query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+		if err != nil {
+			return fmt.Errorf("aihubclient: build request: %w", err)
+		}
+		if contentType != "" {
+			httpReq.Header.Set("Content-Type", contentType)
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		if c.userAgent != "" {
+			httpReq.Header.Set("User-Agent", c.userAgent)
+		}
+		if c.apiKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+		if idemKey != "" {
+			httpReq.Header.Set(IdempotencyKeyHeader, idemKey)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		var retryAfter time.Duration
+		if err == nil {
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return decodeResponse(resp, out)
+			}
+			apiErr := readError(resp)
+			err = apiErr
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			// A 409 for our own key means the first attempt is still running server-side.
+			inFlight := idemKey != "" && apiErr.StatusCode == http.StatusConflict && apiErr.Code == idempotencyInFlightCode
+			if !retryableStatus(resp.StatusCode) && !inFlight {
+				return err
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !retryable || attempt >= c.maxRetries {
+			return err
+		}
+		wait := c.backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("aihubclient: decode response: %w", err)
+	}
+	return nil
+}
+
+func readError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	apiErr := &Error{StatusCode: resp.StatusCode, Body: string(b)}
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(b, &payload) == nil {
+		apiErr.Code = payload.Error
+	}
+	return apiErr
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// backoff is exponential with full jitter, capped at backoffMax.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := float64(c.backoffBase) * math.Pow(2, float64(attempt))
+	if d > float64(c.backoffMax) {
+		d = float64(c.backoffMax)
+	}
+	return time.Duration(mrand.Int64N(int64(d) + 1))
+}
+
+func pathf(format string, refs ...string) string {
+	args := make([]any, 0, len(refs))
+	for _, r := range refs {
+		args = append(args, url.PathEscape(r))
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func itoa(n int) string { return strconv.Itoa(n) }
@@ -0,0 +1,142 @@
+package aihubclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, h http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL, WithAPIKey("test-key"), WithRetry(3, time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+func TestEmitEventRetriesWithSameIdempotencyKey(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/gateway/runs/r_1/events" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		mu.Lock()
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		n := len(keys)
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"run_ref": "r_1", "seq": 7, "kind": "message"})
+	}))
+
+	ev, err := c.EmitEvent(context.Background(), "r_1", "message", map[string]any{"text": "hi"})
+	if err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	if ev.Seq != 7 {
+		t.Fatalf("seq=%d", ev.Seq)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("idempotency keys across attempts: %q", keys)
+	}
+}
+
+func TestNonIdempotentPostIsNotRetried(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "unavailable"})
+	}))
+
+	_, err := c.CreateAgent(context.Background(), CreateAgentRequest{Name: "a"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Code != "unavailable" {
+		t.Fatalf("err=%v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls=%d, want 1", calls)
+	}
+}
+
+func TestWorkLoopRunOnce(t *testing.T) {
+	var completed []string
+	mux := http.NewServeMux()
+	claims := 0
+	mux.HandleFunc("POST /v1/gateway/inbox/claim-next", func(w http.ResponseWriter, r *http.Request) {
+		claims++
+		if claims > 1 {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "no offers"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"work_item_id":     "wi_1",
+			"run_ref":          "r_1",
+			"stage":            "ideation",
+			"lease_expires_at": time.Now().Add(time.Minute).UTC().Format(time.RFC3339),
+		})
+	})
+	mux.HandleFunc("POST /v1/gateway/work-items/{id}/complete", func(w http.ResponseWriter, r *http.Request) {
+		completed = append(completed, r.PathValue("id"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "completed"})
+	})
+	c := newTestClient(t, mux)
+
+	var handled []string
+	loop := &WorkLoop{Client: c, Handler: func(ctx context.Context, item *ClaimedWorkItem) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("handler ctx has no lease deadline")
+		}
+		handled = append(handled, item.WorkItemID)
+		return nil
+	}}
+
+	item, err := loop.RunOnce(context.Background())
+	if err != nil || item == nil || item.WorkItemID != "wi_1" {
+		t.Fatalf("first RunOnce: item=%v err=%v", item, err)
+	}
+	item, err = loop.RunOnce(context.Background())
+	if err != nil || item != nil {
+		t.Fatalf("second RunOnce: item=%v err=%v", item, err)
+	}
+	if len(handled) != 1 || len(completed) != 1 || completed[0] != "wi_1" {
+		t.Fatalf("handled=%v completed=%v", handled, completed)
+	}
+}
+
+func TestWorkLoopLeaseExpired(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/gateway/inbox/claim-next", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"work_item_id":     "wi_1",
+			"lease_expires_at": time.Now().Add(2 * time.Second).UTC().Format(time.RFC3339),
+		})
+	})
+	mux.HandleFunc("POST /v1/gateway/work-items/{id}/complete", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("complete must not be called after the lease ran out")
+	})
+	c := newTestClient(t, mux)
+
+	loop := &WorkLoop{Client: c, LeaseMargin: 10 * time.Second, Handler: func(ctx context.Context, item *ClaimedWorkItem) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	if _, err := loop.RunOnce(context.Background()); !errors.Is(err, ErrLeaseExpired) {
+		t.Fatalf("err=%v, want ErrLeaseExpired", err)
+	}
+}
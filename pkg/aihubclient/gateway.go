@@ -0,0 +1,182 @@
+package aihubclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrNoOffers is returned by ClaimNext when nothing is offered to the agent.
+var ErrNoOffers = errors.New("aihubclient: no offers")
+
+// Poll lists the work items currently offered to the agent.
+func (c *Client) Poll(ctx context.Context) ([]Offer, error) {
+	var out struct {
+		Offers []Offer `json:"offers"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/gateway/inbox/poll"}, &out); err != nil {
+		return nil, err
+	}
+	return out.Offers, nil
+}
+
+// ClaimNext leases the oldest offered work item, or returns ErrNoOffers.
+func (c *Client) ClaimNext(ctx context.Context) (*ClaimedWorkItem, error) {
+	var out ClaimedWorkItem
+	err := c.do(ctx, request{method: http.MethodPost, path: "/gateway/inbox/claim-next", idempotent: true}, &out)
+	if StatusCode(err) == http.StatusNotFound {
+		return nil, ErrNoOffers
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Claim leases a specific offered work item.
+func (c *Client) Claim(ctx context.Context, workItemID string) (*ClaimedWorkItem, error) {
+	var out ClaimedWorkItem
+	if err := c.do(ctx, request{method: http.MethodPost, path: pathf("/gateway/work-items/%s/claim", workItemID), idempotent: true}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWorkItem returns a work item offered to or leased by the agent.
+func (c *Client) GetWorkItem(ctx context.Context, workItemID string) (*WorkItem, error) {
+	var out WorkItem
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/gateway/work-items/%s", workItemID)}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WorkItemSkills returns the skill descriptors pinned to a work item.
+func (c *Client) WorkItemSkills(ctx context.Context, workItemID string) (*WorkItemSkills, error) {
+	var out WorkItemSkills
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/gateway/work-items/%s/skills", workItemID)}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Complete marks a leased work item done. It fails with 409 once the lease has expired.
+func (c *Client) Complete(ctx context.Context, workItemID string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: pathf("/gateway/work-items/%s/complete", workItemID), idempotent: true}, nil)
+}
+
+// EmitEvent appends an event to a run the agent participates in.
+func (c *Client) EmitEvent(ctx context.Context, runRef, kind string, payload map[string]any) (*Event, error) {
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	body := struct {
+		Kind    string         `json:"kind"`
+		Payload map[string]any `json:"payload"`
+	}{Kind: kind, Payload: payload}
+	var out Event
+	if err := c.do(ctx, request{method: http.MethodPost, path: pathf("/gateway/runs/%s/events", runRef), body: body, idempotent: true}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitArtifact stores a new artifact version for a run.
+func (c *Client) SubmitArtifact(ctx context.Context, runRef string, req ArtifactRequest) (*ArtifactResult, error) {
+	var out ArtifactResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: pathf("/gateway/runs/%s/artifacts", runRef), body: req, idempotent: true}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateRun publishes a run as the agent.
+func (c *Client) CreateRun(ctx context.Context, req CreateRunRequest) (string, error) {
+	if req.RequiredTags == nil {
+		req.RequiredTags = []string{}
+	}
+	var out struct {
+		RunRef string `json:"run_ref"`
+	}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/gateway/runs", body: req, idempotent: true}, &out); err != nil {
+		return "", err
+	}
+	return out.RunRef, nil
+}
+
+// WriteTopicMessage writes a structured message to an OSS topic.
+func (c *Client) WriteTopicMessage(ctx context.Context, topicID string, msg TopicMessage) (*TopicWriteResult, error) {
+	var out TopicWriteResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: pathf("/gateway/topics/%s/messages", topicID), body: msg, idempotent: true}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// TextMessageOptions threads a plain-text topic message.
+type TextMessageOptions struct {
+	ReplyTo    string // "agent_ref:message_id"
+	ThreadRoot string
+}
+
+// WriteTopicText writes a plain-text message to an OSS topic.
+func (c *Client) WriteTopicText(ctx context.Context, topicID, text string, opts TextMessageOptions) error {
+	q := url.Values{}
+	if v := strings.TrimSpace(opts.ReplyTo); v != "" {
+		q.Set("reply_to", v)
+	}
+	if v := strings.TrimSpace(opts.ThreadRoot); v != "" {
+		q.Set("thread_root", v)
+	}
+	return c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        pathf("/gateway/topics/%s/messages:text", topicID),
+		query:       q,
+		rawBody:     []byte(text),
+		contentType: "text/plain; charset=utf-8",
+		idempotent:  true,
+	}, nil)
+}
+
+// ProposeTopicText proposes a new topic under topicID (usually "topic_daily_checkin"): the first
+// line is the title (optionally prefixed with a category), the rest the summary.
+func (c *Client) ProposeTopicText(ctx context.Context, topicID, text string) error {
+	return c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        pathf("/gateway/topics/%s/requests:propose-topic-text", topicID),
+		rawBody:     []byte(text),
+		contentType: "text/plain; charset=utf-8",
+		idempotent:  true,
+	}, nil)
+}
+
+// ListTools lists platform tools and whether the agent may call them.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var out struct {
+		Tools []Tool `json:"tools"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/gateway/tools"}, &out); err != nil {
+		return nil, err
+	}
+	return out.Tools, nil
+}
+
+// InvokeTool calls a platform tool in the context of a run. Tool-level failures are returned as
+// an *Error carrying the tool's error code.
+func (c *Client) InvokeTool(ctx context.Context, runRef, tool string, input map[string]any) (*ToolResult, error) {
+	if input == nil {
+		input = map[string]any{}
+	}
+	body := struct {
+		RunRef string         `json:"run_ref"`
+		Tool   string         `json:"tool"`
+		Input  map[string]any `json:"input"`
+	}{RunRef: runRef, Tool: tool, Input: input}
+	var out ToolResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/gateway/tools/invoke", body: body, idempotent: true}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
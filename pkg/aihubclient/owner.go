@@ -0,0 +1,105 @@
+package aihubclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// Owner APIs (user API key).
+
+// Me returns the current user.
+func (c *Client) Me(ctx context.Context) (*Me, error) {
+	var out Me
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/me"}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateAgent registers an agent. The returned API key is shown only once.
+func (c *Client) CreateAgent(ctx context.Context, req CreateAgentRequest) (*CreatedAgent, error) {
+	if req.Tags == nil {
+		req.Tags = []string{}
+	}
+	var out CreatedAgent
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/agents", body: req}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAgents lists the caller's agents.
+func (c *Client) ListAgents(ctx context.Context) ([]AgentSummary, error) {
+	var out struct {
+		Agents []AgentSummary `json:"agents"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/agents"}, &out); err != nil {
+		return nil, err
+	}
+	return out.Agents, nil
+}
+
+// GetAgent returns one of the caller's agents.
+func (c *Client) GetAgent(ctx context.Context, agentRef string) (*Agent, error) {
+	var out Agent
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/agents/%s", agentRef)}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAgent patches an agent.
+func (c *Client) UpdateAgent(ctx context.Context, agentRef string, req UpdateAgentRequest) error {
+	return c.do(ctx, request{method: http.MethodPatch, path: pathf("/agents/%s", agentRef), body: req}, nil)
+}
+
+// DisableAgent disables an agent (its key stops working for the gateway).
+func (c *Client) DisableAgent(ctx context.Context, agentRef string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: pathf("/agents/%s/disable", agentRef)}, nil)
+}
+
+// DeleteAgent deletes an agent.
+func (c *Client) DeleteAgent(ctx context.Context, agentRef string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: pathf("/agents/%s", agentRef)}, nil)
+}
+
+// RotateAgentKey revokes the agent's keys and returns a new one.
+func (c *Client) RotateAgentKey(ctx context.Context, agentRef string) (string, error) {
+	var out struct {
+		APIKey string `json:"api_key"`
+	}
+	if err := c.do(ctx, request{method: http.MethodPost, path: pathf("/agents/%s/keys/rotate", agentRef)}, &out); err != nil {
+		return "", err
+	}
+	return out.APIKey, nil
+}
+
+// ReplaceAgentTags sets the agent's tags and returns the normalized list.
+func (c *Client) ReplaceAgentTags(ctx context.Context, agentRef string, tags []string) ([]string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	body := struct {
+		Tags []string `json:"tags"`
+	}{Tags: tags}
+	var out struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.do(ctx, request{method: http.MethodPut, path: pathf("/agents/%s/tags", agentRef), body: body}, &out); err != nil {
+		return nil, err
+	}
+	return out.Tags, nil
+}
+
+// AddAgentTag adds one tag.
+func (c *Client) AddAgentTag(ctx context.Context, agentRef, tag string) error {
+	body := struct {
+		Tag string `json:"tag"`
+	}{Tag: tag}
+	return c.do(ctx, request{method: http.MethodPost, path: pathf("/agents/%s/tags", agentRef), body: body}, nil)
+}
+
+// RemoveAgentTag removes one tag.
+func (c *Client) RemoveAgentTag(ctx context.Context, agentRef, tag string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: pathf("/agents/%s/tags/%s", agentRef, tag)}, nil)
+}
@@ -0,0 +1,89 @@
+package aihubclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Public reads (no key required).
+
+// ListRuns lists public runs.
+func (c *Client) ListRuns(ctx context.Context, opts ListRunsOptions) (*RunList, error) {
+	q := url.Values{}
+	if v := strings.TrimSpace(opts.Query); v != "" {
+		q.Set("q", v)
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		q.Set("offset", itoa(opts.Offset))
+	}
+	if opts.IncludeSystem {
+		q.Set("include_system", "true")
+	}
+	var out RunList
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/runs", query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRun returns a public run.
+func (c *Client) GetRun(ctx context.Context, runRef string) (*Run, error) {
+	var out Run
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/runs/%s/", runRef)}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRunOutput returns the latest artifact of a run.
+func (c *Client) GetRunOutput(ctx context.Context, runRef string) (*RunOutput, error) {
+	var out RunOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/runs/%s/output", runRef)}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetArtifact returns one artifact version of a run.
+func (c *Client) GetArtifact(ctx context.Context, runRef string, version int) (*Artifact, error) {
+	var out Artifact
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/runs/%s/artifacts/%s", runRef, itoa(version))}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReplayRun returns run events after afterSeq (limit <= 0 uses the server default).
+func (c *Client) ReplayRun(ctx context.Context, runRef string, afterSeq int64, limit int) (*Replay, error) {
+	q := url.Values{}
+	if afterSeq > 0 {
+		q.Set("after_seq", strconv.FormatInt(afterSeq, 10))
+	}
+	if limit > 0 {
+		q.Set("limit", itoa(limit))
+	}
+	var out Replay
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/runs/%s/replay", runRef), query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTopicThread returns the public thread of a topic (limit <= 0 uses the server default).
+func (c *Client) GetTopicThread(ctx context.Context, topicID string, limit int) (*TopicThread, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", itoa(limit))
+	}
+	var out TopicThread
+	if err := c.do(ctx, request{method: http.MethodGet, path: pathf("/topics/%s/thread", topicID), query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
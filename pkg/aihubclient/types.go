@@ -0,0 +1,325 @@
+package aihubclient
+
+import "time"
+
+// Wire types mirror the server's JSON. Unknown fields are ignored, so newer servers stay compatible.
+
+// Offer is a work item offered to the agent (GET /v1/gateway/inbox/poll).
+type Offer struct {
+	WorkItemID      string         `json:"work_item_id"`
+	RunRef          string         `json:"run_ref"`
+	Stage           string         `json:"stage"`
+	Kind            string         `json:"kind"`
+	Status          string         `json:"status"`
+	Goal            string         `json:"goal"`
+	Constraints     string         `json:"constraints"`
+	StageContext    map[string]any `json:"stage_context,omitempty"`
+	AvailableSkills []string       `json:"available_skills,omitempty"`
+	ReviewContext   map[string]any `json:"review_context,omitempty"`
+}
+
+// ClaimedWorkItem is a leased work item; it must be completed before LeaseExpiresAt.
+type ClaimedWorkItem struct {
+	Offer
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+}
+
+// WorkItem is the detail view of a work item offered to or leased by the agent.
+type WorkItem struct {
+	Offer
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	CreatedAt   string     `json:"created_at"`
+	UpdatedAt   string     `json:"updated_at"`
+}
+
+// Skill is a versioned skill descriptor pinned to a work item.
+type Skill struct {
+	Name         string         `json:"name"`
+	Version      int            `json:"version"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"input_schema"`
+	OutputSchema map[string]any `json:"output_schema"`
+	Tool         string         `json:"tool,omitempty"`
+	Deprecated   bool           `json:"deprecated,omitempty"`
+}
+
+// WorkItemSkills is the response of GET /v1/gateway/work-items/{id}/skills.
+type WorkItemSkills struct {
+	WorkItemID string  `json:"work_item_id"`
+	RunRef     string  `json:"run_ref"`
+	Skills     []Skill `json:"skills"`
+}
+
+// Event is a run event.
+type Event struct {
+	RunRef    string         `json:"run_ref"`
+	Seq       int64          `json:"seq"`
+	Kind      string         `json:"kind"`
+	Persona   string         `json:"persona"`
+	Payload   map[string]any `json:"payload"`
+	IsKeyNode bool           `json:"is_key_node"`
+	CreatedAt string         `json:"created_at"`
+}
+
+// Artifact kinds accepted by SubmitArtifact.
+const (
+	ArtifactDraft = "draft"
+	ArtifactFinal = "final"
+)
+
+// ArtifactRequest is the body of POST /v1/gateway/runs/{runRef}/artifacts.
+type ArtifactRequest struct {
+	Kind           string `json:"kind"`
+	Content        string `json:"content"`
+	LinkedEventSeq *int64 `json:"linked_event_seq,omitempty"`
+}
+
+// ArtifactResult identifies a stored artifact version.
+type ArtifactResult struct {
+	RunRef     string `json:"run_ref"`
+	Version    int    `json:"version"`
+	Kind       string `json:"kind"`
+	ArtifactID string `json:"artifact_id,omitempty"`
+}
+
+// CreateRunRequest is the body of POST /v1/gateway/runs.
+type CreateRunRequest struct {
+	Goal         string   `json:"goal"`
+	Constraints  string   `json:"constraints"`
+	RequiredTags []string `json:"required_tags"`
+	Visibility   string   `json:"visibility,omitempty"`
+}
+
+// TopicMessage is the body of POST /v1/gateway/topics/{topicID}/messages.
+type TopicMessage struct {
+	MessageID string         `json:"message_id,omitempty"`
+	Content   map[string]any `json:"content"`
+	Meta      map[string]any `json:"meta,omitempty"`
+}
+
+// TopicWriteResult is returned by topic writes.
+type TopicWriteResult struct {
+	OK        bool   `json:"ok"`
+	ObjectKey string `json:"object_key,omitempty"`
+}
+
+// Tool is a platform tool visible to the agent.
+type Tool struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"input_schema"`
+	OutputSchema map[string]any `json:"output_schema"`
+	Cost         int            `json:"cost"`
+	TimeoutMS    int64          `json:"timeout_ms"`
+	AgentAllowed bool           `json:"agent_allowed"`
+}
+
+// ToolResult is the response of POST /v1/gateway/tools/invoke.
+type ToolResult struct {
+	OK          bool   `json:"ok"`
+	Tool        string `json:"tool"`
+	Result      any    `json:"result,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Message     string `json:"message,omitempty"`
+	LatencyMS   int64  `json:"latency_ms"`
+	ResultBytes int    `json:"result_bytes"`
+	Cost        int    `json:"cost"`
+}
+
+// Me is the current user.
+type Me struct {
+	Provider    string `json:"provider,omitempty"`
+	Login       string `json:"login,omitempty"`
+	Name        string `json:"name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	ProfileURL  string `json:"profile_url,omitempty"`
+	IsAdmin     bool   `json:"is_admin"`
+}
+
+type Personality struct {
+	Extrovert float64 `json:"extrovert"`
+	Curious   float64 `json:"curious"`
+	Creative  float64 `json:"creative"`
+	Stable    float64 `json:"stable"`
+}
+
+type Discovery struct {
+	Public       bool   `json:"public"`
+	OSSEndpoint  string `json:"oss_endpoint,omitempty"`
+	LastSyncedAt string `json:"last_synced_at,omitempty"`
+}
+
+type Autonomous struct {
+	Enabled             bool `json:"enabled"`
+	PollIntervalSeconds int  `json:"poll_interval_seconds"`
+	AutoAcceptMatching  bool `json:"auto_accept_matching"`
+}
+
+// CreateAgentRequest is the body of POST /v1/agents.
+type CreateAgentRequest struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description"`
+	Tags              []string     `json:"tags"`
+	IdentityMode      string       `json:"identity_mode,omitempty"`
+	AvatarURL         string       `json:"avatar_url,omitempty"`
+	Personality       *Personality `json:"personality,omitempty"`
+	Interests         []string     `json:"interests,omitempty"`
+	Capabilities      []string     `json:"capabilities,omitempty"`
+	Bio               string       `json:"bio,omitempty"`
+	Greeting          string       `json:"greeting,omitempty"`
+	Discovery         *Discovery   `json:"discovery,omitempty"`
+	Autonomous        *Autonomous  `json:"autonomous,omitempty"`
+	PersonaTemplateID string       `json:"persona_template_id,omitempty"`
+}
+
+// CreatedAgent carries the new agent's API key; it is only returned once.
+type CreatedAgent struct {
+	AgentRef  string         `json:"agent_ref"`
+	APIKey    string         `json:"api_key"`
+	Endpoints map[string]any `json:"endpoints"`
+}
+
+// UpdateAgentRequest is the body of PATCH /v1/agents/{agentRef}; nil fields are left unchanged.
+type UpdateAgentRequest struct {
+	Name              *string      `json:"name,omitempty"`
+	Description       *string      `json:"description,omitempty"`
+	Status            *string      `json:"status,omitempty"`
+	IdentityMode      *string      `json:"identity_mode,omitempty"`
+	AvatarURL         *string      `json:"avatar_url,omitempty"`
+	Personality       *Personality `json:"personality,omitempty"`
+	Interests         *[]string    `json:"interests,omitempty"`
+	Capabilities      *[]string    `json:"capabilities,omitempty"`
+	Bio               *string      `json:"bio,omitempty"`
+	Greeting          *string      `json:"greeting,omitempty"`
+	Discovery         *Discovery   `json:"discovery,omitempty"`
+	Autonomous        *Autonomous  `json:"autonomous,omitempty"`
+	PersonaTemplateID *string      `json:"persona_template_id,omitempty"`
+}
+
+// AgentSummary is an item of GET /v1/agents.
+type AgentSummary struct {
+	AgentRef     string   `json:"agent_ref"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Status       string   `json:"status"`
+	IdentityMode string   `json:"identity_mode"`
+	Tags         []string `json:"tags"`
+}
+
+// Agent is the owner's full view of an agent (GET /v1/agents/{agentRef}).
+type Agent struct {
+	AgentSummary
+	AvatarURL    string      `json:"avatar_url"`
+	Personality  Personality `json:"personality"`
+	Interests    []string    `json:"interests"`
+	Capabilities []string    `json:"capabilities"`
+	Bio          string      `json:"bio"`
+	Greeting     string      `json:"greeting"`
+	Persona      any         `json:"persona,omitempty"`
+	PromptView   string      `json:"prompt_view"`
+	CardVersion  int         `json:"card_version"`
+	CardReview   string      `json:"card_review_status"`
+	Discovery    Discovery   `json:"discovery"`
+	Autonomous   Autonomous  `json:"autonomous"`
+	CreatedAt    string      `json:"created_at"`
+	UpdatedAt    string      `json:"updated_at"`
+}
+
+// RunSummary is an item of GET /v1/runs.
+type RunSummary struct {
+	RunRef        string `json:"run_ref"`
+	Goal          string `json:"goal"`
+	Constraints   string `json:"constraints"`
+	Status        string `json:"status"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+	OutputVersion int    `json:"output_version"`
+	OutputKind    string `json:"output_kind"`
+	IsSystem      bool   `json:"is_system"`
+	PreviewText   string `json:"preview_text,omitempty"`
+}
+
+// RunList is a page of public runs.
+type RunList struct {
+	Runs       []RunSummary `json:"runs"`
+	HasMore    bool         `json:"has_more"`
+	NextOffset int          `json:"next_offset"`
+}
+
+// ListRunsOptions filters GET /v1/runs.
+type ListRunsOptions struct {
+	Query         string
+	Limit         int
+	Offset        int
+	IncludeSystem bool
+}
+
+// Run is a public run.
+type Run struct {
+	RunRef      string `json:"run_ref"`
+	Goal        string `json:"goal"`
+	Constraints string `json:"constraints"`
+	Status      string `json:"status"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// RunOutput is the latest artifact of a run.
+type RunOutput struct {
+	RunRef    string `json:"run_ref"`
+	Version   int    `json:"version"`
+	Kind      string `json:"kind"`
+	Author    string `json:"author"`
+	CreatedAt string `json:"created_at,omitempty"`
+	Content   string `json:"content"`
+}
+
+// Artifact is one artifact version of a run.
+type Artifact struct {
+	RunRef    string `json:"run_ref"`
+	Version   int    `json:"version"`
+	Kind      string `json:"kind"`
+	Author    any    `json:"author"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+	LinkedSeq *int64 `json:"linked_seq"`
+	ReplayURL string `json:"replay_url"`
+}
+
+// Replay is a page of run events.
+type Replay struct {
+	RunRef   string  `json:"run_ref"`
+	Events   []Event `json:"events"`
+	KeyNodes []Event `json:"key_nodes"`
+	AfterSeq int64   `json:"after_seq"`
+	Limit    int     `json:"limit"`
+}
+
+// TopicThread is the public thread view of a topic.
+type TopicThread struct {
+	Topic struct {
+		TopicID    string `json:"topic_id"`
+		Title      string `json:"title"`
+		Summary    string `json:"summary,omitempty"`
+		Mode       string `json:"mode,omitempty"`
+		Visibility string `json:"visibility,omitempty"`
+	} `json:"topic"`
+	Messages []TopicThreadMessage `json:"messages"`
+}
+
+type TopicMessageRef struct {
+	AgentRef  string `json:"agent_ref"`
+	MessageID string `json:"message_id"`
+}
+
+type TopicThreadMessage struct {
+	Text       string           `json:"text"`
+	ActorName  string           `json:"actor_name,omitempty"`
+	Relation   string           `json:"relation,omitempty"`
+	CreatedAt  string           `json:"created_at"`
+	ReplyTo    *TopicMessageRef `json:"reply_to,omitempty"`
+	ThreadRoot *TopicMessageRef `json:"thread_root,omitempty"`
+	MessageID  string           `json:"message_id"`
+	ActorRef   string           `json:"actor_ref,omitempty"`
+	OccurredAt string           `json:"occurred_at,omitempty"`
+}
@@ -0,0 +1,104 @@
+package aihubclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrLeaseExpired is returned when a handler outlives its work item lease; the item is re-offered
+// by the server and must not be completed.
+var ErrLeaseExpired = errors.New("aihubclient: work item lease expired")
+
+// WorkHandler processes one claimed work item (emit events, submit artifacts, ...). ctx is
+// cancelled LeaseMargin before the lease expires. Returning nil completes the item.
+type WorkHandler func(ctx context.Context, item *ClaimedWorkItem) error
+
+// WorkLoop claims offered work items one at a time and runs Handler on each within its lease.
+type WorkLoop struct {
+	Client  *Client
+	Handler WorkHandler
+
+	// IdleInterval is the wait after an empty inbox or a failed claim (default 5s).
+	IdleInterval time.Duration
+	// LeaseMargin is reserved before lease expiry for the completion call (default 5s).
+	LeaseMargin time.Duration
+	// OnError observes handler/claim/complete errors; the loop keeps running.
+	OnError func(item *ClaimedWorkItem, err error)
+}
+
+// Run processes work items until ctx is done and returns ctx.Err().
+func (l *WorkLoop) Run(ctx context.Context) error {
+	idle := l.IdleInterval
+	if idle <= 0 {
+		idle = 5 * time.Second
+	}
+	for {
+		item, err := l.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && l.OnError != nil {
+			l.OnError(item, err)
+		}
+		if item != nil && err == nil {
+			// Drain the inbox before sleeping.
+			continue
+		}
+		t := time.NewTimer(idle)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce claims and processes at most one work item. It returns (nil, nil) when nothing is
+// offered; otherwise the claimed item and the handler/completion error, if any.
+func (l *WorkLoop) RunOnce(ctx context.Context) (*ClaimedWorkItem, error) {
+	if l.Client == nil || l.Handler == nil {
+		return nil, errors.New("aihubclient: WorkLoop needs Client and Handler")
+	}
+	item, err := l.Client.ClaimNext(ctx)
+	if errors.Is(err, ErrNoOffers) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim: %w", err)
+	}
+
+	margin := l.LeaseMargin
+	if margin <= 0 {
+		margin = 5 * time.Second
+	}
+	hctx := ctx
+	if !item.LeaseExpiresAt.IsZero() {
+		var cancel context.CancelFunc
+		hctx, cancel = context.WithDeadline(ctx, item.LeaseExpiresAt.Add(-margin))
+		defer cancel()
+	}
+
+	herr := l.Handler(hctx, item)
+	if ctx.Err() != nil {
+		return item, ctx.Err()
+	}
+	if errors.Is(hctx.Err(), context.DeadlineExceeded) {
+		return item, ErrLeaseExpired
+	}
+	if herr != nil {
+		// Leave the lease to expire so the item is re-offered.
+		return item, fmt.Errorf("handle work item %s: %w", item.WorkItemID, herr)
+	}
+
+	if err := l.Client.Complete(ctx, item.WorkItemID); err != nil {
+		if StatusCode(err) == http.StatusConflict {
+			return item, fmt.Errorf("%w: %v", ErrLeaseExpired, err)
+		}
+		return item, fmt.Errorf("complete work item %s: %w", item.WorkItemID, err)
+	}
+	return item, nil
+}
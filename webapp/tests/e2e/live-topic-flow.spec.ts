@@ -1,3 +1,4 @@
+import { generateKeyPairSync, sign } from "node:crypto";
 import { expect, test } from "@playwright/test";
 import type { APIRequestContext } from "@playwright/test";
 import { isLiveMode, requireEnv } from "./helpers/liveAuth";
@@ -53,6 +54,38 @@ async function createAgent(
   return { agentRef, agentKey };
 }
 
+async function admitAgent(
+  request: APIRequestContext,
+  baseURL: string,
+  adminApiKey: string,
+  agentRef: string,
+  agentKey: string,
+): Promise<void> {
+  // Topic writes require admission: register a key, sign a challenge, then admin-admit.
+  const { publicKey, privateKey } = generateKeyPairSync("ed25519");
+  const rawPub = publicKey.export({ format: "der", type: "spki" }).subarray(-32);
+  const agentHeaders = { Authorization: `Bearer ${agentKey}` };
+  const reg = await request.put(`${baseURL}/v1/gateway/admission/public-key`, {
+    headers: agentHeaders,
+    data: { public_key: rawPub.toString("base64") },
+  });
+  if (!reg.ok()) throw new Error(`Register public key failed, status=${reg.status()}`);
+  const ch = await request.post(`${baseURL}/v1/gateway/admission/challenge`, { headers: agentHeaders });
+  if (!ch.ok()) throw new Error(`Admission challenge failed, status=${ch.status()}`);
+  const cj = (await ch.json()) as { challenge_id?: string; message?: string };
+  const signature = sign(null, Buffer.from(String(cj.message ?? ""), "utf8"), privateKey).toString("base64");
+  const ver = await request.post(`${baseURL}/v1/gateway/admission/verify`, {
+    headers: agentHeaders,
+    data: { challenge_id: cj.challenge_id, signature },
+  });
+  if (!ver.ok()) throw new Error(`Admission verify failed, status=${ver.status()}`);
+  const admit = await request.post(`${baseURL}/v1/admin/agents/${encodeURIComponent(agentRef)}/admission/admit`, {
+    headers: { Authorization: `Bearer ${adminApiKey}` },
+    data: { reason: "e2e" },
+  });
+  if (!admit.ok()) throw new Error(`Admit agent failed, status=${admit.status()}`);
+}
+
 async function adminCreatePoetryDuelTopic(
   request: APIRequestContext,
   baseURL: string,
@@ -123,6 +156,7 @@ test.describe("live: topic participation (gateway write)", () => {
       const agent = await createAgent(request, base, adminApiKey, agentName);
       agentRef = agent.agentRef;
       agentKey = agent.agentKey;
+      await admitAgent(request, base, adminApiKey, agentRef, agentKey);
 
       const topic = await adminCreatePoetryDuelTopic(request, base, adminApiKey, agentRef);
       topicId = topic.topicId;